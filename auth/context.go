@@ -0,0 +1,28 @@
+package auth
+
+import "context"
+
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	sessionIDKey
+)
+
+// WithSession gắn người dùng đã xác thực và phiên đăng nhập vào context.
+func WithSession(ctx context.Context, userID, sessionID int) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, userID)
+	return context.WithValue(ctx, sessionIDKey, sessionID)
+}
+
+// UserID trả về ID người dùng đã xác thực trong context.
+func UserID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(userIDKey).(int)
+	return id, ok
+}
+
+// SessionID trả về ID phiên đăng nhập hiện tại trong context.
+func SessionID(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(sessionIDKey).(int)
+	return id, ok
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Chi phí bcrypt. Mỗi hash có salt ngẫu nhiên riêng do bcrypt tự sinh.
+const passwordCost = 12
+
+// dummyHash dùng để so sánh khi không tìm thấy người dùng, giúp thời gian
+// phản hồi không tiết lộ username có tồn tại hay không.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), passwordCost)
+
+// HashPassword băm mật khẩu bằng bcrypt.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// IsHashed cho biết giá trị lưu trong database đã là hash bcrypt hay vẫn là
+// mật khẩu dạng plain text từ các phiên bản cũ.
+func IsHashed(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") ||
+		strings.HasPrefix(stored, "$2b$") ||
+		strings.HasPrefix(stored, "$2y$")
+}
+
+// CheckPassword so sánh mật khẩu người dùng nhập với giá trị đã lưu.
+// needsRehash = true khi giá trị lưu vẫn là plain text (hoặc hash với chi phí
+// cũ) và nên được băm lại sau khi đăng nhập thành công.
+func CheckPassword(stored, password string) (ok bool, needsRehash bool) {
+	if !IsHashed(stored) {
+		ok = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return ok, ok
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)); err != nil {
+		return false, false
+	}
+
+	cost, err := bcrypt.Cost([]byte(stored))
+	return true, err == nil && cost < passwordCost
+}
+
+// CheckDummyPassword tiêu tốn thời gian tương đương một lần so sánh bcrypt,
+// dùng cho nhánh không tìm thấy người dùng.
+func CheckDummyPassword(password string) {
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
@@ -8,22 +8,58 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
+	_ "modernc.org/sqlite"
 )
 
-var DB *sql.DB
+// Dialect xác định loại database đang dùng để chọn câu SQL và migration phù hợp
+type Dialect string
 
-func InitDB() (*sql.DB, error) {
+const (
+	MySQL  Dialect = "mysql"
+	SQLite Dialect = "sqlite"
+)
+
+// DB là kết nối database kèm dialect tương ứng
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+}
+
+// InitDB mở kết nối theo DB_DRIVER: "mysql" (mặc định) hoặc "sqlite"
+func InitDB() (*DB, error) {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("Lỗi khi tải file .env, sẽ sử dụng biến môi trường hệ thống")
 	}
 
+	var db *DB
+	switch driver := os.Getenv("DB_DRIVER"); driver {
+	case "", string(MySQL):
+		db, err = openMySQL()
+	case string(SQLite):
+		db, err = openSQLite()
+	default:
+		return nil, fmt.Errorf("DB_DRIVER không hợp lệ: %s (chỉ hỗ trợ mysql hoặc sqlite)", driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("lỗi kết nối cơ sở dữ liệu: %v", err)
+	}
+
+	log.Printf("Đã kết nối thành công đến cơ sở dữ liệu (%s)!", db.Dialect)
+	return db, nil
+}
+
+func openMySQL() (*DB, error) {
 	dbUser := os.Getenv("DB_USER")
 	dbPassword := os.Getenv("DB_PASSWORD")
 	dbHost := os.Getenv("DB_HOST")
 	dbName := os.Getenv("DB_NAME")
 
-	
 	if dbUser == "" || dbPassword == "" || dbHost == "" || dbName == "" {
 		return nil, fmt.Errorf("thiếu biến môi trường bắt buộc: DB_USER, DB_PASSWORD, DB_HOST hoặc DB_NAME")
 	}
@@ -37,7 +73,9 @@ func InitDB() (*sql.DB, error) {
 		Params: map[string]string{
 			"parseTime": "true",
 			"charset":   "utf8mb4",
-			"loc":       "Local",
+			// Lưu và đọc mọi thời gian ở UTC; NOW()/CURRENT_TIMESTAMP cũng theo UTC
+			"loc":       "UTC",
+			"time_zone": "'+00:00'",
 		},
 		AllowNativePasswords: true,
 	}
@@ -46,13 +84,28 @@ func InitDB() (*sql.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("không thể kết nối đến cơ sở dữ liệu: %v", err)
 	}
+	return &DB{DB: db, Dialect: MySQL}, nil
+}
 
-	if err = db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("lỗi kết nối cơ sở dữ liệu: %v", err)
+// openSQLite mở file database cục bộ (DB_PATH, mặc định todo.db)
+func openSQLite() (*DB, error) {
+	path := os.Getenv("DB_PATH")
+	if path == "" {
+		path = "todo.db"
 	}
+	return OpenSQLite(path)
+}
 
-	log.Println("Đã kết nối thành công đến cơ sở dữ liệu!")
-	DB = db
-	return db, nil
+// OpenSQLite mở file database SQLite path với các pragma mà store cần
+func OpenSQLite(path string) (*DB, error) {
+	// _time_format=sqlite lưu thời gian dạng "YYYY-MM-DD HH:MM:SS" để so sánh và
+	// dùng được với các hàm ngày giờ của SQLite. _txlock=immediate giữ quyền ghi ngay từ
+	// đầu transaction: transaction đọc rồi mới ghi sẽ chờ (busy_timeout) thay vì lỗi SQLITE_BUSY.
+	dsn := "file:" + path +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("không thể mở database SQLite: %v", err)
+	}
+	return &DB{DB: db, Dialect: SQLite}, nil
 }
@@ -0,0 +1,336 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/mysql/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// Migration là một phiên bản lược đồ gồm câu lệnh nâng cấp (up) và hạ cấp (down)
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus cho biết một migration đã được áp dụng hay chưa
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// loadMigrations đọc các file migrations/<dialect>/NNNN_ten.up.sql và
+// NNNN_ten.down.sql được nhúng vào binary, sắp xếp theo phiên bản.
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	dir := "migrations/" + string(dialect)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("tên file migration không hợp lệ: %s", name)
+		}
+		versionStr, label, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("phiên bản migration không hợp lệ: %s", name)
+		}
+
+		content, err := migrationFiles.ReadFile(dir + "/" + name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: label}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s thiếu file up hoặc down", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements tách nội dung file SQL thành từng câu lệnh (kết thúc bằng ';'
+// ở cuối dòng) vì driver không bật multiStatements.
+func splitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+func ensureMigrationsTable(db *DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+func appliedMigrations(db *DB) (map[int]time.Time, error) {
+	if err := ensureMigrationsTable(db); err != nil {
+		return nil, fmt.Errorf("không thể tạo bảng schema_migrations: %v", err)
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// legacyTimeZoneVar là biến phiên MySQL mà migration dùng để đổi thời gian cũ (ghi theo giờ
+// địa phương của server trước khi chuyển sang lưu UTC) sang UTC và ngược lại
+const legacyTimeZoneVar = "@legacy_time_zone"
+
+func execMigration(db *DB, content string) error {
+	// Mọi câu lệnh chạy trên cùng một kết nối để dùng chung biến phiên
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if strings.Contains(content, legacyTimeZoneVar) {
+		if err := setLegacyTimeZone(ctx, conn); err != nil {
+			return err
+		}
+	}
+	for _, stmt := range splitStatements(content) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setLegacyTimeZone đặt @legacy_time_zone theo DB_LEGACY_TIME_ZONE. Database đã có người dùng
+// thì bắt buộc phải cấu hình biến này: migration dừng lại (và server không khởi động vì lược đồ
+// chưa cập nhật) thay vì đọc dữ liệu cũ như thể đã ở UTC.
+func setLegacyTimeZone(ctx context.Context, conn *sql.Conn) error {
+	zone := os.Getenv("DB_LEGACY_TIME_ZONE")
+	if zone == "" {
+		var users int
+		if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&users); err != nil {
+			return err
+		}
+		if users > 0 {
+			return fmt.Errorf("database có dữ liệu được ghi theo giờ địa phương của server; hãy đặt DB_LEGACY_TIME_ZONE " +
+				"là múi giờ của server MySQL cũ (ví dụ \"+07:00\", hoặc \"+00:00\" nếu server vốn chạy ở UTC) rồi chạy lại")
+		}
+		zone = "+00:00"
+	}
+
+	// CONVERT_TZ trả về NULL với múi giờ không hợp lệ hoặc tên múi giờ khi MySQL chưa nạp bảng múi giờ
+	var converted sql.NullTime
+	if err := conn.QueryRowContext(ctx, "SELECT CONVERT_TZ('2000-01-01 00:00:00', ?, '+00:00')", zone).Scan(&converted); err != nil {
+		return err
+	}
+	if !converted.Valid {
+		return fmt.Errorf("DB_LEGACY_TIME_ZONE không hợp lệ: %q (dùng dạng \"+07:00\" nếu MySQL chưa nạp bảng múi giờ)", zone)
+	}
+	_, err := conn.ExecContext(ctx, "SET "+legacyTimeZoneVar+" = ?", zone)
+	return err
+}
+
+// GetMigrationStatus trả về trạng thái của tất cả migration
+func GetMigrationStatus(db *DB) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(db.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		status := MigrationStatus{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// MigrateUp áp dụng tất cả migration chưa chạy theo thứ tự phiên bản
+func MigrateUp(db *DB) ([]Migration, error) {
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, s := range statuses {
+		if s.Applied {
+			continue
+		}
+		if err := execMigration(db, s.Up); err != nil {
+			return done, fmt.Errorf("lỗi khi chạy migration %04d_%s: %v", s.Version, s.Name, err)
+		}
+		_, err := db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", s.Version, s.Name, time.Now())
+		if err != nil {
+			return done, fmt.Errorf("lỗi khi ghi nhận migration %04d_%s: %v", s.Version, s.Name, err)
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// MigrateDown hoàn tác steps migration gần nhất
+func MigrateDown(db *DB, steps int) ([]Migration, error) {
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		s := statuses[i]
+		if !s.Applied {
+			continue
+		}
+		if err := execMigration(db, s.Down); err != nil {
+			return done, fmt.Errorf("lỗi khi hoàn tác migration %04d_%s: %v", s.Version, s.Name, err)
+		}
+		if _, err := db.Exec("DELETE FROM schema_migrations WHERE version = ?", s.Version); err != nil {
+			return done, fmt.Errorf("lỗi khi xóa bản ghi migration %04d_%s: %v", s.Version, s.Name, err)
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// CheckSchema trả về lỗi nếu database còn migration chưa được áp dụng
+func CheckSchema(db *DB) error {
+	statuses, err := GetMigrationStatus(db)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("lược đồ database chưa cập nhật, còn %d migration chưa chạy (%s); hãy chạy lệnh \"migrate up\"",
+			len(pending), strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// RunMigrateCommand xử lý lệnh dòng lệnh: migrate up | down [số bước] | status
+func RunMigrateCommand(db *DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("cách dùng: migrate up | down [số bước] | status")
+	}
+
+	switch args[0] {
+	case "up":
+		done, err := MigrateUp(db)
+		for _, m := range done {
+			fmt.Printf("Đã áp dụng %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(done) == 0 {
+			fmt.Println("Lược đồ đã ở phiên bản mới nhất")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("số bước không hợp lệ: %s", args[1])
+			}
+			steps = n
+		}
+		done, err := MigrateDown(db, steps)
+		for _, m := range done {
+			fmt.Printf("Đã hoàn tác %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := GetMigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "chưa chạy"
+			if s.Applied {
+				state = "đã áp dụng lúc " + s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("lệnh migrate không hợp lệ: %s", args[0])
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reminders;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS users;
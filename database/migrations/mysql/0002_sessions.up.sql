@@ -0,0 +1,12 @@
+-- Phiên đăng nhập: chỉ lưu SHA-256 của access token và refresh token
+CREATE TABLE sessions (
+    session_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    access_token_hash CHAR(64) NOT NULL UNIQUE,
+    access_expires_at DATETIME NOT NULL,
+    refresh_token_hash CHAR(64) NOT NULL UNIQUE,
+    refresh_expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_sessions_user (user_id),
+    CONSTRAINT fk_sessions_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
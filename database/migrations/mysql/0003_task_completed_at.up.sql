@@ -0,0 +1,7 @@
+-- Thời điểm công việc chuyển sang Completed; công việc đã hoàn thành trước đây lấy theo updated_at
+ALTER TABLE tasks ADD COLUMN completed_at DATETIME NULL;
+UPDATE tasks SET completed_at = updated_at WHERE status = 'Completed';
+
+-- Các giá trị rỗng từ trước khi có kiểm tra enum được đưa về mặc định
+UPDATE tasks SET status = 'Pending' WHERE status = '';
+UPDATE tasks SET priority = 'Medium' WHERE priority = '';
@@ -0,0 +1,6 @@
+ALTER TABLE tasks
+    DROP INDEX idx_tasks_series,
+    DROP COLUMN occurrence_at,
+    DROP COLUMN recurrence_start,
+    DROP COLUMN series_id,
+    DROP COLUMN recurrence;
@@ -0,0 +1,7 @@
+-- Công việc lặp lại theo RRULE (RFC 5545)
+ALTER TABLE tasks
+    ADD COLUMN recurrence VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN series_id INT NULL,
+    ADD COLUMN recurrence_start DATETIME NULL,
+    ADD COLUMN occurrence_at DATETIME NULL,
+    ADD INDEX idx_tasks_series (series_id);
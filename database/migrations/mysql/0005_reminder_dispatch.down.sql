@@ -0,0 +1,5 @@
+ALTER TABLE reminders
+    DROP INDEX idx_reminders_claim,
+    DROP COLUMN claimed_until,
+    DROP COLUMN claim_token,
+    DROP COLUMN sent_at;
@@ -0,0 +1,8 @@
+-- Bộ gửi nhắc nhở chạy nền: thời điểm gửi và quyền nhận (claim) giữa các instance
+ALTER TABLE reminders
+    ADD COLUMN sent_at DATETIME NULL,
+    ADD COLUMN claim_token CHAR(32) NULL,
+    ADD COLUMN claimed_until DATETIME NULL,
+    ADD INDEX idx_reminders_claim (claim_token);
+
+UPDATE reminders SET sent_at = updated_at WHERE is_sent = TRUE;
@@ -0,0 +1,3 @@
+-- Kênh nhận nhắc nhở: mặc định theo người dùng, có thể ghi đè cho từng nhắc nhở (danh sách cách nhau bởi dấu phẩy)
+ALTER TABLE users ADD COLUMN notification_channels VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE reminders ADD COLUMN channels VARCHAR(100) NOT NULL DEFAULT '';
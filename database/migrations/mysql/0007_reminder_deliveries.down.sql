@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS reminder_deliveries;
+
+ALTER TABLE reminders
+    DROP INDEX idx_reminders_status,
+    DROP COLUMN next_attempt_at,
+    DROP COLUMN attempts,
+    DROP COLUMN status;
@@ -0,0 +1,20 @@
+-- Trạng thái gửi của nhắc nhở và lịch sử từng lần thử gửi
+ALTER TABLE reminders
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at DATETIME NULL,
+    ADD INDEX idx_reminders_status (user_id, status);
+
+UPDATE reminders SET status = 'sent' WHERE is_sent = TRUE;
+
+CREATE TABLE reminder_deliveries (
+    delivery_id INT AUTO_INCREMENT PRIMARY KEY,
+    reminder_id INT NOT NULL,
+    attempt INT NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL,
+    next_retry_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_reminder_deliveries_reminder (reminder_id),
+    CONSTRAINT fk_reminder_deliveries_reminder FOREIGN KEY (reminder_id) REFERENCES reminders (reminder_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS reminder_snoozes;
+
+ALTER TABLE reminders
+    DROP COLUMN acknowledged_at,
+    DROP COLUMN snooze_count,
+    DROP COLUMN snoozed_until;
@@ -0,0 +1,17 @@
+-- Hoãn (snooze) và xác nhận (acknowledge) nhắc nhở; lịch sử hoãn dùng cho thống kê
+ALTER TABLE reminders
+    ADD COLUMN snoozed_until DATETIME NULL,
+    ADD COLUMN snooze_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN acknowledged_at DATETIME NULL;
+
+CREATE TABLE reminder_snoozes (
+    snooze_id INT AUTO_INCREMENT PRIMARY KEY,
+    reminder_id INT NOT NULL,
+    user_id INT NOT NULL,
+    snoozed_at DATETIME NOT NULL,
+    snoozed_until DATETIME NOT NULL,
+    INDEX idx_reminder_snoozes_reminder (reminder_id),
+    INDEX idx_reminder_snoozes_user (user_id),
+    CONSTRAINT fk_reminder_snoozes_reminder FOREIGN KEY (reminder_id) REFERENCES reminders (reminder_id) ON DELETE CASCADE,
+    CONSTRAINT fk_reminder_snoozes_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
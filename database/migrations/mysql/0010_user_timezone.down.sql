@@ -0,0 +1,35 @@
+-- Đổi thời gian về lại giờ địa phương của server mà phiên bản trước đọc (loc=Local)
+UPDATE users SET
+    created_at = CONVERT_TZ(created_at, '+00:00', @legacy_time_zone),
+    last_login = CONVERT_TZ(last_login, '+00:00', @legacy_time_zone);
+UPDATE sessions SET
+    access_expires_at = CONVERT_TZ(access_expires_at, '+00:00', @legacy_time_zone),
+    refresh_expires_at = CONVERT_TZ(refresh_expires_at, '+00:00', @legacy_time_zone),
+    created_at = CONVERT_TZ(created_at, '+00:00', @legacy_time_zone);
+UPDATE tasks SET
+    deadline = CONVERT_TZ(deadline, '+00:00', @legacy_time_zone),
+    created_at = CONVERT_TZ(created_at, '+00:00', @legacy_time_zone),
+    updated_at = CONVERT_TZ(updated_at, '+00:00', @legacy_time_zone),
+    completed_at = CONVERT_TZ(completed_at, '+00:00', @legacy_time_zone),
+    recurrence_start = CONVERT_TZ(recurrence_start, '+00:00', @legacy_time_zone),
+    occurrence_at = CONVERT_TZ(occurrence_at, '+00:00', @legacy_time_zone);
+UPDATE reminders SET
+    reminder_time = CONVERT_TZ(reminder_time, '+00:00', @legacy_time_zone),
+    created_at = CONVERT_TZ(created_at, '+00:00', @legacy_time_zone),
+    updated_at = CONVERT_TZ(updated_at, '+00:00', @legacy_time_zone),
+    sent_at = CONVERT_TZ(sent_at, '+00:00', @legacy_time_zone),
+    claimed_until = CONVERT_TZ(claimed_until, '+00:00', @legacy_time_zone),
+    next_attempt_at = CONVERT_TZ(next_attempt_at, '+00:00', @legacy_time_zone),
+    snoozed_until = CONVERT_TZ(snoozed_until, '+00:00', @legacy_time_zone),
+    acknowledged_at = CONVERT_TZ(acknowledged_at, '+00:00', @legacy_time_zone);
+UPDATE reminder_deliveries SET
+    next_retry_at = CONVERT_TZ(next_retry_at, '+00:00', @legacy_time_zone),
+    created_at = CONVERT_TZ(created_at, '+00:00', @legacy_time_zone);
+UPDATE reminder_snoozes SET
+    snoozed_at = CONVERT_TZ(snoozed_at, '+00:00', @legacy_time_zone),
+    snoozed_until = CONVERT_TZ(snoozed_until, '+00:00', @legacy_time_zone);
+
+ALTER TABLE users
+    DROP COLUMN quiet_hours_end,
+    DROP COLUMN quiet_hours_start,
+    DROP COLUMN timezone;
@@ -0,0 +1,38 @@
+-- Múi giờ và khung giờ yên lặng của người dùng
+ALTER TABLE users
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    ADD COLUMN quiet_hours_start VARCHAR(5) NOT NULL DEFAULT '',
+    ADD COLUMN quiet_hours_end VARCHAR(5) NOT NULL DEFAULT '';
+
+-- Từ phiên bản này mọi thời gian được lưu ở UTC. Dữ liệu cũ được ghi theo giờ địa phương
+-- của server (loc=Local) nên đổi sang UTC theo @legacy_time_zone, do trình chạy migration
+-- đặt từ DB_LEGACY_TIME_ZONE (xem README).
+UPDATE users SET
+    created_at = CONVERT_TZ(created_at, @legacy_time_zone, '+00:00'),
+    last_login = CONVERT_TZ(last_login, @legacy_time_zone, '+00:00');
+UPDATE sessions SET
+    access_expires_at = CONVERT_TZ(access_expires_at, @legacy_time_zone, '+00:00'),
+    refresh_expires_at = CONVERT_TZ(refresh_expires_at, @legacy_time_zone, '+00:00'),
+    created_at = CONVERT_TZ(created_at, @legacy_time_zone, '+00:00');
+UPDATE tasks SET
+    deadline = CONVERT_TZ(deadline, @legacy_time_zone, '+00:00'),
+    created_at = CONVERT_TZ(created_at, @legacy_time_zone, '+00:00'),
+    updated_at = CONVERT_TZ(updated_at, @legacy_time_zone, '+00:00'),
+    completed_at = CONVERT_TZ(completed_at, @legacy_time_zone, '+00:00'),
+    recurrence_start = CONVERT_TZ(recurrence_start, @legacy_time_zone, '+00:00'),
+    occurrence_at = CONVERT_TZ(occurrence_at, @legacy_time_zone, '+00:00');
+UPDATE reminders SET
+    reminder_time = CONVERT_TZ(reminder_time, @legacy_time_zone, '+00:00'),
+    created_at = CONVERT_TZ(created_at, @legacy_time_zone, '+00:00'),
+    updated_at = CONVERT_TZ(updated_at, @legacy_time_zone, '+00:00'),
+    sent_at = CONVERT_TZ(sent_at, @legacy_time_zone, '+00:00'),
+    claimed_until = CONVERT_TZ(claimed_until, @legacy_time_zone, '+00:00'),
+    next_attempt_at = CONVERT_TZ(next_attempt_at, @legacy_time_zone, '+00:00'),
+    snoozed_until = CONVERT_TZ(snoozed_until, @legacy_time_zone, '+00:00'),
+    acknowledged_at = CONVERT_TZ(acknowledged_at, @legacy_time_zone, '+00:00');
+UPDATE reminder_deliveries SET
+    next_retry_at = CONVERT_TZ(next_retry_at, @legacy_time_zone, '+00:00'),
+    created_at = CONVERT_TZ(created_at, @legacy_time_zone, '+00:00');
+UPDATE reminder_snoozes SET
+    snoozed_at = CONVERT_TZ(snoozed_at, @legacy_time_zone, '+00:00'),
+    snoozed_until = CONVERT_TZ(snoozed_until, @legacy_time_zone, '+00:00');
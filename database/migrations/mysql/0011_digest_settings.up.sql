@@ -0,0 +1,12 @@
+-- Lịch gửi bản tổng hợp công việc hằng ngày/hằng tuần
+CREATE TABLE digest_settings (
+    user_id INT PRIMARY KEY,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'off',
+    send_time VARCHAR(5) NOT NULL DEFAULT '07:00',
+    weekday VARCHAR(10) NOT NULL DEFAULT 'monday',
+    channels VARCHAR(100) NOT NULL DEFAULT '',
+    last_sent_at DATETIME NULL,
+    next_run_at DATETIME NULL,
+    INDEX idx_digest_settings_next_run (next_run_at),
+    CONSTRAINT fk_digest_settings_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,21 @@
+-- Nhật ký thay đổi phục vụ đồng bộ offline: mỗi bản ghi giữ một dòng mới nhất,
+-- bản ghi đã xóa giữ lại dòng deleted = TRUE (tombstone)
+CREATE TABLE sync_changes (
+    seq BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    entity VARCHAR(20) NOT NULL,
+    entity_id INT NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at DATETIME NOT NULL,
+    INDEX idx_sync_changes_user (user_id, seq),
+    INDEX idx_sync_changes_entity (entity, entity_id),
+    CONSTRAINT fk_sync_changes_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Dữ liệu đã có được coi là thay đổi đầu tiên; danh mục trước công việc, công việc trước nhắc nhở
+INSERT INTO sync_changes (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, 'category', category_id, FALSE, UTC_TIMESTAMP() FROM categories ORDER BY category_id;
+INSERT INTO sync_changes (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, 'task', task_id, FALSE, updated_at FROM tasks ORDER BY task_id;
+INSERT INTO sync_changes (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, 'reminder', reminder_id, FALSE, updated_at FROM reminders ORDER BY reminder_id;
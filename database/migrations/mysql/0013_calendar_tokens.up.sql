@@ -0,0 +1,7 @@
+-- Token bí mật của feed lịch (.ics); chỉ lưu giá trị băm
+CREATE TABLE calendar_tokens (
+    user_id INT PRIMARY KEY,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at DATETIME NOT NULL,
+    CONSTRAINT fk_calendar_tokens_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,11 @@
+-- Tên tài nguyên và UID của công việc được tạo từ client CalDAV. Không có khóa ngoại tới
+-- tasks: bản ghi được giữ sau khi công việc bị xóa để báo cho client trong sync-collection.
+CREATE TABLE caldav_objects (
+    user_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    uid VARCHAR(255) NOT NULL,
+    task_id INT NOT NULL,
+    PRIMARY KEY (user_id, name),
+    INDEX idx_caldav_objects_task (task_id),
+    CONSTRAINT fk_caldav_objects_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
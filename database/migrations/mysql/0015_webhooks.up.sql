@@ -0,0 +1,37 @@
+-- Webhook gửi sự kiện ra ngoài và hàng đợi gửi (kiêm nhật ký) của từng sự kiện
+CREATE TABLE webhooks (
+    webhook_id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    INDEX idx_webhooks_user (user_id),
+    CONSTRAINT fk_webhooks_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE webhook_deliveries (
+    delivery_id INT AUTO_INCREMENT PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    user_id INT NOT NULL,
+    event_id VARCHAR(64) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    response_status INT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL,
+    next_attempt_at DATETIME NULL,
+    delivered_at DATETIME NULL,
+    replay_of INT NULL,
+    claim_token CHAR(32) NULL,
+    claimed_until DATETIME NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    INDEX idx_webhook_deliveries_webhook (webhook_id, delivery_id),
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at),
+    INDEX idx_webhook_deliveries_claim (claim_token),
+    CONSTRAINT fk_webhook_deliveries_webhook FOREIGN KEY (webhook_id) REFERENCES webhooks (webhook_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+-- Giá trị đã được ánh xạ về enum không được khôi phục
+ALTER TABLE tasks DROP CHECK chk_tasks_priority;
+ALTER TABLE tasks DROP CHECK chk_tasks_status;
@@ -0,0 +1,19 @@
+-- Giá trị trạng thái/độ ưu tiên tự do hoặc sai hoa thường từ trước khi có kiểm tra enum
+-- ('completed', 'Done', 'high'...) được ánh xạ về enum, không nhận ra thì về Pending/Medium.
+-- Nếu không, mọi PUT/PATCH trên các công việc này đều bị từ chối (422).
+UPDATE tasks SET status = CASE
+    WHEN LOWER(TRIM(status)) IN ('completed', 'complete', 'done', 'finished', 'closed') THEN 'Completed'
+    WHEN LOWER(TRIM(status)) IN ('in progress', 'in_progress', 'in-progress', 'inprogress', 'doing', 'started') THEN 'In Progress'
+    ELSE 'Pending'
+END;
+UPDATE tasks SET priority = CASE
+    WHEN LOWER(TRIM(priority)) IN ('high', 'urgent', 'cao', 'p1') THEN 'High'
+    WHEN LOWER(TRIM(priority)) IN ('low', 'thap', 'thấp', 'p4') THEN 'Low'
+    ELSE 'Medium'
+END;
+UPDATE tasks SET completed_at = updated_at WHERE status = 'Completed' AND completed_at IS NULL;
+
+-- Từ nay database từ chối giá trị ngoài enum. So sánh theo utf8mb4_bin vì collation mặc
+-- định không phân biệt hoa thường. Cần MySQL 8.0.16+ để CHECK có hiệu lực.
+ALTER TABLE tasks ADD CONSTRAINT chk_tasks_status CHECK (status COLLATE utf8mb4_bin IN ('Pending', 'In Progress', 'Completed'));
+ALTER TABLE tasks ADD CONSTRAINT chk_tasks_priority CHECK (priority COLLATE utf8mb4_bin IN ('Low', 'Medium', 'High'));
@@ -0,0 +1,28 @@
+-- seq theo người dùng có thể trùng nhau giữa các người dùng: nhật ký được đánh số lại theo
+-- thứ tự ghi, client cần đồng bộ lại từ đầu
+ALTER TABLE sync_changes DROP FOREIGN KEY fk_sync_changes_user;
+RENAME TABLE sync_changes TO sync_changes_by_user;
+
+CREATE TABLE sync_changes (
+    seq BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    entity VARCHAR(20) NOT NULL,
+    entity_id INT NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at DATETIME NOT NULL,
+    INDEX idx_sync_changes_user (user_id, seq),
+    INDEX idx_sync_changes_entity (entity, entity_id),
+    CONSTRAINT fk_sync_changes_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO sync_changes (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, entity, entity_id, deleted, changed_at FROM sync_changes_by_user ORDER BY changed_at, user_id, seq;
+DROP TABLE sync_changes_by_user;
+
+CREATE TABLE sync_sequence (
+    id INT PRIMARY KEY,
+    last_seq BIGINT NOT NULL
+) ENGINE=InnoDB;
+
+INSERT INTO sync_sequence (id, last_seq) SELECT 1, COALESCE(MAX(seq), 0) FROM sync_changes;
+DROP TABLE IF EXISTS sync_state;
@@ -0,0 +1,21 @@
+-- Bộ đếm seq của nhật ký đồng bộ theo từng người dùng, thay cho bộ đếm chung sync_sequence.
+-- Transaction ghi chỉ khóa dòng của người sở hữu thay đổi nên ghi của những người dùng khác
+-- không phải chờ nhau. seq vì vậy chỉ duy nhất trong nhật ký của một người dùng.
+CREATE TABLE sync_state (
+    user_id INT PRIMARY KEY,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    CONSTRAINT fk_sync_state_user FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+) ENGINE=InnoDB;
+
+-- Bộ đếm tiếp tục từ seq lớn nhất của mỗi người dùng để cursor mà client đang giữ vẫn đúng
+INSERT INTO sync_state (user_id, last_seq)
+SELECT u.user_id, COALESCE(MAX(c.seq), 0)
+FROM users u LEFT JOIN sync_changes c ON c.user_id = u.user_id
+GROUP BY u.user_id;
+
+ALTER TABLE sync_changes
+    MODIFY seq BIGINT NOT NULL,
+    DROP PRIMARY KEY,
+    ADD PRIMARY KEY (user_id, seq);
+
+DROP TABLE sync_sequence;
@@ -0,0 +1,11 @@
+-- Phiên đăng nhập: chỉ lưu SHA-256 của access token và refresh token
+CREATE TABLE sessions (
+    session_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    access_token_hash CHAR(64) NOT NULL UNIQUE,
+    access_expires_at DATETIME NOT NULL,
+    refresh_token_hash CHAR(64) NOT NULL UNIQUE,
+    refresh_expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_sessions_user ON sessions (user_id);
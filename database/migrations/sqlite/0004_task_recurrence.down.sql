@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_tasks_series;
+ALTER TABLE tasks DROP COLUMN occurrence_at;
+ALTER TABLE tasks DROP COLUMN recurrence_start;
+ALTER TABLE tasks DROP COLUMN series_id;
+ALTER TABLE tasks DROP COLUMN recurrence;
@@ -0,0 +1,6 @@
+-- Công việc lặp lại theo RRULE (RFC 5545)
+ALTER TABLE tasks ADD COLUMN recurrence VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN series_id INTEGER NULL;
+ALTER TABLE tasks ADD COLUMN recurrence_start DATETIME NULL;
+ALTER TABLE tasks ADD COLUMN occurrence_at DATETIME NULL;
+CREATE INDEX IF NOT EXISTS idx_tasks_series ON tasks (series_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_reminders_claim;
+ALTER TABLE reminders DROP COLUMN claimed_until;
+ALTER TABLE reminders DROP COLUMN claim_token;
+ALTER TABLE reminders DROP COLUMN sent_at;
@@ -0,0 +1,7 @@
+-- Bộ gửi nhắc nhở chạy nền: thời điểm gửi và quyền nhận (claim) giữa các instance
+ALTER TABLE reminders ADD COLUMN sent_at DATETIME NULL;
+ALTER TABLE reminders ADD COLUMN claim_token CHAR(32) NULL;
+ALTER TABLE reminders ADD COLUMN claimed_until DATETIME NULL;
+CREATE INDEX IF NOT EXISTS idx_reminders_claim ON reminders (claim_token);
+
+UPDATE reminders SET sent_at = updated_at WHERE is_sent = TRUE;
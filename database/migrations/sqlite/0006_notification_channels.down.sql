@@ -0,0 +1,2 @@
+ALTER TABLE reminders DROP COLUMN channels;
+ALTER TABLE users DROP COLUMN notification_channels;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS reminder_deliveries;
+
+DROP INDEX IF EXISTS idx_reminders_status;
+ALTER TABLE reminders DROP COLUMN next_attempt_at;
+ALTER TABLE reminders DROP COLUMN attempts;
+ALTER TABLE reminders DROP COLUMN status;
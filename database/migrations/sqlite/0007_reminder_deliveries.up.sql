@@ -0,0 +1,18 @@
+-- Trạng thái gửi của nhắc nhở và lịch sử từng lần thử gửi
+ALTER TABLE reminders ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'scheduled';
+ALTER TABLE reminders ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN next_attempt_at DATETIME NULL;
+CREATE INDEX IF NOT EXISTS idx_reminders_status ON reminders (user_id, status);
+
+UPDATE reminders SET status = 'sent' WHERE is_sent = TRUE;
+
+CREATE TABLE IF NOT EXISTS reminder_deliveries (
+    delivery_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reminder_id INTEGER NOT NULL REFERENCES reminders (reminder_id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    error TEXT NOT NULL,
+    next_retry_at DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_reminder_deliveries_reminder ON reminder_deliveries (reminder_id);
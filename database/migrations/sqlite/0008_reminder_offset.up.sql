@@ -0,0 +1,2 @@
+-- Nhắc nhở tương đối: số phút trước deadline của công việc (NULL = nhắc nhở theo thời điểm cố định)
+ALTER TABLE reminders ADD COLUMN offset_minutes INT NULL;
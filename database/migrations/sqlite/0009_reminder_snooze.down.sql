@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS reminder_snoozes;
+
+ALTER TABLE reminders DROP COLUMN acknowledged_at;
+ALTER TABLE reminders DROP COLUMN snooze_count;
+ALTER TABLE reminders DROP COLUMN snoozed_until;
@@ -0,0 +1,14 @@
+-- Hoãn (snooze) và xác nhận (acknowledge) nhắc nhở; lịch sử hoãn dùng cho thống kê
+ALTER TABLE reminders ADD COLUMN snoozed_until DATETIME NULL;
+ALTER TABLE reminders ADD COLUMN snooze_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reminders ADD COLUMN acknowledged_at DATETIME NULL;
+
+CREATE TABLE IF NOT EXISTS reminder_snoozes (
+    snooze_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    reminder_id INTEGER NOT NULL REFERENCES reminders (reminder_id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    snoozed_at DATETIME NOT NULL,
+    snoozed_until DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_reminder_snoozes_reminder ON reminder_snoozes (reminder_id);
+CREATE INDEX IF NOT EXISTS idx_reminder_snoozes_user ON reminder_snoozes (user_id);
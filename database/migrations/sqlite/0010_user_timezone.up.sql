@@ -0,0 +1,4 @@
+-- Múi giờ và khung giờ yên lặng của người dùng
+ALTER TABLE users ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+ALTER TABLE users ADD COLUMN quiet_hours_start VARCHAR(5) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN quiet_hours_end VARCHAR(5) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Lịch gửi bản tổng hợp công việc hằng ngày/hằng tuần
+CREATE TABLE IF NOT EXISTS digest_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    frequency VARCHAR(10) NOT NULL DEFAULT 'off',
+    send_time VARCHAR(5) NOT NULL DEFAULT '07:00',
+    weekday VARCHAR(10) NOT NULL DEFAULT 'monday',
+    channels VARCHAR(100) NOT NULL DEFAULT '',
+    last_sent_at DATETIME NULL,
+    next_run_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_digest_settings_next_run ON digest_settings (next_run_at);
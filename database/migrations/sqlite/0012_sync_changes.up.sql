@@ -0,0 +1,20 @@
+-- Nhật ký thay đổi phục vụ đồng bộ offline: mỗi bản ghi giữ một dòng mới nhất,
+-- bản ghi đã xóa giữ lại dòng deleted = TRUE (tombstone)
+CREATE TABLE IF NOT EXISTS sync_changes (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    entity VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sync_changes_user ON sync_changes (user_id, seq);
+CREATE INDEX IF NOT EXISTS idx_sync_changes_entity ON sync_changes (entity, entity_id);
+
+-- Dữ liệu đã có được coi là thay đổi đầu tiên; danh mục trước công việc, công việc trước nhắc nhở
+INSERT INTO sync_changes (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, 'category', category_id, FALSE, CURRENT_TIMESTAMP FROM categories ORDER BY category_id;
+INSERT INTO sync_changes (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, 'task', task_id, FALSE, updated_at FROM tasks ORDER BY task_id;
+INSERT INTO sync_changes (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, 'reminder', reminder_id, FALSE, updated_at FROM reminders ORDER BY reminder_id;
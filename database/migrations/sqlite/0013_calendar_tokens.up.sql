@@ -0,0 +1,6 @@
+-- Token bí mật của feed lịch (.ics); chỉ lưu giá trị băm
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    created_at DATETIME NOT NULL
+);
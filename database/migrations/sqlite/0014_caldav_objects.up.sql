@@ -0,0 +1,11 @@
+-- Tên tài nguyên và UID của công việc được tạo từ client CalDAV. Không có khóa ngoại tới
+-- tasks: bản ghi được giữ sau khi công việc bị xóa để báo cho client trong sync-collection.
+CREATE TABLE IF NOT EXISTS caldav_objects (
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    uid VARCHAR(255) NOT NULL,
+    task_id INTEGER NOT NULL,
+    PRIMARY KEY (user_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_caldav_objects_task ON caldav_objects (task_id);
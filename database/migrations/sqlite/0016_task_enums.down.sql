@@ -0,0 +1,3 @@
+-- Giá trị đã được ánh xạ về enum không được khôi phục
+DROP TRIGGER IF EXISTS tasks_enum_update;
+DROP TRIGGER IF EXISTS tasks_enum_insert;
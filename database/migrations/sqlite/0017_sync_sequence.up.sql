@@ -0,0 +1,9 @@
+-- Bộ đếm seq của nhật ký đồng bộ. seq được cấp bằng cách tăng dòng này trong transaction
+-- ghi thay đổi: khóa dòng giữ tới khi commit nên thứ tự seq trùng thứ tự commit, client đọc
+-- theo cursor không bỏ sót thay đổi của transaction commit muộn.
+CREATE TABLE sync_sequence (
+    id INT PRIMARY KEY,
+    last_seq BIGINT NOT NULL
+);
+
+INSERT INTO sync_sequence (id, last_seq) SELECT 1, COALESCE(MAX(seq), 0) FROM sync_changes;
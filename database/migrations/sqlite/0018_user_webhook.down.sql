@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN webhook_secret;
+ALTER TABLE users DROP COLUMN webhook_url;
@@ -0,0 +1,3 @@
+-- Webhook nhận nhắc nhở (kênh webhook) riêng của từng người dùng
+ALTER TABLE users ADD COLUMN webhook_url VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN webhook_secret VARCHAR(255) NOT NULL DEFAULT '';
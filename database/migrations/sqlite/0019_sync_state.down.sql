@@ -0,0 +1,24 @@
+-- seq theo người dùng có thể trùng nhau giữa các người dùng: nhật ký được đánh số lại theo
+-- thứ tự ghi, client cần đồng bộ lại từ đầu
+CREATE TABLE sync_changes_global (
+    seq INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    entity VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at DATETIME NOT NULL
+);
+INSERT INTO sync_changes_global (user_id, entity, entity_id, deleted, changed_at)
+SELECT user_id, entity, entity_id, deleted, changed_at FROM sync_changes ORDER BY changed_at, user_id, seq;
+DROP TABLE sync_changes;
+ALTER TABLE sync_changes_global RENAME TO sync_changes;
+CREATE INDEX idx_sync_changes_user ON sync_changes (user_id, seq);
+CREATE INDEX idx_sync_changes_entity ON sync_changes (entity, entity_id);
+
+CREATE TABLE sync_sequence (
+    id INT PRIMARY KEY,
+    last_seq BIGINT NOT NULL
+);
+
+INSERT INTO sync_sequence (id, last_seq) SELECT 1, COALESCE(MAX(seq), 0) FROM sync_changes;
+DROP TABLE IF EXISTS sync_state;
@@ -0,0 +1,31 @@
+-- Bộ đếm seq của nhật ký đồng bộ theo từng người dùng, thay cho bộ đếm chung sync_sequence.
+-- Transaction ghi chỉ khóa dòng của người sở hữu thay đổi nên ghi của những người dùng khác
+-- không phải chờ nhau. seq vì vậy chỉ duy nhất trong nhật ký của một người dùng.
+CREATE TABLE sync_state (
+    user_id INTEGER PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL DEFAULT 0
+);
+
+-- Bộ đếm tiếp tục từ seq lớn nhất của mỗi người dùng để cursor mà client đang giữ vẫn đúng
+INSERT INTO sync_state (user_id, last_seq)
+SELECT u.user_id, COALESCE(MAX(c.seq), 0)
+FROM users u LEFT JOIN sync_changes c ON c.user_id = u.user_id
+GROUP BY u.user_id;
+
+-- SQLite không đổi được khóa chính nên tạo lại bảng với khóa (user_id, seq)
+CREATE TABLE sync_changes_by_user (
+    seq BIGINT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    entity VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, seq)
+);
+INSERT INTO sync_changes_by_user (seq, user_id, entity, entity_id, deleted, changed_at)
+SELECT seq, user_id, entity, entity_id, deleted, changed_at FROM sync_changes;
+DROP TABLE sync_changes;
+ALTER TABLE sync_changes_by_user RENAME TO sync_changes;
+CREATE INDEX idx_sync_changes_entity ON sync_changes (entity, entity_id);
+
+DROP TABLE sync_sequence;
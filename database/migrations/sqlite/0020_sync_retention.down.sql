@@ -0,0 +1,2 @@
+DROP INDEX idx_sync_changes_tombstones;
+ALTER TABLE sync_state DROP COLUMN pruned_seq;
@@ -0,0 +1,4 @@
+-- Tombstone trong nhật ký đồng bộ chỉ được giữ trong một khoảng thời gian. pruned_seq là
+-- seq lớn nhất của tombstone đã bị xóa: cursor nhỏ hơn giá trị này phải đồng bộ lại từ đầu
+ALTER TABLE sync_state ADD COLUMN pruned_seq BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX idx_sync_changes_tombstones ON sync_changes (deleted, changed_at);
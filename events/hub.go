@@ -0,0 +1,207 @@
+// Package events phát các thay đổi dữ liệu (công việc, danh mục, nhắc nhở) tới
+// client đang kết nối theo thời gian thực. Hub chạy trong tiến trình nên mỗi
+// instance của server chỉ thấy thay đổi do chính nó thực hiện.
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Type là loại sự kiện
+type Type string
+
+const (
+	TaskCreated     Type = "task.created"
+	TaskUpdated     Type = "task.updated"
+	TaskDeleted     Type = "task.deleted"
+	TaskCompleted   Type = "task.completed" // Phát kèm task.updated khi công việc chuyển sang Completed
+	CategoryCreated Type = "category.created"
+	CategoryUpdated Type = "category.updated"
+	CategoryDeleted Type = "category.deleted"
+	ReminderFired   Type = "reminder.fired"
+)
+
+// Types liệt kê mọi loại sự kiện
+var Types = []Type{TaskCreated, TaskUpdated, TaskDeleted, TaskCompleted, CategoryCreated, CategoryUpdated,
+	CategoryDeleted, ReminderFired}
+
+func (t Type) Valid() bool {
+	for _, typ := range Types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Giá trị mặc định của Hub
+const (
+	DefaultHistorySize = 1000
+	subscriberBuffer   = 64
+)
+
+// Event là một thay đổi của người dùng UserID. ID có dạng "<epoch>-<seq>": epoch đổi
+// mỗi lần server khởi động để client biết khi nào không thể nối tiếp được nữa.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      Type      `json:"type"`
+	UserID    int       `json:"user_id"`
+	Data      any       `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+
+	seq uint64
+}
+
+// Hub nhận sự kiện từ handler và bộ gửi nhắc nhở rồi chuyển cho các subscriber của
+// cùng người dùng. Hub giữ History sự kiện gần nhất để client kết nối lại với
+// Last-Event-ID nhận được phần đã lỡ. Mọi method đều an toàn khi Hub là nil.
+type Hub struct {
+	mu          sync.Mutex
+	epoch       int64
+	seq         uint64
+	history     []Event // vòng tròn, tối đa historySize phần tử
+	historySize int
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewHub tạo Hub giữ lại historySize sự kiện gần nhất
+func NewHub(historySize int) *Hub {
+	return &Hub{
+		epoch:       time.Now().UnixNano(),
+		historySize: historySize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Subscription nhận sự kiện của một người dùng qua C. C bị đóng khi Close được gọi,
+// khi Hub đóng hoặc khi subscriber đọc quá chậm (client cần kết nối lại để nối tiếp).
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	userID int
+	hub    *Hub
+}
+
+// Publish ghi nhận và phát một sự kiện cho người dùng userID
+func (h *Hub) Publish(userID int, typ Type, data any) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+
+	h.seq++
+	event := Event{
+		ID:        fmt.Sprintf("%d-%d", h.epoch, h.seq),
+		Type:      typ,
+		UserID:    userID,
+		Data:      data,
+		CreatedAt: time.Now(),
+		seq:       h.seq,
+	}
+	if len(h.history) < h.historySize {
+		h.history = append(h.history, event)
+	} else if h.historySize > 0 {
+		h.history[int((h.seq-1)%uint64(h.historySize))] = event
+	}
+
+	for sub := range h.subscribers {
+		if sub.userID != userID {
+			continue
+		}
+		select {
+		case sub.c <- event:
+		default:
+			// Subscriber quá chậm: ngắt để client kết nối lại và nối tiếp từ lịch sử
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe đăng ký nhận sự kiện của userID. Nếu lastEventID khác rỗng, replay chứa
+// các sự kiện sau lastEventID; resumed = false nghĩa là không thể nối tiếp (ID của
+// lần chạy trước hoặc đã quá cũ) và client nên tải lại toàn bộ dữ liệu.
+func (h *Hub) Subscribe(userID int, lastEventID string) (sub *Subscription, replay []Event, resumed bool) {
+	c := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, userID: userID, hub: h}
+	if h == nil {
+		close(c)
+		return sub, nil, lastEventID == ""
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(c)
+		return sub, nil, false
+	}
+	h.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+	last, ok := h.parseID(lastEventID)
+	if !ok || last > h.seq {
+		return sub, nil, false
+	}
+	oldest := h.seq - uint64(len(h.history)) + 1
+	if last+1 < oldest {
+		return sub, nil, false
+	}
+	for seq := last + 1; seq <= h.seq; seq++ {
+		event := h.history[int((seq-1)%uint64(h.historySize))]
+		if event.UserID == userID {
+			replay = append(replay, event)
+		}
+	}
+	return sub, replay, true
+}
+
+// parseID đọc seq từ ID sự kiện; false nếu ID không thuộc lần chạy hiện tại
+func (h *Hub) parseID(id string) (uint64, bool) {
+	epoch, seq, found := strings.Cut(id, "-")
+	if !found || epoch != strconv.FormatInt(h.epoch, 10) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+// Close hủy đăng ký; gọi nhiều lần không sao
+func (s *Subscription) Close() {
+	if s.hub == nil {
+		return
+	}
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove bỏ subscriber và đóng kênh của nó. Gọi khi đang giữ khóa.
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.c)
+	}
+}
+
+// Close đóng mọi subscription, dùng khi server dừng để các kết nối stream kết thúc
+func (h *Hub) Close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		h.remove(sub)
+	}
+}
@@ -0,0 +1,367 @@
+package handlers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/handlers"
+	"backend/models"
+	"backend/store/memstore"
+
+	"github.com/gorilla/mux"
+)
+
+// ownerFixture là dữ liệu của người dùng A mà người dùng B cố truy cập
+type ownerFixture struct {
+	user       testUser
+	categoryID int
+	taskID     int
+	reminderID int
+	webhookID  int
+	deliveryID int
+}
+
+func newOwnerFixture(a *testAPI, user testUser) ownerFixture {
+	a.t.Helper()
+	f := ownerFixture{user: user}
+
+	var category models.Category
+	a.decode(a.mustDo(user.Token, "POST", "/api/categories", map[string]any{
+		"category_name": "Công việc của " + user.Username,
+	}, http.StatusCreated), &category)
+	f.categoryID = category.ID
+
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Họp hằng tuần",
+		"description": "Chuẩn bị báo cáo",
+		"deadline":    time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+		"category_id": f.categoryID,
+		"recurrence":  "FREQ=WEEKLY",
+	}, http.StatusCreated), &task)
+	f.taskID = task.ID
+
+	var reminder models.Reminder
+	a.decode(a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{
+		"task_id":        f.taskID,
+		"offset_minutes": 30,
+	}, http.StatusCreated), &reminder)
+	f.reminderID = reminder.ID
+
+	var webhook models.Webhook
+	a.decode(a.mustDo(user.Token, "POST", path("/api/users/%d/webhooks", user.ID), map[string]any{
+		"url": "https://hooks.example.com/" + user.Username,
+	}, http.StatusCreated), &webhook)
+	f.webhookID = webhook.ID
+
+	delivery := models.WebhookDelivery{
+		WebhookID: f.webhookID,
+		UserID:    user.ID,
+		EventID:   "1-1",
+		Event:     "task.created",
+		Payload:   `{"event":"task.created"}`,
+	}
+	if err := a.store.CreateWebhookDelivery(a.t.Context(), &delivery); err != nil {
+		a.t.Fatalf("tạo webhook delivery: %v", err)
+	}
+	f.deliveryID = delivery.ID
+
+	a.mustDo(user.Token, "POST", path("/api/users/%d/calendar/token", user.ID), nil, http.StatusCreated)
+	return f
+}
+
+// snapshot đọc lại toàn bộ dữ liệu của chủ sở hữu qua API để phát hiện thay đổi
+func (f ownerFixture) snapshot(a *testAPI) map[string]string {
+	a.t.Helper()
+	views := []string{
+		path("/api/users/%d", f.user.ID),
+		path("/api/users/%d/tasks", f.user.ID),
+		path("/api/users/%d/categories", f.user.ID),
+		path("/api/tasks/%d", f.taskID),
+		path("/api/categories/%d", f.categoryID),
+		path("/api/tasks/%d/reminders", f.taskID),
+		path("/api/reminders/%d/deliveries", f.reminderID),
+		path("/api/reminders/%d/snoozes", f.reminderID),
+		path("/api/users/%d/digest", f.user.ID),
+		path("/api/users/%d/webhooks", f.user.ID),
+		path("/api/webhooks/%d/deliveries", f.webhookID),
+		path("/api/webhooks/%d/deliveries/%d", f.webhookID, f.deliveryID),
+		"/api/sync",
+	}
+	snapshot := map[string]string{}
+	for _, view := range views {
+		snapshot[view] = string(a.mustDo(f.user.Token, "GET", view, nil, http.StatusOK))
+	}
+	token, err := a.store.GetCalendarToken(a.t.Context(), f.user.ID)
+	if err != nil {
+		a.t.Fatalf("đọc token lịch: %v", err)
+	}
+	snapshot["calendar token"] = token
+	return snapshot
+}
+
+// crossUserRequest là một request người dùng B gửi tới tài nguyên của người dùng A
+type crossUserRequest struct {
+	method string
+	// route là mẫu đường dẫn đã đăng ký trong router, dùng để kiểm tra không bỏ sót route nào
+	route  string
+	path   string
+	body   any
+	header []string
+}
+
+func crossUserRequests(t *testing.T, f ownerFixture) []crossUserRequest {
+	u, task, category, reminder := f.user.ID, f.taskID, f.categoryID, f.reminderID
+	webhook, delivery := f.webhookID, f.deliveryID
+
+	taskBody := map[string]any{
+		"title":       "Bị sửa",
+		"description": "Bị sửa",
+		"deadline":    time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339),
+		"priority":    "High",
+		"status":      "Pending",
+	}
+	mergePatch := []string{"Content-Type", "application/merge-patch+json"}
+	importBody, importType := multipartFile(t, "tasks.csv", "title\nBị chèn\n")
+
+	return []crossUserRequest{
+		{method: "GET", route: "/api/users/{id}", path: path("/api/users/%d", u)},
+		{method: "PUT", route: "/api/users/{id}", path: path("/api/users/%d", u), body: map[string]any{
+			"username": "bi-doi-ten", "email": "b@example.com", "full_name": "B",
+		}},
+		{method: "PATCH", route: "/api/users/{id}", path: path("/api/users/%d", u), body: map[string]any{"full_name": "B"}, header: mergePatch},
+		{method: "DELETE", route: "/api/users/{id}", path: path("/api/users/%d", u)},
+		{method: "GET", route: "/api/users/{user_id}/tasks", path: path("/api/users/%d/tasks", u)},
+		{method: "GET", route: "/api/users/{user_id}/categories", path: path("/api/users/%d/categories", u)},
+
+		{method: "GET", route: "/api/tasks/{id}", path: path("/api/tasks/%d", task)},
+		{method: "PUT", route: "/api/tasks/{id}", path: path("/api/tasks/%d", task), body: taskBody},
+		{method: "PATCH", route: "/api/tasks/{id}", path: path("/api/tasks/%d", task), body: map[string]any{"title": "Bị sửa"}, header: mergePatch},
+		{method: "DELETE", route: "/api/tasks/{id}", path: path("/api/tasks/%d", task)},
+		{method: "POST", route: "/api/tasks/{id}/skip", path: path("/api/tasks/%d/skip", task)},
+		{method: "PATCH", route: "/api/tasks/{id}/series", path: path("/api/tasks/%d/series", task), body: map[string]any{"title": "Bị sửa"}, header: mergePatch},
+		{method: "DELETE", route: "/api/tasks/{id}/series", path: path("/api/tasks/%d/series", task)},
+		// Gắn công việc mới của B vào danh mục của A
+		{method: "POST", route: "/api/tasks", path: "/api/tasks", body: map[string]any{
+			"title": "Của B", "description": "Của B", "deadline": taskBody["deadline"], "category_id": category,
+		}},
+
+		{method: "GET", route: "/api/categories/{id}", path: path("/api/categories/%d", category)},
+		{method: "PUT", route: "/api/categories/{id}", path: path("/api/categories/%d", category), body: map[string]any{"category_name": "Bị sửa"}},
+		{method: "PATCH", route: "/api/categories/{id}", path: path("/api/categories/%d", category), body: map[string]any{"category_name": "Bị sửa"}, header: mergePatch},
+		{method: "DELETE", route: "/api/categories/{id}", path: path("/api/categories/%d", category)},
+
+		// Tạo nhắc nhở cho công việc của A
+		{method: "POST", route: "/api/reminders", path: "/api/reminders", body: map[string]any{"task_id": task, "offset_minutes": 10}},
+		{method: "PUT", route: "/api/reminders/{id}", path: path("/api/reminders/%d", reminder), body: map[string]any{"task_id": task, "offset_minutes": 5}},
+		{method: "PATCH", route: "/api/reminders/{id}", path: path("/api/reminders/%d", reminder), body: map[string]any{"offset_minutes": 5}, header: mergePatch},
+		{method: "DELETE", route: "/api/reminders/{id}", path: path("/api/reminders/%d", reminder)},
+		{method: "GET", route: "/api/tasks/{task_id}/reminders", path: path("/api/tasks/%d/reminders", task)},
+		{method: "GET", route: "/api/reminders/{id}/deliveries", path: path("/api/reminders/%d/deliveries", reminder)},
+		{method: "POST", route: "/api/reminders/{id}/redrive", path: path("/api/reminders/%d/redrive", reminder)},
+		{method: "POST", route: "/api/reminders/{id}/snooze", path: path("/api/reminders/%d/snooze", reminder), body: map[string]any{"minutes": 10}},
+		{method: "POST", route: "/api/reminders/{id}/ack", path: path("/api/reminders/%d/ack", reminder)},
+		{method: "GET", route: "/api/reminders/{id}/snoozes", path: path("/api/reminders/%d/snoozes", reminder)},
+		{method: "GET", route: "/api/users/{user_id}/reminders/failed", path: path("/api/users/%d/reminders/failed", u)},
+
+		{method: "GET", route: "/api/users/{user_id}/digest", path: path("/api/users/%d/digest", u)},
+		{method: "PUT", route: "/api/users/{user_id}/digest", path: path("/api/users/%d/digest", u), body: map[string]any{"frequency": "daily", "time": "07:00"}},
+
+		{method: "POST", route: "/api/users/{user_id}/calendar/token", path: path("/api/users/%d/calendar/token", u)},
+		{method: "DELETE", route: "/api/users/{user_id}/calendar/token", path: path("/api/users/%d/calendar/token", u)},
+
+		{method: "POST", route: "/api/users/{user_id}/import", path: path("/api/users/%d/import", u), body: importBody, header: []string{"Content-Type", importType}},
+		{method: "GET", route: "/api/users/{user_id}/export", path: path("/api/users/%d/export", u)},
+
+		{method: "GET", route: "/api/users/{user_id}/webhooks", path: path("/api/users/%d/webhooks", u)},
+		{method: "POST", route: "/api/users/{user_id}/webhooks", path: path("/api/users/%d/webhooks", u), body: map[string]any{"url": "https://evil.example.com/"}},
+		{method: "GET", route: "/api/webhooks/{id}", path: path("/api/webhooks/%d", webhook)},
+		{method: "PUT", route: "/api/webhooks/{id}", path: path("/api/webhooks/%d", webhook), body: map[string]any{"url": "https://evil.example.com/"}},
+		{method: "DELETE", route: "/api/webhooks/{id}", path: path("/api/webhooks/%d", webhook)},
+		{method: "GET", route: "/api/webhooks/{id}/deliveries", path: path("/api/webhooks/%d/deliveries", webhook)},
+		{method: "GET", route: "/api/webhooks/{id}/deliveries/{delivery_id}", path: path("/api/webhooks/%d/deliveries/%d", webhook, delivery)},
+		{method: "POST", route: "/api/webhooks/{id}/deliveries/{delivery_id}/replay", path: path("/api/webhooks/%d/deliveries/%d/replay", webhook, delivery)},
+
+		{method: "GET", route: "/api/users/{user_id}/events", path: path("/api/users/%d/events", u)},
+		{method: "GET", route: "/api/users/{user_id}/ws", path: path("/api/users/%d/ws", u)},
+
+		{method: "GET", route: "/api/users/{user_id}/statistics", path: path("/api/users/%d/statistics", u)},
+		{method: "GET", route: "/api/users/{user_id}/tasks-with-reminders", path: path("/api/users/%d/tasks-with-reminders", u)},
+	}
+}
+
+func multipartFile(t *testing.T, name, content string) ([]byte, string) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(content))
+	mw.WriteField("format", "csv")
+	mw.WriteField("mapping", `{"title":"title"}`)
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), mw.FormDataContentType()
+}
+
+// TestCrossUserAccess gọi mọi route có ID bằng tài khoản của người khác: tất cả phải
+// trả về 404 và dữ liệu của chủ sở hữu không thay đổi
+func TestCrossUserAccess(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice")
+	bob := a.signup("bob")
+	owner := newOwnerFixture(a, alice)
+	// B cũng có dữ liệu riêng để lỗi nhầm ID không bị che bởi "không có gì"
+	newOwnerFixture(a, bob)
+
+	before := owner.snapshot(a)
+	for _, req := range crossUserRequests(t, owner) {
+		resp := a.do(bob.Token, req.method, req.path, req.body, req.header...)
+		body := readBody(t, resp)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s %s bởi người khác: mã %d, muốn 404: %s", req.method, req.path, resp.StatusCode, body)
+		}
+	}
+
+	after := owner.snapshot(a)
+	for view, want := range before {
+		if after[view] != want {
+			t.Errorf("%s đã bị thay đổi bởi người khác:\ntrước: %s\nsau:   %s", view, want, after[view])
+		}
+	}
+}
+
+// TestCrossUserRoutesCovered bảo đảm mỗi route có tham số trong đường dẫn đều nằm trong
+// TestCrossUserAccess, để route mới không bị bỏ quên
+func TestCrossUserRoutesCovered(t *testing.T) {
+	covered := map[string]bool{}
+	for _, req := range crossUserRequests(t, ownerFixture{}) {
+		covered[req.method+" "+req.route] = true
+	}
+	// Route công khai có cơ chế xác thực riêng, được kiểm tra ở các test khác
+	public := map[string]bool{"/api/users/{user_id}/calendar.ics": true}
+
+	router := handlers.New(memstore.New()).Router()
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.Contains(template, "{") || public[template] {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if !covered[method+" "+template] {
+				t.Errorf("route %s %s chưa được kiểm tra truy cập chéo", method, template)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCrossUserBodyReferences kiểm tra các đường vào không mang ID trong URL: đồng bộ
+// offline, feed lịch và CalDAV
+func TestCrossUserBodyReferences(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice")
+	bob := a.signup("bob")
+	owner := newOwnerFixture(a, alice)
+	before := owner.snapshot(a)
+
+	t.Run("sync", func(t *testing.T) {
+		var page struct {
+			Changes []struct {
+				Entity string `json:"entity"`
+				ID     int    `json:"id"`
+			} `json:"changes"`
+		}
+		a.decode(a.mustDo(bob.Token, "GET", "/api/sync", nil, http.StatusOK), &page)
+		if len(page.Changes) != 0 {
+			t.Errorf("B nhận được thay đổi của A: %+v", page.Changes)
+		}
+
+		var push struct {
+			Results []struct {
+				ClientID string `json:"client_id"`
+				Status   string `json:"status"`
+				Code     int    `json:"code"`
+			} `json:"results"`
+		}
+		a.decode(a.mustDo(bob.Token, "POST", "/api/sync", map[string]any{"changes": []map[string]any{
+			{"client_id": "t-update", "entity": "task", "op": "update", "id": owner.taskID, "data": map[string]any{"title": "Bị sửa"}},
+			{"client_id": "t-delete", "entity": "task", "op": "delete", "id": owner.taskID},
+			{"client_id": "c-update", "entity": "category", "op": "update", "id": owner.categoryID, "data": map[string]any{"category_name": "Bị sửa"}},
+			{"client_id": "c-delete", "entity": "category", "op": "delete", "id": owner.categoryID},
+			{"client_id": "t-create", "entity": "task", "op": "create", "data": map[string]any{
+				"title": "Của B", "description": "Của B", "deadline": time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+				"category_id": owner.categoryID,
+			}},
+		}}, http.StatusOK), &push)
+		for _, result := range push.Results {
+			if result.Status != "error" || result.Code != http.StatusNotFound {
+				t.Errorf("%s: kết quả %s/%d, muốn error/404", result.ClientID, result.Status, result.Code)
+			}
+		}
+	})
+
+	t.Run("calendar feed", func(t *testing.T) {
+		var created struct {
+			Token string `json:"token"`
+		}
+		a.decode(a.mustDo(bob.Token, "POST", path("/api/users/%d/calendar/token", bob.ID), nil, http.StatusCreated), &created)
+		resp := a.do("", "GET", path("/api/users/%d/calendar.ics?token=%s", alice.ID, created.Token), nil)
+		if body := readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("feed lịch của A với token của B: mã %d, muốn 404: %s", resp.StatusCode, body)
+		}
+	})
+
+	t.Run("caldav", func(t *testing.T) {
+		var created struct {
+			Token string `json:"token"`
+		}
+		a.decode(a.mustDo(bob.Token, "POST", path("/api/users/%d/calendar/token", bob.ID), nil, http.StatusCreated), &created)
+
+		collection := path("/dav/calendars/%s/%d/", alice.Username, owner.categoryID)
+		object := path("%stask-%d.ics", collection, owner.taskID)
+		requests := []struct{ method, path, body string }{
+			{"PROPFIND", path("/dav/calendars/%s/", alice.Username), ""},
+			{"PROPFIND", collection, ""},
+			{"REPORT", collection, `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav"><D:prop><D:getetag/></D:prop></C:calendar-query>`},
+			{"GET", object, ""},
+			{"PUT", object, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VTODO\r\nUID:x\r\nSUMMARY:Bị sửa\r\nEND:VTODO\r\nEND:VCALENDAR\r\n"},
+			{"DELETE", object, ""},
+		}
+		for _, req := range requests {
+			httpReq, err := http.NewRequestWithContext(t.Context(), req.method, a.server.URL+req.path, strings.NewReader(req.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			httpReq.SetBasicAuth(bob.Username, created.Token)
+			resp, err := a.server.Client().Do(httpReq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body := readBody(t, resp); resp.StatusCode != http.StatusNotFound {
+				t.Errorf("%s %s bởi B: mã %d, muốn 404: %s", req.method, req.path, resp.StatusCode, body)
+			}
+		}
+	})
+
+	after := owner.snapshot(a)
+	for view, want := range before {
+		if after[view] != want {
+			t.Errorf("%s đã bị thay đổi bởi người khác:\ntrước: %s\nsau:   %s", view, want, after[view])
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/auth"
+	"backend/store"
+)
+
+// TokenResponse là cặp token trả về khi đăng nhập hoặc làm mới phiên
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// newTokenPair sinh access token và refresh token mới
+func newTokenPair() (TokenResponse, error) {
+	access, err := auth.NewToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	refresh, err := auth.NewToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+	return TokenResponse{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// issueSession tạo một phiên đăng nhập mới cho người dùng
+func (h *Handler) issueSession(ctx context.Context, userID int) (TokenResponse, error) {
+	tokens, err := newTokenPair()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	now := time.Now()
+	session := store.Session{
+		UserID:           userID,
+		AccessTokenHash:  auth.HashToken(tokens.AccessToken),
+		AccessExpiresAt:  now.Add(auth.AccessTokenTTL),
+		RefreshTokenHash: auth.HashToken(tokens.RefreshToken),
+		RefreshExpiresAt: now.Add(auth.RefreshTokenTTL),
+		CreatedAt:        now,
+	}
+	if err := h.Sessions.CreateSession(ctx, &session); err != nil {
+		return TokenResponse{}, err
+	}
+	return tokens, nil
+}
+
+// RefreshToken đổi refresh token còn hạn lấy cặp token mới (refresh token cũ bị vô hiệu)
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		RespondWithError(w, http.StatusBadRequest, "Thiếu refresh token")
+		return
+	}
+	defer r.Body.Close()
+
+	now := time.Now()
+	oldHash := auth.HashToken(req.RefreshToken)
+	session, err := h.Sessions.GetSessionByRefreshToken(r.Context(), oldHash, now)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Println("Lỗi truy vấn phiên đăng nhập:", err)
+		}
+		RespondWithError(w, http.StatusUnauthorized, "Refresh token không hợp lệ hoặc đã hết hạn")
+		return
+	}
+
+	tokens, err := newTokenPair()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tạo token")
+		return
+	}
+
+	// Xoay vòng cả hai token; store từ chối nếu refresh token cũ vừa được request khác sử dụng
+	session.AccessTokenHash = auth.HashToken(tokens.AccessToken)
+	session.AccessExpiresAt = now.Add(auth.AccessTokenTTL)
+	session.RefreshTokenHash = auth.HashToken(tokens.RefreshToken)
+	session.RefreshExpiresAt = now.Add(auth.RefreshTokenTTL)
+	if err := h.Sessions.RotateSession(r.Context(), oldHash, &session); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusUnauthorized, "Refresh token không hợp lệ hoặc đã hết hạn")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi làm mới phiên đăng nhập")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, tokens)
+}
+
+// Logout hủy phiên đăng nhập hiện tại
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := auth.SessionID(r.Context())
+	if !ok {
+		RespondWithError(w, http.StatusUnauthorized, "Chưa đăng nhập")
+		return
+	}
+
+	if err := h.Sessions.DeleteSession(r.Context(), sessionID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đăng xuất")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Đăng xuất thành công"})
+}
+
+// AuthMiddleware kiểm tra access token trong header Authorization và gắn
+// người dùng đã xác thực vào context của request
+func (h *Handler) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			RespondWithError(w, http.StatusUnauthorized, "Thiếu access token")
+			return
+		}
+
+		session, err := h.Sessions.GetSessionByAccessToken(r.Context(), auth.HashToken(token), time.Now())
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				log.Println("Lỗi truy vấn phiên đăng nhập:", err)
+				RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xác thực")
+				return
+			}
+			RespondWithError(w, http.StatusUnauthorized, "Access token không hợp lệ hoặc đã hết hạn")
+			return
+		}
+
+		ctx := auth.WithSession(r.Context(), session.UserID, session.ID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,570 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/auth"
+	"backend/ical"
+	"backend/models"
+	"backend/store"
+)
+
+// CalDAV (RFC 4791) cho phép ứng dụng lịch đọc và sửa công việc. Mỗi danh mục là một
+// collection chứa các VTODO; công việc không thuộc danh mục nào nằm trong collection
+// "inbox". Đường dẫn:
+//
+//	/dav/                                    gốc, trỏ tới principal
+//	/dav/principals/{username}/              principal, trỏ tới calendar home
+//	/dav/calendars/{username}/               calendar home
+//	/dav/calendars/{username}/{collection}/  collection: ID danh mục hoặc "inbox"
+//	/dav/calendars/{username}/{collection}/{name}.ics
+//
+// Client xác thực bằng HTTP Basic với username và token lịch (POST
+// /users/{user_id}/calendar/token) làm mật khẩu ứng dụng.
+
+const (
+	davRoot  = "/dav/"
+	davInbox = "inbox"
+	// maxDAVBody giới hạn kích thước body của PROPFIND/REPORT/PUT
+	maxDAVBody = 1 << 20
+)
+
+type davKind int
+
+const (
+	davRootResource davKind = iota
+	davPrincipal
+	davHome
+	davCalendar
+	davObject
+)
+
+// davPath là đường dẫn CalDAV đã phân tích
+type davPath struct {
+	kind       davKind
+	username   string
+	collection string
+	name       string
+}
+
+// parseDAVPath phân tích đường dẫn đã giải mã của yêu cầu
+func parseDAVPath(path string) (davPath, bool) {
+	rest, ok := strings.CutPrefix(path, davRoot)
+	if !ok {
+		return davPath{}, false
+	}
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	switch {
+	case rest == "":
+		return davPath{kind: davRootResource}, true
+	case len(parts) == 2 && parts[0] == "principals" && parts[1] != "":
+		return davPath{kind: davPrincipal, username: parts[1]}, true
+	case len(parts) >= 2 && len(parts) <= 4 && parts[0] == "calendars" && parts[1] != "":
+		p := davPath{kind: davHome, username: parts[1]}
+		if len(parts) >= 3 {
+			p.kind, p.collection = davCalendar, parts[2]
+		}
+		if len(parts) == 4 {
+			if !strings.HasSuffix(parts[3], ".ics") || strings.HasSuffix(rest, "/") {
+				return davPath{}, false
+			}
+			p.kind, p.name = davObject, parts[3]
+		}
+		return p, p.collection != "" || p.kind == davHome
+	}
+	return davPath{}, false
+}
+
+// davState là dữ liệu của người dùng dùng chung trong một yêu cầu CalDAV
+type davState struct {
+	user       models.User
+	categories map[int]models.Category
+	byName     map[string]models.CalDAVObject
+	byTask     map[int]models.CalDAVObject
+}
+
+func (h *Handler) loadDAVState(ctx context.Context, user models.User) (*davState, error) {
+	categories, err := h.Categories.ListCategories(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("đọc danh mục: %w", err)
+	}
+	objects, err := h.CalDAV.ListCalDAVObjects(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("đọc tài nguyên CalDAV: %w", err)
+	}
+
+	st := &davState{
+		user:       user,
+		categories: map[int]models.Category{},
+		byName:     map[string]models.CalDAVObject{},
+		byTask:     map[int]models.CalDAVObject{},
+	}
+	for _, category := range categories {
+		st.categories[category.ID] = category
+	}
+	for _, object := range objects {
+		st.byName[object.Name] = object
+		st.byTask[object.TaskID] = object
+	}
+	return st, nil
+}
+
+// object trả về tên tài nguyên và UID của công việc
+func (st *davState) object(task models.Task) models.CalDAVObject {
+	if object, ok := st.byTask[task.ID]; ok {
+		return object
+	}
+	return models.DefaultCalDAVObject(task)
+}
+
+// taskID trả về ID công việc ứng với tên tài nguyên, 0 nếu tên chưa được dùng
+func (st *davState) taskID(name string) int {
+	if object, ok := st.byName[name]; ok {
+		return object.TaskID
+	}
+	id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "task-"), ".ics"))
+	if err != nil || name != fmt.Sprintf("task-%d.ics", id) {
+		return 0
+	}
+	if _, renamed := st.byTask[id]; renamed {
+		return 0
+	}
+	return id
+}
+
+// collectionOf trả về collection chứa công việc. Công việc thuộc danh mục đã bị xóa
+// nằm trong inbox.
+func (st *davState) collectionOf(task models.Task) string {
+	if _, ok := st.categories[task.CategoryID]; ok {
+		return strconv.Itoa(task.CategoryID)
+	}
+	return davInbox
+}
+
+// categoryID trả về ID danh mục của collection; false nếu collection không tồn tại
+func (st *davState) categoryID(collection string) (int, bool) {
+	if collection == davInbox {
+		return 0, true
+	}
+	id, err := strconv.Atoi(collection)
+	if err != nil || collection != strconv.Itoa(id) {
+		return 0, false
+	}
+	_, ok := st.categories[id]
+	return id, ok
+}
+
+// collections liệt kê inbox và các danh mục theo ID
+func (st *davState) collections() []string {
+	names := []string{davInbox}
+	ids := make([]int, 0, len(st.categories))
+	for id := range st.categories {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		names = append(names, strconv.Itoa(id))
+	}
+	return names
+}
+
+func (st *davState) principalHref() string {
+	return davRoot + "principals/" + url.PathEscape(st.user.Username) + "/"
+}
+
+func (st *davState) homeHref() string {
+	return davRoot + "calendars/" + url.PathEscape(st.user.Username) + "/"
+}
+
+func (st *davState) calendarHref(collection string) string {
+	return st.homeHref() + collection + "/"
+}
+
+func (st *davState) objectHref(collection, name string) string {
+	return st.calendarHref(collection) + url.PathEscape(name)
+}
+
+// renderObject trả về đối tượng iCalendar của công việc và ETag tính từ nội dung
+func (st *davState) renderObject(task models.Task) ([]byte, string) {
+	var cal ical.Writer
+	cal.Begin("VCALENDAR")
+	cal.Line("VERSION", "2.0")
+	cal.Text("PRODID", calendarProdID)
+	category := st.categories[task.CategoryID].CategoryName
+	writeCalendarTask(&cal, "VTODO", st.object(task).UID, task, category, nil, task.UpdatedAt)
+	cal.End("VCALENDAR")
+
+	body := cal.Bytes()
+	sum := sha256.Sum256(body)
+	return body, `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ServeCalDAV xử lý mọi yêu cầu dưới /dav/
+func (h *Handler) ServeCalDAV(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	user, ok := h.davAuthenticate(w, r)
+	if !ok {
+		return
+	}
+	r = r.WithContext(auth.WithSession(r.Context(), user.ID, 0))
+	path, ok := parseDAVPath(r.URL.Path)
+	if !ok || (path.kind != davRootResource && path.username != user.Username) {
+		RespondWithError(w, http.StatusNotFound, "Không tìm thấy tài nguyên")
+		return
+	}
+	st, err := h.loadDAVState(r.Context(), user)
+	if err != nil {
+		log.Printf("Lỗi CalDAV của user %d: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc dữ liệu lịch")
+		return
+	}
+	if path.kind >= davCalendar {
+		if _, ok := st.categoryID(path.collection); !ok {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy lịch")
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxDAVBody)
+	switch {
+	case r.Method == "PROPFIND":
+		h.davPropfind(w, r, st, path)
+	case r.Method == "REPORT" && path.kind == davCalendar:
+		h.davReport(w, r, st, path)
+	case (r.Method == http.MethodGet || r.Method == http.MethodHead) && path.kind == davObject:
+		h.davGet(w, r, st, path)
+	case r.Method == http.MethodPut && path.kind == davObject:
+		h.davPut(w, r, st, path)
+	case r.Method == http.MethodDelete && path.kind == davObject:
+		h.davDelete(w, r, st, path)
+	case r.Method == http.MethodDelete && path.kind == davCalendar:
+		RespondWithError(w, http.StatusForbidden, "Không hỗ trợ xóa danh mục qua CalDAV")
+	default:
+		RespondWithError(w, http.StatusMethodNotAllowed, "Phương thức không được hỗ trợ")
+	}
+}
+
+// davAuthenticate kiểm tra HTTP Basic (username + token lịch).
+// Trả về false sau khi đã ghi response lỗi.
+func (h *Handler) davAuthenticate(w http.ResponseWriter, r *http.Request) (models.User, bool) {
+	username, token, ok := r.BasicAuth()
+	if ok {
+		user, err := h.Users.GetUserByUsername(r.Context(), username)
+		if err == nil && h.checkCalendarToken(r.Context(), user.ID, token) {
+			return user, true
+		}
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			log.Println("Lỗi truy vấn người dùng CalDAV:", err)
+			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xác thực")
+			return models.User{}, false
+		}
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="Tasks", charset="UTF-8"`)
+	RespondWithError(w, http.StatusUnauthorized, "Cần username và token lịch")
+	return models.User{}, false
+}
+
+// davLoadObject đọc công việc ứng với tài nguyên path. Công việc không tồn tại hoặc
+// nằm ở collection khác trả về ErrNotFound.
+func (h *Handler) davLoadObject(ctx context.Context, st *davState, path davPath) (models.Task, error) {
+	id := st.taskID(path.name)
+	if id == 0 {
+		return models.Task{}, store.ErrNotFound
+	}
+	task, err := h.Tasks.GetTask(ctx, st.user.ID, id)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if st.collectionOf(task) != path.collection {
+		return models.Task{}, store.ErrNotFound
+	}
+	return task, nil
+}
+
+func (h *Handler) davGet(w http.ResponseWriter, r *http.Request, st *davState, path davPath) {
+	task, err := h.davLoadObject(r.Context(), st, path)
+	if err != nil {
+		respondDAVLoadError(w, err)
+		return
+	}
+
+	body, etag := st.renderObject(task)
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8; component=vtodo")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", task.UpdatedAt.UTC().Format(http.TimeFormat))
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// davPut tạo hoặc thay công việc từ VTODO client gửi. Công việc mới được tạo trong
+// danh mục của collection; PUT vào collection khác chuyển công việc sang danh mục đó.
+func (h *Handler) davPut(w http.ResponseWriter, r *http.Request, st *davState, path davPath) {
+	ctx := r.Context()
+	categoryID, _ := st.categoryID(path.collection)
+
+	root, err := ical.Parse(r.Body)
+	if err != nil || root.Name != "VCALENDAR" {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu iCalendar không hợp lệ")
+		return
+	}
+	todo := davMasterTodo(root)
+	if todo == nil {
+		RespondWithError(w, http.StatusForbidden, "Chỉ hỗ trợ VTODO")
+		return
+	}
+	uid := ""
+	if prop := todo.Prop("UID"); prop != nil {
+		uid = prop.Text()
+	}
+	if uid == "" {
+		RespondWithError(w, http.StatusBadRequest, "Thiếu UID")
+		return
+	}
+
+	var existing *models.Task
+	if id := st.taskID(path.name); id != 0 {
+		task, err := h.Tasks.GetTask(ctx, st.user.ID, id)
+		switch {
+		case err == nil:
+			existing = &task
+		case !errors.Is(err, store.ErrNotFound):
+			respondServiceError(w, fmt.Errorf("lấy thông tin công việc: %w", err))
+			return
+		}
+	}
+
+	// Điều kiện If-Match/If-None-Match chống ghi đè thay đổi của client khác
+	if r.Header.Get("If-None-Match") == "*" && existing != nil {
+		RespondWithError(w, http.StatusPreconditionFailed, "Tài nguyên đã tồn tại")
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" {
+		if existing == nil {
+			RespondWithError(w, http.StatusPreconditionFailed, "Tài nguyên không tồn tại")
+			return
+		}
+		if _, etag := st.renderObject(*existing); match != "*" && match != etag {
+			RespondWithError(w, http.StatusPreconditionFailed, "ETag không khớp")
+			return
+		}
+	}
+
+	if existing != nil && st.object(*existing).UID != uid {
+		davError(w, http.StatusConflict, caldavNS, "no-uid-conflict")
+		return
+	}
+	if existing == nil {
+		if taken, err := h.davUIDTaken(ctx, st, uid); err != nil {
+			respondServiceError(w, err)
+			return
+		} else if taken {
+			davError(w, http.StatusConflict, caldavNS, "no-uid-conflict")
+			return
+		}
+	}
+
+	task, err := taskFromVTODO(todo, st.user.Location())
+	if err != nil {
+		respondServiceError(w, err)
+		return
+	}
+	task.CategoryID = categoryID
+
+	if existing != nil {
+		if task.Deadline.IsZero() {
+			task.Deadline = existing.Deadline
+		}
+		// Ứng dụng lịch chỉ gửi NEEDS-ACTION/COMPLETED: đánh dấu hoàn thành được áp dụng
+		// cả với công việc chưa bắt đầu
+		if _, err := h.replaceTask(ctx, st.user.ID, existing.ID, task, h.applyCompletion); err != nil {
+			respondServiceError(w, err)
+			return
+		}
+		h.davWritten(w, r, st, existing.ID, http.StatusNoContent)
+		return
+	}
+
+	created, err := h.createTask(ctx, st.user.ID, task)
+	if err != nil {
+		respondServiceError(w, err)
+		return
+	}
+	object := models.CalDAVObject{UserID: st.user.ID, Name: path.name, UID: uid, TaskID: created.ID}
+	if err := h.CalDAV.SaveCalDAVObject(ctx, object); err != nil {
+		// Công việc đã được tạo; client sẽ thấy nó dưới tên mặc định ở lần đồng bộ sau
+		log.Printf("Lỗi khi lưu tài nguyên CalDAV của công việc %d: %v", created.ID, err)
+	} else {
+		st.byName[object.Name] = object
+		st.byTask[object.TaskID] = object
+	}
+	h.davWritten(w, r, st, created.ID, http.StatusCreated)
+}
+
+// davWritten trả lời PUT thành công kèm ETag của công việc id đọc lại sau khi ghi, để
+// client dùng cho If-Match lần sau mà không phải GET lại
+func (h *Handler) davWritten(w http.ResponseWriter, r *http.Request, st *davState, id, code int) {
+	task, err := h.Tasks.GetTask(r.Context(), st.user.ID, id)
+	if err != nil {
+		// Đã ghi xong; client không có ETag sẽ tự đọc lại tài nguyên
+		log.Printf("Lỗi khi đọc lại công việc %d sau PUT CalDAV: %v", id, err)
+	} else {
+		_, etag := st.renderObject(task)
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(code)
+}
+
+// davUIDTaken cho biết UID đã thuộc về một công việc còn tồn tại hay chưa
+func (h *Handler) davUIDTaken(ctx context.Context, st *davState, uid string) (bool, error) {
+	id := 0
+	for _, object := range st.byTask {
+		if object.UID == uid {
+			id = object.TaskID
+		}
+	}
+	if id == 0 {
+		if _, err := fmt.Sscanf(uid, "task-%d@backend", &id); err != nil {
+			return false, nil
+		}
+		if _, renamed := st.byTask[id]; renamed {
+			return false, nil
+		}
+	}
+	_, err := h.Tasks.GetTask(ctx, st.user.ID, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("kiểm tra UID: %w", err)
+	}
+	return true, nil
+}
+
+func (h *Handler) davDelete(w http.ResponseWriter, r *http.Request, st *davState, path davPath) {
+	task, err := h.davLoadObject(r.Context(), st, path)
+	if err != nil {
+		respondDAVLoadError(w, err)
+		return
+	}
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" {
+		if _, etag := st.renderObject(task); match != etag {
+			RespondWithError(w, http.StatusPreconditionFailed, "ETag không khớp")
+			return
+		}
+	}
+
+	if err := h.deleteTask(r.Context(), st.user.ID, task.ID); err != nil {
+		respondServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondDAVLoadError(w http.ResponseWriter, err error) {
+	if errors.Is(err, store.ErrNotFound) {
+		RespondWithError(w, http.StatusNotFound, "Không tìm thấy công việc")
+		return
+	}
+	respondServiceError(w, fmt.Errorf("lấy thông tin công việc: %w", err))
+}
+
+// davMasterTodo trả về VTODO chính của đối tượng (bỏ qua các ngoại lệ RECURRENCE-ID)
+func davMasterTodo(root *ical.Component) *ical.Component {
+	for _, child := range root.Components {
+		if child.Name == "VTODO" && child.Prop("RECURRENCE-ID") == nil {
+			return child
+		}
+	}
+	return nil
+}
+
+// davStatuses ánh xạ STATUS của VTODO sang trạng thái công việc
+var davStatuses = map[string]models.TaskStatus{
+	"NEEDS-ACTION": models.StatusPending,
+	"IN-PROCESS":   models.StatusInProgress,
+	"COMPLETED":    models.StatusCompleted,
+}
+
+// taskFromVTODO đọc các trường của công việc từ VTODO. Giờ "floating" được hiểu theo
+// múi giờ của người dùng; công việc không có DESCRIPTION dùng SUMMARY làm mô tả.
+func taskFromVTODO(todo *ical.Component, loc *time.Location) (models.Task, error) {
+	var task models.Task
+	if prop := todo.Prop("SUMMARY"); prop != nil {
+		task.Title = strings.TrimSpace(prop.Text())
+	}
+	if prop := todo.Prop("DESCRIPTION"); prop != nil {
+		task.Description = strings.TrimSpace(prop.Text())
+	}
+	if task.Description == "" {
+		task.Description = task.Title
+	}
+	if task.Title == "" {
+		return models.Task{}, serviceErrorf(http.StatusBadRequest, "Thiếu SUMMARY")
+	}
+
+	due := todo.Prop("DUE")
+	if due == nil {
+		due = todo.Prop("DTSTART")
+	}
+	if due != nil {
+		deadline, err := due.Time(loc)
+		if err != nil {
+			return models.Task{}, serviceErrorf(http.StatusBadRequest, "Thời gian không hợp lệ: %s", due.Value)
+		}
+		task.Deadline = deadline
+	}
+
+	task.Status = models.StatusPending
+	if prop := todo.Prop("STATUS"); prop != nil {
+		status, ok := davStatuses[strings.ToUpper(prop.Value)]
+		if !ok {
+			return models.Task{}, serviceErrorf(http.StatusUnprocessableEntity, "Không hỗ trợ STATUS:%s", prop.Value)
+		}
+		task.Status = status
+	} else if todo.Prop("COMPLETED") != nil {
+		task.Status = models.StatusCompleted
+	}
+
+	if prop := todo.Prop("PRIORITY"); prop != nil {
+		switch priority, _ := strconv.Atoi(prop.Value); {
+		case priority >= 1 && priority <= 4:
+			task.Priority = models.PriorityHigh
+		case priority >= 6 && priority <= 9:
+			task.Priority = models.PriorityLow
+		default:
+			task.Priority = models.PriorityMedium
+		}
+	}
+	return task, nil
+}
+
+// davError ghi lỗi WebDAV kèm điều kiện bị vi phạm (RFC 4918 mục 16)
+func davError(w http.ResponseWriter, code int, ns, condition string) {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	fmt.Fprintf(&b, `<D:error xmlns:D="DAV:"><%s xmlns="%s"/></D:error>`, condition, ns)
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(b.Bytes())
+}
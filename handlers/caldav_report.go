@@ -0,0 +1,680 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/ical"
+	"backend/models"
+	"backend/store"
+)
+
+// Namespace XML dùng trong WebDAV/CalDAV
+const (
+	davNS    = "DAV:"
+	caldavNS = "urn:ietf:params:xml:ns:caldav"
+	csNS     = "http://calendarserver.org/ns/"
+	appleNS  = "http://apple.com/ns/ical/"
+)
+
+var davPrefixes = map[string]string{davNS: "D", caldavNS: "C", csNS: "CS", appleNS: "A"}
+
+// davSyncTokenPrefix đứng trước seq của nhật ký đồng bộ trong sync-token (RFC 6578)
+const davSyncTokenPrefix = "urn:backend:sync:"
+
+// davPropNames là danh sách property được yêu cầu trong <D:prop>
+type davPropNames []xml.Name
+
+func (p *davPropNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			*p = append(*p, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type davPropfindRequest struct {
+	XMLName  xml.Name     `xml:"DAV: propfind"`
+	AllProp  *struct{}    `xml:"DAV: allprop"`
+	PropName *struct{}    `xml:"DAV: propname"`
+	Prop     davPropNames `xml:"DAV: prop"`
+}
+
+// davReportRequest gom các trường của calendar-query, calendar-multiget và
+// sync-collection; XMLName cho biết loại report
+type davReportRequest struct {
+	XMLName   xml.Name
+	AllProp   *struct{}    `xml:"DAV: allprop"`
+	Prop      davPropNames `xml:"DAV: prop"`
+	Filter    *davFilter   `xml:"urn:ietf:params:xml:ns:caldav filter"`
+	Hrefs     []string     `xml:"DAV: href"`
+	SyncToken string       `xml:"DAV: sync-token"`
+}
+
+type davFilter struct {
+	CompFilter davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davCompFilter struct {
+	Name         string          `xml:"name,attr"`
+	IsNotDefined *struct{}       `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TimeRange    *davTimeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	PropFilters  []davPropFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+	CompFilters  []davCompFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type davPropFilter struct {
+	Name         string        `xml:"name,attr"`
+	IsNotDefined *struct{}     `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+	TextMatch    *davTextMatch `xml:"urn:ietf:params:xml:ns:caldav text-match"`
+}
+
+type davTextMatch struct {
+	Value  string `xml:",chardata"`
+	Negate string `xml:"negate-condition,attr"`
+}
+
+type davTimeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// matches kiểm tra component c (cùng tên với bộ lọc) theo RFC 4791 mục 9.7
+func (f davCompFilter) matches(c *ical.Component) bool {
+	if f.IsNotDefined != nil {
+		return false
+	}
+	if f.TimeRange != nil && !f.TimeRange.matches(c) {
+		return false
+	}
+	for _, pf := range f.PropFilters {
+		if !pf.matches(c) {
+			return false
+		}
+	}
+	for _, cf := range f.CompFilters {
+		child := c.Component(strings.ToUpper(cf.Name))
+		if child == nil {
+			if cf.IsNotDefined == nil {
+				return false
+			}
+			continue
+		}
+		if !cf.matches(child) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f davPropFilter) matches(c *ical.Component) bool {
+	prop := c.Prop(strings.ToUpper(f.Name))
+	if f.IsNotDefined != nil {
+		return prop == nil
+	}
+	if prop == nil {
+		return false
+	}
+	if f.TextMatch == nil {
+		return true
+	}
+	found := strings.Contains(strings.ToLower(prop.Text()), strings.ToLower(f.TextMatch.Value))
+	return found != (f.TextMatch.Negate == "yes")
+}
+
+// matches áp dụng time-range cho VTODO theo DUE: start <= DUE < end
+func (tr davTimeRange) matches(c *ical.Component) bool {
+	due := c.Prop("DUE")
+	if due == nil {
+		return true
+	}
+	at, err := due.Time(time.UTC)
+	if err != nil {
+		return false
+	}
+	if start, err := time.Parse("20060102T150405Z", tr.Start); err == nil && at.Before(start) {
+		return false
+	}
+	if end, err := time.Parse("20060102T150405Z", tr.End); err == nil && !at.Before(end) {
+		return false
+	}
+	return true
+}
+
+// davProp là một property với nội dung XML đã escape
+type davProp struct {
+	name  xml.Name
+	value string
+}
+
+func newDAVProp(ns, local, value string) davProp {
+	return davProp{name: xml.Name{Space: ns, Local: local}, value: value}
+}
+
+func davHrefValue(href string) string {
+	return "<D:href>" + davEscape(href) + "</D:href>"
+}
+
+func davEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// davResponse là một <D:response> của multistatus. Status khác 0 nghĩa là response
+// chỉ có trạng thái (ví dụ 404 cho tài nguyên đã bị xóa trong sync-collection).
+type davResponse struct {
+	href    string
+	status  int
+	found   []davProp
+	missing []xml.Name
+}
+
+// davSelect chọn các property được yêu cầu từ danh sách property của tài nguyên.
+// names rỗng nghĩa là allprop; calendar-data chỉ được trả khi yêu cầu rõ.
+func davSelect(href string, props []davProp, names davPropNames, nameOnly bool) davResponse {
+	resp := davResponse{href: href}
+	if len(names) == 0 {
+		for _, prop := range props {
+			if prop.name.Local == "calendar-data" {
+				continue
+			}
+			if nameOnly {
+				prop.value = ""
+			}
+			resp.found = append(resp.found, prop)
+		}
+		return resp
+	}
+	for _, name := range names {
+		found := false
+		for _, prop := range props {
+			if prop.name == name {
+				resp.found = append(resp.found, prop)
+				found = true
+				break
+			}
+		}
+		if !found {
+			resp.missing = append(resp.missing, name)
+		}
+	}
+	return resp
+}
+
+// writeMultistatus ghi response 207 Multi-Status
+func writeMultistatus(w http.ResponseWriter, responses []davResponse, syncToken string) {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	b.WriteString(`<D:multistatus xmlns:D="DAV:" xmlns:C="` + caldavNS + `" xmlns:CS="` + csNS + `" xmlns:A="` + appleNS + `">`)
+	for _, resp := range responses {
+		b.WriteString("<D:response>" + davHrefValue(resp.href))
+		if resp.status != 0 {
+			b.WriteString("<D:status>" + davStatusLine(resp.status) + "</D:status>")
+		}
+		if len(resp.found) > 0 {
+			b.WriteString("<D:propstat><D:prop>")
+			for _, prop := range resp.found {
+				writeDAVElement(&b, prop.name, prop.value)
+			}
+			b.WriteString("</D:prop><D:status>" + davStatusLine(http.StatusOK) + "</D:status></D:propstat>")
+		}
+		if len(resp.missing) > 0 {
+			b.WriteString("<D:propstat><D:prop>")
+			for _, name := range resp.missing {
+				writeDAVElement(&b, name, "")
+			}
+			b.WriteString("</D:prop><D:status>" + davStatusLine(http.StatusNotFound) + "</D:status></D:propstat>")
+		}
+		b.WriteString("</D:response>")
+	}
+	if syncToken != "" {
+		b.WriteString("<D:sync-token>" + davEscape(syncToken) + "</D:sync-token>")
+	}
+	b.WriteString("</D:multistatus>\n")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write(b.Bytes())
+}
+
+func writeDAVElement(b *bytes.Buffer, name xml.Name, value string) {
+	tag := name.Local
+	open := tag
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+		open = tag
+	} else if name.Space != "" {
+		open = tag + ` xmlns="` + davEscape(name.Space) + `"`
+	}
+	if value == "" {
+		b.WriteString("<" + open + "/>")
+		return
+	}
+	b.WriteString("<" + open + ">" + value + "</" + tag + ">")
+}
+
+func davStatusLine(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+// davReadBody giải mã body XML vào v; body trống trả về false
+func davReadBody(r *http.Request, v any) (bool, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return false, nil
+	}
+	return true, xml.Unmarshal(data, v)
+}
+
+// davSyncToken trả về sync-token hiện tại của người dùng: seq lớn nhất trong nhật ký
+// đồng bộ. Nhật ký chỉ giữ một dòng cho mỗi bản ghi nên số lần đọc nhỏ.
+func (h *Handler) davSyncToken(ctx context.Context, userID int) (int64, error) {
+	_, latest, err := h.davChanges(ctx, userID, 0)
+	return latest, err
+}
+
+// errSyncExpired: tombstone sau token đã bị xóa khỏi nhật ký đồng bộ
+var errSyncExpired = errors.New("sync-token đã quá hạn")
+
+// davChanges đọc toàn bộ thay đổi sau since và seq lớn nhất (since nếu không có thay đổi,
+// không nhỏ hơn seq của tombstone đã xóa). since > 0 nhỏ hơn seq của tombstone đã xóa
+// trả về errSyncExpired.
+func (h *Handler) davChanges(ctx context.Context, userID int, since int64) ([]models.Change, int64, error) {
+	var all []models.Change
+	latest := since
+	for {
+		changes, err := h.Sync.ListChanges(ctx, userID, latest, maxSyncLimit)
+		if err != nil {
+			return nil, 0, fmt.Errorf("đọc nhật ký đồng bộ: %w", err)
+		}
+		all = append(all, changes...)
+		if len(changes) > 0 {
+			latest = changes[len(changes)-1].Seq
+		}
+		if len(changes) < maxSyncLimit {
+			break
+		}
+	}
+	pruned, err := h.Sync.PrunedSeq(ctx, userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("đọc nhật ký đồng bộ: %w", err)
+	}
+	if since > 0 && since < pruned {
+		return nil, 0, errSyncExpired
+	}
+	return all, max(latest, pruned), nil
+}
+
+// resourceProps trả về các property của tài nguyên không phải đối tượng công việc
+func (h *Handler) resourceProps(st *davState, path davPath, token string) []davProp {
+	principal := davHrefValue(st.principalHref())
+	props := []davProp{
+		newDAVProp(davNS, "current-user-principal", principal),
+	}
+	switch path.kind {
+	case davRootResource:
+		props = append(props,
+			newDAVProp(davNS, "resourcetype", "<D:collection/>"),
+			newDAVProp(caldavNS, "calendar-home-set", davHrefValue(st.homeHref())),
+		)
+	case davPrincipal:
+		name := st.user.FullName
+		if name == "" {
+			name = st.user.Username
+		}
+		props = append(props,
+			newDAVProp(davNS, "resourcetype", "<D:principal/>"),
+			newDAVProp(davNS, "displayname", davEscape(name)),
+			newDAVProp(davNS, "principal-URL", principal),
+			newDAVProp(caldavNS, "calendar-home-set", davHrefValue(st.homeHref())),
+		)
+		if st.user.Email != "" {
+			props = append(props, newDAVProp(caldavNS, "calendar-user-address-set", davHrefValue("mailto:"+st.user.Email)))
+		}
+	case davHome:
+		props = append(props,
+			newDAVProp(davNS, "resourcetype", "<D:collection/>"),
+			newDAVProp(davNS, "owner", principal),
+			newDAVProp(davNS, "current-user-privilege-set", "<D:privilege><D:read/></D:privilege>"),
+		)
+	case davCalendar:
+		name, description, color := "Inbox", "Công việc không thuộc danh mục nào", ""
+		if id, _ := st.categoryID(path.collection); id != 0 {
+			category := st.categories[id]
+			name, description, color = category.CategoryName, category.Description, category.Color
+		}
+		props = append(props,
+			newDAVProp(davNS, "resourcetype", "<D:collection/><C:calendar/>"),
+			newDAVProp(davNS, "displayname", davEscape(name)),
+			newDAVProp(davNS, "owner", principal),
+			newDAVProp(davNS, "current-user-privilege-set",
+				"<D:privilege><D:read/></D:privilege><D:privilege><D:write/></D:privilege>"+
+					"<D:privilege><D:write-content/></D:privilege><D:privilege><D:bind/></D:privilege>"+
+					"<D:privilege><D:unbind/></D:privilege>"),
+			newDAVProp(davNS, "supported-report-set",
+				"<D:supported-report><D:report><C:calendar-query/></D:report></D:supported-report>"+
+					"<D:supported-report><D:report><C:calendar-multiget/></D:report></D:supported-report>"+
+					"<D:supported-report><D:report><D:sync-collection/></D:report></D:supported-report>"),
+			newDAVProp(caldavNS, "supported-calendar-component-set", `<C:comp name="VTODO"/>`),
+			newDAVProp(davNS, "sync-token", davEscape(token)),
+			newDAVProp(csNS, "getctag", davEscape(token)),
+		)
+		if description != "" {
+			props = append(props, newDAVProp(caldavNS, "calendar-description", davEscape(description)))
+		}
+		if color != "" {
+			props = append(props, newDAVProp(appleNS, "calendar-color", davEscape(color)))
+		}
+	}
+	return props
+}
+
+// objectProps trả về các property của đối tượng công việc, kể cả calendar-data
+func objectProps(st *davState, task models.Task) []davProp {
+	body, etag := st.renderObject(task)
+	return []davProp{
+		newDAVProp(davNS, "resourcetype", ""),
+		newDAVProp(davNS, "getetag", davEscape(etag)),
+		newDAVProp(davNS, "getcontenttype", "text/calendar; charset=utf-8; component=vtodo"),
+		newDAVProp(davNS, "getcontentlength", strconv.Itoa(len(body))),
+		newDAVProp(davNS, "getlastmodified", task.UpdatedAt.UTC().Format(http.TimeFormat)),
+		newDAVProp(caldavNS, "calendar-data", davEscape(string(body))),
+	}
+}
+
+// collectionTasks trả về công việc thuộc collection
+func (h *Handler) collectionTasks(ctx context.Context, st *davState, collection string) ([]models.Task, error) {
+	filter := store.TaskFilter{Sort: store.SortDeadline}
+	if id, _ := st.categoryID(collection); id != 0 {
+		filter.CategoryID = &id
+	}
+	tasks, err := store.ListAllTasks(ctx, h.Tasks, st.user.ID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("đọc công việc: %w", err)
+	}
+	kept := tasks[:0]
+	for _, task := range tasks {
+		if st.collectionOf(task) == collection {
+			kept = append(kept, task)
+		}
+	}
+	return kept, nil
+}
+
+// davPropfind trả về property của tài nguyên và (với Depth: 1) các tài nguyên con
+func (h *Handler) davPropfind(w http.ResponseWriter, r *http.Request, st *davState, path davPath) {
+	ctx := r.Context()
+	var req davPropfindRequest
+	hasBody, err := davReadBody(r, &req)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu XML không hợp lệ")
+		return
+	}
+	names, nameOnly := req.Prop, hasBody && req.PropName != nil
+	if req.AllProp != nil || nameOnly {
+		names = nil
+	}
+	depthOne := r.Header.Get("Depth") != "0"
+
+	if path.kind == davObject {
+		task, err := h.davLoadObject(ctx, st, path)
+		if err != nil {
+			respondDAVLoadError(w, err)
+			return
+		}
+		href := st.objectHref(path.collection, st.object(task).Name)
+		writeMultistatus(w, []davResponse{davSelect(href, objectProps(st, task), names, nameOnly)}, "")
+		return
+	}
+
+	token := ""
+	if path.kind == davCalendar || (path.kind == davHome && depthOne) {
+		seq, err := h.davSyncToken(ctx, st.user.ID)
+		if err != nil {
+			respondServiceError(w, err)
+			return
+		}
+		token = davSyncTokenPrefix + strconv.FormatInt(seq, 10)
+	}
+
+	hrefs := map[davKind]string{
+		davRootResource: davRoot,
+		davPrincipal:    st.principalHref(),
+		davHome:         st.homeHref(),
+		davCalendar:     st.calendarHref(path.collection),
+	}
+	responses := []davResponse{davSelect(hrefs[path.kind], h.resourceProps(st, path, token), names, nameOnly)}
+	if depthOne {
+		switch path.kind {
+		case davHome:
+			for _, collection := range st.collections() {
+				child := davPath{kind: davCalendar, username: path.username, collection: collection}
+				responses = append(responses, davSelect(st.calendarHref(collection), h.resourceProps(st, child, token), names, nameOnly))
+			}
+		case davCalendar:
+			tasks, err := h.collectionTasks(ctx, st, path.collection)
+			if err != nil {
+				respondServiceError(w, err)
+				return
+			}
+			for _, task := range tasks {
+				href := st.objectHref(path.collection, st.object(task).Name)
+				responses = append(responses, davSelect(href, objectProps(st, task), names, nameOnly))
+			}
+		}
+	}
+	writeMultistatus(w, responses, "")
+}
+
+// davReport xử lý calendar-query, calendar-multiget và sync-collection trên một collection
+func (h *Handler) davReport(w http.ResponseWriter, r *http.Request, st *davState, path davPath) {
+	var req davReportRequest
+	if ok, err := davReadBody(r, &req); err != nil || !ok {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu XML không hợp lệ")
+		return
+	}
+	names := req.Prop
+	if req.AllProp != nil {
+		names = nil
+	}
+
+	switch req.XMLName {
+	case xml.Name{Space: caldavNS, Local: "calendar-query"}:
+		h.davCalendarQuery(r.Context(), w, st, path, req, names)
+	case xml.Name{Space: caldavNS, Local: "calendar-multiget"}:
+		h.davMultiget(r.Context(), w, st, path, req, names)
+	case xml.Name{Space: davNS, Local: "sync-collection"}:
+		h.davSyncCollection(r.Context(), w, st, path, req, names)
+	default:
+		davError(w, http.StatusForbidden, davNS, "supported-report")
+	}
+}
+
+func (h *Handler) davCalendarQuery(ctx context.Context, w http.ResponseWriter, st *davState, path davPath, req davReportRequest, names davPropNames) {
+	tasks, err := h.collectionTasks(ctx, st, path.collection)
+	if err != nil {
+		respondServiceError(w, err)
+		return
+	}
+
+	responses := []davResponse{}
+	for _, task := range tasks {
+		if req.Filter != nil {
+			if req.Filter.CompFilter.Name != "VCALENDAR" {
+				break
+			}
+			body, _ := st.renderObject(task)
+			root, err := ical.Parse(bytes.NewReader(body))
+			if err != nil || !req.Filter.CompFilter.matches(root) {
+				continue
+			}
+		}
+		href := st.objectHref(path.collection, st.object(task).Name)
+		responses = append(responses, davSelect(href, objectProps(st, task), names, false))
+	}
+	writeMultistatus(w, responses, "")
+}
+
+func (h *Handler) davMultiget(ctx context.Context, w http.ResponseWriter, st *davState, path davPath, req davReportRequest, names davPropNames) {
+	responses := []davResponse{}
+	for _, href := range req.Hrefs {
+		target, err := url.Parse(strings.TrimSpace(href))
+		var object davPath
+		ok := err == nil
+		if ok {
+			object, ok = parseDAVPath(target.Path)
+		}
+		if !ok || object.kind != davObject || object.username != st.user.Username || object.collection != path.collection {
+			responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+			continue
+		}
+
+		task, err := h.davLoadObject(ctx, st, object)
+		if errors.Is(err, store.ErrNotFound) {
+			responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+			continue
+		}
+		if err != nil {
+			respondDAVLoadError(w, err)
+			return
+		}
+		responses = append(responses, davSelect(href, objectProps(st, task), names, false))
+	}
+	writeMultistatus(w, responses, "")
+}
+
+// davSyncCollection trả về tài nguyên thay đổi trong collection sau sync-token (RFC
+// 6578) dựa trên nhật ký đồng bộ. Token trống là lần đồng bộ đầu tiên.
+func (h *Handler) davSyncCollection(ctx context.Context, w http.ResponseWriter, st *davState, path davPath, req davReportRequest, names davPropNames) {
+	var since int64
+	if token := strings.TrimSpace(req.SyncToken); token != "" {
+		value, ok := strings.CutPrefix(token, davSyncTokenPrefix)
+		seq, err := strconv.ParseInt(value, 10, 64)
+		if !ok || err != nil || seq < 0 {
+			davError(w, http.StatusForbidden, davNS, "valid-sync-token")
+			return
+		}
+		since = seq
+	}
+
+	changes, latest, err := h.davChanges(ctx, st.user.ID, since)
+	if errors.Is(err, errSyncExpired) {
+		// Token cũ hơn tombstone đã xóa: client đồng bộ lại với token trống
+		davError(w, http.StatusForbidden, davNS, "valid-sync-token")
+		return
+	}
+	if err != nil {
+		respondServiceError(w, err)
+		return
+	}
+	if len(changes) == 0 && since > 0 {
+		// Token không thể lớn hơn seq hiện tại của người dùng
+		if latest, err = h.davSyncToken(ctx, st.user.ID); err != nil {
+			respondServiceError(w, err)
+			return
+		}
+		if since > latest {
+			davError(w, http.StatusForbidden, davNS, "valid-sync-token")
+			return
+		}
+		latest = since
+	}
+	token := davSyncTokenPrefix + strconv.FormatInt(latest, 10)
+
+	responses := []davResponse{}
+	if since == 0 {
+		tasks, err := h.collectionTasks(ctx, st, path.collection)
+		if err != nil {
+			respondServiceError(w, err)
+			return
+		}
+		for _, task := range tasks {
+			href := st.objectHref(path.collection, st.object(task).Name)
+			responses = append(responses, davSelect(href, objectProps(st, task), names, false))
+		}
+		writeMultistatus(w, responses, token)
+		return
+	}
+
+	seen := map[int]bool{}
+	report := func(task models.Task) {
+		if seen[task.ID] {
+			return
+		}
+		seen[task.ID] = true
+		href := st.objectHref(path.collection, st.object(task).Name)
+		if st.collectionOf(task) != path.collection {
+			// Công việc đã chuyển sang collection khác
+			responses = append(responses, davResponse{href: href, status: http.StatusNotFound})
+			return
+		}
+		responses = append(responses, davSelect(href, objectProps(st, task), names, false))
+	}
+	for _, change := range changes {
+		switch {
+		case change.Entity == models.SyncTask && change.Deleted:
+			object := st.object(models.Task{ID: change.EntityID, UserID: st.user.ID})
+			if owner, ok := st.byName[object.Name]; ok && owner.TaskID != change.EntityID {
+				// Tên đã được dùng lại cho công việc khác
+				continue
+			}
+			seen[change.EntityID] = true
+			responses = append(responses, davResponse{href: st.objectHref(path.collection, object.Name), status: http.StatusNotFound})
+		case change.Entity == models.SyncTask:
+			task, err := h.Tasks.GetTask(ctx, st.user.ID, change.EntityID)
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				respondDAVLoadError(w, err)
+				return
+			}
+			report(task)
+		case change.Entity == models.SyncCategory && !change.Deleted && strconv.Itoa(change.EntityID) == path.collection:
+			// Đổi tên danh mục làm thay đổi CATEGORIES của mọi công việc trong collection
+			tasks, err := h.collectionTasks(ctx, st, path.collection)
+			if err != nil {
+				respondServiceError(w, err)
+				return
+			}
+			for _, task := range tasks {
+				report(task)
+			}
+		case change.Entity == models.SyncCategory && change.Deleted && path.collection == davInbox:
+			// Công việc của danh mục đã xóa chuyển vào inbox
+			id := change.EntityID
+			tasks, err := store.ListAllTasks(ctx, h.Tasks, st.user.ID, store.TaskFilter{CategoryID: &id})
+			if err != nil {
+				respondServiceError(w, fmt.Errorf("đọc công việc: %w", err))
+				return
+			}
+			for _, task := range tasks {
+				report(task)
+			}
+		}
+	}
+	writeMultistatus(w, responses, token)
+}
@@ -0,0 +1,234 @@
+package handlers_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+// davMultistatus là phần của response 207 mà test cần đọc
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Status   string `xml:"DAV: status"`
+		Propstat []struct {
+			Prop struct {
+				ETag         string `xml:"DAV: getetag"`
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+				ResourceType struct {
+					Calendar *struct{} `xml:"urn:ietf:params:xml:ns:caldav calendar"`
+				} `xml:"DAV: resourcetype"`
+			} `xml:"DAV: prop"`
+			Status string `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+	SyncToken string `xml:"DAV: sync-token"`
+}
+
+// davClient gửi yêu cầu CalDAV bằng HTTP Basic với token lịch của người dùng
+type davClient struct {
+	a        *testAPI
+	username string
+	token    string
+}
+
+func (a *testAPI) davClient(user testUser) davClient {
+	a.t.Helper()
+	var created struct {
+		Token string `json:"token"`
+	}
+	a.decode(a.mustDo(user.Token, "POST", path("/api/users/%d/calendar/token", user.ID), nil, http.StatusCreated), &created)
+	return davClient{a: a, username: user.Username, token: created.Token}
+}
+
+// do gửi yêu cầu và dừng test nếu mã trạng thái khác want; trả về header và body
+func (c davClient) do(method, target, body string, want int, header ...string) (http.Header, string) {
+	t := c.a.t
+	t.Helper()
+	req, err := http.NewRequestWithContext(t.Context(), method, c.a.server.URL+target, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("tạo request %s %s: %v", method, target, err)
+	}
+	req.SetBasicAuth(c.username, c.token)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := c.a.server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, target, err)
+	}
+	data := readBody(t, resp)
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: mã %d, muốn %d: %s", method, target, resp.StatusCode, want, data)
+	}
+	return resp.Header, string(data)
+}
+
+func (c davClient) multistatus(method, target, body string, header ...string) davMultistatus {
+	c.a.t.Helper()
+	_, data := c.do(method, target, body, http.StatusMultiStatus, header...)
+	var ms davMultistatus
+	if err := xml.Unmarshal([]byte(data), &ms); err != nil {
+		c.a.t.Fatalf("giải mã multistatus %s: %v", data, err)
+	}
+	return ms
+}
+
+// vtodo dựng đối tượng VTODO; lines là các dòng thêm vào VTODO (ví dụ STATUS)
+func vtodo(uid, summary string, lines ...string) string {
+	extra := ""
+	for _, line := range lines {
+		extra += line + "\r\n"
+	}
+	return "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nBEGIN:VTODO\r\nUID:" + uid +
+		"\r\nSUMMARY:" + summary + "\r\nDUE:20300101T090000Z\r\n" + extra + "END:VTODO\r\nEND:VCALENDAR\r\n"
+}
+
+func TestCalDAV(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	dav := a.davClient(user)
+
+	var category models.Category
+	a.decode(a.mustDo(user.Token, "POST", "/api/categories", map[string]any{
+		"category_name": "Công việc",
+	}, http.StatusCreated), &category)
+	var report models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Viết báo cáo",
+		"description": "Báo cáo quý",
+		"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		"category_id": category.ID,
+	}, http.StatusCreated), &report)
+
+	home := path("/dav/calendars/%s/", user.Username)
+	inbox := home + "inbox/"
+	object := inbox + "mua-sua.ics"
+	const query = `<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">` +
+		`<D:prop><D:getetag/><C:calendar-data/></D:prop>` +
+		`<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"/></C:comp-filter></C:filter>` +
+		`</C:calendar-query>`
+	syncCollection := func(token string) davMultistatus {
+		t.Helper()
+		return dav.multistatus("REPORT", inbox, `<D:sync-collection xmlns:D="DAV:"><D:sync-token>`+token+
+			`</D:sync-token><D:prop><D:getetag/></D:prop></D:sync-collection>`)
+	}
+	initial := syncCollection("")
+	if len(initial.Responses) != 0 || initial.SyncToken == "" {
+		t.Fatalf("sync-collection lần đầu của inbox trống: %+v", initial)
+	}
+
+	// PUT tạo mới trả về ETag trùng với GET
+	header, _ := dav.do("PUT", object, vtodo("mua-sua@client", "Mua sữa"), http.StatusCreated, "If-None-Match", "*")
+	created := header.Get("ETag")
+	if created == "" {
+		t.Fatal("PUT tạo mới không trả về ETag")
+	}
+	dav.do("PUT", object, vtodo("mua-sua@client", "Mua sữa"), http.StatusPreconditionFailed, "If-None-Match", "*")
+	dav.do("PUT", inbox+"chua-co.ics", vtodo("chua-co@client", "Chưa có"), http.StatusPreconditionFailed, "If-Match", created)
+	header, body := dav.do("GET", object, "", http.StatusOK)
+	if header.Get("ETag") != created || !strings.Contains(body, "SUMMARY:Mua sữa") {
+		t.Fatalf("GET sau khi tạo: ETag %q, muốn %q:\n%s", header.Get("ETag"), created, body)
+	}
+
+	// PUT sửa chỉ được áp dụng với ETag hiện tại
+	dav.do("PUT", object, vtodo("mua-sua@client", "Mua sữa tươi"), http.StatusPreconditionFailed, "If-Match", `"khac"`)
+	header, _ = dav.do("PUT", object, vtodo("mua-sua@client", "Mua sữa tươi"), http.StatusNoContent, "If-Match", created)
+	updated := header.Get("ETag")
+	if updated == "" || updated == created {
+		t.Fatalf("PUT sửa: ETag %q, ETag cũ %q", updated, created)
+	}
+	dav.do("PUT", object, vtodo("mua-sua@client", "Mua sữa chua"), http.StatusPreconditionFailed, "If-Match", created)
+	if header, _ := dav.do("GET", object, "", http.StatusOK); header.Get("ETag") != updated {
+		t.Errorf("GET sau khi sửa: ETag %q, muốn %q", header.Get("ETag"), updated)
+	}
+
+	t.Run("propfind", func(t *testing.T) {
+		if ms := dav.multistatus("PROPFIND", home, "", "Depth", "0"); len(ms.Responses) != 1 || ms.Responses[0].Href != home {
+			t.Errorf("PROPFIND Depth 0 của calendar home: %+v", ms.Responses)
+		}
+		calendars := 0
+		for _, resp := range dav.multistatus("PROPFIND", home, "", "Depth", "1").Responses {
+			if len(resp.Propstat) > 0 && resp.Propstat[0].Prop.ResourceType.Calendar != nil {
+				calendars++
+			}
+		}
+		if calendars != 2 {
+			t.Errorf("PROPFIND Depth 1 của calendar home có %d lịch, muốn 2 (inbox và danh mục)", calendars)
+		}
+
+		ms := dav.multistatus("PROPFIND", inbox, `<D:propfind xmlns:D="DAV:"><D:prop><D:getetag/></D:prop></D:propfind>`, "Depth", "1")
+		if len(ms.Responses) != 2 || ms.Responses[1].Href != object || ms.Responses[1].Propstat[0].Prop.ETag != updated {
+			t.Errorf("PROPFIND Depth 1 của inbox: %+v", ms.Responses)
+		}
+	})
+
+	t.Run("calendar-query", func(t *testing.T) {
+		ms := dav.multistatus("REPORT", inbox, query, "Depth", "1")
+		if len(ms.Responses) != 1 || ms.Responses[0].Href != object {
+			t.Fatalf("calendar-query của inbox: %+v", ms.Responses)
+		}
+		prop := ms.Responses[0].Propstat[0].Prop
+		if prop.ETag != updated || !strings.Contains(prop.CalendarData, "SUMMARY:Mua sữa tươi") {
+			t.Errorf("calendar-query của inbox: %+v", prop)
+		}
+		if ms := dav.multistatus("REPORT", path("%s%d/", home, category.ID), query, "Depth", "1"); len(ms.Responses) != 1 ||
+			!strings.Contains(ms.Responses[0].Propstat[0].Prop.CalendarData, "SUMMARY:Viết báo cáo") {
+			t.Errorf("calendar-query của danh mục: %+v", ms.Responses)
+		}
+	})
+
+	// sync-collection trả về tài nguyên thay đổi sau token, kể cả tài nguyên đã xóa
+	changed := syncCollection(initial.SyncToken)
+	if len(changed.Responses) != 1 || changed.Responses[0].Href != object || changed.Responses[0].Propstat[0].Prop.ETag != updated {
+		t.Fatalf("sync-collection sau khi tạo: %+v", changed.Responses)
+	}
+	if unchanged := syncCollection(changed.SyncToken); len(unchanged.Responses) != 0 || unchanged.SyncToken != changed.SyncToken {
+		t.Errorf("sync-collection không có thay đổi: %+v", unchanged)
+	}
+
+	dav.do("DELETE", object, "", http.StatusPreconditionFailed, "If-Match", created)
+	dav.do("DELETE", object, "", http.StatusNoContent, "If-Match", updated)
+	dav.do("GET", object, "", http.StatusNotFound)
+	dav.do("DELETE", object, "", http.StatusNotFound)
+
+	deleted := syncCollection(changed.SyncToken)
+	if len(deleted.Responses) != 1 || deleted.Responses[0].Href != object || !strings.Contains(deleted.Responses[0].Status, "404") {
+		t.Errorf("sync-collection sau khi xóa: %+v", deleted.Responses)
+	}
+
+	// Tombstone sau token đã bị xóa khỏi nhật ký: token không còn hợp lệ, token mới thì có
+	if _, err := a.store.PruneTombstones(t.Context(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	body = `<D:sync-collection xmlns:D="DAV:"><D:sync-token>` + changed.SyncToken + `</D:sync-token><D:prop/></D:sync-collection>`
+	if _, expired := dav.do("REPORT", inbox, body, http.StatusForbidden); !strings.Contains(expired, "valid-sync-token") {
+		t.Errorf("sync-collection với token quá hạn: %s", expired)
+	}
+	if resynced := syncCollection(syncCollection("").SyncToken); len(resynced.Responses) != 0 {
+		t.Errorf("sync-collection sau khi đồng bộ lại: %+v", resynced.Responses)
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		// Ứng dụng lịch chỉ gửi NEEDS-ACTION/COMPLETED: công việc Pending được đánh dấu
+		// hoàn thành dù workflow yêu cầu đi qua In Progress
+		object := path("%s%d/task-%d.ics", home, category.ID, report.ID)
+		uid := path("task-%d@backend", report.ID)
+		header, _ := dav.do("PUT", object, vtodo(uid, "Viết báo cáo", "STATUS:COMPLETED"), http.StatusNoContent)
+		var task models.Task
+		a.decode(a.mustDo(user.Token, "GET", path("/api/tasks/%d", report.ID), nil, http.StatusOK), &task)
+		if task.Status != models.StatusCompleted || task.CompletedAt == nil {
+			t.Fatalf("PUT STATUS:COMPLETED lên công việc Pending: %+v", task)
+		}
+
+		dav.do("PUT", object, vtodo(uid, "Viết báo cáo", "STATUS:NEEDS-ACTION"), http.StatusNoContent, "If-Match", header.Get("ETag"))
+		a.decode(a.mustDo(user.Token, "GET", path("/api/tasks/%d", report.ID), nil, http.StatusOK), &task)
+		if task.Status != models.StatusPending || task.CompletedAt != nil {
+			t.Errorf("PUT STATUS:NEEDS-ACTION lên công việc đã hoàn thành: %+v", task)
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/auth"
+	"backend/ical"
+	"backend/models"
+	"backend/store"
+
+	"github.com/gorilla/mux"
+)
+
+// calendarProdID định danh phần mềm sinh feed lịch (PRODID)
+const calendarProdID = "-//backend//Task Calendar//VI"
+
+// calendarStatuses ánh xạ trạng thái công việc sang STATUS của VTODO
+var calendarStatuses = map[models.TaskStatus]string{
+	models.StatusPending:    "NEEDS-ACTION",
+	models.StatusInProgress: "IN-PROCESS",
+	models.StatusCompleted:  "COMPLETED",
+}
+
+// calendarPriorities ánh xạ độ ưu tiên sang PRIORITY (1 là cao nhất, 9 là thấp nhất)
+var calendarPriorities = map[models.TaskPriority]string{
+	models.PriorityHigh:   "1",
+	models.PriorityMedium: "5",
+	models.PriorityLow:    "9",
+}
+
+// CreateCalendarToken tạo (hoặc thay) token bí mật của feed lịch. Token chỉ được trả
+// về một lần; token cũ hết hiệu lực ngay.
+func (h *Handler) CreateCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tạo token lịch")
+		return
+	}
+	if err := h.Calendar.SaveCalendarToken(r.Context(), userID, auth.HashToken(token)); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lưu token lịch: "+err.Error())
+		return
+	}
+
+	RespondWithJSON(w, http.StatusCreated, map[string]string{
+		"token": token,
+		"url":   calendarURL(r, userID, token),
+	})
+}
+
+// DeleteCalendarToken tắt feed lịch của người dùng
+func (h *Handler) DeleteCalendarToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.Calendar.DeleteCalendarToken(r.Context(), userID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Feed lịch chưa được bật")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa token lịch")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Đã tắt feed lịch"})
+}
+
+// calendarURL trả về địa chỉ đầy đủ của feed lịch để dán vào ứng dụng lịch
+func calendarURL(r *http.Request, userID int, token string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/api/users/%d/calendar.ics?token=%s", scheme, r.Host, userID, token)
+}
+
+// CalendarFeed trả về công việc của người dùng dưới dạng iCalendar (RFC 5545). Ứng
+// dụng lịch không gửi được header Authorization nên route này công khai và được bảo
+// vệ bằng ?token=. Mỗi công việc là một VTODO có DUE là deadline, hoặc một VEVENT bắt
+// đầu tại deadline với ?as=event; nhắc nhở chưa được xác nhận là các VALARM.
+func (h *Handler) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil || !h.checkCalendarToken(r.Context(), userID, r.URL.Query().Get("token")) {
+		// Không phân biệt người dùng không tồn tại với token sai
+		RespondWithError(w, http.StatusNotFound, "Không tìm thấy lịch")
+		return
+	}
+
+	component := "VTODO"
+	switch r.URL.Query().Get("as") {
+	case "", "todo":
+	case "event":
+		component = "VEVENT"
+	default:
+		RespondWithError(w, http.StatusBadRequest, "Tham số as phải là todo hoặc event")
+		return
+	}
+
+	body, err := h.renderCalendar(r.Context(), userID, component)
+	if err != nil {
+		log.Printf("Lỗi khi tạo feed lịch của user %d: %v", userID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tạo feed lịch")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// checkCalendarToken so khớp token với giá trị băm đã lưu trong thời gian hằng
+func (h *Handler) checkCalendarToken(ctx context.Context, userID int, token string) bool {
+	if token == "" {
+		return false
+	}
+	stored, err := h.Calendar.GetCalendarToken(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Printf("Lỗi khi đọc token lịch của user %d: %v", userID, err)
+		}
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(auth.HashToken(token)), []byte(stored)) == 1
+}
+
+// renderCalendar sinh VCALENDAR chứa mọi công việc và nhắc nhở của userID. Thời gian
+// được ghi theo UTC; X-WR-TIMEZONE cho ứng dụng lịch biết múi giờ hiển thị của người dùng.
+func (h *Handler) renderCalendar(ctx context.Context, userID int, component string) ([]byte, error) {
+	user, err := h.Users.GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("đọc người dùng: %w", err)
+	}
+	tasks, err := store.ListAllTasks(ctx, h.Tasks, userID, store.TaskFilter{Sort: store.SortDeadline})
+	if err != nil {
+		return nil, fmt.Errorf("đọc công việc: %w", err)
+	}
+	categories, err := h.Categories.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("đọc danh mục: %w", err)
+	}
+	reminders, err := h.Reminders.ListReminders(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("đọc nhắc nhở: %w", err)
+	}
+
+	categoryNames := map[int]string{}
+	for _, category := range categories {
+		categoryNames[category.ID] = category.CategoryName
+	}
+	alarms := map[int][]models.Reminder{}
+	for _, reminder := range reminders {
+		if reminder.Status != models.ReminderAcknowledged {
+			alarms[reminder.TaskID] = append(alarms[reminder.TaskID], reminder)
+		}
+	}
+
+	var cal ical.Writer
+	cal.Begin("VCALENDAR")
+	cal.Line("VERSION", "2.0")
+	cal.Text("PRODID", calendarProdID)
+	cal.Line("CALSCALE", "GREGORIAN")
+	cal.Line("METHOD", "PUBLISH")
+	cal.Text("X-WR-CALNAME", "Công việc của "+user.Username)
+	cal.Text("X-WR-TIMEZONE", user.Location().String())
+
+	now := time.Now()
+	for _, task := range tasks {
+		uid := models.DefaultCalDAVObject(task).UID
+		writeCalendarTask(&cal, component, uid, task, categoryNames[task.CategoryID], alarms[task.ID], now)
+	}
+	cal.End("VCALENDAR")
+	return cal.Bytes(), nil
+}
+
+// writeCalendarTask ghi một công việc thành VTODO hoặc VEVENT kèm các VALARM.
+// stamp là DTSTAMP: thời điểm tạo feed, hoặc updated_at với đối tượng CalDAV để ETag ổn định.
+func writeCalendarTask(cal *ical.Writer, component, uid string, task models.Task, category string, reminders []models.Reminder, stamp time.Time) {
+	cal.Begin(component)
+	cal.Text("UID", uid)
+	cal.Time("DTSTAMP", stamp)
+	cal.Time("CREATED", task.CreatedAt)
+	cal.Time("LAST-MODIFIED", task.UpdatedAt)
+	cal.Text("SUMMARY", task.Title)
+	if task.Description != "" {
+		cal.Text("DESCRIPTION", task.Description)
+	}
+
+	if component == "VEVENT" {
+		// STATUS và COMPLETED của VTODO không hợp lệ trong VEVENT
+		cal.Time("DTSTART", task.Deadline)
+	} else {
+		cal.Time("DUE", task.Deadline)
+		if status, ok := calendarStatuses[task.Status]; ok {
+			cal.Line("STATUS", status)
+		}
+		if task.CompletedAt != nil {
+			cal.Time("COMPLETED", *task.CompletedAt)
+		}
+	}
+	if priority, ok := calendarPriorities[task.Priority]; ok {
+		cal.Line("PRIORITY", priority)
+	}
+	if category != "" {
+		cal.Text("CATEGORIES", category)
+	}
+
+	for _, reminder := range reminders {
+		trigger := reminder.ReminderTime
+		if reminder.Status == models.ReminderSnoozed && reminder.SnoozedUntil != nil {
+			trigger = *reminder.SnoozedUntil
+		}
+		cal.Begin("VALARM")
+		cal.Line("ACTION", "DISPLAY")
+		cal.Text("DESCRIPTION", task.Title)
+		cal.Line("TRIGGER;VALUE=DATE-TIME", ical.FormatTime(trigger))
+		cal.End("VALARM")
+	}
+	cal.End(component)
+}
@@ -0,0 +1,117 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/ical"
+	"backend/models"
+)
+
+// calendarFeed tải feed lịch bằng token và trả về VCALENDAR đã đọc
+func (a *testAPI) calendarFeed(user testUser, token, query string) *ical.Component {
+	a.t.Helper()
+	resp := a.do("", "GET", path("/api/users/%d/calendar.ics?token=%s%s", user.ID, url.QueryEscape(token), query), nil)
+	body := readBody(a.t, resp)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/calendar") {
+		a.t.Fatalf("feed lịch: mã %d, Content-Type %q: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+	cal, err := ical.Parse(bytes.NewReader(body))
+	if err != nil {
+		a.t.Fatalf("đọc feed lịch: %v\n%s", err, body)
+	}
+	return cal
+}
+
+func TestCalendarFeed(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	a.mustDo(user.Token, "PATCH", path("/api/users/%d", user.ID), map[string]any{"timezone": "Asia/Ho_Chi_Minh"},
+		http.StatusOK, "Content-Type", "application/merge-patch+json")
+
+	var category models.Category
+	a.decode(a.mustDo(user.Token, "POST", "/api/categories", map[string]any{"category_name": "Công việc"}, http.StatusCreated), &category)
+	var report, shopping models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Họp, báo cáo; quý",
+		"description": "Dòng 1\nDòng 2",
+		"deadline":    "2030-01-02T02:00:00Z",
+		"priority":    "High",
+		"category_id": category.ID,
+	}, http.StatusCreated), &report)
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Mua sữa",
+		"description": "Siêu thị",
+		"deadline":    "2030-01-03T02:00:00Z",
+	}, http.StatusCreated), &shopping)
+	for _, status := range []models.TaskStatus{models.StatusInProgress, models.StatusCompleted} {
+		a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", shopping.ID), map[string]any{"status": status},
+			http.StatusOK, "Content-Type", "application/merge-patch+json")
+	}
+	a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{"task_id": report.ID, "offset_minutes": 30}, http.StatusCreated)
+
+	// Feed chưa bật thì không truy cập được
+	feedPath := path("/api/users/%d/calendar.ics", user.ID)
+	a.mustDo("", "GET", feedPath+"?token=khong-co", nil, http.StatusNotFound)
+	var created struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	a.decode(a.mustDo(user.Token, "POST", path("/api/users/%d/calendar/token", user.ID), nil, http.StatusCreated), &created)
+	if created.Token == "" || !strings.HasSuffix(created.URL, feedPath+"?token="+created.Token) {
+		t.Fatalf("token lịch: %+v", created)
+	}
+
+	cal := a.calendarFeed(user, created.Token, "")
+	if cal.Name != "VCALENDAR" || cal.Prop("VERSION").Value != "2.0" || cal.Prop("X-WR-TIMEZONE").Text() != "Asia/Ho_Chi_Minh" {
+		t.Errorf("VCALENDAR: %+v", cal.Props)
+	}
+	if len(cal.Components) != 2 {
+		t.Fatalf("feed có %d công việc, muốn 2", len(cal.Components))
+	}
+	// Thời gian được ghi theo UTC, văn bản được escape và đọc lại nguyên vẹn
+	todo := cal.Components[0]
+	due, err := todo.Prop("DUE").Time(time.UTC)
+	if err != nil || !due.Equal(report.Deadline) || !strings.HasSuffix(todo.Prop("DUE").Value, "Z") {
+		t.Errorf("DUE: %q (%v)", todo.Prop("DUE").Value, err)
+	}
+	if todo.Name != "VTODO" || todo.Prop("SUMMARY").Text() != report.Title || todo.Prop("DESCRIPTION").Text() != report.Description ||
+		todo.Prop("STATUS").Value != "NEEDS-ACTION" || todo.Prop("PRIORITY").Value != "1" || todo.Prop("CATEGORIES").Text() != "Công việc" {
+		t.Errorf("VTODO của công việc #%d: %+v", report.ID, todo.Props)
+	}
+	alarm := todo.Component("VALARM")
+	if alarm == nil {
+		t.Fatalf("VTODO của công việc #%d không có VALARM", report.ID)
+	}
+	if trigger, err := alarm.Prop("TRIGGER").Time(time.UTC); err != nil || !trigger.Equal(report.Deadline.Add(-30*time.Minute)) ||
+		alarm.Prop("TRIGGER").Params["VALUE"] != "DATE-TIME" || alarm.Prop("ACTION").Value != "DISPLAY" {
+		t.Errorf("VALARM: %+v (%v)", alarm.Props, err)
+	}
+	done := cal.Components[1]
+	if done.Prop("STATUS").Value != "COMPLETED" || done.Prop("COMPLETED") == nil || done.Component("VALARM") != nil {
+		t.Errorf("VTODO của công việc đã hoàn thành: %+v", done.Props)
+	}
+
+	// ?as=event xuất VEVENT bắt đầu tại deadline, không có thuộc tính riêng của VTODO
+	event := a.calendarFeed(user, created.Token, "&as=event").Components[0]
+	if start, err := event.Prop("DTSTART").Time(time.UTC); event.Name != "VEVENT" || err != nil || !start.Equal(report.Deadline) ||
+		event.Prop("STATUS") != nil || event.Prop("DUE") != nil {
+		t.Errorf("VEVENT: %s %+v", event.Name, event.Props)
+	}
+	a.mustDo("", "GET", feedPath+"?as=journal&token="+created.Token, nil, http.StatusBadRequest)
+
+	// Tạo token mới làm token cũ hết hiệu lực; tắt feed làm mọi token hết hiệu lực
+	var rotated struct {
+		Token string `json:"token"`
+	}
+	a.decode(a.mustDo(user.Token, "POST", path("/api/users/%d/calendar/token", user.ID), nil, http.StatusCreated), &rotated)
+	a.mustDo("", "GET", feedPath+"?token="+created.Token, nil, http.StatusNotFound)
+	a.calendarFeed(user, rotated.Token, "")
+	a.mustDo(user.Token, "DELETE", path("/api/users/%d/calendar/token", user.ID), nil, http.StatusOK)
+	a.mustDo("", "GET", feedPath+"?token="+rotated.Token, nil, http.StatusNotFound)
+	a.mustDo(user.Token, "DELETE", path("/api/users/%d/calendar/token", user.ID), nil, http.StatusNotFound)
+}
@@ -1,18 +1,18 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
-	"backend/database"
 	"backend/models"
+	"backend/store"
 
 	"github.com/gorilla/mux"
 )
 
-func CreateCategory(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var category models.Category
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&category); err != nil {
@@ -21,36 +21,16 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	// Kiểm tra trùng lặp danh mục
-	var exists bool
-	err := database.DB.QueryRow("SELECT EXISTS(SELECT 1 FROM categories WHERE category_name = ? AND user_id = ?)", category.CategoryName, category.UserID).Scan(&exists)
+	category, err := h.createCategory(r.Context(), currentUserID(r), category)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi kiểm tra danh mục")
-		return
-	}
-	if exists {
-		RespondWithError(w, http.StatusConflict, "Danh mục đã tồn tại")
-		return
-	}
-
-	query := "INSERT INTO categories (category_name, color, user_id, description) VALUES (?, ?, ?, ?)"
-	result, err := database.DB.Exec(query, category.CategoryName, category.Color, category.UserID, category.Description)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi thêm danh mục")
-		return
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy ID danh mục")
+		respondServiceError(w, err)
 		return
 	}
 
-	category.ID = int(id)
 	RespondWithJSON(w, http.StatusCreated, category)
 }
 
-func GetCategory(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -58,11 +38,9 @@ func GetCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var category models.Category
-	query := "SELECT category_id, category_name, color, user_id, description FROM categories WHERE category_id = ?"
-	err = database.DB.QueryRow(query, id).Scan(&category.ID, &category.CategoryName, &category.Color, &category.UserID, &category.Description)
+	category, err := h.Categories.GetCategory(r.Context(), currentUserID(r), id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, store.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, "Không tìm thấy danh mục")
 			return
 		}
@@ -73,7 +51,7 @@ func GetCategory(w http.ResponseWriter, r *http.Request) {
 	RespondWithJSON(w, http.StatusOK, category)
 }
 
-func UpdateCategory(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -89,24 +67,17 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	query := "UPDATE categories SET category_name = ?, color = ?, description = ? WHERE category_id = ?"
-	result, err := database.DB.Exec(query, category.CategoryName, category.Color, category.Description, id)
+	category, err = h.updateCategory(r.Context(), currentUserID(r), id, category)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi cập nhật danh mục"+err.Error())
-		return
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		RespondWithError(w, http.StatusNotFound, "Không tìm thấy danh mục để cập nhật")
+		respondServiceError(w, err)
 		return
 	}
 
-	category.ID = id
 	RespondWithJSON(w, http.StatusOK, category)
 }
 
-func DeleteCategory(w http.ResponseWriter, r *http.Request) {
+// PatchCategory cập nhật một phần danh mục theo JSON Merge Patch (RFC 7396)
+func (h *Handler) PatchCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -114,16 +85,30 @@ func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := "DELETE FROM categories WHERE category_id = ?"
-	result, err := database.DB.Exec(query, id)
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	category, err := h.patchCategory(r.Context(), currentUserID(r), id, patch)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa danh mục")
+		respondServiceError(w, err)
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, category)
+}
+
+func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		RespondWithError(w, http.StatusNotFound, "Không tìm thấy danh mục để xóa")
+	if err := h.deleteCategory(r.Context(), currentUserID(r), id); err != nil {
+		respondServiceError(w, err)
 		return
 	}
 
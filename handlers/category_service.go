@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"backend/events"
+	"backend/models"
+	"backend/store"
+)
+
+// Các thao tác ghi danh mục dùng chung cho REST (category.go) và đồng bộ offline (sync.go)
+
+// createCategory lưu danh mục mới của userID và phát sự kiện category.created
+func (h *Handler) createCategory(ctx context.Context, userID int, category models.Category) (models.Category, error) {
+	// Danh mục luôn thuộc về người dùng đang đăng nhập, bỏ qua user_id của client
+	category.ID = 0
+	category.UserID = userID
+	if err := h.Categories.CreateCategory(withEvent(ctx, userID, events.CategoryCreated, &category), &category); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			return models.Category{}, serviceErrorf(http.StatusConflict, "Danh mục đã tồn tại")
+		}
+		return models.Category{}, fmt.Errorf("thêm danh mục: %w", err)
+	}
+	h.Events.Publish(category.UserID, events.CategoryCreated, category)
+	return category, nil
+}
+
+// updateCategory thay toàn bộ danh mục id bằng category (ngữ nghĩa của PUT)
+func (h *Handler) updateCategory(ctx context.Context, userID, id int, category models.Category) (models.Category, error) {
+	category.ID = id
+	category.UserID = userID
+	if err := h.Categories.UpdateCategory(withEvent(ctx, userID, events.CategoryUpdated, &category), &category); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return models.Category{}, serviceErrorf(http.StatusNotFound, "Không tìm thấy danh mục để cập nhật")
+		}
+		return models.Category{}, fmt.Errorf("cập nhật danh mục: %w", err)
+	}
+	h.Events.Publish(category.UserID, events.CategoryUpdated, category)
+	return category, nil
+}
+
+// patchCategory áp dụng JSON Merge Patch (RFC 7396) lên danh mục id
+func (h *Handler) patchCategory(ctx context.Context, userID, id int, patch []byte) (models.Category, error) {
+	category, err := h.Categories.GetCategory(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return models.Category{}, serviceErrorf(http.StatusNotFound, "Không tìm thấy danh mục")
+		}
+		return models.Category{}, fmt.Errorf("lấy thông tin danh mục: %w", err)
+	}
+
+	if err := applyMergePatch(&category, patch); err != nil {
+		return models.Category{}, serviceErrorf(http.StatusBadRequest, "Dữ liệu không hợp lệ: %v", err)
+	}
+	if category.CategoryName == "" {
+		return models.Category{}, serviceErrorf(http.StatusBadRequest, "Tên danh mục là bắt buộc")
+	}
+	return h.updateCategory(ctx, userID, id, category)
+}
+
+// deleteCategory xóa danh mục id của userID và phát sự kiện category.deleted
+func (h *Handler) deleteCategory(ctx context.Context, userID, id int) error {
+	ctx = withEvent(ctx, userID, events.CategoryDeleted, map[string]int{"category_id": id})
+	if err := h.Categories.DeleteCategory(ctx, userID, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return serviceErrorf(http.StatusNotFound, "Không tìm thấy danh mục để xóa")
+		}
+		return fmt.Errorf("xóa danh mục: %w", err)
+	}
+	h.Events.Publish(userID, events.CategoryDeleted, map[string]int{"category_id": id})
+	return nil
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// GetDigestSettings trả về lịch gửi bản tổng hợp của người dùng (mặc định là tắt)
+func (h *Handler) GetDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	settings, err := h.Digests.GetDigestSettings(r.Context(), userID)
+	if errors.Is(err, store.ErrNotFound) {
+		settings, err = models.DefaultDigestSettings(userID), nil
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy cấu hình bản tổng hợp")
+		return
+	}
+
+	h.respondDigestSettings(w, r, settings)
+}
+
+// UpdateDigestSettings đặt lịch gửi bản tổng hợp và tính lần gửi kế tiếp
+func (h *Handler) UpdateDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	var settings models.DigestSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ")
+		return
+	}
+	defer r.Body.Close()
+
+	settings.UserID = userID
+	if err := settings.Normalize(); err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	settings.NextRunAt = settings.Next(time.Now(), h.userLocation(r))
+	if err := h.Digests.SaveDigestSettings(r.Context(), &settings); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lưu cấu hình bản tổng hợp: "+err.Error())
+		return
+	}
+
+	saved, err := h.Digests.GetDigestSettings(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy cấu hình bản tổng hợp")
+		return
+	}
+	h.respondDigestSettings(w, r, saved)
+}
+
+func (h *Handler) respondDigestSettings(w http.ResponseWriter, r *http.Request, settings models.DigestSettings) {
+	loc := h.userLocation(r)
+	for _, t := range []**time.Time{&settings.LastSentAt, &settings.NextRunAt} {
+		if *t != nil {
+			local := (*t).In(loc)
+			*t = &local
+		}
+	}
+	RespondWithJSON(w, http.StatusOK, settings)
+}
+
+// onTimezoneChange tính lại lịch gửi bản tổng hợp khi người dùng đổi múi giờ.
+// Lỗi chỉ được ghi log vì hồ sơ đã được lưu.
+func (h *Handler) onTimezoneChange(ctx context.Context, existing, user models.User) {
+	if existing.Timezone == user.Timezone {
+		return
+	}
+	if err := h.rescheduleDigest(ctx, user); err != nil {
+		log.Printf("Lỗi khi hẹn lại bản tổng hợp của user %d: %v", user.ID, err)
+	}
+}
+
+// rescheduleDigest tính lại lần gửi bản tổng hợp kế tiếp theo múi giờ hiện tại của người dùng
+func (h *Handler) rescheduleDigest(ctx context.Context, user models.User) error {
+	settings, err := h.Digests.GetDigestSettings(ctx, user.ID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	settings.NextRunAt = settings.Next(time.Now(), user.Location())
+	return h.Digests.SaveDigestSettings(ctx, &settings)
+}
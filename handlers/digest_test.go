@@ -0,0 +1,163 @@
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/notify"
+)
+
+// digestRecorder là kênh gửi giả, giữ lại các bản tổng hợp đã nhận
+type digestRecorder struct {
+	mu      sync.Mutex
+	digests []notify.Digest
+}
+
+func (d *digestRecorder) Notify(ctx context.Context, n notify.Notification) error { return nil }
+
+func (d *digestRecorder) NotifyDigest(ctx context.Context, digest notify.Digest) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.digests = append(d.digests, digest)
+	return nil
+}
+
+// dueNow dời lần gửi kế tiếp của user về quá khứ để bộ gửi nhận ngay ở lượt quét sau
+func (a *testAPI) dueNow(user testUser) {
+	a.t.Helper()
+	settings, err := a.store.GetDigestSettings(a.t.Context(), user.ID)
+	if err != nil {
+		a.t.Fatalf("đọc cấu hình bản tổng hợp: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	settings.NextRunAt = &past
+	if err := a.store.SaveDigestSettings(a.t.Context(), &settings); err != nil {
+		a.t.Fatalf("lưu cấu hình bản tổng hợp: %v", err)
+	}
+}
+
+func TestDigestSettings(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	a.mustDo(user.Token, "PATCH", path("/api/users/%d", user.ID), map[string]any{"timezone": "Asia/Ho_Chi_Minh"},
+		http.StatusOK, "Content-Type", "application/merge-patch+json")
+	settingsPath := path("/api/users/%d/digest", user.ID)
+
+	var settings models.DigestSettings
+	a.decode(a.mustDo(user.Token, "GET", settingsPath, nil, http.StatusOK), &settings)
+	if settings.Frequency != models.DigestOff || settings.NextRunAt != nil {
+		t.Errorf("cấu hình mặc định: %+v", settings)
+	}
+	for _, body := range []map[string]any{
+		{"frequency": "hourly"},
+		{"frequency": "daily", "time": "25:00"},
+		{"frequency": "weekly", "weekday": "someday"},
+		{"frequency": "daily", "channels": []string{"sms"}},
+	} {
+		a.mustDo(user.Token, "PUT", settingsPath, body, http.StatusUnprocessableEntity)
+	}
+
+	// Lần gửi kế tiếp là 07:00 giờ Việt Nam của ngày trong tuần đã chọn, trong vòng 7 ngày tới
+	loc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+	a.decode(a.mustDo(user.Token, "PUT", settingsPath, map[string]any{"frequency": "weekly", "weekday": "Friday"}, http.StatusOK), &settings)
+	next := settings.NextRunAt
+	if next == nil || next.Location().String() == "UTC" {
+		t.Fatalf("next_run_at: %v", next)
+	}
+	if local := next.In(loc); local.Weekday() != time.Friday || local.Hour() != 7 || local.Minute() != 0 ||
+		!next.After(time.Now()) || next.After(time.Now().Add(7*24*time.Hour)) {
+		t.Errorf("next_run_at của bản hằng tuần: %s", local)
+	}
+	a.decode(a.mustDo(user.Token, "PUT", settingsPath, map[string]any{"frequency": "daily", "time": "21:30"}, http.StatusOK), &settings)
+	if local := settings.NextRunAt.In(loc); local.Hour() != 21 || local.Minute() != 30 ||
+		!settings.NextRunAt.After(time.Now()) || settings.NextRunAt.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("next_run_at của bản hằng ngày: %s", local)
+	}
+
+	// Tắt bản tổng hợp thì không còn lần gửi kế tiếp
+	a.decode(a.mustDo(user.Token, "PUT", settingsPath, map[string]any{"frequency": "off"}, http.StatusOK), &settings)
+	if settings.NextRunAt != nil {
+		t.Errorf("next_run_at sau khi tắt: %v", settings.NextRunAt)
+	}
+}
+
+func TestDigestScheduler(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice")
+	bob := a.signup("bob")
+	carol := a.signup("carol")
+
+	var upcoming, late models.Task
+	a.decode(a.mustDo(alice.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Viết báo cáo",
+		"description": "Báo cáo quý",
+		"deadline":    time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated), &upcoming)
+	a.decode(a.mustDo(alice.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Gọi khách hàng",
+		"description": "Hẹn lịch họp",
+		"deadline":    time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated), &late)
+	a.mustDo(carol.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Đóng tiền điện",
+		"description": "Hóa đơn tháng",
+		"deadline":    time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated)
+
+	// alice nhận bản hằng tuần, bob không có công việc nào, carol chọn kênh chưa cấu hình
+	a.mustDo(alice.Token, "PUT", path("/api/users/%d/digest", alice.ID), map[string]any{"frequency": "weekly"}, http.StatusOK)
+	a.mustDo(bob.Token, "PUT", path("/api/users/%d/digest", bob.ID), map[string]any{"frequency": "daily"}, http.StatusOK)
+	a.mustDo(carol.Token, "PUT", path("/api/users/%d/digest", carol.ID),
+		map[string]any{"frequency": "daily", "channels": []string{"webhook"}}, http.StatusOK)
+
+	recorder := &digestRecorder{}
+	scheduler := notify.NewDigestScheduler(a.store, &notify.Router{
+		Channels: map[models.NotificationChannel]notify.Notifier{models.ChannelEmail: recorder},
+	})
+	if sent, err := scheduler.SendDue(t.Context()); err != nil || sent != 0 {
+		t.Fatalf("gửi khi chưa đến giờ: %d, %v", sent, err)
+	}
+
+	for _, user := range []testUser{alice, bob, carol} {
+		a.dueNow(user)
+	}
+	if sent, err := scheduler.SendDue(t.Context()); err != nil || sent != 1 {
+		t.Fatalf("gửi bản tổng hợp: %d, %v; muốn 1", sent, err)
+	}
+	if len(recorder.digests) != 1 {
+		t.Fatalf("đã nhận %d bản tổng hợp, muốn 1", len(recorder.digests))
+	}
+	digest := recorder.digests[0]
+	ids := func(tasks []models.Task) []int {
+		var out []int
+		for _, task := range tasks {
+			out = append(out, task.ID)
+		}
+		return out
+	}
+	if digest.User.ID != alice.ID || digest.Frequency != models.DigestWeekly ||
+		!slices.Equal(ids(digest.DueSoon), []int{upcoming.ID}) || !slices.Equal(ids(digest.Overdue), []int{late.ID}) {
+		t.Errorf("bản tổng hợp: user %d, %s, đến hạn %v, quá hạn %v", digest.User.ID, digest.Frequency, ids(digest.DueSoon), ids(digest.Overdue))
+	}
+
+	// Mọi lịch đều được dời sang lần kế tiếp, kể cả bản rỗng và bản gửi lỗi (không thử lại);
+	// chỉ bản đã gửi được mới có last_sent_at
+	for _, user := range []testUser{alice, bob, carol} {
+		var settings models.DigestSettings
+		a.decode(a.mustDo(user.Token, "GET", path("/api/users/%d/digest", user.ID), nil, http.StatusOK), &settings)
+		if settings.NextRunAt == nil || !settings.NextRunAt.After(time.Now()) {
+			t.Errorf("%s: next_run_at %v chưa được dời", user.Username, settings.NextRunAt)
+		}
+		if (settings.LastSentAt != nil) != (user.ID == alice.ID) {
+			t.Errorf("%s: last_sent_at %v", user.Username, settings.LastSentAt)
+		}
+	}
+	if sent, err := scheduler.SendDue(t.Context()); err != nil || sent != 0 || len(recorder.digests) != 1 {
+		t.Errorf("lượt quét kế tiếp gửi lại: %d, %v", sent, err)
+	}
+}
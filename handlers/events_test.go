@@ -0,0 +1,162 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+// sseEvent là một sự kiện đọc được từ stream SSE
+type sseEvent struct {
+	ID   string
+	Type string
+	Data string
+}
+
+// sseStream là một kết nối tới /events; sự kiện được đọc nền và chuyển vào events
+type sseStream struct {
+	t      *testing.T
+	cancel context.CancelFunc
+	events chan sseEvent
+}
+
+// openEvents mở stream sự kiện của user; header là các cặp tên/giá trị thêm vào request
+func (a *testAPI) openEvents(user testUser, query string, header ...string) *sseStream {
+	a.t.Helper()
+	ctx, cancel := context.WithCancel(a.t.Context())
+	req, err := http.NewRequestWithContext(ctx, "GET", a.server.URL+path("/api/users/%d/events%s", user.ID, query), nil)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+user.Token)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := a.server.Client().Do(req)
+	if err != nil {
+		a.t.Fatalf("mở stream sự kiện: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		a.t.Fatalf("stream sự kiện: mã %d, Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	s := &sseStream{t: a.t, cancel: cancel, events: make(chan sseEvent, 16)}
+	go func() {
+		defer resp.Body.Close()
+		defer close(s.events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Type = value
+			case "data":
+				event.Data = value
+			case "":
+				// Dòng trống kết thúc một sự kiện; khối chỉ có retry hoặc comment thì bỏ qua
+				if event.Type != "" {
+					s.events <- event
+				}
+				event = sseEvent{}
+			}
+		}
+	}()
+	a.t.Cleanup(s.Close)
+	return s
+}
+
+// next chờ sự kiện kế tiếp
+func (s *sseStream) next() sseEvent {
+	s.t.Helper()
+	select {
+	case event, ok := <-s.events:
+		if !ok {
+			s.t.Fatal("stream sự kiện bị đóng")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		s.t.Fatal("không nhận được sự kiện sau 5s")
+	}
+	return sseEvent{}
+}
+
+func (s *sseStream) Close() { s.cancel() }
+
+// seq là số thứ tự trong ID sự kiện "<epoch>-<seq>"
+func (e sseEvent) seq() int {
+	_, seq, _ := strings.Cut(e.ID, "-")
+	n, _ := strconv.Atoi(seq)
+	return n
+}
+
+func TestEventStreamResume(t *testing.T) {
+	a := newTestAPI(t)
+	alice := a.signup("alice")
+	bob := a.signup("bob")
+	// createTask tạo công việc và trả về ID
+	createTask := func(user testUser, title string) int {
+		t.Helper()
+		var task models.Task
+		a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+			"title":       title,
+			"description": "Mô tả",
+			"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		}, http.StatusCreated), &task)
+		return task.ID
+	}
+
+	// Stream mới không có sự kiện reset và chỉ nhận thay đổi của chính người dùng
+	stream := a.openEvents(alice, "")
+	createTask(bob, "Việc của bob")
+	report := createTask(alice, "Viết báo cáo")
+	first := stream.next()
+	var payload struct {
+		Type string      `json:"type"`
+		Data models.Task `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(first.Data), &payload); err != nil {
+		t.Fatalf("data của sự kiện %q: %v", first.Data, err)
+	}
+	if first.Type != "task.created" || first.ID == "" || payload.Type != "task.created" || payload.Data.ID != report {
+		t.Fatalf("sự kiện đầu tiên: %+v", first)
+	}
+	stream.Close()
+
+	// Các thay đổi lúc mất kết nối được gửi lại khi nối tiếp bằng Last-Event-ID
+	shopping := createTask(alice, "Mua sữa")
+	a.mustDo(alice.Token, "DELETE", path("/api/tasks/%d", report), nil, http.StatusOK)
+	createTask(bob, "Việc khác của bob")
+	resumed := a.openEvents(alice, "", "Last-Event-ID", first.ID)
+	created, deleted := resumed.next(), resumed.next()
+	if created.Type != "task.created" || !strings.Contains(created.Data, path(`"task_id":%d`, shopping)) {
+		t.Errorf("sự kiện gửi lại đầu tiên: %+v", created)
+	}
+	if deleted.Type != "task.deleted" || deleted.seq() <= created.seq() {
+		t.Errorf("sự kiện gửi lại thứ hai: %+v (sau %s)", deleted, created.ID)
+	}
+	// Sau phần gửi lại, stream tiếp tục với sự kiện mới
+	createTask(alice, "Gọi khách hàng")
+	if live := resumed.next(); live.Type != "task.created" || live.seq() <= deleted.seq() {
+		t.Errorf("sự kiện mới sau khi nối tiếp: %+v", live)
+	}
+
+	// ID không thuộc lần chạy hiện tại (kể cả qua ?last_event_id=) thì client phải tải lại từ đầu
+	for _, stale := range []*sseStream{
+		a.openEvents(alice, "", "Last-Event-ID", "1-1"),
+		a.openEvents(alice, "?last_event_id=khong-hop-le"),
+	} {
+		if event := stale.next(); event.Type != "reset" {
+			t.Errorf("sự kiện đầu tiên với ID cũ: %+v, muốn reset", event)
+		}
+	}
+}
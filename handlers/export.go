@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/models"
+	"backend/store"
+)
+
+// exportReminderBatch là số nhắc nhở đọc mỗi lần khi xuất
+const exportReminderBatch = 500
+
+var (
+	exportTaskHeader = []string{"task_id", "title", "description", "deadline", "priority", "status", "category_id",
+		"category_name", "created_at", "updated_at", "completed_at", "recurrence"}
+	exportCategoryHeader = []string{"category_id", "category_name", "color", "description"}
+	exportReminderHeader = []string{"id", "task_id", "reminder_time", "offset_minutes", "status", "is_sent", "sent_at",
+		"channels", "attempts", "snoozed_until", "snooze_count", "acknowledged_at"}
+)
+
+// exporter ghi từng file của bản xuất vào ZIP. Công việc và nhắc nhở được đọc theo
+// từng trang và ghi ngay nên bộ nhớ dùng không phụ thuộc vào số bản ghi.
+type exporter struct {
+	h          *Handler
+	ctx        context.Context
+	userID     int
+	loc        *time.Location
+	zip        *zip.Writer
+	categories []models.Category
+	names      map[int]string
+}
+
+// ExportAccount trả về toàn bộ dữ liệu của người dùng dưới dạng file ZIP gồm:
+//   - profile.json, statistics.json
+//   - categories.json/.csv, tasks.json/.csv, reminders.json/.csv
+//   - checklists/*.md: danh sách công việc dạng checklist Markdown cho mỗi danh mục
+//
+// Thời gian được ghi theo múi giờ của người dùng. ZIP được ghi thẳng vào response;
+// nếu có lỗi giữa chừng, file ZIP bị cắt ngang (không có mục lục) để client nhận ra.
+func (h *Handler) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.GetUser(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc thông tin người dùng")
+		return
+	}
+	categories, err := h.Categories.ListCategories(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh mục: "+err.Error())
+		return
+	}
+
+	loc := user.Location()
+	filename := fmt.Sprintf("export-%d-%s.zip", userID, time.Now().In(loc).Format("20060102"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+
+	e := &exporter{
+		h:          h,
+		ctx:        r.Context(),
+		userID:     userID,
+		loc:        loc,
+		zip:        zip.NewWriter(w),
+		categories: categories,
+		names:      map[int]string{},
+	}
+	for _, category := range categories {
+		e.names[category.ID] = category.CategoryName
+	}
+	if err := e.run(user); err != nil {
+		log.Printf("Lỗi khi xuất dữ liệu của user %d: %v", userID, err)
+		return
+	}
+	if err := e.zip.Close(); err != nil {
+		log.Printf("Lỗi khi xuất dữ liệu của user %d: %v", userID, err)
+	}
+}
+
+func (e *exporter) run(user models.User) error {
+	user.Password, user.WebhookSecret = "", ""
+	user.In(e.loc)
+	if err := e.writeJSON("profile.json", user); err != nil {
+		return err
+	}
+	stats, err := e.h.Tasks.GetTaskStatistics(e.ctx, e.userID)
+	if err != nil {
+		return fmt.Errorf("đọc thống kê: %w", err)
+	}
+	if err := e.writeJSON("statistics.json", stats); err != nil {
+		return err
+	}
+
+	if err := e.writeJSON("categories.json", e.categories); err != nil {
+		return err
+	}
+	if err := e.writeCategoriesCSV(); err != nil {
+		return err
+	}
+	if err := e.writeTasksJSON(); err != nil {
+		return err
+	}
+	if err := e.writeTasksCSV(); err != nil {
+		return err
+	}
+	if err := e.writeRemindersJSON(); err != nil {
+		return err
+	}
+	if err := e.writeRemindersCSV(); err != nil {
+		return err
+	}
+	return e.writeChecklists()
+}
+
+// create mở một file mới trong ZIP; file trước đó được coi là đã ghi xong
+func (e *exporter) create(name string) (io.Writer, error) {
+	return e.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+}
+
+func (e *exporter) writeJSON(name string, v any) error {
+	f, err := e.create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// eachTask duyệt công việc theo deadline, đã đổi sang múi giờ của người dùng
+func (e *exporter) eachTask(filter store.TaskFilter, fn func(models.Task) error) error {
+	filter.Sort = store.SortDeadline
+	return store.EachTask(e.ctx, e.h.Tasks, e.userID, filter, func(task models.Task) error {
+		task.In(e.loc)
+		return fn(task)
+	})
+}
+
+// eachReminder duyệt nhắc nhở theo ID, mỗi lần đọc exportReminderBatch bản ghi
+func (e *exporter) eachReminder(fn func(models.Reminder) error) error {
+	afterID := 0
+	for {
+		reminders, err := e.h.Reminders.ListRemindersAfter(e.ctx, e.userID, afterID, exportReminderBatch)
+		if err != nil {
+			return fmt.Errorf("đọc nhắc nhở: %w", err)
+		}
+		for _, reminder := range reminders {
+			reminder.In(e.loc)
+			if err := fn(reminder); err != nil {
+				return err
+			}
+			afterID = reminder.ID
+		}
+		if len(reminders) < exportReminderBatch {
+			return nil
+		}
+	}
+}
+
+// jsonArray ghi một mảng JSON từng phần tử một
+type jsonArray struct {
+	w     *bufio.Writer
+	count int
+}
+
+func (a *jsonArray) add(v any) error {
+	data, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	if a.count > 0 {
+		a.w.WriteString(",")
+	}
+	a.w.WriteString("\n  ")
+	a.w.Write(data)
+	a.count++
+	return nil
+}
+
+func (e *exporter) streamJSON(name string, each func(add func(any) error) error) error {
+	f, err := e.create(name)
+	if err != nil {
+		return err
+	}
+	array := &jsonArray{w: bufio.NewWriter(f)}
+	array.w.WriteString("[")
+	if err := each(array.add); err != nil {
+		return err
+	}
+	if array.count > 0 {
+		array.w.WriteString("\n")
+	}
+	array.w.WriteString("]\n")
+	return array.w.Flush()
+}
+
+func (e *exporter) streamCSV(name string, header []string, each func(write func([]string) error) error) error {
+	f, err := e.create(name)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(f)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := each(writer.Write); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func (e *exporter) writeCategoriesCSV() error {
+	return e.streamCSV("categories.csv", exportCategoryHeader, func(write func([]string) error) error {
+		for _, category := range e.categories {
+			if err := write([]string{strconv.Itoa(category.ID), csvText(category.CategoryName), csvText(category.Color), csvText(category.Description)}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (e *exporter) writeTasksJSON() error {
+	return e.streamJSON("tasks.json", func(add func(any) error) error {
+		return e.eachTask(store.TaskFilter{}, func(task models.Task) error { return add(task) })
+	})
+}
+
+func (e *exporter) writeTasksCSV() error {
+	return e.streamCSV("tasks.csv", exportTaskHeader, func(write func([]string) error) error {
+		return e.eachTask(store.TaskFilter{}, func(task models.Task) error {
+			return write([]string{
+				strconv.Itoa(task.ID),
+				csvText(task.Title),
+				csvText(task.Description),
+				formatExportTime(&task.Deadline),
+				string(task.Priority),
+				string(task.Status),
+				strconv.Itoa(task.CategoryID),
+				csvText(e.names[task.CategoryID]),
+				formatExportTime(&task.CreatedAt),
+				formatExportTime(&task.UpdatedAt),
+				formatExportTime(task.CompletedAt),
+				task.Recurrence,
+			})
+		})
+	})
+}
+
+func (e *exporter) writeRemindersJSON() error {
+	return e.streamJSON("reminders.json", func(add func(any) error) error {
+		return e.eachReminder(func(reminder models.Reminder) error { return add(reminder) })
+	})
+}
+
+func (e *exporter) writeRemindersCSV() error {
+	return e.streamCSV("reminders.csv", exportReminderHeader, func(write func([]string) error) error {
+		return e.eachReminder(func(reminder models.Reminder) error {
+			offset := ""
+			if reminder.OffsetMinutes != nil {
+				offset = strconv.Itoa(*reminder.OffsetMinutes)
+			}
+			return write([]string{
+				strconv.Itoa(reminder.ID),
+				strconv.Itoa(reminder.TaskID),
+				formatExportTime(&reminder.ReminderTime),
+				offset,
+				string(reminder.Status),
+				strconv.FormatBool(reminder.IsSent),
+				formatExportTime(reminder.SentAt),
+				models.FormatChannels(reminder.Channels),
+				strconv.Itoa(reminder.Attempts),
+				formatExportTime(reminder.SnoozedUntil),
+				strconv.Itoa(reminder.SnoozeCount),
+				formatExportTime(reminder.AcknowledgedAt),
+			})
+		})
+	})
+}
+
+// writeChecklists ghi một file Markdown cho mỗi danh mục và một file cho công việc
+// không thuộc danh mục nào (kể cả công việc của danh mục đã bị xóa)
+func (e *exporter) writeChecklists() error {
+	for _, category := range e.categories {
+		name := fmt.Sprintf("checklists/%d-%s.md", category.ID, exportSlug(category.CategoryName))
+		id := category.ID
+		filter := store.TaskFilter{CategoryID: &id}
+		err := e.writeChecklist(name, category.CategoryName, category.Description, filter, nil)
+		if err != nil {
+			return err
+		}
+	}
+	uncategorized := func(task models.Task) bool {
+		_, ok := e.names[task.CategoryID]
+		return !ok
+	}
+	return e.writeChecklist("checklists/khong-danh-muc.md", "Không có danh mục", "", store.TaskFilter{}, uncategorized)
+}
+
+func (e *exporter) writeChecklist(name, title, description string, filter store.TaskFilter, include func(models.Task) bool) error {
+	f, err := e.create(name)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "# %s\n\n", markdownEscape(title))
+	if description != "" {
+		fmt.Fprintf(w, "%s\n\n", markdownEscape(description))
+	}
+
+	count := 0
+	err = e.eachTask(filter, func(task models.Task) error {
+		if include != nil && !include(task) {
+			return nil
+		}
+		count++
+		mark := " "
+		if task.Status == models.StatusCompleted {
+			mark = "x"
+		}
+		fmt.Fprintf(w, "- [%s] %s — hạn %s · %s", mark, markdownEscape(task.Title),
+			task.Deadline.Format("02/01/2006 15:04"), task.Priority)
+		if task.Status == models.StatusInProgress {
+			fmt.Fprint(w, " · đang làm")
+		}
+		if task.CompletedAt != nil {
+			fmt.Fprintf(w, " · xong %s", task.CompletedAt.Format("02/01/2006 15:04"))
+		}
+		w.WriteString("\n")
+		if task.Description != "" && task.Description != task.Title {
+			for _, line := range strings.Split(task.Description, "\n") {
+				fmt.Fprintf(w, "  %s\n", markdownEscape(line))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		w.WriteString("_Không có công việc._\n")
+	}
+	return w.Flush()
+}
+
+// csvText thêm dấu ' trước ô văn bản bắt đầu bằng =, +, -, @ (hoặc tab, CR) để bảng tính
+// không coi nội dung người dùng nhập là công thức khi mở file CSV (CSV injection)
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// formatExportTime ghi thời điểm theo RFC 3339, chuỗi rỗng nếu không có
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// markdownEscape escape các ký tự có ý nghĩa trong Markdown để tiêu đề hiển thị nguyên văn
+func markdownEscape(s string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimRight(s, "\r") {
+		if strings.ContainsRune("\\`*_[]<>#|~", r) {
+			b.WriteByte('\\')
+		}
+		if r == '\n' || r == '\r' {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// exportSlug chuyển tên thành phần tên file an toàn: giữ chữ và số, còn lại thành "-"
+func exportSlug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+		if b.Len() >= 60 {
+			break
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "khong-ten"
+	}
+	return slug
+}
@@ -0,0 +1,157 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+// exportZip tải bản xuất của user và trả về nội dung từng file theo tên
+func (a *testAPI) exportZip(user testUser) map[string]string {
+	a.t.Helper()
+	data := a.mustDo(user.Token, "GET", path("/api/users/%d/export", user.ID), nil, http.StatusOK)
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		a.t.Fatalf("giải nén bản xuất: %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			a.t.Fatalf("mở %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			a.t.Fatalf("đọc %s: %v", f.Name, err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+// readCSV đọc file CSV của bản xuất thành các dòng (kể cả dòng tiêu đề)
+func readCSV(t *testing.T, files map[string]string, name string) [][]string {
+	t.Helper()
+	records, err := csv.NewReader(bytes.NewBufferString(files[name])).ReadAll()
+	if err != nil {
+		t.Fatalf("%s không phải CSV hợp lệ: %v", name, err)
+	}
+	return records
+}
+
+func TestExportCSVFormulas(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+
+	a.mustDo(user.Token, "POST", "/api/categories", map[string]any{
+		"category_name": "@Khách hàng",
+		"description":   "+84 90 000 0000",
+	}, http.StatusCreated)
+	a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       `=HYPERLINK("http://example.com","Mở")`,
+		"description": "-1+1",
+		"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated)
+
+	files := a.exportZip(user)
+	tasks := readCSV(t, files, "tasks.csv")
+	if len(tasks) != 2 {
+		t.Fatalf("tasks.csv có %d dòng, muốn 2", len(tasks))
+	}
+	if title, description := tasks[1][1], tasks[1][2]; title != `'=HYPERLINK("http://example.com","Mở")` || description != "'-1+1" {
+		t.Errorf("ô văn bản giống công thức: title %q, description %q", title, description)
+	}
+	if id := tasks[1][0]; id == "" || id[0] == '\'' {
+		t.Errorf("ô số bị đổi: %q", id)
+	}
+	categories := readCSV(t, files, "categories.csv")
+	if len(categories) != 2 || categories[1][1] != "'@Khách hàng" || categories[1][3] != "'+84 90 000 0000" {
+		t.Errorf("categories.csv: %q", categories)
+	}
+}
+
+func TestExportAccount(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	a.mustDo(user.Token, "PATCH", path("/api/users/%d", user.ID), map[string]any{"timezone": "Asia/Ho_Chi_Minh"},
+		http.StatusOK, "Content-Type", "application/merge-patch+json")
+
+	var category models.Category
+	a.decode(a.mustDo(user.Token, "POST", "/api/categories", map[string]any{"category_name": "Công việc"}, http.StatusCreated), &category)
+	var report, shopping models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Viết báo cáo",
+		"description": "Báo cáo quý",
+		"deadline":    "2030-01-02T02:00:00Z",
+		"category_id": category.ID,
+	}, http.StatusCreated), &report)
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Mua *sữa*",
+		"description": "Siêu thị",
+		"deadline":    "2030-01-03T02:00:00Z",
+	}, http.StatusCreated), &shopping)
+	a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{"task_id": report.ID, "offset_minutes": 30}, http.StatusCreated)
+
+	files := a.exportZip(user)
+	checklist := path("checklists/%d-công-việc.md", category.ID)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{"categories.csv", "categories.json", checklist, "checklists/khong-danh-muc.md", "profile.json",
+		"reminders.csv", "reminders.json", "statistics.json", "tasks.csv", "tasks.json"}
+	sort.Strings(want)
+	if !slices.Equal(names, want) {
+		t.Fatalf("các file trong bản xuất: %v, muốn %v", names, want)
+	}
+
+	// Hồ sơ không chứa mật khẩu; thời gian theo múi giờ của người dùng
+	if strings.Contains(files["profile.json"], "password") {
+		t.Errorf("profile.json chứa mật khẩu: %s", files["profile.json"])
+	}
+	var tasks []models.Task
+	if err := json.Unmarshal([]byte(files["tasks.json"]), &tasks); err != nil {
+		t.Fatalf("tasks.json: %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Errorf("tasks.json có %d công việc, muốn 2", len(tasks))
+	}
+	rows := readCSV(t, files, "tasks.csv")
+	if len(rows) != 3 || rows[0][0] != "task_id" {
+		t.Fatalf("tasks.csv: %q", rows)
+	}
+	for _, row := range rows[1:] {
+		if row[0] != strconv.Itoa(report.ID) {
+			continue
+		}
+		if row[3] != "2030-01-02T09:00:00+07:00" || row[7] != "Công việc" {
+			t.Errorf("dòng của công việc #%d: deadline %q, danh mục %q", report.ID, row[3], row[7])
+		}
+	}
+	reminders := readCSV(t, files, "reminders.csv")
+	if len(reminders) != 2 || reminders[1][1] != strconv.Itoa(report.ID) || reminders[1][3] != "30" ||
+		reminders[1][2] != "2030-01-02T08:30:00+07:00" {
+		t.Errorf("reminders.csv: %q", reminders)
+	}
+
+	// Checklist theo danh mục; công việc không có danh mục nằm trong khong-danh-muc.md
+	if got := files[checklist]; !strings.Contains(got, "# Công việc") || !strings.Contains(got, "- [ ] Viết báo cáo — hạn 02/01/2030 09:00") {
+		t.Errorf("%s:\n%s", checklist, got)
+	}
+	if got := files["checklists/khong-danh-muc.md"]; !strings.Contains(got, `- [ ] Mua \*sữa\*`) || strings.Contains(got, "Viết báo cáo") {
+		t.Errorf("khong-danh-muc.md:\n%s", got)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"backend/events"
+	"backend/models"
+	"backend/store"
+)
+
+// Handler gom các phụ thuộc của tầng HTTP; mỗi route là một method của Handler
+type Handler struct {
+	Users      store.UserStore
+	Sessions   store.SessionStore
+	Tasks      store.TaskStore
+	Categories store.CategoryStore
+	Reminders  store.ReminderStore
+	Digests    store.DigestStore
+	Calendar   store.CalendarStore
+	CalDAV     store.CalDAVStore
+	Sync       store.SyncStore
+	Imports    store.ImportStore
+	Webhooks   store.WebhookStore
+	// Events phát thay đổi dữ liệu tới các client đang nghe /users/{user_id}/events
+	Events *events.Hub
+	// Workflow quy định các bước chuyển trạng thái công việc được phép
+	Workflow models.Workflow
+}
+
+// New tạo Handler dùng chung một store cho tất cả các kho dữ liệu
+func New(s store.Store) *Handler {
+	return &Handler{
+		Users:      s,
+		Sessions:   s,
+		Tasks:      s,
+		Categories: s,
+		Reminders:  s,
+		Digests:    s,
+		Calendar:   s,
+		CalDAV:     s,
+		Sync:       s,
+		Imports:    s,
+		Webhooks:   s,
+		Events:     events.NewHub(events.DefaultHistorySize),
+		Workflow:   models.DefaultWorkflow,
+	}
+}
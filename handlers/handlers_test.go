@@ -0,0 +1,137 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/handlers"
+	"backend/store/memstore"
+)
+
+// testAPI là server HTTP chạy toàn bộ router trên memstore, không cần database
+type testAPI struct {
+	t      *testing.T
+	store  *memstore.Store
+	server *httptest.Server
+}
+
+// testUser là người dùng đã đăng nhập qua API
+type testUser struct {
+	ID       int
+	Username string
+	Token    string
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	st := memstore.New()
+	h := handlers.New(st)
+	server := httptest.NewServer(h.Router())
+	// Route stream (SSE, WebSocket) trả lỗi ngay khi bị từ chối; nếu không, test dừng sau timeout thay vì treo
+	server.Client().Timeout = 10 * time.Second
+	t.Cleanup(func() {
+		h.Events.Close()
+		server.Close()
+	})
+	return &testAPI{t: t, store: st, server: server}
+}
+
+// signup tạo người dùng mới rồi đăng nhập để lấy access token
+func (a *testAPI) signup(username string) testUser {
+	a.t.Helper()
+	a.mustDo("", "POST", "/api/users", map[string]string{
+		"username":  username,
+		"email":     username + "@example.com",
+		"password":  "mat-khau-" + username,
+		"full_name": username,
+	}, http.StatusCreated)
+
+	var login struct {
+		AccessToken string `json:"access_token"`
+	}
+	a.decode(a.mustDo("", "POST", "/api/users/login", map[string]string{
+		"username": username,
+		"password": "mat-khau-" + username,
+	}, http.StatusOK), &login)
+
+	user, err := a.store.GetUserByUsername(a.t.Context(), username)
+	if err != nil {
+		a.t.Fatalf("không đọc được người dùng %s: %v", username, err)
+	}
+	return testUser{ID: user.ID, Username: username, Token: login.AccessToken}
+}
+
+// do gửi request với access token (bỏ trống token để gọi route công khai). body là
+// []byte, string hoặc giá trị được mã hóa JSON.
+func (a *testAPI) do(token, method, path string, body any, header ...string) *http.Response {
+	a.t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	case string:
+		reader = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			a.t.Fatalf("mã hóa body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(a.t.Context(), method, a.server.URL+path, reader)
+	if err != nil {
+		a.t.Fatalf("tạo request %s %s: %v", method, path, err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := a.server.Client().Do(req)
+	if err != nil {
+		a.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp
+}
+
+// mustDo giống do nhưng dừng test nếu mã trạng thái khác want; trả về body
+func (a *testAPI) mustDo(token, method, path string, body any, want int, header ...string) []byte {
+	a.t.Helper()
+	resp := a.do(token, method, path, body, header...)
+	data := readBody(a.t, resp)
+	if resp.StatusCode != want {
+		a.t.Fatalf("%s %s: mã %d, muốn %d: %s", method, path, resp.StatusCode, want, data)
+	}
+	return data
+}
+
+func (a *testAPI) decode(data []byte, v any) {
+	a.t.Helper()
+	if err := json.Unmarshal(data, v); err != nil {
+		a.t.Fatalf("giải mã %s: %v", data, err)
+	}
+}
+
+func readBody(t *testing.T, resp *http.Response) []byte {
+	t.Helper()
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("đọc body: %v", err)
+	}
+	return data
+}
+
+func path(format string, args ...any) string {
+	return fmt.Sprintf(format, args...)
+}
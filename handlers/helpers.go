@@ -2,7 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
+
+	"backend/auth"
+	"backend/models"
+
+	"github.com/gorilla/mux"
 )
 
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -23,3 +32,63 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 func RespondWithError(w http.ResponseWriter, code int, message string) {
 	RespondWithJSON(w, code, map[string]string{"error": message})
 }
+
+// currentUserID trả về ID người dùng đã được AuthMiddleware xác thực
+func currentUserID(r *http.Request) int {
+	userID, _ := auth.UserID(r.Context())
+	return userID
+}
+
+// pathUserID đọc ID người dùng trong đường dẫn và chỉ chấp nhận khi trùng với
+// người dùng đang đăng nhập. Dữ liệu của người khác được coi như không tồn tại (404).
+func pathUserID(w http.ResponseWriter, r *http.Request, key string) (int, bool) {
+	userID, err := strconv.Atoi(mux.Vars(r)[key])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "ID người dùng không hợp lệ")
+		return 0, false
+	}
+	if userID != currentUserID(r) {
+		RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Không tìm thấy người dùng với ID: %d", userID))
+		return 0, false
+	}
+	return userID, true
+}
+
+// validateChannels trả về 422 nếu danh sách kênh thông báo có kênh không hợp lệ.
+// Trả về false sau khi đã ghi response lỗi.
+func validateChannels(w http.ResponseWriter, channels []models.NotificationChannel) bool {
+	if err := models.ValidateChannels(channels); err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	return true
+}
+
+// serviceError là lỗi của một thao tác dùng chung giữa REST và các giao thức khác
+// (WebSocket, đồng bộ offline) kèm mã HTTP tương ứng
+type serviceError struct {
+	Code    int
+	Message string
+}
+
+func (e *serviceError) Error() string { return e.Message }
+
+func serviceErrorf(code int, format string, args ...any) error {
+	return &serviceError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// serviceErrorStatus trả về mã HTTP và thông báo của err; lỗi không phải *serviceError là 500
+func serviceErrorStatus(err error) (int, string) {
+	var se *serviceError
+	if errors.As(err, &se) {
+		return se.Code, se.Message
+	}
+	log.Printf("Lỗi khi xử lý yêu cầu: %v", err)
+	return http.StatusInternalServerError, "Lỗi khi xử lý yêu cầu: " + err.Error()
+}
+
+// respondServiceError ghi response lỗi cho err trả về từ các thao tác dùng chung
+func respondServiceError(w http.ResponseWriter, err error) {
+	code, message := serviceErrorStatus(err)
+	RespondWithError(w, code, message)
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/events"
+	"backend/importer"
+	"backend/models"
+	"backend/store"
+)
+
+const (
+	// maxImportSize giới hạn kích thước file nhập
+	maxImportSize = 10 << 20
+	// importPreviewSize là số công việc được trả về để xem trước khi dry_run
+	importPreviewSize = 20
+)
+
+// importSummary mô tả kết quả (hoặc bản xem trước) một lần nhập
+type importSummary struct {
+	Format             importer.Format   `json:"format"`
+	DryRun             bool              `json:"dry_run"`
+	CategoriesCreated  []models.Category `json:"categories_created"`
+	CategoriesExisting []string          `json:"categories_existing"`
+	TasksCreated       int               `json:"tasks_created"`
+	TasksCompleted     int               `json:"tasks_completed"`
+	Skipped            int               `json:"skipped"`
+	Warnings           []string          `json:"warnings"`
+	// Preview là các công việc đầu tiên sẽ được tạo, chỉ có khi dry_run
+	Preview []models.Task `json:"preview,omitempty"`
+}
+
+// ImportTasks nhập công việc từ Todoist (JSON hoặc CSV), Trello, Google Tasks hoặc CSV
+// bất kỳ. Request là multipart/form-data với các trường:
+//   - file: file xuất từ công cụ khác (bắt buộc)
+//   - format: todoist, todoist-csv, trello, google-tasks hoặc csv; bỏ trống để tự nhận dạng
+//   - list: tên danh mục cho công việc không thuộc danh sách nào
+//   - mapping: JSON ánh xạ trường sang tên cột, bắt buộc với format=csv
+//   - dry_run: true để chỉ xem trước, không ghi gì
+//
+// Danh sách/project trở thành danh mục (dùng lại danh mục trùng tên), thẻ/mục trở thành
+// công việc. Mọi thứ được ghi trong một transaction: lỗi thì không có gì được nhập.
+func (h *Handler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			RespondWithError(w, http.StatusRequestEntityTooLarge, "File nhập quá lớn")
+			return
+		}
+		RespondWithError(w, http.StatusBadRequest, "Thiếu file cần nhập (trường file của multipart/form-data)")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Không đọc được file nhập")
+		return
+	}
+	if len(data) > maxImportSize {
+		RespondWithError(w, http.StatusRequestEntityTooLarge, "File nhập quá lớn")
+		return
+	}
+
+	opts := importer.Options{
+		Format:   importer.Format(r.FormValue("format")),
+		List:     r.FormValue("list"),
+		Location: h.userLocation(r),
+	}
+	if opts.Format != "" && !opts.Format.Valid() {
+		RespondWithError(w, http.StatusBadRequest, "Định dạng không hợp lệ: "+string(opts.Format))
+		return
+	}
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Mapping không hợp lệ: "+err.Error())
+			return
+		}
+	}
+	dryRun := false
+	if value := r.FormValue("dry_run"); value != "" {
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			RespondWithError(w, http.StatusBadRequest, "Tham số dry_run không hợp lệ")
+			return
+		}
+	}
+
+	result, err := importer.Parse(data, opts)
+	if err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, "Không đọc được file nhập: "+err.Error())
+		return
+	}
+
+	batch, summary, err := h.buildImport(r, userID, result)
+	if err != nil {
+		respondServiceError(w, err)
+		return
+	}
+	summary.DryRun = dryRun
+	if dryRun {
+		for i := 0; i < len(batch.Tasks) && i < importPreviewSize; i++ {
+			task := batch.Tasks[i].Task
+			task.In(opts.Location)
+			summary.Preview = append(summary.Preview, task)
+		}
+		RespondWithJSON(w, http.StatusOK, summary)
+		return
+	}
+
+	// Store điền ID vào batch khi ghi nên sự kiện webhook trỏ tới phần tử của batch
+	outbox := make([]store.Event, 0, len(batch.Categories)+len(batch.Tasks))
+	for i := range batch.Categories {
+		outbox = append(outbox, store.Event{Type: string(events.CategoryCreated), UserID: userID, Data: &batch.Categories[i]})
+	}
+	for i := range batch.Tasks {
+		outbox = append(outbox, store.Event{Type: string(events.TaskCreated), UserID: userID, Data: &batch.Tasks[i].Task})
+	}
+	if err := h.Imports.ImportTasks(store.WithEvents(r.Context(), outbox...), userID, batch); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			// Danh mục trùng tên vừa được tạo bởi một request khác
+			RespondWithError(w, http.StatusConflict, "Danh mục đã tồn tại, vui lòng thử lại")
+			return
+		}
+		log.Printf("Lỗi khi nhập công việc của user %d: %v", userID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi nhập công việc")
+		return
+	}
+
+	// batch đã được điền ID; summary.CategoriesCreated dùng chung slice với batch
+	for _, category := range batch.Categories {
+		h.Events.Publish(userID, events.CategoryCreated, category)
+	}
+	for _, item := range batch.Tasks {
+		h.Events.Publish(userID, events.TaskCreated, item.Task)
+	}
+	RespondWithJSON(w, http.StatusCreated, summary)
+}
+
+// buildImport chuyển kết quả đọc file thành batch cần ghi: danh sách trùng tên với danh
+// mục có sẵn được dùng lại, các danh sách khác trở thành danh mục mới
+func (h *Handler) buildImport(r *http.Request, userID int, result *importer.Result) (*store.ImportBatch, *importSummary, error) {
+	existing, err := h.Categories.ListCategories(r.Context(), userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	categoryIDs := map[string]int{}
+	for _, category := range existing {
+		categoryIDs[category.CategoryName] = category.ID
+	}
+
+	batch := &store.ImportBatch{}
+	summary := &importSummary{
+		Format:             result.Format,
+		CategoriesCreated:  []models.Category{},
+		CategoriesExisting: []string{},
+		Skipped:            result.Skipped,
+		Warnings:           result.Warnings,
+	}
+	newCategories := map[string]int{}
+	for _, name := range result.Lists {
+		if _, ok := categoryIDs[name]; ok {
+			summary.CategoriesExisting = append(summary.CategoriesExisting, name)
+			continue
+		}
+		newCategories[name] = len(batch.Categories)
+		batch.Categories = append(batch.Categories, models.Category{CategoryName: name, UserID: userID})
+	}
+	if summary.Warnings == nil {
+		summary.Warnings = []string{}
+	}
+
+	now := time.Now()
+	for _, item := range result.Items {
+		task := models.Task{
+			Title:       item.Title,
+			Description: item.Description,
+			Priority:    item.Priority,
+			Status:      models.StatusPending,
+			UserID:      userID,
+		}
+		// Mô tả là bắt buộc khi tạo công việc qua API
+		if task.Description == "" {
+			task.Description = item.Title
+		}
+		task.Deadline = now
+		if item.Deadline != nil {
+			task.Deadline = *item.Deadline
+		}
+		if item.Completed {
+			task.Status = models.StatusCompleted
+			completedAt := now
+			if item.CompletedAt != nil {
+				completedAt = *item.CompletedAt
+			}
+			task.CompletedAt = &completedAt
+			summary.TasksCompleted++
+		}
+		if err := normalizeTaskEnums(&task); err != nil {
+			return nil, nil, err
+		}
+
+		imported := store.ImportedTask{Task: task, Category: -1}
+		if index, ok := newCategories[item.List]; ok {
+			imported.Category = index
+		} else {
+			imported.Task.CategoryID = categoryIDs[item.List]
+		}
+		batch.Tasks = append(batch.Tasks, imported)
+	}
+	summary.TasksCreated = len(batch.Tasks)
+	if len(batch.Categories) > 0 {
+		summary.CategoriesCreated = batch.Categories
+	}
+	return batch, summary, nil
+}
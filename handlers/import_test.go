@@ -0,0 +1,111 @@
+package handlers_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// importFile gửi file cần nhập cùng các trường form tới /import
+func (a *testAPI) importFile(user testUser, data string, fields map[string]string, wantStatus int) []byte {
+	a.t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("file", "export")
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	part.Write([]byte(data))
+	for name, value := range fields {
+		mw.WriteField(name, value)
+	}
+	if err := mw.Close(); err != nil {
+		a.t.Fatal(err)
+	}
+	return a.mustDo(user.Token, "POST", path("/api/users/%d/import", user.ID), buf.Bytes(), wantStatus,
+		"Content-Type", mw.FormDataContentType())
+}
+
+func TestImportTasks(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	var existing models.Category
+	a.decode(a.mustDo(user.Token, "POST", "/api/categories", map[string]any{"category_name": "Cần làm"}, http.StatusCreated), &existing)
+
+	board := `{
+		"name": "Dự án",
+		"lists": [{"id": "l1", "name": "Cần làm"}, {"id": "l2", "name": "Đang làm"}],
+		"cards": [
+			{"name": "Thiết kế", "idList": "l1", "due": "2030-01-02T02:00:00.000Z", "labels": [{"name": "Cao"}]},
+			{"name": "Kiểm thử", "desc": "Viết test", "idList": "l2", "dueComplete": true},
+			{"name": "Thẻ cũ", "idList": "l2", "closed": true}
+		]}`
+	type summary struct {
+		Format             string            `json:"format"`
+		DryRun             bool              `json:"dry_run"`
+		CategoriesCreated  []models.Category `json:"categories_created"`
+		CategoriesExisting []string          `json:"categories_existing"`
+		TasksCreated       int               `json:"tasks_created"`
+		TasksCompleted     int               `json:"tasks_completed"`
+		Skipped            int               `json:"skipped"`
+		Preview            []models.Task     `json:"preview"`
+	}
+	// listTasks trả về mọi công việc hiện có của user
+	listTasks := func() []models.Task {
+		t.Helper()
+		tasks, err := store.ListAllTasks(t.Context(), a.store, user.ID, store.TaskFilter{Sort: store.SortCreatedAt})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tasks
+	}
+
+	// dry_run chỉ xem trước: không tạo danh mục hay công việc nào
+	var preview summary
+	a.decode(a.importFile(user, board, map[string]string{"dry_run": "true"}, http.StatusOK), &preview)
+	if preview.Format != "trello" || !preview.DryRun || preview.TasksCreated != 2 || preview.TasksCompleted != 1 || preview.Skipped != 1 ||
+		len(preview.CategoriesCreated) != 1 || preview.CategoriesCreated[0].CategoryName != "Đang làm" ||
+		!slices.Equal(preview.CategoriesExisting, []string{"Cần làm"}) || len(preview.Preview) != 2 {
+		t.Fatalf("bản xem trước: %+v", preview)
+	}
+	if first := preview.Preview[0]; first.Title != "Thiết kế" || first.Priority != models.PriorityHigh || first.CategoryID != existing.ID ||
+		!first.Deadline.Equal(time.Date(2030, 1, 2, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("công việc đầu tiên trong bản xem trước: %+v", first)
+	}
+	if tasks := listTasks(); len(tasks) != 0 {
+		t.Fatalf("dry_run đã tạo %d công việc", len(tasks))
+	}
+
+	var imported summary
+	a.decode(a.importFile(user, board, nil, http.StatusCreated), &imported)
+	if imported.DryRun || imported.TasksCreated != 2 || len(imported.CategoriesCreated) != 1 || imported.CategoriesCreated[0].ID == 0 {
+		t.Fatalf("kết quả nhập: %+v", imported)
+	}
+	tasks := listTasks()
+	if len(tasks) != 2 {
+		t.Fatalf("đã nhập %d công việc, muốn 2", len(tasks))
+	}
+	design, review := tasks[0], tasks[1]
+	if design.CategoryID != existing.ID || design.Status != models.StatusPending || design.Description != "Thiết kế" {
+		t.Errorf("công việc trong danh mục có sẵn: %+v", design)
+	}
+	if review.CategoryID != imported.CategoriesCreated[0].ID || review.Status != models.StatusCompleted || review.CompletedAt == nil ||
+		review.Description != "Viết test" {
+		t.Errorf("công việc trong danh mục mới: %+v", review)
+	}
+
+	// File hoặc tham số sai bị từ chối trước khi ghi
+	a.importFile(user, board, map[string]string{"format": "asana"}, http.StatusBadRequest)
+	a.importFile(user, board, map[string]string{"dry_run": "có lẽ"}, http.StatusBadRequest)
+	a.importFile(user, "Tiêu đề\nMua sữa\n", map[string]string{"format": "csv"}, http.StatusUnprocessableEntity)
+	a.importFile(user, `{"foo": 1}`, nil, http.StatusUnprocessableEntity)
+	if tasks := listTasks(); len(tasks) != 2 {
+		t.Errorf("sau các lần nhập lỗi có %d công việc, muốn 2", len(tasks))
+	}
+}
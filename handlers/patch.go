@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+)
+
+var errPatchNotObject = errors.New("merge patch phải là một JSON object")
+
+// readMergePatch đọc body của request PATCH. Chấp nhận application/merge-patch+json
+// (RFC 7396) và application/json. Trả về false sau khi đã ghi response lỗi.
+func readMergePatch(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/merge-patch+json" && mediaType != "application/json") {
+			RespondWithError(w, http.StatusUnsupportedMediaType, "Content-Type phải là application/merge-patch+json")
+			return nil, false
+		}
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Không đọc được dữ liệu")
+		return nil, false
+	}
+	defer r.Body.Close()
+	return body, true
+}
+
+// applyMergePatch áp dụng JSON Merge Patch (RFC 7396) lên target (con trỏ tới struct).
+// Chỉ các trường có trong patch bị thay đổi; trường mang giá trị null được đưa về giá trị rỗng.
+func applyMergePatch(target any, patch []byte) error {
+	var patchValue any
+	if err := json.Unmarshal(patch, &patchValue); err != nil {
+		return err
+	}
+	if _, ok := patchValue.(map[string]any); !ok {
+		return errPatchNotObject
+	}
+
+	original, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := json.Unmarshal(original, &doc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergePatch(doc, patchValue))
+	if err != nil {
+		return err
+	}
+
+	// Đưa target về giá trị rỗng trước khi giải mã để các trường bị xóa (null) trở về mặc định
+	reflect.ValueOf(target).Elem().SetZero()
+	return json.Unmarshal(merged, target)
+}
+
+// mergePatch cài đặt thuật toán MergePatch trong RFC 7396, mục 2
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
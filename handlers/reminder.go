@@ -2,17 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
-	"backend/database"
 	"backend/models"
+	"backend/store"
 
 	"github.com/gorilla/mux"
 )
 
-func CreateReminder(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateReminder(w http.ResponseWriter, r *http.Request) {
 	var reminder models.Reminder
 	if err := json.NewDecoder(r.Body).Decode(&reminder); err != nil {
 		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không đúng định dạng JSON: "+err.Error())
@@ -20,51 +21,55 @@ func CreateReminder(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	if reminder.TaskID == 0 || reminder.UserID == 0 || reminder.ReminderTime.IsZero() {
+	// Cần thời điểm cố định (reminder_time) hoặc số phút trước deadline (offset_minutes)
+	if reminder.TaskID == 0 || (reminder.ReminderTime.IsZero() && reminder.OffsetMinutes == nil) {
 		RespondWithError(w, http.StatusBadRequest, "Thiếu thông tin cần thiết")
 		return
 	}
+	if !validateChannels(w, reminder.Channels) {
+		return
+	}
+	reminder.ResetDelivery()
+
+	// Nhắc nhở luôn thuộc về người dùng đang đăng nhập, bỏ qua user_id trong body
+	reminder.UserID = currentUserID(r)
+	task, err := h.Tasks.GetTask(r.Context(), reminder.UserID, reminder.TaskID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy công việc")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi kiểm tra công việc: "+err.Error())
+		return
+	}
+	if !applyReminderOffset(w, task, &reminder) {
+		return
+	}
 
 	// Kiểm tra xem có nhắc nhở nào cho cùng task_id và user_id trong khoảng thời gian gần reminder_time không
-	var exists int
 	// Khoảng thời gian kiểm tra: 1 phút (60 giây) trước và sau reminder_time
 	lowerBound := reminder.ReminderTime.Add(-1 * time.Minute)
 	upperBound := reminder.ReminderTime.Add(1 * time.Minute)
-	queryCheck := `
-		SELECT COUNT(*) 
-		FROM reminders 
-		WHERE task_id = ? 
-		AND user_id = ? 
-		AND reminder_time BETWEEN ? AND ?`
-	err := database.DB.QueryRow(queryCheck, reminder.TaskID, reminder.UserID, lowerBound, upperBound).Scan(&exists)
+	exists, err := h.Reminders.HasReminderBetween(r.Context(), reminder.UserID, reminder.TaskID, lowerBound, upperBound)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi kiểm tra nhắc nhở trùng lặp: "+err.Error())
 		return
 	}
-	if exists > 0 {
+	if exists {
 		RespondWithError(w, http.StatusConflict, "Đã có nhắc nhở cho công việc này trong khoảng thời gian gần thời điểm bạn chọn")
 		return
 	}
 
 	// Nếu không có nhắc nhở nào trong khoảng thời gian, tiến hành tạo mới
-	query := "INSERT INTO reminders (task_id, user_id, reminder_time, is_sent, created_at, updated_at) VALUES (?, ?, ?, ?, NOW(), NOW())"
-	result, err := database.DB.Exec(query, reminder.TaskID, reminder.UserID, reminder.ReminderTime, reminder.IsSent)
-	if err != nil {
+	if err := h.Reminders.CreateReminder(r.Context(), &reminder); err != nil {
 		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tạo nhắc nhở: "+err.Error())
 		return
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy ID nhắc nhở")
-		return
-	}
-
-	reminder.ID = int(id)
-	RespondWithJSON(w, http.StatusCreated, reminder)
+	h.respondReminder(w, r, http.StatusCreated, reminder)
 }
 
-func GetTaskReminders(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetTaskReminders(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	taskID, err := strconv.Atoi(vars["task_id"])
 	if err != nil {
@@ -72,33 +77,26 @@ func GetTaskReminders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := "SELECT reminder_id, task_id, user_id, reminder_time, is_sent FROM reminders WHERE task_id = ?"
-	rows, err := database.DB.Query(query, taskID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách nhắc nhở")
-		return
-	}
-	defer rows.Close()
-
-	reminders := []models.Reminder{}
-	for rows.Next() {
-		var reminder models.Reminder
-		if err := rows.Scan(&reminder.ID, &reminder.TaskID, &reminder.UserID, &reminder.ReminderTime, &reminder.IsSent); err != nil {
-			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc dữ liệu nhắc nhở")
+	userID := currentUserID(r)
+	if _, err := h.Tasks.GetTask(r.Context(), userID, taskID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy công việc")
 			return
 		}
-		reminders = append(reminders, reminder)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi kiểm tra công việc")
+		return
 	}
 
-	if err = rows.Err(); err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi truy vấn danh sách nhắc nhở")
+	reminders, err := h.Reminders.ListTaskReminders(r.Context(), userID, taskID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách nhắc nhở")
 		return
 	}
 
-	RespondWithJSON(w, http.StatusOK, reminders)
+	h.respondReminders(w, r, reminders)
 }
 
-func UpdateReminder(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateReminder(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -113,24 +111,48 @@ func UpdateReminder(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	query := "UPDATE reminders SET reminder_time = ?, is_sent = ?, user_id = ?, updated_at = NOW() WHERE reminder_id = ?"
-	result, err := database.DB.Exec(query, reminder.ReminderTime, reminder.IsSent, reminder.UserID, id)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi cập nhật nhắc nhở: "+err.Error())
+	reminder.ID = id
+	reminder.UserID = currentUserID(r)
+	if !validateChannels(w, reminder.Channels) {
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở để cập nhật")
+	// Nhắc nhở không được chuyển sang công việc khác; deadline của công việc dùng cho offset_minutes
+	existing, err := h.Reminders.GetReminder(r.Context(), reminder.UserID, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở để cập nhật")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy thông tin nhắc nhở")
+		return
+	}
+	reminder.TaskID = existing.TaskID
+	if reminder.OffsetMinutes != nil {
+		task, err := h.Tasks.GetTask(r.Context(), reminder.UserID, reminder.TaskID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi kiểm tra công việc: "+err.Error())
+			return
+		}
+		if !applyReminderOffset(w, task, &reminder) {
+			return
+		}
+	}
+	reminder.ResetDelivery()
+	if err := h.Reminders.UpdateReminder(r.Context(), &reminder); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở để cập nhật")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi cập nhật nhắc nhở: "+err.Error())
 		return
 	}
 
-	reminder.ID = id
-	RespondWithJSON(w, http.StatusOK, reminder)
+	h.respondReminder(w, r, http.StatusOK, reminder)
 }
 
-func DeleteReminder(w http.ResponseWriter, r *http.Request) {
+// PatchReminder cập nhật một phần nhắc nhở theo JSON Merge Patch (RFC 7396)
+func (h *Handler) PatchReminder(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -138,75 +160,118 @@ func DeleteReminder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := "DELETE FROM reminders WHERE reminder_id = ?"
-	result, err := database.DB.Exec(query, id)
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	userID := currentUserID(r)
+	reminder, err := h.Reminders.GetReminder(r.Context(), userID, id)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa nhắc nhở")
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy thông tin nhắc nhở")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở để xóa")
+	taskID := reminder.TaskID
+	if err := applyMergePatch(&reminder, patch); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ: "+err.Error())
 		return
 	}
 
-	RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Đã xóa nhắc nhở thành công"})
-}
+	// Nhắc nhở không được chuyển sang công việc hay người dùng khác
+	reminder.ID = id
+	reminder.TaskID = taskID
+	reminder.UserID = userID
+	if reminder.OffsetMinutes != nil {
+		task, err := h.Tasks.GetTask(r.Context(), userID, taskID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi kiểm tra công việc: "+err.Error())
+			return
+		}
+		if !applyReminderOffset(w, task, &reminder) {
+			return
+		}
+	}
+	if reminder.ReminderTime.IsZero() {
+		RespondWithError(w, http.StatusBadRequest, "Thiếu thời gian nhắc nhở")
+		return
+	}
+	if !validateChannels(w, reminder.Channels) {
+		return
+	}
+	reminder.ResetDelivery()
 
-// GetTasksWithReminders trả về danh sách các task có reminder của một user
-func GetTasksWithReminders(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.Atoi(vars["user_id"])
-	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "ID người dùng không hợp lệ")
+	if err := h.Reminders.UpdateReminder(r.Context(), &reminder); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở để cập nhật")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi cập nhật nhắc nhở: "+err.Error())
 		return
 	}
 
-	// Truy vấn SQL để lấy các task có reminder
-	query := `
-		SELECT DISTINCT t.task_id, t.user_id, t.title, t.description, t.priority, t.status, t.deadline, t.created_at, t.updated_at
-		FROM tasks t
-		INNER JOIN reminders r ON t.task_id = r.task_id
-		WHERE t.user_id = ?`
+	h.respondReminder(w, r, http.StatusOK, reminder)
+}
 
-	rows, err := database.DB.Query(query, userID)
+func (h *Handler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách công việc: "+err.Error())
+		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
 		return
 	}
-	defer rows.Close()
-
-	// Khai báo slice để lưu danh sách task
-	tasks := []models.Task{}
-
-	// Duyệt qua các dòng kết quả
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID,
-			&task.UserID,
-			&task.Title,
-			&task.Description,
-			&task.Priority,
-			&task.Status,
-			&task.Deadline,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
-		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc dữ liệu công việc: "+err.Error())
+
+	if err := h.Reminders.DeleteReminder(r.Context(), currentUserID(r), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở để xóa")
 			return
 		}
-		tasks = append(tasks, task)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa nhắc nhở")
+		return
 	}
 
-	// Kiểm tra lỗi sau khi duyệt rows
-	if err = rows.Err(); err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi truy vấn danh sách công việc: "+err.Error())
+	RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Đã xóa nhắc nhở thành công"})
+}
+
+// GetTasksWithReminders trả về danh sách các task có reminder của một user
+func (h *Handler) GetTasksWithReminders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
 		return
 	}
 
-	// Trả về danh sách task dưới dạng JSON
-	RespondWithJSON(w, http.StatusOK, tasks)
+	// Dùng chung bộ lọc với GetUserTasks, chỉ giữ các task có reminder
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.WithReminders = true
+
+	page, err := h.Tasks.ListTasks(r.Context(), userID, filter)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách công việc: "+err.Error())
+		return
+	}
+
+	// Trả về trang task dưới dạng JSON
+	h.respondTaskPage(w, r, page)
+}
+
+// applyReminderOffset tính reminder_time của nhắc nhở tương đối từ deadline của công việc.
+// Trả về false sau khi đã ghi response lỗi.
+func applyReminderOffset(w http.ResponseWriter, task models.Task, reminder *models.Reminder) bool {
+	if reminder.OffsetMinutes == nil {
+		return true
+	}
+	if *reminder.OffsetMinutes < 0 {
+		RespondWithError(w, http.StatusUnprocessableEntity, "offset_minutes phải lớn hơn hoặc bằng 0")
+		return false
+	}
+	reminder.ReminderTime = task.Deadline.Add(-time.Duration(*reminder.OffsetMinutes) * time.Minute)
+	return true
 }
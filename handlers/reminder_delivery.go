@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/models"
+	"backend/store"
+
+	"github.com/gorilla/mux"
+)
+
+// GetFailedReminders liệt kê các nhắc nhở đã gửi thất bại quá số lần cho phép
+func (h *Handler) GetFailedReminders(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	reminders, err := h.Reminders.ListFailedReminders(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách nhắc nhở thất bại")
+		return
+	}
+
+	h.respondReminders(w, r, reminders)
+}
+
+// GetReminderDeliveries trả về lịch sử các lần thử gửi của một nhắc nhở
+func (h *Handler) GetReminderDeliveries(w http.ResponseWriter, r *http.Request) {
+	reminder, ok := h.loadReminder(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.Reminders.ListReminderDeliveries(r.Context(), reminder.ID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy lịch sử gửi nhắc nhở")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// RedriveReminder đưa nhắc nhở failed trở lại hàng đợi để gửi lại ngay
+func (h *Handler) RedriveReminder(w http.ResponseWriter, r *http.Request) {
+	reminder, ok := h.loadReminder(w, r)
+	if !ok {
+		return
+	}
+	if reminder.Status != models.ReminderFailed {
+		RespondWithError(w, http.StatusConflict, "Chỉ có thể gửi lại nhắc nhở ở trạng thái failed")
+		return
+	}
+
+	if err := h.Reminders.RedriveReminder(r.Context(), reminder.UserID, reminder.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusConflict, "Chỉ có thể gửi lại nhắc nhở ở trạng thái failed")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi gửi lại nhắc nhở: "+err.Error())
+		return
+	}
+
+	reminder.Status = models.ReminderScheduled
+	reminder.Attempts = 0
+	reminder.NextAttemptAt = nil
+	h.respondReminder(w, r, http.StatusOK, reminder)
+}
+
+// loadReminder đọc nhắc nhở {id} của người dùng đang đăng nhập.
+// Trả về false sau khi đã ghi response lỗi.
+func (h *Handler) loadReminder(w http.ResponseWriter, r *http.Request) (models.Reminder, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
+		return models.Reminder{}, false
+	}
+
+	reminder, err := h.Reminders.GetReminder(r.Context(), currentUserID(r), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy nhắc nhở")
+			return models.Reminder{}, false
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy thông tin nhắc nhở")
+		return models.Reminder{}, false
+	}
+	return reminder, true
+}
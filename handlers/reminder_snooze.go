@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// Giờ gửi lại (theo múi giờ của người dùng) khi hoãn tới "tomorrow"
+const snoozeTomorrowHour = 8
+
+// snoozeRequest: chỉ được dùng một trong hai trường minutes hoặc until.
+// until là thời điểm RFC 3339 hoặc "tomorrow" (8 giờ sáng hôm sau theo múi giờ của người dùng).
+type snoozeRequest struct {
+	Minutes *int    `json:"minutes"`
+	Until   *string `json:"until"`
+}
+
+// snoozeUntil tính thời điểm gửi lại của yêu cầu hoãn tại thời điểm now (ở múi giờ của người dùng)
+func (req snoozeRequest) snoozeUntil(now time.Time) (time.Time, error) {
+	switch {
+	case req.Minutes != nil && req.Until != nil:
+		return time.Time{}, errors.New("chỉ được dùng một trong hai trường minutes hoặc until")
+	case req.Minutes != nil:
+		if *req.Minutes <= 0 {
+			return time.Time{}, errors.New("minutes phải là số nguyên dương")
+		}
+		return now.Add(time.Duration(*req.Minutes) * time.Minute), nil
+	case req.Until != nil:
+		if *req.Until == "tomorrow" {
+			tomorrow := now.AddDate(0, 0, 1)
+			return time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), snoozeTomorrowHour, 0, 0, 0, now.Location()), nil
+		}
+		until, err := time.Parse(time.RFC3339, *req.Until)
+		if err != nil {
+			return time.Time{}, errors.New("until phải là thời điểm RFC 3339 hoặc \"tomorrow\"")
+		}
+		if !until.After(now) {
+			return time.Time{}, errors.New("until phải ở tương lai")
+		}
+		return until, nil
+	}
+	return time.Time{}, errors.New("cần minutes hoặc until")
+}
+
+// SnoozeReminder hoãn một nhắc nhở đã gửi; bộ gửi sẽ gửi lại khi hết thời gian hoãn
+func (h *Handler) SnoozeReminder(w http.ResponseWriter, r *http.Request) {
+	var req snoozeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ")
+		return
+	}
+	defer r.Body.Close()
+
+	reminder, ok := h.loadReminder(w, r)
+	if !ok {
+		return
+	}
+	if !reminder.Status.CanTransition(models.ReminderSnoozed) {
+		RespondWithError(w, http.StatusConflict, "Chỉ có thể hoãn nhắc nhở đã gửi hoặc đang hoãn")
+		return
+	}
+
+	now := time.Now()
+	until, err := req.snoozeUntil(now.In(h.userLocation(r)))
+	if err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	if err := h.Reminders.SnoozeReminder(r.Context(), reminder.UserID, reminder.ID, until, now); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusConflict, "Chỉ có thể hoãn nhắc nhở đã gửi hoặc đang hoãn")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi hoãn nhắc nhở: "+err.Error())
+		return
+	}
+
+	reminder.Status = models.ReminderSnoozed
+	reminder.IsSent = false
+	reminder.SnoozedUntil = &until
+	reminder.SnoozeCount++
+	reminder.Attempts = 0
+	reminder.NextAttemptAt = nil
+	h.respondReminder(w, r, http.StatusOK, reminder)
+}
+
+// AcknowledgeReminder đánh dấu người dùng đã xác nhận nhắc nhở; nhắc nhở sẽ không được gửi nữa
+func (h *Handler) AcknowledgeReminder(w http.ResponseWriter, r *http.Request) {
+	reminder, ok := h.loadReminder(w, r)
+	if !ok {
+		return
+	}
+	if !reminder.Status.CanTransition(models.ReminderAcknowledged) {
+		RespondWithError(w, http.StatusConflict, "Chỉ có thể xác nhận nhắc nhở đã gửi, đang hoãn hoặc đã thất bại")
+		return
+	}
+
+	now := time.Now()
+	if err := h.Reminders.AcknowledgeReminder(r.Context(), reminder.UserID, reminder.ID, now); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusConflict, "Chỉ có thể xác nhận nhắc nhở đã gửi, đang hoãn hoặc đã thất bại")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xác nhận nhắc nhở: "+err.Error())
+		return
+	}
+
+	reminder.Status = models.ReminderAcknowledged
+	reminder.SnoozedUntil = nil
+	reminder.NextAttemptAt = nil
+	reminder.AcknowledgedAt = &now
+	h.respondReminder(w, r, http.StatusOK, reminder)
+}
+
+// GetReminderSnoozes trả về lịch sử hoãn của một nhắc nhở
+func (h *Handler) GetReminderSnoozes(w http.ResponseWriter, r *http.Request) {
+	reminder, ok := h.loadReminder(w, r)
+	if !ok {
+		return
+	}
+
+	snoozes, err := h.Reminders.ListReminderSnoozes(r.Context(), reminder.UserID, reminder.ID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy lịch sử hoãn nhắc nhở")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, snoozes)
+}
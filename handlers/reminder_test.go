@@ -0,0 +1,293 @@
+package handlers_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/notify"
+	"backend/store"
+)
+
+// dueReminder tạo công việc kèm một nhắc nhở đã đến hạn, trả về ID nhắc nhở
+func (a *testAPI) dueReminder(user testUser) int {
+	a.t.Helper()
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Nộp hồ sơ",
+		"description": "Hồ sơ xin việc",
+		"deadline":    time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated), &task)
+	var reminder models.Reminder
+	a.decode(a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{
+		"task_id":       task.ID,
+		"reminder_time": time.Now().Add(-time.Minute).UTC().Format(time.RFC3339),
+	}, http.StatusCreated), &reminder)
+	return reminder.ID
+}
+
+// claim nhận nhắc nhở id như bộ gửi nền tại thời điểm now
+func (a *testAPI) claim(id int, now time.Time) models.Reminder {
+	a.t.Helper()
+	claimed, err := a.store.ClaimDueReminders(a.t.Context(), now, 10, time.Minute)
+	if err != nil {
+		a.t.Fatalf("nhận nhắc nhở: %v", err)
+	}
+	for _, reminder := range claimed {
+		if reminder.ID == id {
+			return reminder
+		}
+	}
+	a.t.Fatalf("nhắc nhở #%d không được nhận: %+v", id, claimed)
+	return models.Reminder{}
+}
+
+func TestReminderClaimLost(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	id := a.dueReminder(user)
+	ctx := t.Context()
+
+	// Lượt nhận đầu hết lease, instance khác nhận lại; kết quả của lượt đầu không được ghi
+	first := a.claim(id, time.Now())
+	second := a.claim(id, time.Now().Add(2*time.Minute))
+	if err := a.store.MarkReminderSent(ctx, first, time.Now()); !errors.Is(err, store.ErrStale) {
+		t.Errorf("MarkReminderSent với quyền nhận cũ: %v, muốn ErrStale", err)
+	}
+	if err := a.store.ScheduleRetry(ctx, first, 1, time.Now()); !errors.Is(err, store.ErrStale) {
+		t.Errorf("ScheduleRetry với quyền nhận cũ: %v, muốn ErrStale", err)
+	}
+	if err := a.store.MarkReminderFailed(ctx, first, 5); !errors.Is(err, store.ErrStale) {
+		t.Errorf("MarkReminderFailed với quyền nhận cũ: %v, muốn ErrStale", err)
+	}
+
+	if err := a.store.MarkReminderSent(ctx, second, time.Now()); err != nil {
+		t.Fatalf("MarkReminderSent với quyền nhận hiện tại: %v", err)
+	}
+	reminder, err := a.store.GetReminder(ctx, user.ID, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reminder.Status != models.ReminderSent || reminder.Attempts != 0 {
+		t.Errorf("nhắc nhở sau khi gửi: %+v", reminder)
+	}
+	// Quyền nhận chỉ dùng được một lần
+	if err := a.store.MarkReminderFailed(ctx, second, 5); !errors.Is(err, store.ErrStale) {
+		t.Errorf("MarkReminderFailed sau khi đã gửi: %v, muốn ErrStale", err)
+	}
+}
+
+func TestReminderUserChangeRevokesClaim(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	ctx := t.Context()
+
+	// Người dùng sửa nhắc nhở trong lúc bộ gửi nền đang gửi
+	id := a.dueReminder(user)
+	claimed := a.claim(id, time.Now())
+	later := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	a.mustDo(user.Token, "PUT", path("/api/reminders/%d", id), map[string]any{"reminder_time": later}, http.StatusOK)
+	if err := a.store.MarkReminderSent(ctx, claimed, time.Now()); !errors.Is(err, store.ErrStale) {
+		t.Errorf("MarkReminderSent sau khi sửa: %v, muốn ErrStale", err)
+	}
+	reminder, err := a.store.GetReminder(ctx, user.ID, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reminder.IsSent || reminder.Status != models.ReminderScheduled {
+		t.Errorf("nhắc nhở đã sửa bị ghi đè: %+v", reminder)
+	}
+
+	// Người dùng xác nhận nhắc nhở đã hết thời gian hoãn trong lúc bộ gửi nền gửi lại
+	id = a.dueReminder(user)
+	if err := a.store.MarkReminderSent(ctx, a.claim(id, time.Now()), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	a.mustDo(user.Token, "POST", path("/api/reminders/%d/snooze", id), map[string]any{"minutes": 5}, http.StatusOK)
+	claimed = a.claim(id, time.Now().Add(10*time.Minute))
+	a.mustDo(user.Token, "POST", path("/api/reminders/%d/ack", id), nil, http.StatusOK)
+	if err := a.store.MarkReminderSent(ctx, claimed, time.Now()); !errors.Is(err, store.ErrStale) {
+		t.Errorf("MarkReminderSent sau khi xác nhận: %v, muốn ErrStale", err)
+	}
+	if reminder, err = a.store.GetReminder(ctx, user.ID, id); err != nil {
+		t.Fatal(err)
+	}
+	if reminder.Status != models.ReminderAcknowledged {
+		t.Errorf("xác nhận bị ghi đè: %+v", reminder)
+	}
+}
+
+func TestRelativeReminderFollowsDeadline(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	ctx := t.Context()
+
+	deadline := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Nộp hồ sơ",
+		"description": "Hồ sơ xin việc",
+		"deadline":    deadline.Format(time.RFC3339),
+	}, http.StatusCreated), &task)
+	var relative, absolute models.Reminder
+	a.decode(a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{
+		"task_id":        task.ID,
+		"offset_minutes": 90,
+	}, http.StatusCreated), &relative)
+	a.decode(a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{
+		"task_id":       task.ID,
+		"reminder_time": deadline.Add(-10 * time.Minute).Format(time.RFC3339),
+	}, http.StatusCreated), &absolute)
+
+	// Nhắc nhở 90 phút trước deadline đã đến hạn và được gửi
+	if err := a.store.MarkReminderSent(ctx, a.claim(relative.ID, time.Now()), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dời deadline: nhắc nhở tương đối được tính lại (và gửi lại vì thời điểm mới ở tương
+	// lai) ngay trong lần lưu công việc, nhắc nhở cố định giữ nguyên
+	moved := deadline.Add(3 * time.Hour)
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"deadline": moved.Format(time.RFC3339)},
+		http.StatusOK, "Content-Type", "application/merge-patch+json")
+	reminder, err := a.store.GetReminder(ctx, user.ID, relative.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := moved.Add(-90 * time.Minute); !reminder.ReminderTime.Equal(want) || reminder.IsSent ||
+		reminder.Status != models.ReminderScheduled {
+		t.Errorf("nhắc nhở tương đối sau khi dời deadline: %v (%s, is_sent=%v), muốn %v chưa gửi",
+			reminder.ReminderTime, reminder.Status, reminder.IsSent, want)
+	}
+	if reminder, err = a.store.GetReminder(ctx, user.ID, absolute.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !reminder.ReminderTime.Equal(absolute.ReminderTime) {
+		t.Errorf("nhắc nhở cố định bị dời: %v, muốn %v", reminder.ReminderTime, absolute.ReminderTime)
+	}
+
+	// Mọi lần lưu công việc qua store đều tính lại nhắc nhở tương đối
+	task.Deadline = deadline.Add(24 * time.Hour)
+	if err := a.store.UpdateTask(ctx, &task); err != nil {
+		t.Fatal(err)
+	}
+	if reminder, err = a.store.GetReminder(ctx, user.ID, relative.ID); err != nil {
+		t.Fatal(err)
+	}
+	if want := task.Deadline.Add(-90 * time.Minute); !reminder.ReminderTime.Equal(want) {
+		t.Errorf("nhắc nhở tương đối sau UpdateTask: %v, muốn %v", reminder.ReminderTime, want)
+	}
+}
+
+// flakyNotifier là kênh gửi giả: trả về lỗi khi fail bật, đếm số lần được gọi
+type flakyNotifier struct {
+	mu    sync.Mutex
+	fail  bool
+	calls int
+}
+
+func (f *flakyNotifier) Notify(ctx context.Context, n notify.Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.fail {
+		return errors.New("máy chủ thư không phản hồi")
+	}
+	return nil
+}
+
+func TestReminderRetryAndRedrive(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	id := a.dueReminder(user)
+
+	email := &flakyNotifier{fail: true}
+	webhook := &flakyNotifier{}
+	dispatcher := notify.NewDispatcher(a.store, &notify.Router{
+		Channels: map[models.NotificationChannel]notify.Notifier{models.ChannelEmail: email, models.ChannelWebhook: webhook},
+		Default:  []models.NotificationChannel{models.ChannelEmail, models.ChannelWebhook},
+	})
+	dispatcher.Backoff = notify.Backoff{Base: time.Millisecond, Max: time.Millisecond, MaxAttempts: 3}
+	// dispatch chạy một lượt quét sau khi lần gửi lại trước đó đã đến hạn
+	dispatch := func() int {
+		t.Helper()
+		time.Sleep(5 * time.Millisecond)
+		sent, err := dispatcher.DispatchDue(t.Context())
+		if err != nil {
+			t.Fatalf("gửi nhắc nhở: %v", err)
+		}
+		return sent
+	}
+
+	// Email lỗi ở cả 3 lần thử; webhook đã gửi được ở lần đầu thì không gửi lại
+	for attempt := 1; attempt <= 3; attempt++ {
+		if sent := dispatch(); sent != 0 {
+			t.Fatalf("lần thử %d: gửi xong %d nhắc nhở, muốn 0", attempt, sent)
+		}
+		reminder, err := a.store.GetReminder(t.Context(), user.ID, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reminder.Attempts != attempt {
+			t.Errorf("lần thử %d: attempts %d", attempt, reminder.Attempts)
+		}
+		if want := attempt < 3; (reminder.NextAttemptAt != nil) != want || (reminder.Status == models.ReminderFailed) == want {
+			t.Errorf("lần thử %d: status %s, next_attempt_at %v", attempt, reminder.Status, reminder.NextAttemptAt)
+		}
+	}
+	if email.calls != 3 || webhook.calls != 1 {
+		t.Errorf("số lần gọi: email %d, webhook %d; muốn 3 và 1", email.calls, webhook.calls)
+	}
+	var deliveries []models.ReminderDelivery
+	a.decode(a.mustDo(user.Token, "GET", path("/api/reminders/%d/deliveries", id), nil, http.StatusOK), &deliveries)
+	if len(deliveries) != 4 {
+		t.Fatalf("lịch sử gửi có %d lần, muốn 4: %+v", len(deliveries), deliveries)
+	}
+	for _, d := range deliveries {
+		switch {
+		case d.Channel == models.ChannelWebhook && (d.Attempt != 1 || !d.Succeeded()):
+			t.Errorf("lần gửi webhook: %+v", d)
+		case d.Channel == models.ChannelEmail && (d.Succeeded() || (d.NextRetryAt == nil) != (d.Attempt == 3)):
+			t.Errorf("lần gửi email thứ %d: %+v", d.Attempt, d)
+		}
+	}
+
+	// Nhắc nhở failed nằm trong hàng đợi lỗi cho tới khi được gửi lại
+	var failed []models.Reminder
+	a.decode(a.mustDo(user.Token, "GET", path("/api/users/%d/reminders/failed", user.ID), nil, http.StatusOK), &failed)
+	if len(failed) != 1 || failed[0].ID != id || failed[0].Attempts != 3 {
+		t.Fatalf("nhắc nhở failed: %+v", failed)
+	}
+	if sent := dispatch(); sent != 0 || email.calls != 3 {
+		t.Errorf("nhắc nhở failed vẫn được gửi: %d, email %d lần", sent, email.calls)
+	}
+	stranger := a.signup("mallory")
+	a.mustDo(stranger.Token, "POST", path("/api/reminders/%d/redrive", id), nil, http.StatusNotFound)
+
+	var redriven models.Reminder
+	a.decode(a.mustDo(user.Token, "POST", path("/api/reminders/%d/redrive", id), nil, http.StatusOK), &redriven)
+	if redriven.Status != models.ReminderScheduled || redriven.Attempts != 0 || redriven.NextAttemptAt != nil {
+		t.Errorf("nhắc nhở sau khi gửi lại: %+v", redriven)
+	}
+	a.mustDo(user.Token, "POST", path("/api/reminders/%d/redrive", id), nil, http.StatusConflict)
+	a.decode(a.mustDo(user.Token, "GET", path("/api/users/%d/reminders/failed", user.ID), nil, http.StatusOK), &failed)
+	if len(failed) != 0 {
+		t.Errorf("hàng đợi lỗi sau khi gửi lại: %+v", failed)
+	}
+
+	// Gửi lại là lượt mới: mọi kênh được gửi lại từ đầu
+	email.fail = false
+	if sent := dispatch(); sent != 1 || email.calls != 4 || webhook.calls != 2 {
+		t.Errorf("sau khi gửi lại: gửi xong %d, email %d lần, webhook %d lần", sent, email.calls, webhook.calls)
+	}
+	reminder, err := a.store.GetReminder(t.Context(), user.ID, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reminder.Status != models.ReminderSent || !reminder.IsSent {
+		t.Errorf("nhắc nhở sau khi gửi xong: %+v", reminder)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Router đăng ký toàn bộ route của API
+func (h *Handler) Router() *mux.Router {
+	router := mux.NewRouter()
+
+	// Các route công khai (không cần token)
+	router.HandleFunc("/api/users", h.CreateUser).Methods("POST")
+	router.HandleFunc("/api/users/login", h.Login).Methods("POST")
+	router.HandleFunc("/api/auth/refresh", h.RefreshToken).Methods("POST")
+	// Feed lịch được bảo vệ bằng ?token= riêng vì ứng dụng lịch không gửi được access token
+	router.HandleFunc("/api/users/{user_id}/calendar.ics", h.CalendarFeed).Methods("GET")
+	// CalDAV dùng HTTP Basic với token lịch làm mật khẩu ứng dụng
+	router.Handle("/.well-known/caldav", http.RedirectHandler("/dav/", http.StatusMovedPermanently))
+	router.PathPrefix("/dav/").HandlerFunc(h.ServeCalDAV)
+
+	// Các route còn lại yêu cầu access token
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(h.AuthMiddleware)
+
+	api.HandleFunc("/auth/logout", h.Logout).Methods("POST")
+
+	// Router API cho User
+	api.HandleFunc("/users/{id}", h.GetUser).Methods("GET")
+	api.HandleFunc("/users/{id}", h.UpdateUser).Methods("PUT")
+	api.HandleFunc("/users/{id}", h.PatchUser).Methods("PATCH")
+	api.HandleFunc("/users/{id}", h.DeleteUser).Methods("DELETE")
+	api.HandleFunc("/users/{user_id}/tasks", h.GetUserTasks).Methods("GET")
+	api.HandleFunc("/users/{user_id}/categories", h.GetUserCategories).Methods("GET")
+
+	// Router API cho Task
+	api.HandleFunc("/tasks", h.CreateTask).Methods("POST")
+	api.HandleFunc("/tasks/{id}", h.GetTask).Methods("GET")
+	api.HandleFunc("/tasks/{id}", h.UpdateTask).Methods("PUT")
+	api.HandleFunc("/tasks/{id}", h.PatchTask).Methods("PATCH")
+	api.HandleFunc("/tasks/{id}", h.DeleteTask).Methods("DELETE")
+
+	// Chuỗi công việc lặp lại
+	api.HandleFunc("/tasks/{id}/skip", h.SkipOccurrence).Methods("POST")
+	api.HandleFunc("/tasks/{id}/series", h.PatchSeries).Methods("PATCH")
+	api.HandleFunc("/tasks/{id}/series", h.StopSeries).Methods("DELETE")
+
+	// Router API cho Category
+	api.HandleFunc("/categories", h.CreateCategory).Methods("POST")
+	api.HandleFunc("/categories/{id}", h.GetCategory).Methods("GET")
+	api.HandleFunc("/categories/{id}", h.UpdateCategory).Methods("PUT")
+	api.HandleFunc("/categories/{id}", h.PatchCategory).Methods("PATCH")
+	api.HandleFunc("/categories/{id}", h.DeleteCategory).Methods("DELETE")
+
+	// Router API cho Reminder
+	api.HandleFunc("/reminders", h.CreateReminder).Methods("POST")
+	api.HandleFunc("/reminders/{id}", h.UpdateReminder).Methods("PUT")
+	api.HandleFunc("/reminders/{id}", h.PatchReminder).Methods("PATCH")
+	api.HandleFunc("/reminders/{id}", h.DeleteReminder).Methods("DELETE")
+	api.HandleFunc("/tasks/{task_id}/reminders", h.GetTaskReminders).Methods("GET")
+	api.HandleFunc("/reminders/{id}/deliveries", h.GetReminderDeliveries).Methods("GET")
+	api.HandleFunc("/reminders/{id}/redrive", h.RedriveReminder).Methods("POST")
+	api.HandleFunc("/reminders/{id}/snooze", h.SnoozeReminder).Methods("POST")
+	api.HandleFunc("/reminders/{id}/ack", h.AcknowledgeReminder).Methods("POST")
+	api.HandleFunc("/reminders/{id}/snoozes", h.GetReminderSnoozes).Methods("GET")
+	api.HandleFunc("/users/{user_id}/reminders/failed", h.GetFailedReminders).Methods("GET")
+
+	api.HandleFunc("/users/{user_id}/digest", h.GetDigestSettings).Methods("GET")
+	api.HandleFunc("/users/{user_id}/digest", h.UpdateDigestSettings).Methods("PUT")
+
+	api.HandleFunc("/users/{user_id}/calendar/token", h.CreateCalendarToken).Methods("POST")
+	api.HandleFunc("/users/{user_id}/calendar/token", h.DeleteCalendarToken).Methods("DELETE")
+
+	api.HandleFunc("/users/{user_id}/import", h.ImportTasks).Methods("POST")
+	api.HandleFunc("/users/{user_id}/export", h.ExportAccount).Methods("GET")
+
+	// Webhook gửi sự kiện ra ngoài
+	api.HandleFunc("/users/{user_id}/webhooks", h.GetWebhooks).Methods("GET")
+	api.HandleFunc("/users/{user_id}/webhooks", h.CreateWebhook).Methods("POST")
+	api.HandleFunc("/webhooks/{id}", h.GetWebhook).Methods("GET")
+	api.HandleFunc("/webhooks/{id}", h.UpdateWebhook).Methods("PUT")
+	api.HandleFunc("/webhooks/{id}", h.DeleteWebhook).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", h.GetWebhookDeliveries).Methods("GET")
+	api.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}", h.GetWebhookDelivery).Methods("GET")
+	api.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/replay", h.ReplayWebhookDelivery).Methods("POST")
+
+	api.HandleFunc("/users/{user_id}/events", h.StreamEvents).Methods("GET")
+	api.HandleFunc("/users/{user_id}/ws", h.LiveSync).Methods("GET")
+
+	// Đồng bộ offline
+	api.HandleFunc("/sync", h.GetSyncChanges).Methods("GET")
+	api.HandleFunc("/sync", h.PushSyncChanges).Methods("POST")
+
+	// statistics
+	api.HandleFunc("/users/{user_id}/statistics", h.GetUserTaskStatistics).Methods("GET")
+	api.HandleFunc("/users/{user_id}/tasks-with-reminders", h.GetTasksWithReminders).Methods("GET")
+
+	return router
+}
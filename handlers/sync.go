@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// Đồng bộ offline: client kéo các thay đổi sau cursor bằng GET /sync và đẩy thay đổi
+// đã thực hiện khi offline bằng POST /sync. Phiên bản của một bản ghi là seq của thay
+// đổi gần nhất trong nhật ký đồng bộ.
+
+const (
+	defaultSyncLimit = 500
+	maxSyncLimit     = 1000
+	// maxSyncPush giới hạn số thay đổi trong một lần POST /sync
+	maxSyncPush = 500
+)
+
+// initialCursorPrefix đánh dấu cursor giữa các trang của lần đồng bộ đầu tiên. Client
+// chưa giữ bản ghi nào từ trước lần đồng bộ này nên cursor đó không bị coi là quá hạn khi
+// tombstone cũ bị xóa (PrunedSeq).
+const initialCursorPrefix = "init-"
+
+// Thao tác client gửi trong POST /sync
+const (
+	syncCreate = "create"
+	syncUpdate = "update"
+	syncDelete = "delete"
+)
+
+// Kết quả áp dụng một thay đổi của client
+const (
+	syncApplied  = "applied"
+	syncConflict = "conflict"
+	syncError    = "error"
+)
+
+// syncChange là một bản ghi đã thay đổi; Data là trạng thái hiện tại (không có với tombstone)
+type syncChange struct {
+	Entity  models.SyncEntity `json:"entity"`
+	ID      int               `json:"id"`
+	Version int64             `json:"version"`
+	Deleted bool              `json:"deleted"`
+	Data    any               `json:"data,omitempty"`
+}
+
+type syncPage struct {
+	Changes []syncChange `json:"changes"`
+	// Cursor truyền vào ?since= ở lần đồng bộ sau
+	Cursor  string `json:"cursor"`
+	HasMore bool   `json:"has_more"`
+}
+
+// syncPush là một thay đổi client thực hiện khi offline. Với update/delete, BaseVersion
+// là phiên bản client đã sửa (phát hiện xung đột theo phiên bản); nếu không có thì
+// UpdatedAt là thời điểm client sửa (last-write-wins). CategoryRef trỏ tới client_id của
+// một danh mục được tạo trước đó trong cùng lần gửi.
+type syncPush struct {
+	ClientID    string            `json:"client_id"`
+	Entity      models.SyncEntity `json:"entity"`
+	Op          string            `json:"op"`
+	ID          int               `json:"id,omitempty"`
+	BaseVersion *int64            `json:"base_version,omitempty"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty"`
+	CategoryRef string            `json:"category_ref,omitempty"`
+	Data        json.RawMessage   `json:"data,omitempty"`
+}
+
+// syncResult là kết quả của một syncPush. Khi xung đột (Code 409), Data và Version là
+// trạng thái hiện tại trên server (Deleted nếu bản ghi đã bị xóa).
+type syncResult struct {
+	ClientID string `json:"client_id"`
+	Status   string `json:"status"`
+	ID       int    `json:"id,omitempty"`
+	Version  int64  `json:"version,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+	Code     int    `json:"code,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Data     any    `json:"data,omitempty"`
+}
+
+// GetSyncChanges trả về các bản ghi được tạo, sửa hoặc xóa sau cursor ?since=.
+// Lần đồng bộ đầu tiên (không có since) trả về toàn bộ dữ liệu hiện có, không kèm tombstone.
+func (h *Handler) GetSyncChanges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var since int64
+	value, initial := strings.CutPrefix(query.Get("since"), initialCursorPrefix)
+	if value != "" {
+		var err error
+		since, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || since < 0 {
+			RespondWithError(w, http.StatusBadRequest, "Cursor không hợp lệ")
+			return
+		}
+	}
+	initial = initial || since == 0
+	limit := defaultSyncLimit
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSyncLimit {
+			RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("limit phải từ 1 đến %d", maxSyncLimit))
+			return
+		}
+		limit = n
+	}
+
+	userID := currentUserID(r)
+	changes, err := h.Sync.ListChanges(r.Context(), userID, since, limit+1)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc nhật ký đồng bộ")
+		return
+	}
+	pruned, err := h.Sync.PrunedSeq(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc nhật ký đồng bộ")
+		return
+	}
+	if !initial && since < pruned {
+		// Tombstone sau cursor đã bị xóa nên client có thể còn giữ bản ghi đã bị xóa
+		RespondWithError(w, http.StatusGone, "Cursor đã quá hạn, cần đồng bộ lại từ đầu (bỏ since)")
+		return
+	}
+
+	page := syncPage{Changes: []syncChange{}}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		page.HasMore = true
+	}
+	last := since
+	if len(changes) > 0 {
+		last = changes[len(changes)-1].Seq
+	}
+	switch {
+	case initial && page.HasMore:
+		page.Cursor = initialCursorPrefix + strconv.FormatInt(last, 10)
+	case initial:
+		// Tombstone đã xóa không còn trong nhật ký nhưng cursor cuối vẫn phải đi qua chúng
+		page.Cursor = strconv.FormatInt(max(last, pruned), 10)
+	default:
+		page.Cursor = strconv.FormatInt(last, 10)
+	}
+
+	loc := h.userLocation(r)
+	for _, change := range changes {
+		item := syncChange{Entity: change.Entity, ID: change.EntityID, Version: change.Seq, Deleted: change.Deleted}
+		if change.Deleted {
+			if since > 0 {
+				page.Changes = append(page.Changes, item)
+			}
+			continue
+		}
+		item.Data, err = h.syncRecord(r.Context(), userID, change.Entity, change.EntityID, loc)
+		if errors.Is(err, store.ErrNotFound) {
+			// Bản ghi vừa bị xóa; tombstone của nó nằm sau cursor này
+			continue
+		}
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc dữ liệu đồng bộ")
+			return
+		}
+		page.Changes = append(page.Changes, item)
+	}
+
+	RespondWithJSON(w, http.StatusOK, page)
+}
+
+// PushSyncChanges áp dụng lần lượt các thay đổi offline của client. Mỗi thay đổi được
+// kiểm tra và lưu như REST API và có kết quả riêng; một thay đổi lỗi hoặc xung đột
+// không làm hỏng các thay đổi còn lại.
+func (h *Handler) PushSyncChanges(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Changes []syncPush `json:"changes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+	defer r.Body.Close()
+	if len(req.Changes) > maxSyncPush {
+		RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Tối đa %d thay đổi mỗi lần đồng bộ", maxSyncPush))
+		return
+	}
+
+	userID := currentUserID(r)
+	loc := h.userLocation(r)
+	// categoryRefs ánh xạ client_id của danh mục vừa tạo sang ID trên server
+	categoryRefs := map[string]int{}
+	results := make([]syncResult, 0, len(req.Changes))
+	for _, change := range req.Changes {
+		result := h.applySyncChange(r.Context(), userID, change, categoryRefs, loc)
+		if result.Status == syncApplied && change.Op == syncCreate && change.Entity == models.SyncCategory && change.ClientID != "" {
+			categoryRefs[change.ClientID] = result.ID
+		}
+		results = append(results, result)
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+// applySyncChange kiểm tra xung đột rồi áp dụng một thay đổi của client
+func (h *Handler) applySyncChange(ctx context.Context, userID int, change syncPush, categoryRefs map[string]int, loc *time.Location) syncResult {
+	result := syncResult{ClientID: change.ClientID, ID: change.ID}
+	fail := func(err error) syncResult {
+		result.Status = syncError
+		result.Code, result.Error = serviceErrorStatus(err)
+		return result
+	}
+
+	switch {
+	case change.Entity == models.SyncReminder:
+		return fail(serviceErrorf(http.StatusUnprocessableEntity, "Nhắc nhở chỉ được đồng bộ từ server; hãy dùng /api/reminders"))
+	case !change.Entity.Valid():
+		return fail(serviceErrorf(http.StatusBadRequest, "entity không hợp lệ: %q", change.Entity))
+	case change.Op != syncCreate && change.Op != syncUpdate && change.Op != syncDelete:
+		return fail(serviceErrorf(http.StatusBadRequest, "op không hợp lệ: %q", change.Op))
+	case change.Op != syncCreate && change.ID <= 0:
+		return fail(serviceErrorf(http.StatusBadRequest, "Thiếu id của bản ghi"))
+	case change.Op != syncDelete && len(change.Data) == 0:
+		return fail(serviceErrorf(http.StatusBadRequest, "Thiếu data"))
+	}
+
+	if change.CategoryRef != "" && change.Entity == models.SyncTask && change.Op != syncDelete {
+		categoryID, ok := categoryRefs[change.CategoryRef]
+		if !ok {
+			return fail(serviceErrorf(http.StatusUnprocessableEntity, "category_ref %q không khớp danh mục nào được tạo trước đó", change.CategoryRef))
+		}
+		data, err := setJSONField(change.Data, "category_id", categoryID)
+		if err != nil {
+			return fail(serviceErrorf(http.StatusBadRequest, "Dữ liệu không hợp lệ: %v", err))
+		}
+		change.Data = data
+	}
+
+	conflict := func(latest models.Change) syncResult {
+		result.Status, result.Code = syncConflict, http.StatusConflict
+		result.Version, result.Deleted = latest.Seq, latest.Deleted
+		if !latest.Deleted {
+			data, err := h.syncRecord(ctx, userID, change.Entity, change.ID, loc)
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				return fail(err)
+			}
+			result.Data = data
+		}
+		return result
+	}
+
+	if change.Op != syncCreate {
+		cond := store.Precondition{Entity: change.Entity, ID: change.ID, Version: change.BaseVersion, UpdatedAt: change.UpdatedAt}
+		latest, err := h.Sync.LatestChange(ctx, userID, change.Entity, change.ID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fail(err)
+		}
+		if err == nil && latest.Deleted {
+			if change.Op == syncDelete {
+				// Xóa lại bản ghi đã xóa coi như thành công để client có thể gửi lại an toàn
+				result.Status, result.Version, result.Deleted = syncApplied, latest.Seq, true
+				return result
+			}
+			if cond.Conflicts(latest) {
+				return conflict(latest)
+			}
+		}
+		// Phiên bản được so trong chính transaction ghi bản ghi, nên hai thiết bị cùng sửa
+		// từ một phiên bản chỉ có một thay đổi được áp dụng
+		ctx = store.WithPrecondition(ctx, cond)
+	}
+
+	id, data, err := h.applySyncOp(ctx, userID, change)
+	if errors.Is(err, store.ErrPrecondition) {
+		latest, err := h.Sync.LatestChange(ctx, userID, change.Entity, change.ID)
+		if err != nil {
+			return fail(err)
+		}
+		return conflict(latest)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	result.ID = id
+	result.Status = syncApplied
+	if latest, err := h.Sync.LatestChange(ctx, userID, change.Entity, id); err == nil {
+		result.Version, result.Deleted = latest.Seq, latest.Deleted
+	}
+	switch v := data.(type) {
+	case models.Task:
+		v.In(loc)
+		result.Data = v
+	case nil:
+	default:
+		result.Data = v
+	}
+	return result
+}
+
+// applySyncOp thực hiện thay đổi qua cùng các hàm với REST API. update dùng ngữ nghĩa
+// JSON Merge Patch để client chỉ gửi các trường đã sửa.
+func (h *Handler) applySyncOp(ctx context.Context, userID int, change syncPush) (int, any, error) {
+	invalid := func(err error) error {
+		return serviceErrorf(http.StatusBadRequest, "Dữ liệu không hợp lệ: %v", err)
+	}
+
+	switch change.Entity {
+	case models.SyncTask:
+		switch change.Op {
+		case syncCreate:
+			var task models.Task
+			if err := json.Unmarshal(change.Data, &task); err != nil {
+				return 0, nil, invalid(err)
+			}
+			task, err := h.createTask(ctx, userID, task)
+			return task.ID, task, err
+		case syncUpdate:
+			task, err := h.patchTask(ctx, userID, change.ID, change.Data)
+			return change.ID, task, err
+		case syncDelete:
+			return change.ID, nil, h.deleteTask(ctx, userID, change.ID)
+		}
+	case models.SyncCategory:
+		switch change.Op {
+		case syncCreate:
+			var category models.Category
+			if err := json.Unmarshal(change.Data, &category); err != nil {
+				return 0, nil, invalid(err)
+			}
+			category, err := h.createCategory(ctx, userID, category)
+			return category.ID, category, err
+		case syncUpdate:
+			category, err := h.patchCategory(ctx, userID, change.ID, change.Data)
+			return change.ID, category, err
+		case syncDelete:
+			return change.ID, nil, h.deleteCategory(ctx, userID, change.ID)
+		}
+	}
+	return 0, nil, serviceErrorf(http.StatusBadRequest, "Thao tác không được hỗ trợ")
+}
+
+// syncRecord đọc trạng thái hiện tại của một bản ghi, thời gian theo múi giờ loc
+func (h *Handler) syncRecord(ctx context.Context, userID int, entity models.SyncEntity, id int, loc *time.Location) (any, error) {
+	switch entity {
+	case models.SyncTask:
+		task, err := h.Tasks.GetTask(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		task.In(loc)
+		return task, nil
+	case models.SyncCategory:
+		return h.Categories.GetCategory(ctx, userID, id)
+	case models.SyncReminder:
+		reminder, err := h.Reminders.GetReminder(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		reminder.In(loc)
+		return reminder, nil
+	}
+	return nil, store.ErrNotFound
+}
+
+// setJSONField đặt key = value trong JSON object data
+func setJSONField(data json.RawMessage, key string, value any) (json.RawMessage, error) {
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	if object == nil {
+		return nil, errPatchNotObject
+	}
+	object[key] = value
+	return json.Marshal(object)
+}
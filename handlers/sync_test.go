@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+type syncResult struct {
+	ClientID string          `json:"client_id"`
+	Status   string          `json:"status"`
+	Version  int64           `json:"version"`
+	Code     int             `json:"code"`
+	Data     json.RawMessage `json:"data"`
+}
+
+func TestSyncPushBaseVersionConflict(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Viết báo cáo",
+		"description": "Báo cáo quý",
+		"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated), &task)
+	latest, err := a.store.LatestChange(t.Context(), user.ID, models.SyncTask, task.ID)
+	if err != nil {
+		t.Fatalf("phiên bản của công việc: %v", err)
+	}
+
+	// Nhiều thiết bị cùng sửa từ một phiên bản: chỉ một thay đổi được áp dụng
+	results := make([]syncResult, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var resp struct {
+				Results []syncResult `json:"results"`
+			}
+			a.decode(a.mustDo(user.Token, "POST", "/api/sync", map[string]any{"changes": []map[string]any{{
+				"client_id":    fmt.Sprintf("c%d", i),
+				"entity":       "task",
+				"op":           "update",
+				"id":           task.ID,
+				"base_version": latest.Seq,
+				"data":         map[string]any{"title": fmt.Sprintf("Bản sửa %d", i)},
+			}}}, http.StatusOK), &resp)
+			if len(resp.Results) == 1 {
+				results[i] = resp.Results[0]
+			}
+		}()
+	}
+	wg.Wait()
+
+	applied := 0
+	for _, result := range results {
+		switch result.Status {
+		case "applied":
+			applied++
+		case "conflict":
+			if result.Code != http.StatusConflict || result.Version <= latest.Seq || len(result.Data) == 0 {
+				t.Errorf("kết quả xung đột: %+v", result)
+			}
+		default:
+			t.Errorf("kết quả: %+v", result)
+		}
+	}
+	if applied != 1 {
+		t.Errorf("%d thay đổi được áp dụng, muốn 1", applied)
+	}
+
+	// Kiểm tra phiên bản nằm trong chính thao tác ghi, không phải lần đọc trước đó của handler
+	ctx := store.WithPrecondition(t.Context(), store.Precondition{Entity: models.SyncTask, ID: task.ID, Version: &latest.Seq})
+	if err := a.store.UpdateTask(ctx, &task); !errors.Is(err, store.ErrPrecondition) {
+		t.Errorf("sửa từ phiên bản cũ: %v, muốn ErrPrecondition", err)
+	}
+	if err := a.store.DeleteTask(ctx, user.ID, task.ID); !errors.Is(err, store.ErrPrecondition) {
+		t.Errorf("xóa từ phiên bản cũ: %v, muốn ErrPrecondition", err)
+	}
+}
+
+func TestSyncCursorExpired(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+
+	var page struct {
+		Changes []json.RawMessage `json:"changes"`
+		Cursor  string            `json:"cursor"`
+		HasMore bool              `json:"has_more"`
+	}
+	var tasks [2]models.Task
+	for i := range tasks {
+		a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+			"title":       fmt.Sprintf("Công việc %d", i),
+			"description": "Báo cáo quý",
+			"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		}, http.StatusCreated), &tasks[i])
+	}
+	a.decode(a.mustDo(user.Token, "GET", "/api/sync", nil, http.StatusOK), &page)
+	cursor := page.Cursor
+
+	// Tombstone được ghi sau cursor rồi bị xóa khỏi nhật ký: cursor không còn dùng được
+	a.mustDo(user.Token, "DELETE", path("/api/tasks/%d", tasks[0].ID), nil, http.StatusOK)
+	if _, err := a.store.PruneTombstones(t.Context(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	a.mustDo(user.Token, "GET", "/api/sync?since="+cursor, nil, http.StatusGone)
+
+	// Đồng bộ lại từ đầu, kể cả theo từng trang, nhận cursor mới không bị coi là quá hạn
+	var tasks2 [2]models.Task
+	for i := range tasks2 {
+		a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+			"title":       fmt.Sprintf("Công việc mới %d", i),
+			"description": "Báo cáo quý",
+			"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		}, http.StatusCreated), &tasks2[i])
+	}
+	a.decode(a.mustDo(user.Token, "GET", "/api/sync?limit=1", nil, http.StatusOK), &page)
+	total := len(page.Changes)
+	for page.HasMore {
+		a.decode(a.mustDo(user.Token, "GET", "/api/sync?limit=1&since="+page.Cursor, nil, http.StatusOK), &page)
+		total += len(page.Changes)
+	}
+	if total != 3 {
+		t.Errorf("đồng bộ lại: %d bản ghi, muốn 3", total)
+	}
+	a.mustDo(user.Token, "DELETE", path("/api/tasks/%d", tasks2[1].ID), nil, http.StatusOK)
+	if _, err := a.store.PruneTombstones(t.Context(), time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	// Tombstone cuối cùng đã bị xóa: lần đồng bộ đầu tiên vẫn trả cursor đi qua nó
+	a.decode(a.mustDo(user.Token, "GET", "/api/sync", nil, http.StatusOK), &page)
+	a.mustDo(user.Token, "GET", "/api/sync?since="+page.Cursor, nil, http.StatusOK)
+}
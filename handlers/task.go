@@ -2,22 +2,20 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"strconv"
-	"time"
 
-	"backend/database"
 	"backend/models"
-
-	"database/sql"
+	"backend/store"
 
 	"github.com/gorilla/mux"
 )
 
-func CreateTask(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 	var task models.Task
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&task); err != nil {
@@ -26,51 +24,16 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	// Kiểm tra deadline, nếu không có thì mặc định là now
-	if task.Deadline.IsZero() {
-		task.Deadline = time.Now()
-	}
-
-	// Kiểm tra dữ liệu đầu vào
-	if task.Title == "" || task.Description == "" || task.Deadline.IsZero() {
-		RespondWithError(w, http.StatusBadRequest, "Thiếu thông tin cần thiết")
-		return
-	}
-
-	now := time.Now()
-	query := `INSERT INTO tasks 
-	          (title, description, deadline, priority, status, category_id, user_id, created_at, updated_at) 
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	result, err := database.DB.Exec(
-		query,
-		task.Title,
-		task.Description,
-		task.Deadline,
-		task.Priority,
-		task.Status,
-		task.CategoryID,
-		task.UserID,
-		now,
-		now,
-	)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi thêm công việc: "+err.Error())
-		return
-	}
-
-	id, err := result.LastInsertId()
+	task, err := h.createTask(r.Context(), currentUserID(r), task)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy ID công việc")
+		respondServiceError(w, err)
 		return
 	}
 
-	task.ID = int(id)
-	task.CreatedAt = now
-	task.UpdatedAt = now
-	RespondWithJSON(w, http.StatusCreated, task)
+	h.respondTask(w, r, http.StatusCreated, task)
 }
 
-func GetTask(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idStr, ok := vars["id"]
 	if !ok {
@@ -84,24 +47,9 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var task models.Task
-	query := `SELECT task_id, title, description, deadline, priority, status, 
-              category_id, user_id, created_at, updated_at 
-              FROM tasks WHERE task_id = ?`
-	err = database.DB.QueryRow(query, id).Scan(
-		&task.ID,
-		&task.Title,
-		&task.Description,
-		&task.Deadline,
-		&task.Priority,
-		&task.Status,
-		&task.CategoryID,
-		&task.UserID,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+	task, err := h.Tasks.GetTask(r.Context(), currentUserID(r), id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, store.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Không tìm thấy công việc với ID: %d", id))
 			return
 		}
@@ -110,10 +58,10 @@ func GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	RespondWithJSON(w, http.StatusOK, task)
+	h.respondTask(w, r, http.StatusOK, task)
 }
 
-func UpdateTask(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -122,55 +70,30 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var task models.Task
-	body, err := io.ReadAll(r.Body) // Error: undefined: io
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		RespondWithError(w, http.StatusBadRequest, "Unable to read request body")
 		return
 	}
 	defer r.Body.Close()
 
-	fmt.Printf("Request Body: %s\n", string(body))
-
 	if err := json.Unmarshal(body, &task); err != nil {
-		fmt.Printf("Error decoding JSON: %v\n", err)
+		log.Printf("Error decoding JSON: %v", err)
 		RespondWithError(w, http.StatusBadRequest, "Invalid data: "+err.Error())
 		return
 	}
 
-	now := time.Now()
-	query := `UPDATE tasks 
-              SET title = ?, description = ?, deadline = ?, priority = ?, 
-              status = ?, category_id = ?, updated_at = ? 
-              WHERE task_id = ?`
-	result, err := database.DB.Exec(
-		query,
-		task.Title,
-		task.Description,
-		task.Deadline,
-		task.Priority,
-		task.Status,
-		task.CategoryID,
-		now,
-		id,
-	)
+	task, err = h.updateTask(r.Context(), currentUserID(r), id, task)
 	if err != nil {
-		fmt.Printf("Database error: %v\n", err)
-		RespondWithError(w, http.StatusInternalServerError, "Error updating task: "+err.Error())
-		return
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		RespondWithError(w, http.StatusNotFound, "Task not found for updating")
+		respondServiceError(w, err)
 		return
 	}
 
-	task.ID = id
-	task.UpdatedAt = now
-	RespondWithJSON(w, http.StatusOK, task)
+	h.respondTask(w, r, http.StatusOK, task)
 }
 
-func DeleteTask(w http.ResponseWriter, r *http.Request) {
+// PatchTask cập nhật một phần công việc theo JSON Merge Patch (RFC 7396)
+func (h *Handler) PatchTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -178,16 +101,30 @@ func DeleteTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := "DELETE FROM tasks WHERE task_id = ?"
-	result, err := database.DB.Exec(query, id)
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	task, err := h.patchTask(r.Context(), currentUserID(r), id, patch)
+	if err != nil {
+		respondServiceError(w, err)
+		return
+	}
+
+	h.respondTask(w, r, http.StatusOK, task)
+}
+
+func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa công việc")
+		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		RespondWithError(w, http.StatusNotFound, "Không tìm thấy công việc để xóa")
+	if err := h.deleteTask(r.Context(), currentUserID(r), id); err != nil {
+		respondServiceError(w, err)
 		return
 	}
 
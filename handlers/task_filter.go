@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// parseTaskFilter đọc bộ lọc từ query string:
+//
+//	status, priority, category_id, deadline_from, deadline_to (RFC 3339), overdue=true|false,
+//	sort=deadline|priority|created_at|updated_at (thêm "-" phía trước để giảm dần),
+//	limit, cursor (next_cursor của trang trước)
+func parseTaskFilter(r *http.Request) (store.TaskFilter, error) {
+	q := r.URL.Query()
+	filter := store.TaskFilter{
+		Status:   models.TaskStatus(q.Get("status")),
+		Priority: models.TaskPriority(q.Get("priority")),
+		Sort:     store.SortCreatedAt,
+		Limit:    store.DefaultTaskLimit,
+	}
+	if filter.Status != "" && !filter.Status.Valid() {
+		return filter, fmt.Errorf("status không hợp lệ: %q", filter.Status)
+	}
+	if filter.Priority != "" && !filter.Priority.Valid() {
+		return filter, fmt.Errorf("priority không hợp lệ: %q", filter.Priority)
+	}
+
+	if v := q.Get("category_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("category_id không hợp lệ")
+		}
+		filter.CategoryID = &id
+	}
+
+	for key, dest := range map[string]**time.Time{"deadline_from": &filter.DeadlineFrom, "deadline_to": &filter.DeadlineTo} {
+		if v := q.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("%s phải theo định dạng RFC 3339", key)
+			}
+			*dest = &t
+		}
+	}
+
+	if v := q.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("overdue phải là true hoặc false")
+		}
+		filter.Overdue = &overdue
+	}
+
+	if v := q.Get("sort"); v != "" {
+		field := strings.TrimPrefix(v, "-")
+		switch field {
+		case store.SortDeadline, store.SortPriority, store.SortCreatedAt, store.SortUpdatedAt:
+		default:
+			return filter, fmt.Errorf("sort chỉ hỗ trợ deadline, priority, created_at hoặc updated_at")
+		}
+		filter.Sort = field
+		filter.Desc = strings.HasPrefix(v, "-")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return filter, fmt.Errorf("limit không hợp lệ")
+		}
+		filter.Limit = min(limit, store.MaxTaskLimit)
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := store.DecodeTaskCursor(v, filter.Sort, filter.Desc)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/events"
+	"backend/models"
+	"backend/recurrence"
+	"backend/store"
+
+	"github.com/gorilla/mux"
+)
+
+// Một chuỗi lặp chỉ có một công việc đang mở mang RRULE. Khi công việc đó được
+// hoàn thành (hoặc bỏ qua), lần lặp kế tiếp được tạo ra/dời tới kèm các nhắc nhở.
+
+// prepareRecurrence kiểm tra RRULE khi tạo công việc và đặt DTSTART của chuỗi là deadline
+func prepareRecurrence(task *models.Task) error {
+	task.SeriesID = nil
+	task.RecurrenceStart = nil
+	task.OccurrenceAt = nil
+	if task.Recurrence == "" {
+		return nil
+	}
+
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return serviceErrorf(http.StatusUnprocessableEntity, "RRULE không hợp lệ: %v", err)
+	}
+	start := task.Deadline
+	task.Recurrence = rule.String()
+	task.RecurrenceStart = &start
+	task.OccurrenceAt = &start
+	return nil
+}
+
+// keepSeriesFields giữ nguyên thông tin chuỗi khi sửa một lần lặp qua PUT/PATCH;
+// thay đổi cả chuỗi phải đi qua /tasks/{id}/series
+func keepSeriesFields(existing models.Task, task *models.Task) {
+	task.Recurrence = existing.Recurrence
+	task.SeriesID = existing.SeriesID
+	task.RecurrenceStart = existing.RecurrenceStart
+	task.OccurrenceAt = existing.OccurrenceAt
+}
+
+// nextOccurrence dựng (chưa lưu) công việc cho lần lặp kế tiếp của task. Các lần lặp
+// được tính theo giờ địa phương loc của người dùng để giữ nguyên giờ khi đổi giờ mùa hè.
+func nextOccurrence(task models.Task, loc *time.Location) (models.Task, error) {
+	rule, err := recurrence.Parse(task.Recurrence)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	start, at := task.Deadline, task.Deadline
+	if task.RecurrenceStart != nil {
+		start = *task.RecurrenceStart
+	}
+	if task.OccurrenceAt != nil {
+		at = *task.OccurrenceAt
+	}
+	next, err := rule.Next(start.In(loc), at.In(loc))
+	if err != nil {
+		return models.Task{}, err
+	}
+	start, next = start.UTC(), next.UTC()
+
+	return models.Task{
+		Title:           task.Title,
+		Description:     task.Description,
+		Deadline:        next,
+		Priority:        task.Priority,
+		Status:          models.StatusPending,
+		CategoryID:      task.CategoryID,
+		UserID:          task.UserID,
+		Recurrence:      task.Recurrence,
+		SeriesID:        task.SeriesID,
+		RecurrenceStart: &start,
+		OccurrenceAt:    &next,
+	}, nil
+}
+
+// advanceSeries lưu task; nếu công việc lặp vừa chuyển sang Completed thì lần lặp kế tiếp
+// (kèm nhắc nhở) được tạo trong cùng transaction và trả về. Công việc đã hoàn thành không
+// còn mang RRULE; chuỗi tiếp tục ở công việc mới.
+func (h *Handler) advanceSeries(ctx context.Context, existing models.Task, task *models.Task) (*models.Task, error) {
+	if task.Recurrence == "" || existing.Status == models.StatusCompleted || task.Status != models.StatusCompleted {
+		return nil, h.Tasks.UpdateTask(ctx, task)
+	}
+
+	next, err := nextOccurrence(*task, h.locationOf(ctx, task.UserID))
+	if errors.Is(err, recurrence.ErrNoMoreOccurrences) {
+		task.Recurrence = ""
+		return nil, h.Tasks.UpdateTask(ctx, task)
+	}
+	if err != nil {
+		return nil, err
+	}
+	reminders, err := h.carriedReminders(ctx, *task, next)
+	if err != nil {
+		return nil, err
+	}
+	task.Recurrence = ""
+	ctx = withEvent(ctx, next.UserID, events.TaskCreated, &next)
+	if err := h.Tasks.CompleteOccurrence(ctx, task, &next, reminders); err != nil {
+		return nil, err
+	}
+	return &next, nil
+}
+
+// carriedReminders dựng (chưa lưu) bản sao nhắc nhở của from cho to, dời theo khoảng
+// cách giữa hai deadline
+func (h *Handler) carriedReminders(ctx context.Context, from, to models.Task) ([]models.Reminder, error) {
+	reminders, err := h.Reminders.ListTaskReminders(ctx, from.UserID, from.ID)
+	if err != nil {
+		return nil, err
+	}
+	shift := to.Deadline.Sub(from.Deadline)
+	for i := range reminders {
+		reminder := &reminders[i]
+		reminder.ID = 0
+		reminder.TaskID = 0
+		reminder.ReminderTime = reminder.ReminderTime.Add(shift)
+		if reminder.OffsetMinutes != nil {
+			// Nhắc nhở tương đối bám theo deadline của lần lặp mới
+			reminder.ReminderTime = to.Deadline.Add(-time.Duration(*reminder.OffsetMinutes) * time.Minute)
+		}
+		reminder.IsSent = false
+		reminder.ResetDelivery()
+	}
+	return reminders, nil
+}
+
+// loadRecurringTask đọc công việc {id} và đảm bảo đó là lần lặp đang mở của một chuỗi.
+// Trả về false sau khi đã ghi response lỗi.
+func (h *Handler) loadRecurringTask(w http.ResponseWriter, r *http.Request) (models.Task, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
+		return models.Task{}, false
+	}
+
+	task, err := h.Tasks.GetTask(r.Context(), currentUserID(r), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Không tìm thấy công việc với ID: %d", id))
+			return models.Task{}, false
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy thông tin công việc")
+		return models.Task{}, false
+	}
+	if task.Recurrence == "" {
+		RespondWithError(w, http.StatusUnprocessableEntity, "Công việc không phải lần lặp đang mở của một chuỗi lặp")
+		return models.Task{}, false
+	}
+	return task, true
+}
+
+// SkipOccurrence bỏ qua lần lặp hiện tại: dời công việc và nhắc nhở tới lần lặp kế tiếp
+func (h *Handler) SkipOccurrence(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadRecurringTask(w, r)
+	if !ok {
+		return
+	}
+
+	next, err := nextOccurrence(task, h.userLocation(r))
+	if err != nil {
+		if errors.Is(err, recurrence.ErrNoMoreOccurrences) {
+			RespondWithError(w, http.StatusUnprocessableEntity, "Đây là lần lặp cuối cùng; hãy dừng chuỗi hoặc xóa công việc")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tính lần lặp kế tiếp: "+err.Error())
+		return
+	}
+
+	// Công việc và nhắc nhở được dời trong cùng một transaction, chỉ khi lần lặp vẫn là
+	// lần vừa đọc; request bỏ qua trùng nhận 409 thay vì dời thêm một lần lặp nữa
+	from := task.Deadline
+	task.Deadline = next.Deadline
+	task.OccurrenceAt = next.OccurrenceAt
+	task.Status = models.StatusPending
+	task.CompletedAt = nil
+	if err := h.Tasks.SkipOccurrence(withEvent(r.Context(), task.UserID, events.TaskUpdated, &task), &task, from); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy công việc để cập nhật")
+		case errors.Is(err, store.ErrStale):
+			RespondWithError(w, http.StatusConflict, "Lần lặp này đã được bỏ qua hoặc thay đổi bởi một yêu cầu khác")
+		default:
+			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi bỏ qua lần lặp: "+err.Error())
+		}
+		return
+	}
+
+	h.Events.Publish(task.UserID, events.TaskUpdated, task)
+	h.respondTask(w, r, http.StatusOK, task)
+}
+
+// PatchSeries sửa lần lặp hiện tại và các lần sau theo JSON Merge Patch (RFC 7396).
+// Đổi deadline hoặc recurrence sẽ tách thành chuỗi mới bắt đầu từ lần lặp này;
+// các lần đã hoàn thành trước đó giữ nguyên.
+func (h *Handler) PatchSeries(w http.ResponseWriter, r *http.Request) {
+	patch, ok := readMergePatch(w, r)
+	if !ok {
+		return
+	}
+
+	task, ok := h.loadRecurringTask(w, r)
+	if !ok {
+		return
+	}
+
+	existing := task
+	if err := applyMergePatch(&task, patch); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	// Không cho phép đổi ID, chủ sở hữu hay tự đặt thông tin chuỗi qua patch
+	task.ID = existing.ID
+	task.UserID = existing.UserID
+	task.SeriesID = existing.SeriesID
+	task.RecurrenceStart = existing.RecurrenceStart
+	task.OccurrenceAt = existing.OccurrenceAt
+	if task.Title == "" || task.Description == "" || task.Deadline.IsZero() {
+		RespondWithError(w, http.StatusBadRequest, "Thiếu thông tin cần thiết")
+		return
+	}
+	if err := h.validateTaskChange(r.Context(), existing, &task); err != nil {
+		respondServiceError(w, err)
+		return
+	}
+
+	if task.Recurrence != "" {
+		rule, err := recurrence.Parse(task.Recurrence)
+		if err != nil {
+			RespondWithError(w, http.StatusUnprocessableEntity, "RRULE không hợp lệ: "+err.Error())
+			return
+		}
+		if task.Recurrence != existing.Recurrence || !task.Deadline.Equal(existing.Deadline) {
+			// Giữ nguyên tổng số lần lặp của COUNT khi chỉ dời thời gian
+			if task.Recurrence == existing.Recurrence && rule.Count > 0 &&
+				existing.RecurrenceStart != nil && existing.OccurrenceAt != nil {
+				loc := h.userLocation(r)
+				rule.Count -= rule.Index(existing.RecurrenceStart.In(loc), existing.OccurrenceAt.In(loc))
+			}
+			start := task.Deadline
+			task.SeriesID = &task.ID
+			task.RecurrenceStart = &start
+			task.OccurrenceAt = &start
+		}
+		task.Recurrence = rule.String()
+	}
+
+	task, err := h.saveTask(r.Context(), existing, task)
+	if err != nil {
+		respondServiceError(w, err)
+		return
+	}
+	h.respondTask(w, r, http.StatusOK, task)
+}
+
+// StopSeries dừng chuỗi lặp: công việc hiện tại trở thành công việc thường
+// và sẽ không sinh thêm lần lặp nào
+func (h *Handler) StopSeries(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.loadRecurringTask(w, r)
+	if !ok {
+		return
+	}
+
+	task.Recurrence = ""
+	if err := h.Tasks.UpdateTask(withEvent(r.Context(), task.UserID, events.TaskUpdated, &task), &task); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi dừng chuỗi lặp: "+err.Error())
+		return
+	}
+
+	h.Events.Publish(task.UserID, events.TaskUpdated, task)
+	h.respondTask(w, r, http.StatusOK, task)
+}
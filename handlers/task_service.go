@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend/events"
+	"backend/models"
+	"backend/store"
+)
+
+// Các thao tác ghi công việc dùng chung cho REST (task.go), WebSocket (ws.go) và
+// đồng bộ offline (sync.go). Lỗi do dữ liệu của client là *serviceError; tầng giao
+// tiếp quyết định cách trả lỗi cho client.
+
+// loadTask đọc công việc id của userID; công việc không tồn tại là lỗi 404
+func (h *Handler) loadTask(ctx context.Context, userID, id int) (models.Task, error) {
+	task, err := h.Tasks.GetTask(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return models.Task{}, serviceErrorf(http.StatusNotFound, "Không tìm thấy công việc với ID: %d", id)
+		}
+		return models.Task{}, fmt.Errorf("lấy thông tin công việc: %w", err)
+	}
+	return task, nil
+}
+
+// createTask kiểm tra và lưu công việc mới của userID, sau đó phát sự kiện task.created
+func (h *Handler) createTask(ctx context.Context, userID int, task models.Task) (models.Task, error) {
+	// Kiểm tra deadline, nếu không có thì mặc định là now
+	if task.Deadline.IsZero() {
+		task.Deadline = time.Now()
+	}
+	if task.Title == "" || task.Description == "" {
+		return models.Task{}, serviceErrorf(http.StatusBadRequest, "Thiếu thông tin cần thiết")
+	}
+	if err := normalizeTaskEnums(&task); err != nil {
+		return models.Task{}, err
+	}
+	if err := prepareRecurrence(&task); err != nil {
+		return models.Task{}, err
+	}
+	task.CompletedAt = nil
+	if task.Status == models.StatusCompleted {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	// Công việc luôn thuộc về người dùng đang đăng nhập, bỏ qua user_id của client
+	task.ID = 0
+	task.UserID = userID
+	if err := h.checkTaskCategory(ctx, task.CategoryID, task.UserID); err != nil {
+		return models.Task{}, err
+	}
+
+	if err := h.Tasks.CreateTask(withEvent(ctx, userID, events.TaskCreated, &task), &task); err != nil {
+		return models.Task{}, fmt.Errorf("thêm công việc: %w", err)
+	}
+	h.Events.Publish(task.UserID, events.TaskCreated, task)
+	return task, nil
+}
+
+// updateTask thay toàn bộ công việc id bằng task (ngữ nghĩa của PUT)
+func (h *Handler) updateTask(ctx context.Context, userID, id int, task models.Task) (models.Task, error) {
+	return h.replaceTask(ctx, userID, id, task, h.applyTransition)
+}
+
+// replaceTask thay toàn bộ công việc id bằng task, kiểm tra chuyển trạng thái bằng transition
+func (h *Handler) replaceTask(ctx context.Context, userID, id int, task models.Task,
+	transition func(existing models.Task, task *models.Task) error) (models.Task, error) {
+	task.ID = id
+	task.UserID = userID
+	if err := normalizeTaskEnums(&task); err != nil {
+		return models.Task{}, err
+	}
+
+	existing, err := h.loadTask(ctx, userID, id)
+	if err != nil {
+		return models.Task{}, err
+	}
+	keepSeriesFields(existing, &task)
+	if err := transition(existing, &task); err != nil {
+		return models.Task{}, err
+	}
+	if err := h.checkTaskCategory(ctx, task.CategoryID, task.UserID); err != nil {
+		return models.Task{}, err
+	}
+	return h.saveTask(ctx, existing, task)
+}
+
+// completeTask đánh dấu công việc id đã hoàn thành từ bất kỳ trạng thái nào workflow
+// đi tới được Completed (applyCompletion)
+func (h *Handler) completeTask(ctx context.Context, userID, id int) (models.Task, error) {
+	existing, err := h.loadTask(ctx, userID, id)
+	if err != nil {
+		return models.Task{}, err
+	}
+	task := existing
+	task.Status = models.StatusCompleted
+	if err := h.applyCompletion(existing, &task); err != nil {
+		return models.Task{}, err
+	}
+	return h.saveTask(ctx, existing, task)
+}
+
+// patchTask áp dụng JSON Merge Patch (RFC 7396) lên công việc id
+func (h *Handler) patchTask(ctx context.Context, userID, id int, patch []byte) (models.Task, error) {
+	task, err := h.loadTask(ctx, userID, id)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	existing := task
+	if err := applyMergePatch(&task, patch); err != nil {
+		return models.Task{}, serviceErrorf(http.StatusBadRequest, "Dữ liệu không hợp lệ: %v", err)
+	}
+
+	// Không cho phép đổi ID, chủ sở hữu hoặc chuỗi lặp qua patch
+	task.ID = id
+	task.UserID = userID
+	keepSeriesFields(existing, &task)
+	if task.Title == "" || task.Description == "" || task.Deadline.IsZero() {
+		return models.Task{}, serviceErrorf(http.StatusBadRequest, "Thiếu thông tin cần thiết")
+	}
+	if err := h.validateTaskChange(ctx, existing, &task); err != nil {
+		return models.Task{}, err
+	}
+	return h.saveTask(ctx, existing, task)
+}
+
+// saveTask lưu thay đổi của một công việc đã được kiểm tra, sinh lần lặp kế tiếp nếu
+// cần và phát sự kiện task.updated (và task.completed). Nhắc nhở tương đối được store
+// tính lại theo deadline mới trong cùng transaction.
+func (h *Handler) saveTask(ctx context.Context, existing, task models.Task) (models.Task, error) {
+	// Sự kiện webhook được ghi cùng thay đổi
+	ctx = withEvent(ctx, task.UserID, events.TaskUpdated, &task)
+	if completed(existing, task) {
+		ctx = withEvent(ctx, task.UserID, events.TaskCompleted, &task)
+	}
+	// Hoàn thành một lần lặp sẽ sinh lần lặp kế tiếp
+	next, err := h.advanceSeries(ctx, existing, &task)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return models.Task{}, serviceErrorf(http.StatusNotFound, "Không tìm thấy công việc để cập nhật")
+		}
+		if errors.Is(err, store.ErrStale) {
+			return models.Task{}, serviceErrorf(http.StatusConflict, "Lần lặp này đã được hoàn thành bởi một yêu cầu khác")
+		}
+		return models.Task{}, fmt.Errorf("cập nhật công việc: %w", err)
+	}
+	if next != nil {
+		h.Events.Publish(next.UserID, events.TaskCreated, *next)
+	}
+	h.publishTaskUpdated(existing, task)
+	return task, nil
+}
+
+// publishTaskUpdated phát task.updated, kèm task.completed nếu công việc vừa được hoàn thành
+func (h *Handler) publishTaskUpdated(existing, task models.Task) {
+	h.Events.Publish(task.UserID, events.TaskUpdated, task)
+	if completed(existing, task) {
+		h.Events.Publish(task.UserID, events.TaskCompleted, task)
+	}
+}
+
+// completed cho biết công việc vừa chuyển sang Completed (sự kiện task.completed)
+func completed(existing, task models.Task) bool {
+	return existing.Status != models.StatusCompleted && task.Status == models.StatusCompleted
+}
+
+// deleteTask xóa công việc id của userID và phát sự kiện task.deleted
+func (h *Handler) deleteTask(ctx context.Context, userID, id int) error {
+	ctx = withEvent(ctx, userID, events.TaskDeleted, map[string]int{"task_id": id})
+	if err := h.Tasks.DeleteTask(ctx, userID, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return serviceErrorf(http.StatusNotFound, "Không tìm thấy công việc để xóa")
+		}
+		return fmt.Errorf("xóa công việc: %w", err)
+	}
+	h.Events.Publish(userID, events.TaskDeleted, map[string]int{"task_id": id})
+	return nil
+}
+
+// validateTaskChange kiểm tra status/priority, bước chuyển trạng thái và danh mục
+// của công việc đã tồn tại trước khi lưu
+func (h *Handler) validateTaskChange(ctx context.Context, existing models.Task, task *models.Task) error {
+	if err := normalizeTaskEnums(task); err != nil {
+		return err
+	}
+	if err := h.applyTransition(existing, task); err != nil {
+		return err
+	}
+	return h.checkTaskCategory(ctx, task.CategoryID, task.UserID)
+}
+
+// checkTaskCategory đảm bảo danh mục gắn với công việc (nếu có) thuộc về người dùng
+func (h *Handler) checkTaskCategory(ctx context.Context, categoryID, userID int) error {
+	if categoryID == 0 {
+		return nil
+	}
+	if _, err := h.Categories.GetCategory(ctx, userID, categoryID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return serviceErrorf(http.StatusNotFound, "Không tìm thấy danh mục")
+		}
+		return fmt.Errorf("kiểm tra danh mục: %w", err)
+	}
+	return nil
+}
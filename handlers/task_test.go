@@ -0,0 +1,331 @@
+package handlers_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func TestAuthRequired(t *testing.T) {
+	a := newTestAPI(t)
+	for _, token := range []string{"", "token-khong-ton-tai"} {
+		resp := a.do(token, "GET", "/api/tasks/1", nil)
+		readBody(t, resp)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("token %q: mã %d, muốn 401", token, resp.StatusCode)
+		}
+	}
+}
+
+func TestTaskLifecycle(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	mergePatch := []string{"Content-Type", "application/merge-patch+json"}
+
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Viết báo cáo",
+		"description": "Báo cáo quý",
+		"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		"user_id":     user.ID + 100,
+	}, http.StatusCreated), &task)
+	if task.UserID != user.ID || task.Status != models.StatusPending || task.Priority != models.PriorityMedium {
+		t.Fatalf("công việc mới: %+v", task)
+	}
+
+	// Không được nhảy thẳng từ Pending sang Completed
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "Completed"},
+		http.StatusUnprocessableEntity, mergePatch...)
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "In Progress"},
+		http.StatusOK, mergePatch...)
+	a.decode(a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "Completed"},
+		http.StatusOK, mergePatch...), &task)
+	if task.CompletedAt == nil {
+		t.Errorf("completed_at chưa được đặt khi hoàn thành: %+v", task)
+	}
+
+	a.mustDo(user.Token, "DELETE", path("/api/tasks/%d", task.ID), nil, http.StatusOK)
+	a.mustDo(user.Token, "GET", path("/api/tasks/%d", task.ID), nil, http.StatusNotFound)
+}
+
+func TestRecurringCompletionCreatesOneOccurrence(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	mergePatch := []string{"Content-Type", "application/merge-patch+json"}
+
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Họp tuần",
+		"description": "Họp nhóm",
+		"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+		"recurrence":  "FREQ=WEEKLY",
+	}, http.StatusCreated), &task)
+	a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{
+		"task_id":        task.ID,
+		"offset_minutes": 30,
+	}, http.StatusCreated)
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "In Progress"},
+		http.StatusOK, mergePatch...)
+	a.decode(a.mustDo(user.Token, "GET", path("/api/tasks/%d", task.ID), nil, http.StatusOK), &task)
+
+	// Nhiều request cùng hoàn thành một lần lặp chỉ được sinh đúng một lần lặp kế tiếp
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := a.do(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "Completed"},
+				mergePatch...)
+			readBody(t, resp)
+			if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+				t.Errorf("hoàn thành lần lặp: mã %d", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var page struct {
+		Items []models.Task `json:"items"`
+	}
+	a.decode(a.mustDo(user.Token, "GET", path("/api/users/%d/tasks", user.ID), nil, http.StatusOK), &page)
+	if len(page.Items) != 2 {
+		t.Fatalf("có %d công việc, muốn 2 (lần đã hoàn thành và lần kế tiếp): %+v", len(page.Items), page.Items)
+	}
+	var next models.Task
+	for _, item := range page.Items {
+		if item.ID != task.ID {
+			next = item
+		}
+	}
+	if next.Status != models.StatusPending || next.Recurrence == "" || !next.Deadline.After(task.Deadline) {
+		t.Errorf("lần lặp kế tiếp: %+v", next)
+	}
+
+	var reminders []models.Reminder
+	a.decode(a.mustDo(user.Token, "GET", path("/api/tasks/%d/reminders", next.ID), nil, http.StatusOK), &reminders)
+	if len(reminders) != 1 {
+		t.Errorf("lần lặp kế tiếp có %d nhắc nhở, muốn 1", len(reminders))
+	}
+
+	// Request đọc trạng thái cũ (In Progress) trước khi lần lặp được hoàn thành không được sinh thêm
+	stale := task
+	stale.Status = models.StatusCompleted
+	stale.Recurrence = ""
+	again := next
+	again.ID = 0
+	if err := a.store.CompleteOccurrence(t.Context(), &stale, &again, nil); !errors.Is(err, store.ErrStale) {
+		t.Errorf("hoàn thành lại lần lặp: %v, muốn ErrStale", err)
+	}
+}
+
+func TestSkipOccurrence(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+
+	deadline := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Họp tuần",
+		"description": "Họp nhóm",
+		"deadline":    deadline.Format(time.RFC3339),
+		"recurrence":  "FREQ=WEEKLY",
+	}, http.StatusCreated), &task)
+	var relative, absolute models.Reminder
+	a.decode(a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{
+		"task_id":        task.ID,
+		"offset_minutes": 30,
+	}, http.StatusCreated), &relative)
+	a.decode(a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{
+		"task_id":       task.ID,
+		"reminder_time": deadline.Add(-2 * time.Hour).Format(time.RFC3339),
+	}, http.StatusCreated), &absolute)
+
+	var skipped models.Task
+	a.decode(a.mustDo(user.Token, "POST", path("/api/tasks/%d/skip", task.ID), nil, http.StatusOK), &skipped)
+	week := 7 * 24 * time.Hour
+	if !skipped.Deadline.Equal(deadline.Add(week)) || skipped.Status != models.StatusPending {
+		t.Fatalf("công việc sau khi bỏ qua: %+v, muốn deadline %v", skipped, deadline.Add(week))
+	}
+
+	// Nhắc nhở được dời cùng công việc
+	var reminders []models.Reminder
+	a.decode(a.mustDo(user.Token, "GET", path("/api/tasks/%d/reminders", task.ID), nil, http.StatusOK), &reminders)
+	want := map[int]time.Time{
+		relative.ID: relative.ReminderTime.Add(week),
+		absolute.ID: absolute.ReminderTime.Add(week),
+	}
+	if len(reminders) != len(want) {
+		t.Fatalf("có %d nhắc nhở, muốn %d", len(reminders), len(want))
+	}
+	for _, reminder := range reminders {
+		if !reminder.ReminderTime.Equal(want[reminder.ID]) || reminder.IsSent || reminder.Status != models.ReminderScheduled {
+			t.Errorf("nhắc nhở #%d: %v (%s), muốn %v", reminder.ID, reminder.ReminderTime, reminder.Status, want[reminder.ID])
+		}
+	}
+
+	// Yêu cầu bỏ qua đọc lần lặp cũ (trước lần bỏ qua ở trên) không được dời thêm lần nữa
+	stale := skipped
+	stale.Deadline = skipped.Deadline.Add(week)
+	if err := a.store.SkipOccurrence(t.Context(), &stale, deadline); !errors.Is(err, store.ErrStale) {
+		t.Errorf("bỏ qua lần lặp cũ: %v, muốn ErrStale", err)
+	}
+	a.decode(a.mustDo(user.Token, "GET", path("/api/tasks/%d", task.ID), nil, http.StatusOK), &task)
+	if !task.Deadline.Equal(skipped.Deadline) {
+		t.Errorf("deadline sau lần bỏ qua cũ: %v, muốn %v", task.Deadline, skipped.Deadline)
+	}
+
+	a.mustDo(user.Token, "POST", path("/api/tasks/%d/skip", task.ID+100), nil, http.StatusNotFound)
+}
+
+func TestTaskStatusWorkflow(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	mergePatch := []string{"Content-Type", "application/merge-patch+json"}
+	body := func(fields map[string]any) map[string]any {
+		fields["title"] = "Viết báo cáo"
+		fields["description"] = "Báo cáo quý"
+		fields["deadline"] = time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+		return fields
+	}
+
+	// Giá trị ngoài tập hợp lệ bị từ chối, giá trị bỏ trống nhận mặc định
+	a.mustDo(user.Token, "POST", "/api/tasks", body(map[string]any{"status": "Done"}), http.StatusUnprocessableEntity)
+	a.mustDo(user.Token, "POST", "/api/tasks", body(map[string]any{"priority": "urgent"}), http.StatusUnprocessableEntity)
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", body(map[string]any{}), http.StatusCreated), &task)
+	if task.Status != models.StatusPending || task.Priority != models.PriorityMedium {
+		t.Fatalf("giá trị mặc định: %q, %q", task.Status, task.Priority)
+	}
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"priority": "Critical"},
+		http.StatusUnprocessableEntity, mergePatch...)
+
+	// Mỗi bước theo workflow mặc định; bước bị từ chối không đổi công việc đã lưu
+	var completedAt *time.Time
+	for _, step := range []struct {
+		status    models.TaskStatus
+		want      int
+		completed bool
+	}{
+		{models.StatusCompleted, http.StatusUnprocessableEntity, false},
+		{models.StatusInProgress, http.StatusOK, false},
+		{models.StatusPending, http.StatusOK, false},
+		{models.StatusInProgress, http.StatusOK, false},
+		{models.StatusCompleted, http.StatusOK, true},
+		{models.StatusCompleted, http.StatusOK, true},
+		{models.StatusInProgress, http.StatusUnprocessableEntity, true},
+		{models.StatusPending, http.StatusOK, false},
+	} {
+		a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": step.status},
+			step.want, mergePatch...)
+		var got models.Task
+		a.decode(a.mustDo(user.Token, "GET", path("/api/tasks/%d", task.ID), nil, http.StatusOK), &got)
+		if step.want == http.StatusOK && got.Status != step.status {
+			t.Errorf("sau khi chuyển sang %q: trạng thái %q", step.status, got.Status)
+		}
+		if (got.CompletedAt != nil) != step.completed {
+			t.Errorf("sau khi chuyển sang %q (mã %d): completed_at %v", step.status, step.want, got.CompletedAt)
+		}
+		// Lưu lại Completed không đổi thời điểm hoàn thành
+		if completedAt != nil && got.CompletedAt != nil && !got.CompletedAt.Equal(*completedAt) {
+			t.Errorf("completed_at đổi từ %v sang %v", completedAt, got.CompletedAt)
+		}
+		completedAt = got.CompletedAt
+	}
+}
+
+func TestTaskListFilters(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	var category models.Category
+	a.decode(a.mustDo(user.Token, "POST", "/api/categories", map[string]any{"category_name": "Công việc"}, http.StatusCreated), &category)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	specs := []struct {
+		priority models.TaskPriority
+		deadline time.Time
+		category bool
+	}{
+		{models.PriorityLow, now.Add(-2 * time.Hour), false},
+		{models.PriorityHigh, now.Add(time.Hour), true},
+		{models.PriorityMedium, now.Add(-time.Hour), true},
+		{models.PriorityHigh, now.Add(3 * time.Hour), false},
+		{models.PriorityMedium, now.Add(2 * time.Hour), false},
+	}
+	ids := make([]int, len(specs))
+	for i, spec := range specs {
+		body := map[string]any{
+			"title":       path("Công việc %d", i),
+			"description": "Mô tả",
+			"deadline":    spec.deadline.Format(time.RFC3339),
+			"priority":    spec.priority,
+		}
+		if spec.category {
+			body["category_id"] = category.ID
+		}
+		var task models.Task
+		a.decode(a.mustDo(user.Token, "POST", "/api/tasks", body, http.StatusCreated), &task)
+		ids[i] = task.ID
+	}
+	a.mustDo(user.Token, "POST", "/api/reminders", map[string]any{"task_id": ids[3], "offset_minutes": 10}, http.StatusCreated)
+
+	type page struct {
+		Items      []models.Task `json:"items"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	// list đọc mọi trang của query, mỗi trang tối đa 2 công việc, và trả về ID theo thứ tự
+	list := func(route, query string) []int {
+		t.Helper()
+		var got []int
+		cursor := ""
+		for {
+			var p page
+			a.decode(a.mustDo(user.Token, "GET", path("/api/users/%d/%s?limit=2&%s&cursor=%s", user.ID, route, query, cursor),
+				nil, http.StatusOK), &p)
+			if len(p.Items) > 2 {
+				t.Fatalf("%s: trang có %d công việc, limit 2", query, len(p.Items))
+			}
+			for _, task := range p.Items {
+				got = append(got, task.ID)
+			}
+			if p.NextCursor == "" {
+				return got
+			}
+			cursor = url.QueryEscape(p.NextCursor)
+		}
+	}
+	for _, tc := range []struct {
+		route, query string
+		want         []int
+	}{
+		{"tasks", "sort=deadline", []int{ids[0], ids[2], ids[1], ids[4], ids[3]}},
+		{"tasks", "sort=-deadline", []int{ids[3], ids[4], ids[1], ids[2], ids[0]}},
+		{"tasks", "sort=-priority", []int{ids[3], ids[1], ids[4], ids[2], ids[0]}},
+		{"tasks", "sort=created_at", ids},
+		{"tasks", "priority=High", []int{ids[1], ids[3]}},
+		{"tasks", path("category_id=%d&sort=deadline", category.ID), []int{ids[2], ids[1]}},
+		{"tasks", "overdue=true&sort=deadline", []int{ids[0], ids[2]}},
+		{"tasks", "deadline_from=" + url.QueryEscape(now.Format(time.RFC3339)) + "&deadline_to=" +
+			url.QueryEscape(now.Add(2*time.Hour).Format(time.RFC3339)) + "&sort=deadline", []int{ids[1], ids[4]}},
+		{"tasks-with-reminders", "sort=deadline", []int{ids[3]}},
+	} {
+		if got := list(tc.route, tc.query); !slices.Equal(got, tc.want) {
+			t.Errorf("%s?%s: %v, muốn %v", tc.route, tc.query, got, tc.want)
+		}
+	}
+
+	// Tham số sai và cursor của cách sắp xếp khác bị từ chối
+	for _, query := range []string{"sort=title", "status=Done", "overdue=maybe", "deadline_from=hôm-nay", "limit=0"} {
+		a.mustDo(user.Token, "GET", path("/api/users/%d/tasks?%s", user.ID, query), nil, http.StatusBadRequest)
+	}
+	var first page
+	a.decode(a.mustDo(user.Token, "GET", path("/api/users/%d/tasks?limit=2&sort=deadline", user.ID), nil, http.StatusOK), &first)
+	a.mustDo(user.Token, "GET", path("/api/users/%d/tasks?sort=priority&cursor=%s", user.ID, url.QueryEscape(first.NextCursor)),
+		nil, http.StatusBadRequest)
+}
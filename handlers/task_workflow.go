@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/models"
+)
+
+// normalizeTaskEnums gán giá trị mặc định cho status/priority bị bỏ trống và
+// từ chối các giá trị nằm ngoài tập hợp lệ (422)
+func normalizeTaskEnums(task *models.Task) error {
+	if task.Status == "" {
+		task.Status = models.StatusPending
+	}
+	if task.Priority == "" {
+		task.Priority = models.PriorityMedium
+	}
+	if !task.Status.Valid() {
+		return serviceErrorf(http.StatusUnprocessableEntity,
+			"Trạng thái không hợp lệ: %q (chấp nhận: %s)", task.Status, joinEnum(models.TaskStatuses))
+	}
+	if !task.Priority.Valid() {
+		return serviceErrorf(http.StatusUnprocessableEntity,
+			"Độ ưu tiên không hợp lệ: %q (chấp nhận: %s)", task.Priority, joinEnum(models.TaskPriorities))
+	}
+	return nil
+}
+
+// applyTransition kiểm tra chuyển trạng thái từ công việc hiện tại sang công việc mới
+// theo workflow đã cấu hình và cập nhật completed_at tương ứng
+func (h *Handler) applyTransition(existing models.Task, task *models.Task) error {
+	if !h.Workflow.CanTransition(existing.Status, task.Status) {
+		return serviceErrorf(http.StatusUnprocessableEntity,
+			"Không thể chuyển trạng thái từ %q sang %q", existing.Status, task.Status)
+	}
+	switch {
+	case task.Status != models.StatusCompleted:
+		task.CompletedAt = nil
+	case existing.Status == models.StatusCompleted:
+		task.CompletedAt = existing.CompletedAt
+	default:
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+	return nil
+}
+
+// applyCompletion như applyTransition nhưng coi việc chuyển sang Completed là thao tác
+// "đánh dấu hoàn thành" của client chỉ có hai trạng thái (ứng dụng lịch, task.complete):
+// công việc đi qua các trạng thái trung gian của workflow (Pending -> In Progress ->
+// Completed) trong cùng một lần lưu, miễn là workflow đi tới được Completed
+func (h *Handler) applyCompletion(existing models.Task, task *models.Task) error {
+	if task.Status == models.StatusCompleted && existing.Status != models.StatusCompleted &&
+		h.Workflow.Reaches(existing.Status, models.StatusCompleted) {
+		now := time.Now()
+		task.CompletedAt = &now
+		return nil
+	}
+	return h.applyTransition(existing, task)
+}
+
+func joinEnum[T ~string](values []T) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = string(v)
+	}
+	return strings.Join(parts, ", ")
+}
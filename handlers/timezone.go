@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// Thời gian được lưu ở UTC; các hàm dưới đây đổi sang múi giờ của người dùng
+// đang đăng nhập trước khi trả về.
+
+// userLocation trả về múi giờ của người dùng đang đăng nhập
+func (h *Handler) userLocation(r *http.Request) *time.Location {
+	return h.locationOf(r.Context(), currentUserID(r))
+}
+
+// locationOf trả về múi giờ của người dùng userID; UTC nếu không đọc được hồ sơ
+func (h *Handler) locationOf(ctx context.Context, userID int) *time.Location {
+	user, err := h.Users.GetUser(ctx, userID)
+	if err != nil {
+		log.Printf("Lỗi khi đọc múi giờ của user %d: %v", userID, err)
+		return time.UTC
+	}
+	return user.Location()
+}
+
+// validateUserSettings kiểm tra kênh thông báo, múi giờ, khung giờ yên lặng và webhook của hồ sơ.
+// Trả về false sau khi đã ghi response lỗi.
+func validateUserSettings(w http.ResponseWriter, user *models.User) bool {
+	if !validateChannels(w, user.NotificationChannels) {
+		return false
+	}
+	if user.Timezone == "" {
+		user.Timezone = "UTC"
+	}
+	if err := models.ValidateTimezone(user.Timezone); err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	if _, err := models.ParseQuietHours(user.QuietHoursStart, user.QuietHoursEnd); err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	return validateUserWebhook(w, user)
+}
+
+func (h *Handler) respondTask(w http.ResponseWriter, r *http.Request, code int, task models.Task) {
+	task.In(h.userLocation(r))
+	RespondWithJSON(w, code, task)
+}
+
+func (h *Handler) respondTaskPage(w http.ResponseWriter, r *http.Request, page store.TaskPage) {
+	loc := h.userLocation(r)
+	for i := range page.Items {
+		page.Items[i].In(loc)
+	}
+	RespondWithJSON(w, http.StatusOK, page)
+}
+
+func (h *Handler) respondReminder(w http.ResponseWriter, r *http.Request, code int, reminder models.Reminder) {
+	reminder.In(h.userLocation(r))
+	RespondWithJSON(w, code, reminder)
+}
+
+func (h *Handler) respondReminders(w http.ResponseWriter, r *http.Request, reminders []models.Reminder) {
+	loc := h.userLocation(r)
+	for i := range reminders {
+		reminders[i].In(loc)
+	}
+	RespondWithJSON(w, http.StatusOK, reminders)
+}
@@ -1,96 +1,76 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"time"
 
-	"backend/database"
+	"backend/auth"
 	"backend/models"
-
-	"github.com/go-sql-driver/mysql"
-
-	"github.com/gorilla/mux"
+	"backend/store"
 )
 
-func CreateUser(w http.ResponseWriter, r *http.Request) {
-    var user models.User
-    decoder := json.NewDecoder(r.Body)
-    if err := decoder.Decode(&user); err != nil {
-        log.Printf("Lỗi giải mã JSON: %v", err)
-        RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ")
-        return
-    }
-    defer r.Body.Close()
-
-    log.Printf("Dữ liệu nhận được: %+v", user)
-
-    if user.Username == "" || user.Email == "" || user.Password == "" || user.FullName == "" {
-        log.Printf("Trường rỗng: Username=%s, Email=%s, Password=%s, FullName=%s",
-            user.Username, user.Email, user.Password, user.FullName)
-        RespondWithError(w, http.StatusBadRequest, "Tất cả các trường là bắt buộc")
-        return
-    }
-
-    query := `INSERT INTO users (username, email, password, full_name, created_at) 
-              VALUES (?, ?, ?, ?, ?)`
-    result, err := database.DB.Exec(query, user.Username, user.Email, user.Password, user.FullName, time.Now())
-    if err != nil {
-        if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
-            log.Printf("Trùng lặp username hoặc email: %v", err)
-            RespondWithError(w, http.StatusConflict, "Username hoặc email đã tồn tại")
-        } else {
-            log.Printf("Lỗi SQL: %v", err)
-            RespondWithError(w, http.StatusInternalServerError, "Lỗi khi thêm người dùng: "+err.Error())
-        }
-        return
-    }
-
-    id, err := result.LastInsertId()
-    if err != nil {
-        log.Printf("Lỗi lấy ID: %v", err)
-        RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy ID người dùng")
-        return
-    }
-
-    user.ID = int(id)
-    w.Header().Set("Content-Type", "application/json")
-    RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
-        "user": user,
-    })
-}
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&user); err != nil {
+		log.Printf("Lỗi giải mã JSON: %v", err)
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ")
+		return
+	}
+	defer r.Body.Close()
 
-func GetUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr, ok := vars["id"]
-	if !ok {
-		RespondWithError(w, http.StatusBadRequest, "Thiếu ID người dùng")
+	log.Printf("Dữ liệu nhận được: username=%s, email=%s", user.Username, user.Email)
+
+	if user.Username == "" || user.Email == "" || user.Password == "" || user.FullName == "" {
+		log.Printf("Trường rỗng: Username=%s, Email=%s, FullName=%s",
+			user.Username, user.Email, user.FullName)
+		RespondWithError(w, http.StatusBadRequest, "Tất cả các trường là bắt buộc")
+		return
+	}
+	if !validateUserSettings(w, &user) {
 		return
 	}
 
-	id, err := strconv.Atoi(idStr)
+	hashedPassword, err := auth.HashPassword(user.Password)
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
+		log.Printf("Lỗi băm mật khẩu: %v", err)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xử lý mật khẩu")
 		return
 	}
 
-	var user models.User
-	var lastLogin sql.NullTime
-	query := "SELECT user_id, username, email, full_name, created_at, last_login FROM users WHERE user_id = ?"
-	err = database.DB.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.FullName,
-		&user.CreatedAt,
-		&lastLogin,
-	)
+	user.Password = hashedPassword
+	user.CreatedAt = time.Now()
+	if err := h.Users.CreateUser(r.Context(), &user); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			log.Printf("Trùng lặp username hoặc email: %v", err)
+			RespondWithError(w, http.StatusConflict, "Username hoặc email đã tồn tại")
+		} else {
+			log.Printf("Lỗi SQL: %v", err)
+			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi thêm người dùng: "+err.Error())
+		}
+		return
+	}
+
+	user.Password, user.WebhookSecret = "", ""
+	user.In(user.Location())
+	RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"user": user,
+	})
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUserID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	user, err := h.Users.GetUser(r.Context(), id)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, store.ErrNotFound) {
 			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Không tìm thấy người dùng với ID: %d", id))
 			return
 		}
@@ -99,26 +79,32 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if lastLogin.Valid {
-		user.LastLogin = &lastLogin.Time
-	}
-
-	w.Header().Set("Content-Type", "application/json")
+	user.WebhookSecret = ""
+	user.In(user.Location())
 	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Lấy thông tin người dùng thành công",
 		"user":    user,
 	})
 }
 
-func UpdateUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUserID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	existing, err := h.Users.GetUser(r.Context(), id)
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Không tìm thấy người dùng với ID: %d", id))
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy thông tin người dùng")
 		return
 	}
 
-	var user models.User
+	// Trường bị bỏ trống trong body (múi giờ, giờ yên lặng, kênh nhận...) giữ giá trị đang lưu
+	user := existing
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&user); err != nil {
 		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ")
@@ -126,118 +112,133 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	query := "UPDATE users SET username = ?, email = ?, full_name = ? WHERE user_id = ?"
-	_, err = database.DB.Exec(query, user.Username, user.Email, user.FullName, id)
-	if err != nil {
+	user.ID = id
+	user.CreatedAt, user.LastLogin = existing.CreatedAt, existing.LastLogin
+	if !validateUserSettings(w, &user) {
+		return
+	}
+	if err := h.Users.UpdateUser(r.Context(), &user); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, "Username hoặc email đã tồn tại")
+			return
+		}
 		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi cập nhật người dùng: "+err.Error())
 		return
 	}
+	h.onTimezoneChange(r.Context(), existing, user)
 
-	user.ID = id
-	w.Header().Set("Content-Type", "application/json")
+	user.Password, user.WebhookSecret = "", ""
+	user.In(user.Location())
 	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Cập nhật người dùng thành công",
 		"user":    user,
 	})
 }
 
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
+// PatchUser cập nhật một phần hồ sơ người dùng theo JSON Merge Patch (RFC 7396).
+// Mật khẩu không thể đổi qua endpoint này.
+func (h *Handler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUserID(w, r, "id")
+	if !ok {
 		return
 	}
 
-	query := "DELETE FROM users WHERE user_id = ?"
-	_, err = database.DB.Exec(query, id)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa người dùng")
+	patch, ok := readMergePatch(w, r)
+	if !ok {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Đã xóa người dùng thành công"})
-}
-
-func GetUserTasks(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.Atoi(vars["user_id"])
+	user, err := h.Users.GetUser(r.Context(), id)
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "ID người dùng không hợp lệ")
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, fmt.Sprintf("Không tìm thấy người dùng với ID: %d", id))
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy thông tin người dùng")
 		return
 	}
 
-	// Lấy danh sách công việc của người dùng
-	query := `SELECT task_id, title, description, deadline, priority, status, 
-              category_id, user_id, created_at, updated_at 
-              FROM tasks WHERE user_id = ?`
-	rows, err := database.DB.Query(query, userID)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách công việc")
+	existing := user
+	createdAt, lastLogin := user.CreatedAt, user.LastLogin
+	if err := applyMergePatch(&user, patch); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ: "+err.Error())
+		return
+	}
+
+	user.ID = id
+	user.Password = ""
+	user.CreatedAt, user.LastLogin = createdAt, lastLogin
+	if user.Username == "" || user.Email == "" || user.FullName == "" {
+		RespondWithError(w, http.StatusBadRequest, "Username, email và họ tên là bắt buộc")
+		return
+	}
+	if !validateUserSettings(w, &user) {
 		return
 	}
-	defer rows.Close()
-
-	tasks := []models.Task{}
-	for rows.Next() {
-		var task models.Task
-		err := rows.Scan(
-			&task.ID,
-			&task.Title,
-			&task.Description,
-			&task.Deadline,
-			&task.Priority,
-			&task.Status,
-			&task.CategoryID,
-			&task.UserID,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
-		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc dữ liệu công việc")
+
+	if err := h.Users.UpdateUser(r.Context(), &user); err != nil {
+		if errors.Is(err, store.ErrConflict) {
+			RespondWithError(w, http.StatusConflict, "Username hoặc email đã tồn tại")
 			return
 		}
-		tasks = append(tasks, task)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi cập nhật người dùng: "+err.Error())
+		return
 	}
+	h.onTimezoneChange(r.Context(), existing, user)
 
-	if err = rows.Err(); err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi truy vấn danh sách công việc")
+	user.WebhookSecret = ""
+	user.In(user.Location())
+	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "Cập nhật người dùng thành công",
+		"user":    user,
+	})
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathUserID(w, r, "id")
+	if !ok {
 		return
 	}
 
-	RespondWithJSON(w, http.StatusOK, tasks)
+	if err := h.Users.DeleteUser(r.Context(), id); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa người dùng")
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Đã xóa người dùng thành công"})
 }
 
-func GetUserCategories(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.Atoi(vars["user_id"])
+func (h *Handler) GetUserTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	filter, err := parseTaskFilter(r)
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, "ID người dùng không hợp lệ")
+		RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	query := "SELECT category_id, category_name, color, user_id FROM categories WHERE user_id = ?"
-	rows, err := database.DB.Query(query, userID)
+	// Lấy danh sách công việc của người dùng theo trang
+	page, err := h.Tasks.ListTasks(r.Context(), userID, filter)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách danh mục")
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách công việc")
 		return
 	}
-	defer rows.Close()
 
-	categories := []models.Category{}
-	for rows.Next() {
-		var category models.Category
-		err := rows.Scan(&category.ID, &category.CategoryName, &category.Color, &category.UserID)
-		if err != nil {
-			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi đọc dữ liệu danh mục")
-			return
-		}
-		categories = append(categories, category)
+	h.respondTaskPage(w, r, page)
+}
+
+func (h *Handler) GetUserCategories(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
 	}
 
-	if err = rows.Err(); err != nil {
-		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi truy vấn danh sách danh mục")
+	categories, err := h.Categories.ListCategories(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách danh mục")
 		return
 	}
 
@@ -251,7 +252,7 @@ type LoginRequest struct {
 }
 
 // Login xử lý việc đăng nhập người dùng
-func Login(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var loginReq LoginRequest
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&loginReq); err != nil {
@@ -261,41 +262,58 @@ func Login(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	// Truy vấn thông tin người dùng từ database
-	var user models.User
-	var storedPassword string
-
-	query := "SELECT user_id, username, email, password, full_name, created_at FROM users WHERE username = ?"
-	err := database.DB.QueryRow(query, loginReq.Username).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&storedPassword,
-		&user.FullName,
-		&user.CreatedAt,
-	)
-
+	user, err := h.Users.GetUserByUsername(r.Context(), loginReq.Username)
 	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			log.Println("Lỗi truy vấn SQL:", err)
+		}
+		// Vẫn so sánh với hash giả để thời gian phản hồi không lộ username có tồn tại hay không
+		auth.CheckDummyPassword(loginReq.Password)
 		RespondWithError(w, http.StatusUnauthorized, "Tên đăng nhập hoặc mật khẩu không đúng")
 		return
 	}
 
-	// Kiểm tra mật khẩu (so sánh trực tiếp)
-	if storedPassword != loginReq.Password {
+	// Kiểm tra mật khẩu (bcrypt hoặc plain text của tài khoản cũ)
+	ok, needsRehash := auth.CheckPassword(user.Password, loginReq.Password)
+	if !ok {
 		RespondWithError(w, http.StatusUnauthorized, "Tên đăng nhập hoặc mật khẩu không đúng")
 		return
 	}
 
+	// Nâng cấp mật khẩu plain text cũ lên hash sau khi đăng nhập thành công
+	if needsRehash {
+		if hashed, err := auth.HashPassword(loginReq.Password); err != nil {
+			log.Printf("Lỗi băm lại mật khẩu cho user %d: %v", user.ID, err)
+		} else if err := h.Users.UpdatePassword(r.Context(), user.ID, hashed); err != nil {
+			log.Printf("Lỗi cập nhật hash mật khẩu cho user %d: %v", user.ID, err)
+		}
+	}
+
 	// Cập nhật thời gian đăng nhập cuối cùng
 	now := time.Now()
-	database.DB.Exec("UPDATE users SET last_login = ? WHERE user_id = ?", now, user.ID)
+	if err := h.Users.UpdateLastLogin(r.Context(), user.ID, now); err != nil {
+		log.Printf("Lỗi cập nhật last_login cho user %d: %v", user.ID, err)
+	}
+
+	// Tạo phiên đăng nhập mới
+	tokens, err := h.issueSession(r.Context(), user.ID)
+	if err != nil {
+		log.Printf("Lỗi tạo phiên đăng nhập cho user %d: %v", user.ID, err)
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tạo phiên đăng nhập")
+		return
+	}
 
 	// Xóa mật khẩu trước khi trả về
-	user.Password = ""
+	user.Password, user.WebhookSecret = "", ""
+	user.In(user.Location())
 
-	// Trả về thông tin người dùng
-	w.Header().Set("Content-Type", "application/json")
+	// Trả về thông tin người dùng kèm token
 	RespondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"message": "Đăng nhập thành công",
-		"user":    user,
+		"message":       "Đăng nhập thành công",
+		"user":          user,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
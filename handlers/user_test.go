@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/models"
+)
+
+func TestUpdateUserKeepsOmittedSettings(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	mergePatch := []string{"Content-Type", "application/merge-patch+json"}
+
+	a.mustDo(user.Token, "PATCH", path("/api/users/%d", user.ID), map[string]any{
+		"timezone":              "Asia/Ho_Chi_Minh",
+		"quiet_hours_start":     "22:00",
+		"quiet_hours_end":       "07:00",
+		"notification_channels": []string{"email"},
+	}, http.StatusOK, mergePatch...)
+
+	// Client cũ chỉ gửi các trường hồ sơ cơ bản
+	var resp struct {
+		User models.User `json:"user"`
+	}
+	a.decode(a.mustDo(user.Token, "PUT", path("/api/users/%d", user.ID), map[string]any{
+		"username":  "alice",
+		"email":     "alice@example.com",
+		"full_name": "Alice Nguyễn",
+	}, http.StatusOK), &resp)
+
+	stored, err := a.store.GetUser(t.Context(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range []models.User{resp.User, stored} {
+		if got.FullName != "Alice Nguyễn" || got.Timezone != "Asia/Ho_Chi_Minh" ||
+			got.QuietHoursStart != "22:00" || got.QuietHoursEnd != "07:00" ||
+			len(got.NotificationChannels) != 1 || got.NotificationChannels[0] != models.ChannelEmail {
+			t.Errorf("cài đặt bị mất sau PUT: %+v", got)
+		}
+	}
+}
+
+func TestUserWebhookSettings(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	mergePatch := []string{"Content-Type", "application/merge-patch+json"}
+	profile := path("/api/users/%d", user.ID)
+
+	for _, patch := range []map[string]any{
+		{"notification_channels": []string{"webhook"}},
+		{"webhook_url": "http://example.com/hook", "webhook_secret": "bi-mat-du-dai-16-ky-tu"},
+		{"webhook_url": "https://example.com/hook"},
+		{"webhook_url": "https://example.com/hook", "webhook_secret": "ngan"},
+	} {
+		a.mustDo(user.Token, "PATCH", profile, patch, http.StatusUnprocessableEntity, mergePatch...)
+	}
+
+	var resp struct {
+		User map[string]any `json:"user"`
+	}
+	a.decode(a.mustDo(user.Token, "PATCH", profile, map[string]any{
+		"webhook_url":           "https://example.com/hook",
+		"webhook_secret":        "bi-mat-du-dai-16-ky-tu",
+		"notification_channels": []string{"webhook"},
+	}, http.StatusOK, mergePatch...), &resp)
+	if _, leaked := resp.User["webhook_secret"]; leaked || resp.User["webhook_url"] != "https://example.com/hook" {
+		t.Errorf("hồ sơ sau khi đặt webhook: %v", resp.User)
+	}
+
+	// PUT không gửi secret giữ secret đang lưu; secret chỉ dùng khi gửi nhắc nhở
+	a.decode(a.mustDo(user.Token, "PUT", profile, map[string]any{
+		"username":  "alice",
+		"email":     "alice@example.com",
+		"full_name": "Alice",
+	}, http.StatusOK), &resp)
+	a.decode(a.mustDo(user.Token, "GET", profile, nil, http.StatusOK), &resp)
+	if _, leaked := resp.User["webhook_secret"]; leaked {
+		t.Errorf("GET trả về webhook_secret: %v", resp.User)
+	}
+	stored, err := a.store.GetUser(t.Context(), user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.WebhookURL != "https://example.com/hook" || stored.WebhookSecret != "bi-mat-du-dai-16-ky-tu" {
+		t.Errorf("webhook đã lưu: %q, %q", stored.WebhookURL, stored.WebhookSecret)
+	}
+
+	// Xóa URL thì secret cũng bị xóa
+	a.mustDo(user.Token, "PATCH", profile, map[string]any{"webhook_url": "", "notification_channels": nil},
+		http.StatusOK, mergePatch...)
+	if stored, _ = a.store.GetUser(t.Context(), user.ID); stored.WebhookSecret != "" {
+		t.Errorf("secret còn lại sau khi xóa webhook_url: %q", stored.WebhookSecret)
+	}
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"backend/auth"
+	"backend/events"
+	"backend/models"
+	"backend/notify"
+	"backend/store"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxWebhookURLLength = 2048
+	// minWebhookSecretLength là độ dài tối thiểu của secret do người dùng tự đặt
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 255
+	defaultDeliveryLimit   = 50
+	maxDeliveryLimit       = 200
+)
+
+// webhookRequest là body khi tạo hoặc sửa webhook. Secret trống khi tạo thì server tự
+// sinh, khi sửa thì giữ secret cũ; Active bỏ trống là true khi tạo và giữ nguyên khi sửa.
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Active *bool    `json:"active"`
+}
+
+// GetWebhooks liệt kê webhook của người dùng (không kèm secret)
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.Webhooks.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy danh sách webhook")
+		return
+	}
+	loc := h.userLocation(r)
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+		webhooks[i].In(loc)
+	}
+	RespondWithJSON(w, http.StatusOK, webhooks)
+}
+
+// CreateWebhook đăng ký webhook mới. Secret dùng để ký payload chỉ được trả về ở đây.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ")
+		return
+	}
+	defer r.Body.Close()
+
+	webhook := models.Webhook{UserID: userID, Active: true}
+	if !applyWebhookRequest(w, &webhook, req) {
+		return
+	}
+	if webhook.Secret == "" {
+		secret, err := auth.NewToken()
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tạo secret cho webhook")
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	if err := h.Webhooks.CreateWebhook(r.Context(), &webhook); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi tạo webhook: "+err.Error())
+		return
+	}
+	webhook.In(h.userLocation(r))
+	RespondWithJSON(w, http.StatusCreated, webhook)
+}
+
+// GetWebhook trả về một webhook (không kèm secret)
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+	h.respondWebhook(w, r, webhook)
+}
+
+// UpdateWebhook thay URL, danh sách sự kiện, trạng thái bật/tắt và (nếu có) secret
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondWithError(w, http.StatusBadRequest, "Dữ liệu không hợp lệ")
+		return
+	}
+	defer r.Body.Close()
+
+	if !applyWebhookRequest(w, &webhook, req) {
+		return
+	}
+	if err := h.Webhooks.UpdateWebhook(r.Context(), &webhook); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy webhook")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi cập nhật webhook: "+err.Error())
+		return
+	}
+	h.respondWebhook(w, r, webhook)
+}
+
+// DeleteWebhook xóa webhook cùng nhật ký gửi của nó
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Webhooks.DeleteWebhook(r.Context(), webhook.UserID, webhook.ID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy webhook")
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi xóa webhook")
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Đã xóa webhook"})
+}
+
+// GetWebhookDeliveries trả về các lần gửi gần nhất của webhook, mới nhất trước
+// (?limit=, mặc định 50, tối đa 200). Payload chỉ có khi xem từng delivery.
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhook, ok := h.loadWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxDeliveryLimit {
+			RespondWithError(w, http.StatusBadRequest, "Tham số limit phải từ 1 đến 200")
+			return
+		}
+		limit = n
+	}
+
+	deliveries, err := h.Webhooks.ListWebhookDeliveries(r.Context(), webhook.UserID, webhook.ID, limit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy nhật ký gửi webhook")
+		return
+	}
+	loc := h.userLocation(r)
+	for i := range deliveries {
+		deliveries[i].In(loc)
+	}
+	RespondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// GetWebhookDelivery trả về một lần gửi kèm payload đã gửi
+func (h *Handler) GetWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, ok := h.loadWebhookDelivery(w, r)
+	if !ok {
+		return
+	}
+	delivery.In(h.userLocation(r))
+	RespondWithJSON(w, http.StatusOK, delivery)
+}
+
+// ReplayWebhookDelivery gửi lại payload của một delivery bất kỳ (kể cả đã thành công)
+// dưới dạng delivery mới có replay_of trỏ về delivery gốc. Chữ ký được tính lại với
+// secret hiện tại; webhook đang tắt thì delivery chờ tới khi được bật lại.
+func (h *Handler) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	original, ok := h.loadWebhookDelivery(w, r)
+	if !ok {
+		return
+	}
+
+	replay := models.WebhookDelivery{
+		WebhookID: original.WebhookID,
+		UserID:    original.UserID,
+		EventID:   original.EventID,
+		Event:     original.Event,
+		Payload:   original.Payload,
+		ReplayOf:  &original.ID,
+	}
+	if err := h.Webhooks.CreateWebhookDelivery(r.Context(), &replay); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi gửi lại webhook: "+err.Error())
+		return
+	}
+	replay.In(h.userLocation(r))
+	RespondWithJSON(w, http.StatusAccepted, replay)
+}
+
+// applyWebhookRequest kiểm tra và gán dữ liệu từ request vào webhook.
+// Trả về false sau khi đã ghi response lỗi.
+// validateUserWebhook kiểm tra webhook nhận nhắc nhở trong hồ sơ: URL hợp lệ phải kèm
+// secret, và kênh webhook chỉ được chọn làm mặc định khi đã có URL. URL trống thì xóa secret.
+func validateUserWebhook(w http.ResponseWriter, user *models.User) bool {
+	if user.WebhookURL == "" {
+		user.WebhookSecret = ""
+		for _, channel := range user.NotificationChannels {
+			if channel == models.ChannelWebhook {
+				RespondWithError(w, http.StatusUnprocessableEntity, "Cần webhook_url để nhận nhắc nhở qua kênh webhook")
+				return false
+			}
+		}
+		return true
+	}
+	if len(user.WebhookURL) > maxWebhookURLLength {
+		RespondWithError(w, http.StatusUnprocessableEntity, "URL của webhook quá dài")
+		return false
+	}
+	if err := notify.ValidateWebhookURL(user.WebhookURL); err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	if length := utf8.RuneCountInString(user.WebhookSecret); length < minWebhookSecretLength || length > maxWebhookSecretLength {
+		RespondWithError(w, http.StatusUnprocessableEntity, "webhook_secret phải dài từ 16 đến 255 ký tự")
+		return false
+	}
+	return true
+}
+
+func applyWebhookRequest(w http.ResponseWriter, webhook *models.Webhook, req webhookRequest) bool {
+	if req.URL == "" {
+		RespondWithError(w, http.StatusBadRequest, "Thiếu URL của webhook")
+		return false
+	}
+	if len(req.URL) > maxWebhookURLLength {
+		RespondWithError(w, http.StatusUnprocessableEntity, "URL của webhook quá dài")
+		return false
+	}
+	if err := notify.ValidateWebhookURL(req.URL); err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+	if req.Secret != "" {
+		length := utf8.RuneCountInString(req.Secret)
+		if length < minWebhookSecretLength || length > maxWebhookSecretLength {
+			RespondWithError(w, http.StatusUnprocessableEntity, "Secret của webhook phải dài từ 16 đến 255 ký tự")
+			return false
+		}
+		webhook.Secret = req.Secret
+	}
+	if err := models.ValidateWebhookEvents(req.Events); err != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+		return false
+	}
+
+	events := []string{}
+	seen := map[string]bool{}
+	for _, event := range req.Events {
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	webhook.URL = req.URL
+	webhook.Events = events
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	return true
+}
+
+// loadWebhook đọc webhook {id} của người dùng đang đăng nhập.
+// Trả về false sau khi đã ghi response lỗi.
+func (h *Handler) loadWebhook(w http.ResponseWriter, r *http.Request) (models.Webhook, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
+		return models.Webhook{}, false
+	}
+
+	webhook, err := h.Webhooks.GetWebhook(r.Context(), currentUserID(r), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithError(w, http.StatusNotFound, "Không tìm thấy webhook")
+			return models.Webhook{}, false
+		}
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy thông tin webhook")
+		return models.Webhook{}, false
+	}
+	return webhook, true
+}
+
+// loadWebhookDelivery đọc delivery {delivery_id} thuộc webhook {id} của người dùng đang đăng nhập.
+// Trả về false sau khi đã ghi response lỗi.
+func (h *Handler) loadWebhookDelivery(w http.ResponseWriter, r *http.Request) (models.WebhookDelivery, bool) {
+	webhook, ok := h.loadWebhook(w, r)
+	if !ok {
+		return models.WebhookDelivery{}, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["delivery_id"])
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, "ID không hợp lệ")
+		return models.WebhookDelivery{}, false
+	}
+
+	delivery, err := h.Webhooks.GetWebhookDelivery(r.Context(), webhook.UserID, id)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		RespondWithError(w, http.StatusInternalServerError, "Lỗi khi lấy nhật ký gửi webhook")
+		return models.WebhookDelivery{}, false
+	}
+	if err != nil || delivery.WebhookID != webhook.ID {
+		RespondWithError(w, http.StatusNotFound, "Không tìm thấy lần gửi webhook")
+		return models.WebhookDelivery{}, false
+	}
+	return delivery, true
+}
+
+func (h *Handler) respondWebhook(w http.ResponseWriter, r *http.Request, webhook models.Webhook) {
+	webhook.Secret = ""
+	webhook.In(h.userLocation(r))
+	RespondWithJSON(w, http.StatusOK, webhook)
+}
+
+// withEvent gắn sự kiện typ vào ctx của một thao tác ghi để store ghi nó vào outbox webhook
+// trong cùng transaction (xem store.WithEvents). Sự kiện chỉ được ghi một lần, bởi thao tác
+// ghi đầu tiên nhận ctx trả về và commit thành công; data nên là con trỏ tới bản ghi đang
+// được ghi.
+func withEvent(ctx context.Context, userID int, typ events.Type, data any) context.Context {
+	return store.WithEvents(ctx, store.Event{Type: string(typ), UserID: userID, Data: data})
+}
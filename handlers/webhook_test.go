@@ -0,0 +1,103 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend/models"
+)
+
+func TestWebhookOutbox(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+	mergePatch := []string{"Content-Type", "application/merge-patch+json"}
+
+	var webhook, inactive models.Webhook
+	a.decode(a.mustDo(user.Token, "POST", path("/api/users/%d/webhooks", user.ID), map[string]any{
+		"url":    "https://example.com/hook",
+		"events": []string{"task.created", "task.completed", "category.created"},
+	}, http.StatusCreated), &webhook)
+	a.decode(a.mustDo(user.Token, "POST", path("/api/users/%d/webhooks", user.ID), map[string]any{
+		"url":    "https://example.com/off",
+		"active": false,
+	}, http.StatusCreated), &inactive)
+
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Viết báo cáo",
+		"description": "Báo cáo quý",
+		"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated), &task)
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "In Progress"},
+		http.StatusOK, mergePatch...)
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "Completed"},
+		http.StatusOK, mergePatch...)
+
+	// Thay đổi bị từ chối (xung đột phiên bản) không để lại sự kiện nào
+	stale := int64(0)
+	var push struct {
+		Results []syncResult `json:"results"`
+	}
+	a.decode(a.mustDo(user.Token, "POST", "/api/sync", map[string]any{"changes": []map[string]any{{
+		"client_id":    "c1",
+		"entity":       "task",
+		"op":           "delete",
+		"id":           task.ID,
+		"base_version": stale,
+	}}}, http.StatusOK), &push)
+	if len(push.Results) != 1 || push.Results[0].Status != "conflict" {
+		t.Fatalf("xóa từ phiên bản cũ: %+v", push.Results)
+	}
+
+	// Nhập nhiều công việc: mỗi bản ghi một delivery, ghi cùng transaction nhập
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("format", "csv")
+	form.WriteField("mapping", `{"title": "title", "category": "list"}`)
+	file, _ := form.CreateFormFile("file", "tasks.csv")
+	file.Write([]byte("title,list\nMua sữa,Việc nhà\nRửa bát,Việc nhà\nĐi chợ,Việc nhà\n"))
+	form.Close()
+	a.mustDo(user.Token, "POST", path("/api/users/%d/import", user.ID), body.Bytes(), http.StatusCreated,
+		"Content-Type", form.FormDataContentType())
+
+	var deliveries []models.WebhookDelivery
+	a.decode(a.mustDo(user.Token, "GET", path("/api/webhooks/%d/deliveries?limit=200", webhook.ID), nil, http.StatusOK), &deliveries)
+	count := map[string]int{}
+	for _, delivery := range deliveries {
+		count[delivery.Event]++
+		if delivery.Status != models.WebhookPending {
+			t.Errorf("delivery #%d: trạng thái %s, muốn pending", delivery.ID, delivery.Status)
+		}
+	}
+	want := map[string]int{"task.created": 4, "task.completed": 1, "category.created": 1}
+	if len(count) != len(want) || count["task.created"] != want["task.created"] ||
+		count["task.completed"] != want["task.completed"] || count["category.created"] != want["category.created"] {
+		t.Errorf("delivery theo sự kiện: %v, muốn %v", count, want)
+	}
+
+	// Payload chứa bản ghi sau khi ghi (có ID do store cấp)
+	first := deliveries[len(deliveries)-1]
+	a.decode(a.mustDo(user.Token, "GET", path("/api/webhooks/%d/deliveries/%d", webhook.ID, first.ID), nil, http.StatusOK), &first)
+	var payload struct {
+		ID     string      `json:"id"`
+		Event  string      `json:"event"`
+		UserID int         `json:"user_id"`
+		Data   models.Task `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(first.Payload), &payload); err != nil {
+		t.Fatalf("giải mã payload %s: %v", first.Payload, err)
+	}
+	if payload.ID == "" || payload.ID != first.EventID || payload.Event != "task.created" ||
+		payload.UserID != user.ID || payload.Data.ID != task.ID {
+		t.Errorf("payload của task.created: %s", first.Payload)
+	}
+
+	a.decode(a.mustDo(user.Token, "GET", path("/api/webhooks/%d/deliveries", inactive.ID), nil, http.StatusOK), &deliveries)
+	if len(deliveries) != 0 {
+		t.Errorf("webhook đang tắt có %d delivery, muốn 0", len(deliveries))
+	}
+}
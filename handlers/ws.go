@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"backend/events"
+	"backend/models"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	// wsMaxMessage giới hạn kích thước một message client gửi lên
+	wsMaxMessage = 1 << 20
+)
+
+// Các loại yêu cầu client gửi qua WebSocket
+const (
+	wsPing         = "ping"
+	wsTaskCreate   = "task.create"
+	wsTaskUpdate   = "task.update"
+	wsTaskPatch    = "task.patch"
+	wsTaskComplete = "task.complete"
+	wsTaskDelete   = "task.delete"
+)
+
+var wsUpgrader = websocket.Upgrader{ReadBufferSize: 4096, WriteBufferSize: 4096}
+
+// wsRequest là yêu cầu của client. ID do client tạo và được trả lại trong ack/error
+// để client ghép phản hồi với yêu cầu.
+type wsRequest struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	TaskID int             `json:"task_id,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// wsMessage là message server gửi xuống: "ack", "error", "event" hoặc "reset"
+type wsMessage struct {
+	Type   string        `json:"type"`
+	ID     string        `json:"id,omitempty"`
+	Status int           `json:"status,omitempty"`
+	Data   any           `json:"data,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Event  *events.Event `json:"event,omitempty"`
+}
+
+// wsConn tuần tự hóa việc ghi vì websocket.Conn chỉ cho phép một goroutine ghi
+type wsConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (c *wsConn) send(msg wsMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return c.conn.WriteJSON(msg)
+}
+
+func (c *wsConn) control(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteControl(messageType, data, time.Now().Add(wsWriteWait))
+}
+
+// LiveSync mở kết nối WebSocket hai chiều: server đẩy các sự kiện như /events,
+// client gửi thay đổi công việc và nhận ack/error theo ID yêu cầu. Các thay đổi đi
+// qua cùng đường kiểm tra và lưu với REST API. Client kết nối lại với
+// ?last_event_id= để nhận các sự kiện đã lỡ.
+func (h *Handler) LiveSync(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUserID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade đã ghi response lỗi
+		return
+	}
+	defer conn.Close()
+	c := &wsConn{conn: conn}
+
+	sub, replay, resumed := h.Events.Subscribe(userID, r.URL.Query().Get("last_event_id"))
+	defer sub.Close()
+
+	if !resumed {
+		if err := c.send(wsMessage{Type: "reset"}); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := c.send(wsMessage{Type: "event", Event: &event}); err != nil {
+			return
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.readWS(r.Context(), c, userID)
+	}()
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case event, open := <-sub.C:
+			if !open {
+				// Hub đóng hoặc client đọc quá chậm; client sẽ kết nối lại với last_event_id
+				c.control(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""))
+				return
+			}
+			if err := c.send(wsMessage{Type: "event", Event: &event}); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := c.control(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readWS đọc và xử lý lần lượt các yêu cầu của client cho tới khi kết nối đóng
+func (h *Handler) readWS(ctx context.Context, c *wsConn, userID int) {
+	c.conn.SetReadLimit(wsMaxMessage)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+
+		var req wsRequest
+		reply := wsMessage{Type: "error", Status: http.StatusBadRequest, Error: "Dữ liệu không hợp lệ"}
+		if err := json.Unmarshal(data, &req); err == nil {
+			reply = h.handleWSRequest(ctx, userID, req)
+		}
+		if err := c.send(reply); err != nil {
+			return
+		}
+	}
+}
+
+// handleWSRequest thực hiện một yêu cầu và trả về ack hoặc error mang ID của yêu cầu
+func (h *Handler) handleWSRequest(ctx context.Context, userID int, req wsRequest) wsMessage {
+	fail := func(code int, message string) wsMessage {
+		return wsMessage{Type: "error", ID: req.ID, Status: code, Error: message}
+	}
+	if req.ID == "" {
+		return fail(http.StatusBadRequest, "Thiếu id của yêu cầu")
+	}
+	switch req.Type {
+	case wsPing, wsTaskCreate:
+	case wsTaskUpdate, wsTaskPatch, wsTaskComplete, wsTaskDelete:
+		if req.TaskID <= 0 {
+			return fail(http.StatusBadRequest, "Thiếu task_id")
+		}
+	default:
+		return fail(http.StatusBadRequest, "Loại yêu cầu không được hỗ trợ: "+req.Type)
+	}
+
+	var (
+		task   models.Task
+		status = http.StatusOK
+		err    error
+	)
+	switch req.Type {
+	case wsPing:
+		return wsMessage{Type: "ack", ID: req.ID, Status: http.StatusOK}
+	case wsTaskCreate, wsTaskUpdate:
+		if err := json.Unmarshal(req.Data, &task); err != nil {
+			return fail(http.StatusBadRequest, "Dữ liệu không hợp lệ: "+err.Error())
+		}
+		if req.Type == wsTaskCreate {
+			task, err = h.createTask(ctx, userID, task)
+			status = http.StatusCreated
+		} else {
+			task, err = h.updateTask(ctx, userID, req.TaskID, task)
+		}
+	case wsTaskPatch:
+		task, err = h.patchTask(ctx, userID, req.TaskID, req.Data)
+	case wsTaskComplete:
+		task, err = h.completeTask(ctx, userID, req.TaskID)
+	case wsTaskDelete:
+		if err := h.deleteTask(ctx, userID, req.TaskID); err != nil {
+			code, message := serviceErrorStatus(err)
+			return fail(code, message)
+		}
+		return wsMessage{Type: "ack", ID: req.ID, Status: http.StatusOK, Data: map[string]int{"task_id": req.TaskID}}
+	}
+	if err != nil {
+		code, message := serviceErrorStatus(err)
+		return fail(code, message)
+	}
+
+	task.In(h.locationOf(ctx, userID))
+	return wsMessage{Type: "ack", ID: req.ID, Status: status, Data: task}
+}
@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsReply là message server gửi qua WebSocket
+type wsReply struct {
+	Type   string          `json:"type"`
+	ID     string          `json:"id"`
+	Status int             `json:"status"`
+	Data   json.RawMessage `json:"data"`
+	Error  string          `json:"error"`
+	Event  *struct {
+		Type string          `json:"type"`
+		Data json.RawMessage `json:"data"`
+	} `json:"event"`
+}
+
+// dialWS mở kết nối WebSocket tới /ws của user
+func (a *testAPI) dialWS(user testUser) *websocket.Conn {
+	a.t.Helper()
+	url := "ws" + strings.TrimPrefix(a.server.URL, "http") + path("/api/users/%d/ws", user.ID)
+	conn, resp, err := websocket.DefaultDialer.DialContext(a.t.Context(), url,
+		http.Header{"Authorization": {"Bearer " + user.Token}})
+	if err != nil {
+		a.t.Fatalf("kết nối WebSocket: %v", err)
+	}
+	resp.Body.Close()
+	a.t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// wsRequest gửi một yêu cầu và đọc tới khi nhận phản hồi cùng id; các sự kiện đến
+// trước phản hồi được trả về kèm theo
+func wsRequest(t *testing.T, conn *websocket.Conn, req map[string]any) (wsReply, []wsReply) {
+	t.Helper()
+	if err := conn.WriteJSON(req); err != nil {
+		t.Fatalf("gửi %v: %v", req, err)
+	}
+	var seen []wsReply
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var msg wsReply
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("đọc phản hồi của %v: %v", req["id"], err)
+		}
+		if msg.ID == req["id"] {
+			return msg, seen
+		}
+		seen = append(seen, msg)
+	}
+}
+
+func TestLiveSyncComplete(t *testing.T) {
+	a := newTestAPI(t)
+	user := a.signup("alice")
+
+	var task models.Task
+	a.decode(a.mustDo(user.Token, "POST", "/api/tasks", map[string]any{
+		"title":       "Viết báo cáo",
+		"description": "Báo cáo quý",
+		"deadline":    time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339),
+	}, http.StatusCreated), &task)
+
+	conn := a.dialWS(user)
+
+	// task.complete hoàn thành công việc Pending dù workflow yêu cầu đi qua In Progress
+	reply, seen := wsRequest(t, conn, map[string]any{"id": "c-1", "type": "task.complete", "task_id": task.ID})
+	if reply.Type != "ack" || reply.Status != http.StatusOK {
+		t.Fatalf("task.complete: %+v", reply)
+	}
+	var completed models.Task
+	if err := json.Unmarshal(reply.Data, &completed); err != nil || completed.Status != models.StatusCompleted || completed.CompletedAt == nil {
+		t.Fatalf("công việc sau task.complete: %s", reply.Data)
+	}
+
+	// Sự kiện của chính yêu cầu cũng được đẩy xuống, trước hoặc sau ack
+	reply, more := wsRequest(t, conn, map[string]any{"id": "c-2", "type": "ping"})
+	if reply.Type != "ack" {
+		t.Fatalf("ping: %+v", reply)
+	}
+	events := map[string]bool{}
+	for _, msg := range append(seen, more...) {
+		if msg.Type == "event" && msg.Event != nil {
+			events[msg.Event.Type] = true
+		}
+	}
+	if !events["task.updated"] || !events["task.completed"] {
+		t.Errorf("sự kiện nhận được: %v, muốn task.updated và task.completed", events)
+	}
+
+	a.mustDo(user.Token, "PATCH", path("/api/tasks/%d", task.ID), map[string]any{"status": "Pending"},
+		http.StatusOK, "Content-Type", "application/merge-patch+json")
+
+	for _, tc := range []struct {
+		req  map[string]any
+		want int
+	}{
+		{map[string]any{"id": "c-3", "type": "task.complete", "task_id": task.ID + 100}, http.StatusNotFound},
+		{map[string]any{"id": "c-4", "type": "task.complete"}, http.StatusBadRequest},
+		{map[string]any{"id": "c-5", "type": "task.archive", "task_id": task.ID}, http.StatusBadRequest},
+		{map[string]any{"id": "c-6", "type": "task.patch", "task_id": task.ID, "data": map[string]any{"status": "Completed"}},
+			http.StatusUnprocessableEntity},
+	} {
+		if reply, _ := wsRequest(t, conn, tc.req); reply.Type != "error" || reply.Status != tc.want {
+			t.Errorf("%v: %+v, muốn lỗi %d", tc.req, reply, tc.want)
+		}
+	}
+}
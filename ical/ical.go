@@ -0,0 +1,90 @@
+// Package ical ghi dữ liệu iCalendar (RFC 5545): dòng kết thúc bằng CRLF, dòng dài
+// hơn 75 octet được gấp lại và giá trị TEXT được escape.
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets là độ dài tối đa của một dòng, không tính CRLF (RFC 5545 mục 3.1)
+const maxLineOctets = 75
+
+// timeFormat là dạng DATE-TIME theo UTC (hậu tố Z), không cần VTIMEZONE
+const timeFormat = "20060102T150405Z"
+
+// Writer ghi lần lượt các component và property vào bộ đệm
+type Writer struct {
+	buf bytes.Buffer
+}
+
+// Begin mở component name (VCALENDAR, VTODO, VEVENT, VALARM...)
+func (w *Writer) Begin(name string) {
+	w.Line("BEGIN", name)
+}
+
+// End đóng component name
+func (w *Writer) End(name string) {
+	w.Line("END", name)
+}
+
+// Line ghi property với giá trị đã đúng định dạng. name có thể kèm tham số,
+// ví dụ "TRIGGER;VALUE=DATE-TIME".
+func (w *Writer) Line(name, value string) {
+	line := name + ":" + value
+	for len(line) > maxLineOctets {
+		// Không cắt giữa một ký tự UTF-8 nhiều byte
+		cut := maxLineOctets
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.buf.WriteString(line[:cut])
+		w.buf.WriteString("\r\n")
+		// Dòng gấp bắt đầu bằng một khoảng trắng, khoảng trắng này tính vào 75 octet
+		line = " " + line[cut:]
+	}
+	w.buf.WriteString(line)
+	w.buf.WriteString("\r\n")
+}
+
+// Text ghi property kiểu TEXT sau khi escape giá trị
+func (w *Writer) Text(name, value string) {
+	w.Line(name, Escape(value))
+}
+
+// Time ghi property kiểu DATE-TIME theo UTC
+func (w *Writer) Time(name string, t time.Time) {
+	w.Line(name, FormatTime(t))
+}
+
+// Bytes trả về dữ liệu đã ghi
+func (w *Writer) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// Escape escape giá trị TEXT (RFC 5545 mục 3.3.11) và bỏ các ký tự điều khiển
+// không được phép (trừ tab)
+func Escape(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r < 0x20 && r != '\t') || r == 0x7f {
+			return -1
+		}
+		return r
+	}, textEscaper.Replace(s))
+}
+
+// FormatTime định dạng t thành DATE-TIME theo UTC, ví dụ 20240131T090000Z
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
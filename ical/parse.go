@@ -0,0 +1,211 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Component là một component đã đọc (VCALENDAR, VTODO...) cùng các component con
+type Component struct {
+	Name       string
+	Props      []Property
+	Components []*Component
+}
+
+// Property là một dòng "NAME;PARAM=value:giá trị". Tên và tên tham số được viết hoa;
+// Value giữ nguyên dạng đã escape.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Prop trả về property đầu tiên có tên name, nil nếu không có
+func (c *Component) Prop(name string) *Property {
+	for i := range c.Props {
+		if c.Props[i].Name == name {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// Component trả về component con đầu tiên có tên name, nil nếu không có
+func (c *Component) Component(name string) *Component {
+	for _, child := range c.Components {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Text trả về giá trị TEXT đã bỏ escape
+func (p *Property) Text() string {
+	return Unescape(p.Value)
+}
+
+// Time đọc giá trị DATE hoặc DATE-TIME. Giá trị có hậu tố Z là UTC; giá trị có TZID
+// hoặc giờ "floating" được hiểu theo múi giờ IANA của TZID, nếu không nhận ra thì theo loc.
+func (p *Property) Time(loc *time.Location) (time.Time, error) {
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(strings.Trim(tzid, `"`)); err == nil {
+			loc = tz
+		}
+	}
+	value := p.Value
+	switch {
+	case p.Params["VALUE"] == "DATE" || len(value) == len("20060102"):
+		return time.ParseInLocation("20060102", value, loc)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(timeFormat, value)
+	default:
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+}
+
+// Unescape bỏ escape của giá trị TEXT
+func Unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Parse đọc một đối tượng iCalendar (thường là một VCALENDAR)
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	var stack []*Component
+	for n, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("dòng %d: %w", n+1, err)
+		}
+		switch prop.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(prop.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			} else if root != nil {
+				return nil, errors.New("có nhiều hơn một component gốc")
+			} else {
+				root = component
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(prop.Value) {
+				return nil, fmt.Errorf("dòng %d: END:%s không khớp", n+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("dòng %d: property nằm ngoài component", n+1)
+			}
+			current := stack[len(stack)-1]
+			current.Props = append(current.Props, prop)
+		}
+	}
+	if root == nil {
+		return nil, errors.New("không có dữ liệu iCalendar")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("thiếu END:%s", stack[len(stack)-1].Name)
+	}
+	return root, nil
+}
+
+// unfold ghép các dòng bị gấp (dòng bắt đầu bằng khoảng trắng hoặc tab) và bỏ dòng trống
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine tách một dòng thành tên, tham số và giá trị. Dấu ":" và ";" nằm trong
+// tham số được đặt trong ngoặc kép không được coi là dấu phân cách.
+func parseLine(line string) (Property, error) {
+	prop := Property{Params: map[string]string{}}
+	quoted := false
+	nameEnd := -1
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';' && nameEnd < 0:
+			nameEnd = i
+		case c == ':':
+			if nameEnd < 0 {
+				nameEnd = i
+			}
+			prop.Name = strings.ToUpper(line[:nameEnd])
+			prop.Value = line[i+1:]
+			for _, param := range splitParams(line[nameEnd:i]) {
+				key, value, _ := strings.Cut(param, "=")
+				prop.Params[strings.ToUpper(key)] = value
+			}
+			if prop.Name == "" {
+				return Property{}, errors.New("thiếu tên property")
+			}
+			return prop, nil
+		}
+	}
+	return Property{}, errors.New("thiếu dấu ':'")
+}
+
+// splitParams tách chuỗi ";A=1;B=\"x;y\"" thành các tham số
+func splitParams(s string) []string {
+	var params []string
+	quoted := false
+	start := -1
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ';' && !quoted:
+			if start >= 0 {
+				params = append(params, s[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start >= 0 && start < len(s) {
+		params = append(params, s[start:])
+	}
+	return params
+}
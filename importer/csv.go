@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"backend/models"
+)
+
+// parseCSV đọc file CSV bất kỳ: dòng đầu là tên cột, opts.Mapping cho biết cột nào
+// chứa trường nào của công việc
+func parseCSV(data []byte, opts Options, result *Result) error {
+	mapping := opts.Mapping
+	if mapping.Title == "" {
+		return errors.New("mapping phải có cột title")
+	}
+
+	reader := newCSVReader(data)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("CSV không hợp lệ: %w", err)
+	}
+	columns := csvColumns(header)
+	for field, column := range map[string]string{
+		"title": mapping.Title, "description": mapping.Description, "deadline": mapping.Deadline,
+		"priority": mapping.Priority, "status": mapping.Status, "category": mapping.Category,
+		"completed_at": mapping.CompletedAt,
+	} {
+		if column != "" && csvValue(header, columns, column) == "" {
+			return fmt.Errorf("không có cột %q (mapping %s)", column, field)
+		}
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("dòng %d: %w", line, err)
+		}
+		where := fmt.Sprintf("dòng %d", line)
+
+		item := Item{
+			List:        csvValue(record, columns, mapping.Category),
+			Title:       csvValue(record, columns, mapping.Title),
+			Description: csvValue(record, columns, mapping.Description),
+		}
+		if item.List == "" {
+			item.List = opts.List
+		}
+		if value := csvValue(record, columns, mapping.Priority); value != "" {
+			priority, ok := parsePriority(value)
+			if !ok {
+				result.warn("%s: độ ưu tiên không hợp lệ %q, dùng mặc định", where, value)
+			}
+			item.Priority = priority
+		}
+		if value := csvValue(record, columns, mapping.Status); value != "" {
+			status, ok := parseStatus(value)
+			if !ok {
+				result.warn("%s: trạng thái không hợp lệ %q, coi là chưa hoàn thành", where, value)
+			}
+			item.Completed = status == models.StatusCompleted
+		}
+		if value := csvValue(record, columns, mapping.Deadline); value != "" {
+			if deadline, err := parseTime(value, mapping.DateFormat, opts.Location); err == nil {
+				item.Deadline = &deadline
+			} else {
+				result.warn("%s: %v", where, err)
+			}
+		}
+		if value := csvValue(record, columns, mapping.CompletedAt); value != "" {
+			if at, err := parseTime(value, mapping.DateFormat, opts.Location); err == nil {
+				item.CompletedAt = &at
+				item.Completed = true
+			} else {
+				result.warn("%s: %v", where, err)
+			}
+		}
+		result.add(item, where)
+	}
+}
@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type googleTaskLists struct {
+	Items []struct {
+		Title string `json:"title"`
+		Items []struct {
+			Title     string `json:"title"`
+			Notes     string `json:"notes"`
+			Status    string `json:"status"`
+			Due       string `json:"due"`
+			Completed string `json:"completed"`
+			Deleted   bool   `json:"deleted"`
+		} `json:"items"`
+	} `json:"items"`
+}
+
+// parseGoogleTasks đọc Tasks.json của Google Takeout. Google Tasks chỉ lưu ngày hạn
+// (phần giờ luôn là 00:00 UTC) nên hạn được đặt vào đầu ngày đó theo múi giờ của người dùng.
+func parseGoogleTasks(data []byte, opts Options, result *Result) error {
+	var takeout googleTaskLists
+	if err := json.Unmarshal(data, &takeout); err != nil {
+		return fmt.Errorf("JSON của Google Tasks không hợp lệ: %w", err)
+	}
+
+	for _, list := range takeout.Items {
+		for i, task := range list.Items {
+			where := fmt.Sprintf("%s, mục %d", list.Title, i+1)
+			if task.Deleted {
+				result.Skipped++
+				continue
+			}
+			item := Item{
+				List:        list.Title,
+				Title:       task.Title,
+				Description: task.Notes,
+				Completed:   task.Status == "completed",
+			}
+			if task.Due != "" {
+				due, err := time.Parse(time.RFC3339Nano, task.Due)
+				if err == nil {
+					deadline := time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, opts.Location)
+					item.Deadline = &deadline
+				} else {
+					result.warn("%s: thời gian không hợp lệ: %q", where, task.Due)
+				}
+			}
+			if task.Completed != "" {
+				if at, err := time.Parse(time.RFC3339Nano, task.Completed); err == nil {
+					item.CompletedAt = &at
+				}
+			}
+			result.add(item, where)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+// Package importer đọc dữ liệu xuất từ Todoist, Trello, Google Tasks và file CSV bất
+// kỳ thành danh sách công việc trung gian. Package không ghi gì vào database; việc
+// ánh xạ sang danh mục/công việc và lưu do handler thực hiện.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/models"
+)
+
+// Format là định dạng dữ liệu nguồn
+type Format string
+
+const (
+	// Todoist là JSON của Todoist (Sync API: projects + items, hoặc mảng task của REST API)
+	Todoist Format = "todoist"
+	// TodoistCSV là file CSV khi xuất một project (cột TYPE, CONTENT, PRIORITY, DATE...)
+	TodoistCSV Format = "todoist-csv"
+	// Trello là JSON xuất từ một board (lists + cards)
+	Trello Format = "trello"
+	// GoogleTasks là Tasks.json trong Google Takeout
+	GoogleTasks Format = "google-tasks"
+	// CSV là file CSV bất kỳ, đọc theo Mapping
+	CSV Format = "csv"
+)
+
+// Formats liệt kê các định dạng được hỗ trợ
+var Formats = []Format{Todoist, TodoistCSV, Trello, GoogleTasks, CSV}
+
+func (f Format) Valid() bool {
+	for _, format := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Giới hạn độ dài theo lược đồ database
+const (
+	maxTitleLength    = 255
+	maxListNameLength = 100
+	// maxWarnings giới hạn số cảnh báo trả về cho một lần nhập
+	maxWarnings = 50
+)
+
+// Item là một công việc đọc được. List là tên danh sách/project chứa công việc,
+// rỗng nếu nguồn không có danh sách.
+type Item struct {
+	List        string
+	Title       string
+	Description string
+	Deadline    *time.Time
+	// Priority rỗng nghĩa là dùng độ ưu tiên mặc định
+	Priority    models.TaskPriority
+	Completed   bool
+	CompletedAt *time.Time
+}
+
+// Result là kết quả đọc một file
+type Result struct {
+	Format Format
+	// Lists là tên các danh sách theo thứ tự xuất hiện, không trùng
+	Lists []string
+	Items []Item
+	// Skipped đếm các mục bị bỏ qua (đã lưu trữ, đã xóa, thiếu tiêu đề...)
+	Skipped  int
+	Warnings []string
+}
+
+// Mapping ánh xạ trường của công việc sang tên cột trong file CSV
+type Mapping struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Deadline    string `json:"deadline"`
+	Priority    string `json:"priority"`
+	Status      string `json:"status"`
+	Category    string `json:"category"`
+	CompletedAt string `json:"completed_at"`
+	// DateFormat là layout Go của các cột thời gian; rỗng thì thử các dạng phổ biến
+	DateFormat string `json:"date_format"`
+}
+
+// Options điều khiển việc đọc
+type Options struct {
+	// Format rỗng thì tự nhận dạng theo nội dung
+	Format Format
+	// List là tên danh sách cho công việc mà nguồn không gắn danh sách (CSV của Todoist...)
+	List    string
+	Mapping Mapping
+	// Location là múi giờ của các thời điểm không kèm múi giờ
+	Location *time.Location
+}
+
+// Parse đọc dữ liệu theo định dạng trong opts
+func Parse(data []byte, opts Options) (*Result, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.Format == "" {
+		format, err := Detect(data)
+		if err != nil {
+			return nil, err
+		}
+		opts.Format = format
+	}
+
+	result := &Result{Format: opts.Format}
+	var err error
+	switch opts.Format {
+	case Todoist:
+		err = parseTodoist(data, opts, result)
+	case TodoistCSV:
+		err = parseTodoistCSV(data, opts, result)
+	case Trello:
+		err = parseTrello(data, opts, result)
+	case GoogleTasks:
+		err = parseGoogleTasks(data, opts, result)
+	case CSV:
+		err = parseCSV(data, opts, result)
+	default:
+		return nil, fmt.Errorf("định dạng không được hỗ trợ: %q", opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Detect nhận dạng định dạng theo nội dung file
+func Detect(data []byte) (Format, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(data) == 0 {
+		return "", errors.New("file rỗng")
+	}
+	switch data[0] {
+	case '[':
+		return Todoist, nil
+	case '{':
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return "", fmt.Errorf("JSON không hợp lệ: %w", err)
+		}
+		var kind string
+		json.Unmarshal(fields["kind"], &kind)
+		switch {
+		case fields["cards"] != nil && fields["lists"] != nil:
+			return Trello, nil
+		case kind == "tasks#taskLists":
+			return GoogleTasks, nil
+		case fields["items"] != nil || fields["projects"] != nil:
+			return Todoist, nil
+		}
+		return "", errors.New("không nhận dạng được file JSON")
+	}
+
+	header, err := newCSVReader(data).Read()
+	if err != nil {
+		return "", fmt.Errorf("CSV không hợp lệ: %w", err)
+	}
+	columns := csvColumns(header)
+	if _, ok := columns["type"]; ok {
+		if _, ok := columns["content"]; ok {
+			return TodoistCSV, nil
+		}
+	}
+	return CSV, nil
+}
+
+// add thêm một công việc; công việc không có tiêu đề bị bỏ qua
+func (r *Result) add(item Item, where string) {
+	item.Title = strings.TrimSpace(item.Title)
+	item.List = truncate(strings.TrimSpace(item.List), maxListNameLength)
+	if item.Title == "" {
+		r.skip("%s: thiếu tiêu đề", where)
+		return
+	}
+	if utf8.RuneCountInString(item.Title) > maxTitleLength {
+		r.warn("%s: tiêu đề dài hơn %d ký tự đã bị cắt", where, maxTitleLength)
+		item.Title = truncate(item.Title, maxTitleLength)
+	}
+	if item.List != "" {
+		r.addList(item.List)
+	}
+	r.Items = append(r.Items, item)
+}
+
+func (r *Result) addList(name string) {
+	for _, list := range r.Lists {
+		if list == name {
+			return
+		}
+	}
+	r.Lists = append(r.Lists, name)
+}
+
+func (r *Result) skip(format string, args ...any) {
+	r.Skipped++
+	r.warn(format, args...)
+}
+
+func (r *Result) warn(format string, args ...any) {
+	if len(r.Warnings) < maxWarnings {
+		r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// timeLayouts là các dạng thời gian được thử khi không có layout cụ thể. Ngày dạng
+// dd/mm/yyyy theo thói quen ở Việt Nam.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006 15:04",
+	"02/01/2006",
+}
+
+// parseTime đọc thời điểm theo layout (hoặc các dạng phổ biến); giá trị không kèm múi
+// giờ được hiểu theo loc
+func parseTime(value, layout string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	layouts := timeLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("thời gian không hợp lệ: %q", value)
+}
+
+// parsePriority đọc độ ưu tiên dạng chữ (tiếng Anh hoặc tiếng Việt) hoặc số 1-3 (1 là cao nhất)
+func parsePriority(value string) (models.TaskPriority, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		return "", true
+	case "high", "urgent", "cao", "gấp", "1":
+		return models.PriorityHigh, true
+	case "medium", "normal", "trung bình", "2":
+		return models.PriorityMedium, true
+	case "low", "thấp", "3":
+		return models.PriorityLow, true
+	}
+	return "", false
+}
+
+// parseStatus đọc trạng thái dạng chữ; giá trị lạ là chưa hoàn thành
+func parseStatus(value string) (models.TaskStatus, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "pending", "todo", "to do", "open", "false", "no", "0", "chưa làm":
+		return models.StatusPending, true
+	case "in progress", "doing", "started", "đang làm":
+		return models.StatusInProgress, true
+	case "completed", "complete", "done", "closed", "x", "true", "yes", "1", "hoàn thành", "xong":
+		return models.StatusCompleted, true
+	}
+	return models.StatusPending, false
+}
+
+// newCSVReader đọc CSV phân tách bằng dấu phẩy, hoặc dấu chấm phẩy nếu dòng tiêu đề
+// chỉ có dấu chấm phẩy (Excel với locale châu Âu/Việt Nam)
+func newCSVReader(data []byte) *csv.Reader {
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(firstLine, []byte(";")) && !bytes.Contains(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	return reader
+}
+
+// csvColumns trả về vị trí các cột theo tên (không phân biệt hoa thường)
+func csvColumns(header []string) map[string]int {
+	columns := map[string]int{}
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[key]; !ok {
+			columns[key] = i
+		}
+	}
+	return columns
+}
+
+// csvValue trả về giá trị của cột name trong record, rỗng nếu không có
+func csvValue(record []string, columns map[string]int, name string) string {
+	i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+	if !ok || name == "" || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,177 @@
+package importer_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/importer"
+	"backend/models"
+)
+
+// parse đọc data và dừng test nếu lỗi
+func parse(t *testing.T, data string, opts importer.Options) *importer.Result {
+	t.Helper()
+	result, err := importer.Parse([]byte(data), opts)
+	if err != nil {
+		t.Fatalf("đọc %s: %v", opts.Format, err)
+	}
+	return result
+}
+
+func TestDetect(t *testing.T) {
+	for data, want := range map[string]importer.Format{
+		`[{"content": "Mua sữa"}]`:                      importer.Todoist,
+		`{"projects": [], "items": []}`:                 importer.Todoist,
+		`{"name": "Board", "lists": [], "cards": []}`:   importer.Trello,
+		`{"kind": "tasks#taskLists", "items": []}`:      importer.GoogleTasks,
+		"TYPE,CONTENT,PRIORITY\ntask,Mua sữa,1\n":       importer.TodoistCSV,
+		"\xef\xbb\xbfTiêu đề;Hạn\nMua sữa;02/01/2030\n": importer.CSV,
+	} {
+		if got, err := importer.Detect([]byte(data)); err != nil || got != want {
+			t.Errorf("Detect(%q) = %q, %v; muốn %q", data, got, err, want)
+		}
+	}
+	for _, data := range []string{"", "  ", `{"foo": 1}`, `{không phải JSON`} {
+		if got, err := importer.Detect([]byte(data)); err == nil {
+			t.Errorf("Detect(%q) = %q, muốn lỗi", data, got)
+		}
+	}
+}
+
+func TestParseTodoist(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+	result := parse(t, `{
+		"projects": [{"id": "p1", "name": "Công việc"}],
+		"items": [
+			{"id": "1", "content": "Viết báo cáo", "project_id": "p1", "priority": 4, "due": {"date": "2030-01-02T09:00:00"}},
+			{"id": 2, "content": "Gọi khách hàng", "project_id": "p9", "priority": 1, "due": {"date": "2030-01-02T09:00:00", "timezone": "Europe/Berlin"}},
+			{"id": "3", "content": "Đã xong", "project_id": "p1", "checked": true, "completed_at": "2029-12-31T10:00:00Z"},
+			{"id": "4", "content": "Đã xóa", "is_deleted": true},
+			{"id": "5", "content": "  ", "due": {"date": "ngày mai"}}
+		]}`, importer.Options{Format: importer.Todoist, List: "Hộp thư", Location: loc})
+
+	if len(result.Items) != 3 || result.Skipped != 2 || !slices.Equal(result.Lists, []string{"Công việc", "Hộp thư"}) {
+		t.Fatalf("kết quả: %d mục, bỏ qua %d, danh sách %q", len(result.Items), result.Skipped, result.Lists)
+	}
+	report, call, done := result.Items[0], result.Items[1], result.Items[2]
+	if report.List != "Công việc" || report.Priority != models.PriorityHigh ||
+		!report.Deadline.Equal(time.Date(2030, 1, 2, 9, 0, 0, 0, loc)) {
+		t.Errorf("mục có project: %+v", report)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	if call.List != "Hộp thư" || call.Priority != models.PriorityLow || !call.Deadline.Equal(time.Date(2030, 1, 2, 9, 0, 0, 0, berlin)) {
+		t.Errorf("mục có timezone riêng: %+v", call)
+	}
+	if !done.Completed || done.CompletedAt == nil || !done.CompletedAt.Equal(time.Date(2029, 12, 31, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("mục đã hoàn thành: %+v", done)
+	}
+	// Mảng task của REST API cũng được nhận
+	if rest := parse(t, `[{"id": "7", "content": "Mua sữa", "priority": 2}]`, importer.Options{}); rest.Format != importer.Todoist ||
+		len(rest.Items) != 1 || rest.Items[0].Priority != models.PriorityMedium {
+		t.Errorf("REST API: %+v", rest)
+	}
+}
+
+func TestParseTodoistCSV(t *testing.T) {
+	result := parse(t, "TYPE,CONTENT,DESCRIPTION,PRIORITY,DATE,TIMEZONE\n"+
+		"task,Viết báo cáo,Báo cáo quý,1,2030-01-02 09:00,Asia/Ho_Chi_Minh\n"+
+		"note,Gửi cho sếp,,,,\n"+
+		"note,Kèm số liệu,,,,\n"+
+		"task,Tập thể dục,,4,every day,\n"+
+		"section,Tuần sau,,,,\n", importer.Options{Format: importer.TodoistCSV, List: "Dự án"})
+
+	if len(result.Items) != 2 || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "every day") {
+		t.Fatalf("kết quả: %+v", result)
+	}
+	report, workout := result.Items[0], result.Items[1]
+	loc, _ := time.LoadLocation("Asia/Ho_Chi_Minh")
+	if report.List != "Dự án" || report.Priority != models.PriorityHigh || report.Description != "Báo cáo quý\n\nGửi cho sếp\n\nKèm số liệu" ||
+		!report.Deadline.Equal(time.Date(2030, 1, 2, 9, 0, 0, 0, loc)) {
+		t.Errorf("công việc kèm ghi chú: %+v", report)
+	}
+	if workout.Priority != models.PriorityLow || workout.Deadline != nil {
+		t.Errorf("công việc có ngày dạng ngôn ngữ tự nhiên: %+v", workout)
+	}
+}
+
+func TestParseTrello(t *testing.T) {
+	result := parse(t, `{
+		"name": "Dự án",
+		"lists": [{"id": "l1", "name": "Cần làm"}, {"id": "l2", "name": "Lưu trữ", "closed": true}],
+		"cards": [
+			{"name": "Thiết kế", "desc": "Bản nháp", "idList": "l1", "due": "2030-01-02T02:00:00.000Z", "labels": [{"name": "", "color": "red"}]},
+			{"name": "Kiểm thử", "idList": "l1", "dueComplete": true, "labels": [{"name": "Thấp", "color": "red"}]},
+			{"name": "Thẻ đã lưu trữ", "idList": "l1", "closed": true},
+			{"name": "Trong list đã lưu trữ", "idList": "l2"},
+			{"name": "Sai ngày", "idList": "l1", "due": "hôm qua"}
+		]}`, importer.Options{})
+
+	if result.Format != importer.Trello || len(result.Items) != 3 || result.Skipped != 2 || !slices.Equal(result.Lists, []string{"Cần làm"}) {
+		t.Fatalf("kết quả: %+v", result)
+	}
+	design, review, invalid := result.Items[0], result.Items[1], result.Items[2]
+	if design.Priority != models.PriorityHigh || design.Description != "Bản nháp" ||
+		!design.Deadline.Equal(time.Date(2030, 1, 2, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("thẻ nhãn đỏ: %+v", design)
+	}
+	// Tên nhãn được ưu tiên hơn màu nhãn
+	if review.Priority != models.PriorityLow || !review.Completed {
+		t.Errorf("thẻ có nhãn Thấp: %+v", review)
+	}
+	if invalid.Deadline != nil || len(result.Warnings) != 1 {
+		t.Errorf("thẻ sai ngày: %+v, cảnh báo %q", invalid, result.Warnings)
+	}
+}
+
+func TestParseGoogleTasks(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	result := parse(t, `{"kind": "tasks#taskLists", "items": [
+		{"title": "Việc nhà", "items": [
+			{"title": "Dọn nhà", "notes": "Phòng khách", "status": "needsAction", "due": "2030-01-02T00:00:00.000Z"},
+			{"title": "Rửa xe", "status": "completed", "completed": "2029-12-30T08:00:00.000Z"},
+			{"title": "Đã xóa", "deleted": true}
+		]}]}`, importer.Options{Location: loc})
+
+	if len(result.Items) != 2 || result.Skipped != 1 || !slices.Equal(result.Lists, []string{"Việc nhà"}) {
+		t.Fatalf("kết quả: %+v", result)
+	}
+	// Hạn chỉ có ngày nên được đặt vào đầu ngày theo múi giờ của người dùng
+	clean, wash := result.Items[0], result.Items[1]
+	if clean.Description != "Phòng khách" || clean.Completed || !clean.Deadline.Equal(time.Date(2030, 1, 2, 0, 0, 0, 0, loc)) {
+		t.Errorf("công việc có hạn: %+v", clean)
+	}
+	if !wash.Completed || wash.CompletedAt == nil || wash.Deadline != nil {
+		t.Errorf("công việc đã hoàn thành: %+v", wash)
+	}
+}
+
+func TestParseCSVMapping(t *testing.T) {
+	mapping := importer.Mapping{
+		Title: "Tiêu đề", Deadline: "Hạn", Priority: "Mức độ", Status: "Trạng thái", Category: "Nhóm",
+		DateFormat: "02/01/2006 15:04",
+	}
+	result := parse(t, "Tiêu đề;Hạn;Mức độ;Trạng thái;Nhóm\n"+
+		"Viết báo cáo;02/01/2030 09:00;Cao;chưa làm;Công việc\n"+
+		"Mua sữa;2030-01-03;khẩn cấp;xong;\n"+
+		";02/01/2030 09:00;;;\n", importer.Options{Format: importer.CSV, Mapping: mapping, List: "Khác"})
+
+	if len(result.Items) != 2 || result.Skipped != 1 || !slices.Equal(result.Lists, []string{"Công việc", "Khác"}) {
+		t.Fatalf("kết quả: %+v", result)
+	}
+	report, milk := result.Items[0], result.Items[1]
+	if report.Priority != models.PriorityHigh || report.Completed || !report.Deadline.Equal(time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("dòng đầy đủ: %+v", report)
+	}
+	// Giá trị không đọc được chỉ sinh cảnh báo
+	if milk.List != "Khác" || milk.Priority != "" || !milk.Completed || milk.Deadline != nil || len(result.Warnings) != 3 {
+		t.Errorf("dòng có giá trị sai: %+v, cảnh báo %q", milk, result.Warnings)
+	}
+
+	for _, mapping := range []importer.Mapping{{}, {Title: "Tên"}} {
+		if _, err := importer.Parse([]byte("Tiêu đề\nMua sữa\n"), importer.Options{Format: importer.CSV, Mapping: mapping}); err == nil {
+			t.Errorf("mapping %+v: muốn lỗi", mapping)
+		}
+	}
+}
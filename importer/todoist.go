@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"backend/models"
+)
+
+type todoistDue struct {
+	Date     string `json:"date"`
+	Timezone string `json:"timezone"`
+}
+
+type todoistItem struct {
+	ID          json.RawMessage `json:"id"`
+	Content     string          `json:"content"`
+	Description string          `json:"description"`
+	ProjectID   json.RawMessage `json:"project_id"`
+	Priority    int             `json:"priority"`
+	Due         *todoistDue     `json:"due"`
+	Checked     bool            `json:"checked"`
+	IsCompleted bool            `json:"is_completed"`
+	CompletedAt string          `json:"completed_at"`
+	IsDeleted   bool            `json:"is_deleted"`
+}
+
+type todoistProject struct {
+	ID   json.RawMessage `json:"id"`
+	Name string          `json:"name"`
+}
+
+// todoistPriority ánh xạ priority của Todoist API (4 là p1, cao nhất; 1 là p4, mặc định)
+func todoistPriority(priority int) models.TaskPriority {
+	switch priority {
+	case 4:
+		return models.PriorityHigh
+	case 2, 3:
+		return models.PriorityMedium
+	case 1:
+		return models.PriorityLow
+	}
+	return ""
+}
+
+// rawID chuẩn hóa ID của Todoist (số ở API cũ, chuỗi ở API mới)
+func rawID(raw json.RawMessage) string {
+	return strings.Trim(string(raw), `"`)
+}
+
+// todoistTime đọc ngày hạn của Todoist: "2024-01-31", "2024-01-31T10:00:00" (theo
+// timezone của hạn hoặc loc) hoặc "2024-01-31T10:00:00Z"
+func todoistTime(due *todoistDue, loc *time.Location) (time.Time, error) {
+	if due.Timezone != "" {
+		if tz, err := time.LoadLocation(due.Timezone); err == nil {
+			loc = tz
+		}
+	}
+	return parseTime(due.Date, "", loc)
+}
+
+// parseTodoist đọc JSON của Sync API ({"projects": [...], "items": [...]}) hoặc mảng
+// task của REST API
+func parseTodoist(data []byte, opts Options, result *Result) error {
+	var backup struct {
+		Projects []todoistProject `json:"projects"`
+		Items    []todoistItem    `json:"items"`
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		if err := json.Unmarshal(data, &backup.Items); err != nil {
+			return fmt.Errorf("JSON của Todoist không hợp lệ: %w", err)
+		}
+	} else if err := json.Unmarshal(data, &backup); err != nil {
+		return fmt.Errorf("JSON của Todoist không hợp lệ: %w", err)
+	}
+
+	projects := map[string]string{}
+	for _, project := range backup.Projects {
+		projects[rawID(project.ID)] = project.Name
+	}
+	for i, raw := range backup.Items {
+		where := fmt.Sprintf("mục %d", i+1)
+		if raw.IsDeleted {
+			result.Skipped++
+			continue
+		}
+		item := Item{
+			List:        projects[rawID(raw.ProjectID)],
+			Title:       raw.Content,
+			Description: raw.Description,
+			Priority:    todoistPriority(raw.Priority),
+			Completed:   raw.Checked || raw.IsCompleted,
+		}
+		if item.List == "" {
+			item.List = opts.List
+		}
+		if raw.Due != nil && raw.Due.Date != "" {
+			if deadline, err := todoistTime(raw.Due, opts.Location); err == nil {
+				item.Deadline = &deadline
+			} else {
+				result.warn("%s: %v", where, err)
+			}
+		}
+		if raw.CompletedAt != "" {
+			if at, err := parseTime(raw.CompletedAt, "", opts.Location); err == nil {
+				item.CompletedAt = &at
+			}
+		}
+		result.add(item, where)
+	}
+	return nil
+}
+
+// parseTodoistCSV đọc file CSV khi xuất một project của Todoist. Mỗi dòng TYPE=task là
+// một công việc, dòng TYPE=note được nối vào mô tả của công việc ngay trước nó. Cột
+// PRIORITY dùng số của giao diện (1 là p1, cao nhất) và cột DATE có thể là ngôn ngữ
+// tự nhiên ("every day"); giá trị không đọc được chỉ sinh cảnh báo.
+func parseTodoistCSV(data []byte, opts Options, result *Result) error {
+	reader := newCSVReader(data)
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("CSV không hợp lệ: %w", err)
+	}
+	columns := csvColumns(header)
+
+	var last *Item
+	lastLine := 0
+	flush := func() {
+		if last != nil {
+			result.add(*last, fmt.Sprintf("dòng %d", lastLine))
+			last = nil
+		}
+	}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("dòng %d: %w", line, err)
+		}
+
+		switch strings.ToLower(csvValue(record, columns, "type")) {
+		case "task":
+			flush()
+			item := Item{
+				List:        opts.List,
+				Title:       csvValue(record, columns, "content"),
+				Description: csvValue(record, columns, "description"),
+			}
+			switch csvValue(record, columns, "priority") {
+			case "1":
+				item.Priority = models.PriorityHigh
+			case "2", "3":
+				item.Priority = models.PriorityMedium
+			case "4":
+				item.Priority = models.PriorityLow
+			}
+			if date := csvValue(record, columns, "date"); date != "" {
+				loc := opts.Location
+				if name := csvValue(record, columns, "timezone"); name != "" {
+					if tz, err := time.LoadLocation(name); err == nil {
+						loc = tz
+					}
+				}
+				if deadline, err := parseTime(date, "", loc); err == nil {
+					item.Deadline = &deadline
+				} else {
+					result.warn("dòng %d: không đọc được ngày %q", line, date)
+				}
+			}
+			last, lastLine = &item, line
+		case "note":
+			if last != nil {
+				if note := csvValue(record, columns, "content"); note != "" {
+					last.Description = strings.TrimSpace(last.Description + "\n\n" + note)
+				}
+			}
+		}
+	}
+	flush()
+	return nil
+}
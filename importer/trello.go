@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/models"
+)
+
+type trelloBoard struct {
+	Name  string `json:"name"`
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		Name        string  `json:"name"`
+		Desc        string  `json:"desc"`
+		IDList      string  `json:"idList"`
+		Due         *string `json:"due"`
+		DueComplete bool    `json:"dueComplete"`
+		Closed      bool    `json:"closed"`
+		Labels      []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+}
+
+// trelloPriority suy ra độ ưu tiên từ nhãn của thẻ: theo tên nhãn, hoặc nhãn đỏ là cao
+func trelloPriority(names, colors []string) models.TaskPriority {
+	for _, name := range names {
+		if priority, ok := parsePriority(name); ok && priority != "" {
+			return priority
+		}
+	}
+	for _, color := range colors {
+		if strings.EqualFold(color, "red") {
+			return models.PriorityHigh
+		}
+	}
+	return ""
+}
+
+// parseTrello đọc JSON xuất từ một board: mỗi list là một danh sách, mỗi thẻ là một
+// công việc. Thẻ đã lưu trữ hoặc nằm trong list đã lưu trữ bị bỏ qua.
+func parseTrello(data []byte, opts Options, result *Result) error {
+	var board trelloBoard
+	if err := json.Unmarshal(data, &board); err != nil {
+		return fmt.Errorf("JSON của Trello không hợp lệ: %w", err)
+	}
+
+	lists := map[string]string{}
+	closed := map[string]bool{}
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+		closed[list.ID] = list.Closed
+	}
+	for i, card := range board.Cards {
+		where := fmt.Sprintf("thẻ %d", i+1)
+		if card.Closed || closed[card.IDList] {
+			result.Skipped++
+			continue
+		}
+
+		var names, colors []string
+		for _, label := range card.Labels {
+			names = append(names, label.Name)
+			colors = append(colors, label.Color)
+		}
+		item := Item{
+			List:        lists[card.IDList],
+			Title:       card.Name,
+			Description: card.Desc,
+			Priority:    trelloPriority(names, colors),
+			Completed:   card.DueComplete,
+		}
+		if item.List == "" {
+			item.List = opts.List
+		}
+		if card.Due != nil && *card.Due != "" {
+			if deadline, err := time.Parse(time.RFC3339Nano, *card.Due); err == nil {
+				item.Deadline = &deadline
+			} else {
+				result.warn("%s: thời gian không hợp lệ: %q", where, *card.Due)
+			}
+		}
+		result.add(item, where)
+	}
+	return nil
+}
@@ -3,12 +3,20 @@ package main
 import (
 	"backend/database"
 	"backend/handlers"
+	"backend/models"
+	"backend/notify"
+	"backend/store"
+	"backend/store/sqlstore"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-
-	"github.com/gorilla/mux"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 )
 
 func main() {
@@ -24,54 +32,148 @@ func main() {
 
 	fmt.Println("Kết nối cơ sở dữ liệu thành công!")
 
-	if err != nil {
-		log.Fatalf("Không thể khởi tạo cơ sở dữ liệu: %v", err)
+	// Lệnh quản lý lược đồ: go run . migrate up | down [n] | status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := database.RunMigrateCommand(db, os.Args[2:]); err != nil {
+			log.Fatalf("Lỗi migrate: %v", err)
+		}
+		return
+	}
+
+	// Không khởi động server khi lược đồ database còn cũ hơn mã nguồn
+	if err := database.CheckSchema(db); err != nil {
+		log.Fatalf("Không thể khởi động server: %v", err)
+	}
+
+	// Các handler nhận store qua struct thay vì dùng biến toàn cục
+	st := sqlstore.New(db)
+	h := handlers.New(st)
+
+	// Đồ thị chuyển trạng thái có thể ghi đè, ví dụ:
+	// TASK_TRANSITIONS="Pending>In Progress,In Progress>Completed,Completed>Pending"
+	if spec := os.Getenv("TASK_TRANSITIONS"); spec != "" {
+		workflow, err := models.ParseWorkflow(spec)
+		if err != nil {
+			log.Fatalf("Cấu hình TASK_TRANSITIONS không hợp lệ: %v", err)
+		}
+		h.Workflow = workflow
 	}
-	defer db.Close()
 
 	// Thiết lập router
-	router := mux.NewRouter()
-
-	// Router API cho User
-	router.HandleFunc("/api/users", handlers.CreateUser).Methods("POST")
-	router.HandleFunc("/api/users/{id}", handlers.GetUser).Methods("GET")
-	router.HandleFunc("/api/users/{id}", handlers.UpdateUser).Methods("PUT")
-	router.HandleFunc("/api/users/{id}", handlers.DeleteUser).Methods("DELETE")
-	router.HandleFunc("/api/users/{user_id}/tasks", handlers.GetUserTasks).Methods("GET")
-	router.HandleFunc("/api/users/{user_id}/categories", handlers.GetUserCategories).Methods("GET")
-	router.HandleFunc("/api/users/login", handlers.Login).Methods("POST")
-
-	// Router API cho Task
-	router.HandleFunc("/api/tasks", handlers.CreateTask).Methods("POST")
-	router.HandleFunc("/api/tasks/{id}", handlers.GetTask).Methods("GET")
-	router.HandleFunc("/api/tasks/{id}", handlers.UpdateTask).Methods("PUT")
-	router.HandleFunc("/api/tasks/{id}", handlers.DeleteTask).Methods("DELETE")
-
-	// Router API cho Category
-	router.HandleFunc("/api/categories", handlers.CreateCategory).Methods("POST")
-	router.HandleFunc("/api/categories/{id}", handlers.GetCategory).Methods("GET")
-	router.HandleFunc("/api/categories/{id}", handlers.UpdateCategory).Methods("PUT")
-	router.HandleFunc("/api/categories/{id}", handlers.DeleteCategory).Methods("DELETE")
-
-	// Router API cho Reminder
-	router.HandleFunc("/api/reminders", handlers.CreateReminder).Methods("POST")
-	router.HandleFunc("/api/reminders/{id}", handlers.UpdateReminder).Methods("PUT")
-	router.HandleFunc("/api/reminders/{id}", handlers.DeleteReminder).Methods("DELETE")
-	router.HandleFunc("/api/tasks/{task_id}/reminders", handlers.GetTaskReminders).Methods("GET")
-
-	// statistics
-	router.HandleFunc("/api/users/{user_id}/statistics", handlers.GetUserTaskStatistics).Methods("GET")
-	router.HandleFunc("/api/users/{user_id}/tasks-with-reminders", handlers.GetTasksWithReminders).Methods("GET")
+	router := h.Router()
+
+	// ctx bị hủy khi nhận SIGINT/SIGTERM để dừng các tác vụ nền và server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	notifier, err := notify.RouterFromEnv()
+	if err != nil {
+		log.Fatalf("Cấu hình kênh thông báo không hợp lệ: %v", err)
+	}
+
+	// Bộ gửi nhắc nhở chạy nền; tắt bằng REMINDER_DISPATCHER=off
+	if os.Getenv("REMINDER_DISPATCHER") != "off" {
+		dispatcher := notify.NewDispatcher(st, notifier)
+		dispatcher.Events = h.Events
+		if v := os.Getenv("REMINDER_POLL_INTERVAL"); v != "" {
+			interval, err := time.ParseDuration(v)
+			if err != nil || interval <= 0 {
+				log.Fatalf("REMINDER_POLL_INTERVAL không hợp lệ: %q", v)
+			}
+			dispatcher.Interval = interval
+		}
+		if v := os.Getenv("REMINDER_MAX_ATTEMPTS"); v != "" {
+			attempts, err := strconv.Atoi(v)
+			if err != nil || attempts < 1 {
+				log.Fatalf("REMINDER_MAX_ATTEMPTS không hợp lệ: %q", v)
+			}
+			dispatcher.Backoff.MaxAttempts = attempts
+		}
+		if v := os.Getenv("REMINDER_RETRY_BASE"); v != "" {
+			base, err := time.ParseDuration(v)
+			if err != nil || base <= 0 {
+				log.Fatalf("REMINDER_RETRY_BASE không hợp lệ: %q", v)
+			}
+			dispatcher.Backoff.Base = base
+		}
+		go dispatcher.Run(ctx)
+	}
+
+	// Bản tổng hợp hằng ngày/hằng tuần; tắt bằng DIGEST_SCHEDULER=off
+	if os.Getenv("DIGEST_SCHEDULER") != "off" {
+		digests := notify.NewDigestScheduler(st, notifier)
+		if v := os.Getenv("DIGEST_POLL_INTERVAL"); v != "" {
+			interval, err := time.ParseDuration(v)
+			if err != nil || interval <= 0 {
+				log.Fatalf("DIGEST_POLL_INTERVAL không hợp lệ: %q", v)
+			}
+			digests.Interval = interval
+		}
+		go digests.Run(ctx)
+	}
+
+	// Webhook của người dùng; tắt bằng WEBHOOK_DISPATCHER=off. WEBHOOK_ALLOW_PRIVATE=on cho
+	// phép gửi tới localhost/mạng nội bộ khi phát triển.
+	if os.Getenv("WEBHOOK_DISPATCHER") != "off" {
+		webhooks := notify.NewWebhookDispatcher(st, os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "on")
+		if v := os.Getenv("WEBHOOK_POLL_INTERVAL"); v != "" {
+			interval, err := time.ParseDuration(v)
+			if err != nil || interval <= 0 {
+				log.Fatalf("WEBHOOK_POLL_INTERVAL không hợp lệ: %q", v)
+			}
+			webhooks.Interval = interval
+		}
+		if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+			attempts, err := strconv.Atoi(v)
+			if err != nil || attempts < 1 {
+				log.Fatalf("WEBHOOK_MAX_ATTEMPTS không hợp lệ: %q", v)
+			}
+			webhooks.Backoff.MaxAttempts = attempts
+		}
+		if v := os.Getenv("WEBHOOK_RETRY_BASE"); v != "" {
+			base, err := time.ParseDuration(v)
+			if err != nil || base <= 0 {
+				log.Fatalf("WEBHOOK_RETRY_BASE không hợp lệ: %q", v)
+			}
+			webhooks.Backoff.Base = base
+		}
+		go webhooks.Run(ctx)
+	}
+
+	// Xóa tombstone quá hạn khỏi nhật ký đồng bộ; tắt bằng SYNC_PRUNER=off
+	if os.Getenv("SYNC_PRUNER") != "off" {
+		pruner := store.NewTombstonePruner(st)
+		if v := os.Getenv("SYNC_TOMBSTONE_RETENTION"); v != "" {
+			retention, err := time.ParseDuration(v)
+			if err != nil || retention <= 0 {
+				log.Fatalf("SYNC_TOMBSTONE_RETENTION không hợp lệ: %q", v)
+			}
+			pruner.Retention = retention
+		}
+		go pruner.Run(ctx)
+	}
+
 	// Khởi động server
-	
 	port := os.Getenv("PORT")
 	if port == "" {
 		log.Println("Không tìm thấy PORT, dùng mặc định: 8080")
 		port = "8080"
 	}
 
+	server := &http.Server{Addr: "0.0.0.0:" + port, Handler: router}
+	// Đóng các stream sự kiện đang mở để Shutdown không phải chờ chúng
+	server.RegisterOnShutdown(h.Events.Close)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Lỗi khi dừng server: %v", err)
+		}
+	}()
+
 	fmt.Printf("✅ Server đang chạy tại: http://0.0.0.0:%s\n", port)
-	if err := http.ListenAndServe("0.0.0.0:"+port, router); err != nil {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		fmt.Printf("Không thể khởi động server: %v\n", err)
 	}
 }
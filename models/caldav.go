@@ -0,0 +1,24 @@
+package models
+
+import "fmt"
+
+// CalDAVObject gắn một công việc với tên tài nguyên và UID do client CalDAV đặt khi
+// tạo công việc bằng PUT. Bản ghi được giữ lại sau khi công việc bị xóa để client
+// đồng bộ biết tài nguyên nào đã mất.
+type CalDAVObject struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"` // ví dụ "3f2a....ics"
+	UID    string `json:"uid"`
+	TaskID int    `json:"task_id"`
+}
+
+// DefaultCalDAVObject là tên tài nguyên và UID của công việc chưa có bản ghi riêng;
+// UID trùng với UID trong feed lịch .ics
+func DefaultCalDAVObject(task Task) CalDAVObject {
+	return CalDAVObject{
+		UserID: task.UserID,
+		Name:   fmt.Sprintf("task-%d.ics", task.ID),
+		UID:    fmt.Sprintf("task-%d@backend", task.ID),
+		TaskID: task.ID,
+	}
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DigestFrequency là tần suất gửi bản tổng hợp công việc
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+func (f DigestFrequency) Valid() bool {
+	return f == DigestOff || f == DigestDaily || f == DigestWeekly
+}
+
+// Giá trị mặc định khi người dùng chưa cấu hình bản tổng hợp
+const (
+	DefaultDigestTime    = "07:00"
+	DefaultDigestWeekday = "monday"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// DigestSettings là lịch gửi bản tổng hợp (công việc đến hạn, quá hạn, đã hoàn thành)
+// của một người dùng. Time và Weekday tính theo múi giờ trong hồ sơ người dùng.
+type DigestSettings struct {
+	UserID    int             `json:"user_id"`
+	Frequency DigestFrequency `json:"frequency"`
+	Time      string          `json:"time"`    // "HH:MM"
+	Weekday   string          `json:"weekday"` // chỉ dùng với weekly, ví dụ "monday"
+	// Channels là các kênh nhận bản tổng hợp; trống là email
+	Channels   []NotificationChannel `json:"channels"`
+	LastSentAt *time.Time            `json:"last_sent_at"`
+	NextRunAt  *time.Time            `json:"next_run_at"`
+}
+
+// DefaultDigestSettings là cấu hình của người dùng chưa đăng ký nhận bản tổng hợp
+func DefaultDigestSettings(userID int) DigestSettings {
+	return DigestSettings{
+		UserID:    userID,
+		Frequency: DigestOff,
+		Time:      DefaultDigestTime,
+		Weekday:   DefaultDigestWeekday,
+		Channels:  []NotificationChannel{},
+	}
+}
+
+// Normalize điền giá trị mặc định rồi kiểm tra cấu hình
+func (s *DigestSettings) Normalize() error {
+	if s.Frequency == "" {
+		s.Frequency = DigestOff
+	}
+	if s.Time == "" {
+		s.Time = DefaultDigestTime
+	}
+	if s.Weekday == "" {
+		s.Weekday = DefaultDigestWeekday
+	}
+	s.Weekday = strings.ToLower(s.Weekday)
+
+	if !s.Frequency.Valid() {
+		return fmt.Errorf("frequency chỉ nhận off, daily hoặc weekly")
+	}
+	if _, err := parseClock(s.Time); err != nil {
+		return fmt.Errorf("time phải có dạng HH:MM")
+	}
+	if _, ok := weekdayNames[s.Weekday]; !ok {
+		return fmt.Errorf("weekday không hợp lệ: %q", s.Weekday)
+	}
+	return ValidateChannels(s.Channels)
+}
+
+// Next trả về lần gửi kế tiếp sau thời điểm after theo múi giờ loc; nil nếu đã tắt
+func (s DigestSettings) Next(after time.Time, loc *time.Location) *time.Time {
+	if s.Frequency != DigestDaily && s.Frequency != DigestWeekly {
+		return nil
+	}
+	minute, err := parseClock(s.Time)
+	if err != nil {
+		return nil
+	}
+
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, loc)
+	for !next.After(after) || (s.Frequency == DigestWeekly && next.Weekday() != weekdayNames[s.Weekday]) {
+		next = time.Date(next.Year(), next.Month(), next.Day()+1, minute/60, minute%60, 0, 0, loc)
+	}
+	next = next.UTC()
+	return &next
+}
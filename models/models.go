@@ -3,25 +3,41 @@ package models
 import "time"
 
 type User struct {
-    ID        int        `json:"user_id"`
-    Username  string     `json:"username"`
-    Email     string     `json:"email"`
-    Password  string     `json:"password"` // Sửa tag để ánh xạ từ JSON
-    FullName  string     `json:"full_name"`
-    CreatedAt time.Time  `json:"created_at"`
-    LastLogin *time.Time `json:"last_login"`
+	ID        int        `json:"user_id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Password  string     `json:"password,omitempty"` // Chỉ nhận từ JSON, không bao giờ trả về
+	FullName  string     `json:"full_name"`
+	CreatedAt time.Time  `json:"created_at"`
+	LastLogin *time.Time `json:"last_login"`
+	// NotificationChannels là các kênh nhận nhắc nhở mặc định của người dùng
+	NotificationChannels []NotificationChannel `json:"notification_channels"`
+	// Timezone là múi giờ IANA (ví dụ "Asia/Ho_Chi_Minh") dùng để hiển thị thời gian; trống là UTC
+	Timezone string `json:"timezone"`
+	// Khung giờ yên lặng "HH:MM" theo múi giờ của người dùng; nhắc nhở rơi vào đây được hoãn tới cuối khung
+	QuietHoursStart string `json:"quiet_hours_start"`
+	QuietHoursEnd   string `json:"quiet_hours_end"`
+	// Webhook nhận nhắc nhở qua kênh webhook; secret dùng để ký payload, chỉ nhận từ JSON
+	WebhookURL    string `json:"webhook_url"`
+	WebhookSecret string `json:"webhook_secret,omitempty"`
 }
 type Task struct {
-	ID          int       `json:"task_id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Deadline    time.Time `json:"deadline"`
-	Priority    string    `json:"priority"`
-	Status      string    `json:"status"`
-	CategoryID  int       `json:"category_id"`
-	UserID      int       `json:"user_id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int          `json:"task_id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Deadline    time.Time    `json:"deadline"`
+	Priority    TaskPriority `json:"priority"`
+	Status      TaskStatus   `json:"status"`
+	CategoryID  int          `json:"category_id"`
+	UserID      int          `json:"user_id"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	CompletedAt *time.Time   `json:"completed_at"`
+	// Recurrence là RRULE (RFC 5545) của chuỗi lặp; chỉ công việc đang mở của chuỗi mang giá trị này
+	Recurrence      string     `json:"recurrence"`
+	SeriesID        *int       `json:"series_id"`
+	RecurrenceStart *time.Time `json:"recurrence_start"` // DTSTART của chuỗi
+	OccurrenceAt    *time.Time `json:"occurrence_at"`    // Lần lặp theo lịch mà công việc này đại diện
 }
 
 type Category struct {
@@ -33,14 +49,26 @@ type Category struct {
 }
 
 type Reminder struct {
-	ID           int       `json:"id"`           // Giữ là "id" để đồng bộ với Flutter
+	ID           int       `json:"id"` // Giữ là "id" để đồng bộ với Flutter
 	TaskID       int       `json:"task_id"`
 	UserID       int       `json:"user_id"`
 	ReminderTime time.Time `json:"reminder_time"`
-	IsSent       bool      `json:"is_sent"`      // Đổi thành bool để đồng bộ với Flutter
+	// OffsetMinutes (nếu có) là số phút trước deadline; reminder_time được tính lại khi deadline đổi
+	OffsetMinutes *int       `json:"offset_minutes"`
+	IsSent        bool       `json:"is_sent"` // Đổi thành bool để đồng bộ với Flutter
+	SentAt        *time.Time `json:"sent_at"`
+	// Channels ghi đè kênh mặc định của người dùng cho riêng nhắc nhở này
+	Channels       []NotificationChannel `json:"channels"`
+	Status         ReminderStatus        `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at"`
+	SnoozedUntil   *time.Time            `json:"snoozed_until"`
+	SnoozeCount    int                   `json:"snooze_count"`
+	AcknowledgedAt *time.Time            `json:"acknowledged_at"`
+	// ClaimToken là quyền nhận của bộ gửi nền, chỉ có ở nhắc nhở trả về từ ClaimDueReminders
+	ClaimToken string `json:"-"`
 }
 
-
 type TaskStatistics struct {
 	ID             int       `json:"stat_id"`
 	UserID         int       `json:"user_id"`
@@ -51,13 +79,13 @@ type TaskStatistics struct {
 }
 
 type UserTaskStatistics struct {
-    UserID           int                     `json:"user_id"`
-    TotalTasks       int                     `json:"total_tasks"`
-    CompletedTasks   int                     `json:"completed_tasks"`
-    InProgressTasks  int                     `json:"in_progress_tasks"`
-    PendingTasks     int                     `json:"pending_tasks"`
-    OverdueTasks     int                     `json:"overdue_tasks"`
-    TasksByMonth     map[string]int          `json:"tasks_by_month"`
-    CompletedByMonth map[string]int          `json:"completed_by_month"`
+	UserID           int            `json:"user_id"`
+	TotalTasks       int            `json:"total_tasks"`
+	CompletedTasks   int            `json:"completed_tasks"`
+	InProgressTasks  int            `json:"in_progress_tasks"`
+	PendingTasks     int            `json:"pending_tasks"`
+	OverdueTasks     int            `json:"overdue_tasks"`
+	TotalSnoozes     int            `json:"total_snoozes"` // Số lần hoãn nhắc nhở
+	TasksByMonth     map[string]int `json:"tasks_by_month"`
+	CompletedByMonth map[string]int `json:"completed_by_month"`
 }
-
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotificationChannel là kênh gửi nhắc nhở
+type NotificationChannel string
+
+const (
+	ChannelLog     NotificationChannel = "log"
+	ChannelEmail   NotificationChannel = "email"
+	ChannelWebhook NotificationChannel = "webhook"
+)
+
+// NotificationChannels liệt kê các kênh hợp lệ
+var NotificationChannels = []NotificationChannel{ChannelLog, ChannelEmail, ChannelWebhook}
+
+func (c NotificationChannel) Valid() bool {
+	for _, channel := range NotificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateChannels kiểm tra danh sách kênh, trả về lỗi với kênh không hợp lệ đầu tiên
+func ValidateChannels(channels []NotificationChannel) error {
+	for _, channel := range channels {
+		if !channel.Valid() {
+			return fmt.Errorf("kênh thông báo không hợp lệ: %q", channel)
+		}
+	}
+	return nil
+}
+
+// FormatChannels nối danh sách kênh thành chuỗi "email,webhook" để lưu vào database
+func FormatChannels(channels []NotificationChannel) string {
+	parts := make([]string, len(channels))
+	for i, channel := range channels {
+		parts[i] = string(channel)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseChannels tách chuỗi "email,webhook" thành danh sách kênh
+func ParseChannels(s string) []NotificationChannel {
+	channels := []NotificationChannel{}
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			channels = append(channels, NotificationChannel(part))
+		}
+	}
+	return channels
+}
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// ReminderStatus là trạng thái gửi của nhắc nhở
+type ReminderStatus string
+
+const (
+	ReminderScheduled ReminderStatus = "scheduled"
+	ReminderSent      ReminderStatus = "sent"
+	// ReminderSnoozed: người dùng hoãn nhắc nhở, sẽ gửi lại lúc snoozed_until
+	ReminderSnoozed ReminderStatus = "snoozed"
+	// ReminderAcknowledged: người dùng đã xác nhận/tắt nhắc nhở, không gửi nữa
+	ReminderAcknowledged ReminderStatus = "acknowledged"
+	// ReminderFailed: đã thử gửi quá số lần cho phép, chờ người dùng gửi lại
+	ReminderFailed ReminderStatus = "failed"
+)
+
+// reminderTransitions là các bước chuyển trạng thái do người dùng hoặc bộ gửi thực hiện.
+// Sửa nhắc nhở qua PUT/PATCH luôn đưa về scheduled (hoặc sent nếu is_sent = true).
+var reminderTransitions = map[ReminderStatus][]ReminderStatus{
+	ReminderScheduled: {ReminderSent, ReminderFailed},
+	ReminderSent:      {ReminderSnoozed, ReminderAcknowledged},
+	ReminderSnoozed:   {ReminderSnoozed, ReminderSent, ReminderAcknowledged, ReminderFailed},
+	ReminderFailed:    {ReminderScheduled, ReminderAcknowledged},
+}
+
+// CanTransition cho biết nhắc nhở ở trạng thái s có được chuyển sang to hay không
+func (s ReminderStatus) CanTransition(to ReminderStatus) bool {
+	for _, next := range reminderTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetDelivery đặt lại trạng thái gửi theo is_sent sau khi người dùng tạo hoặc sửa
+// nhắc nhở (hoặc nhắc nhở bị dời theo công việc): nhắc nhở chưa gửi (kể cả đang failed,
+// snoozed hay acknowledged) được lên lịch gửi lại từ đầu
+func (r *Reminder) ResetDelivery() {
+	r.Status = ReminderScheduled
+	if r.IsSent {
+		r.Status = ReminderSent
+	}
+	r.Attempts = 0
+	r.NextAttemptAt = nil
+	r.SnoozedUntil = nil
+	r.AcknowledgedAt = nil
+}
+
+// FollowDeadline tính lại reminder_time của nhắc nhở tương đối (có offset_minutes) theo
+// deadline của công việc. Nhắc nhở đã gửi được gửi lại nếu thời điểm mới còn sau now.
+// Trả về false nếu nhắc nhở không tương đối hoặc không cần đổi.
+func (r *Reminder) FollowDeadline(deadline, now time.Time) bool {
+	if r.OffsetMinutes == nil {
+		return false
+	}
+	at := deadline.Add(-time.Duration(*r.OffsetMinutes) * time.Minute)
+	if at.Equal(r.ReminderTime) {
+		return false
+	}
+	r.ReminderTime = at
+	if at.After(now) {
+		r.IsSent = false
+		r.ResetDelivery()
+	}
+	return true
+}
+
+// ReminderSnooze là một lần người dùng hoãn nhắc nhở, giữ lại để thống kê
+type ReminderSnooze struct {
+	ID           int       `json:"snooze_id"`
+	ReminderID   int       `json:"reminder_id"`
+	UserID       int       `json:"user_id"`
+	SnoozedAt    time.Time `json:"snoozed_at"`
+	SnoozedUntil time.Time `json:"snoozed_until"`
+}
+
+// ReminderDelivery là một lần thử gửi nhắc nhở qua một kênh
+type ReminderDelivery struct {
+	ID          int                 `json:"delivery_id"`
+	ReminderID  int                 `json:"reminder_id"`
+	Attempt     int                 `json:"attempt"`
+	Channel     NotificationChannel `json:"channel"`
+	Error       string              `json:"error"` // Rỗng nếu gửi thành công
+	NextRetryAt *time.Time          `json:"next_retry_at"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// Succeeded cho biết lần gửi có thành công hay không
+func (d ReminderDelivery) Succeeded() bool {
+	return d.Error == ""
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// SyncEntity là loại bản ghi được theo dõi trong nhật ký đồng bộ
+type SyncEntity string
+
+const (
+	SyncTask     SyncEntity = "task"
+	SyncCategory SyncEntity = "category"
+	SyncReminder SyncEntity = "reminder"
+)
+
+func (e SyncEntity) Valid() bool {
+	return e == SyncTask || e == SyncCategory || e == SyncReminder
+}
+
+// Change là thay đổi gần nhất của một bản ghi. Seq tăng đơn điệu trên toàn hệ thống
+// và được dùng làm cursor đồng bộ lẫn phiên bản của bản ghi; Deleted đánh dấu tombstone.
+type Change struct {
+	Seq       int64      `json:"seq"`
+	UserID    int        `json:"user_id"`
+	Entity    SyncEntity `json:"entity"`
+	EntityID  int        `json:"entity_id"`
+	Deleted   bool       `json:"deleted"`
+	ChangedAt time.Time  `json:"changed_at"`
+}
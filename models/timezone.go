@@ -0,0 +1,133 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Thời gian luôn được lưu ở UTC; khi trả về cho người dùng hoặc gửi nhắc nhở thì
+// đổi sang múi giờ trong hồ sơ người dùng.
+
+// ValidateTimezone kiểm tra tên múi giờ IANA, ví dụ "Asia/Ho_Chi_Minh". Chuỗi rỗng là UTC.
+func ValidateTimezone(name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("múi giờ không hợp lệ: %q", name)
+	}
+	return nil
+}
+
+// Location trả về múi giờ của người dùng; UTC nếu chưa đặt hoặc không đọc được
+func (u User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// QuietHours là khung giờ yên lặng hằng ngày [Start, End) tính bằng phút từ nửa đêm
+// theo múi giờ của người dùng. Start > End nghĩa là khung giờ qua nửa đêm (22:00-07:00).
+type QuietHours struct {
+	Start int
+	End   int
+}
+
+// ParseQuietHours đọc khung giờ yên lặng dạng "HH:MM". Trả về nil nếu cả hai đều trống.
+func ParseQuietHours(start, end string) (*QuietHours, error) {
+	if start == "" && end == "" {
+		return nil, nil
+	}
+	if start == "" || end == "" {
+		return nil, errors.New("cần cả quiet_hours_start và quiet_hours_end")
+	}
+	s, err := parseClock(start)
+	if err != nil {
+		return nil, fmt.Errorf("quiet_hours_start không hợp lệ: %q", start)
+	}
+	e, err := parseClock(end)
+	if err != nil {
+		return nil, fmt.Errorf("quiet_hours_end không hợp lệ: %q", end)
+	}
+	if s == e {
+		return nil, errors.New("quiet_hours_start và quiet_hours_end không được trùng nhau")
+	}
+	return &QuietHours{Start: s, End: e}, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Until cho biết t có nằm trong khung giờ yên lặng (theo múi giờ loc) hay không
+// và nếu có thì trả về thời điểm khung giờ kết thúc
+func (q QuietHours) Until(t time.Time, loc *time.Location) (time.Time, bool) {
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	var inside bool
+	if q.Start < q.End {
+		inside = minute >= q.Start && minute < q.End
+	} else {
+		inside = minute >= q.Start || minute < q.End
+	}
+	if !inside {
+		return time.Time{}, false
+	}
+
+	day := local
+	if minute >= q.End {
+		// Khung giờ qua nửa đêm, kết thúc vào sáng hôm sau
+		day = local.AddDate(0, 0, 1)
+	}
+	end := time.Date(day.Year(), day.Month(), day.Day(), q.End/60, q.End%60, 0, 0, loc)
+	return end, true
+}
+
+// QuietHours trả về khung giờ yên lặng của người dùng (nil nếu không đặt)
+func (u User) QuietHours() *QuietHours {
+	q, err := ParseQuietHours(u.QuietHoursStart, u.QuietHoursEnd)
+	if err != nil {
+		return nil
+	}
+	return q
+}
+
+// In đổi các mốc thời gian của hồ sơ sang múi giờ loc để trả về
+func (u *User) In(loc *time.Location) {
+	u.CreatedAt = u.CreatedAt.In(loc)
+	u.LastLogin = inLocation(u.LastLogin, loc)
+}
+
+// In đổi các mốc thời gian của công việc sang múi giờ loc để trả về
+func (t *Task) In(loc *time.Location) {
+	t.Deadline = t.Deadline.In(loc)
+	t.CreatedAt = t.CreatedAt.In(loc)
+	t.UpdatedAt = t.UpdatedAt.In(loc)
+	t.CompletedAt = inLocation(t.CompletedAt, loc)
+	t.RecurrenceStart = inLocation(t.RecurrenceStart, loc)
+	t.OccurrenceAt = inLocation(t.OccurrenceAt, loc)
+}
+
+// In đổi các mốc thời gian của nhắc nhở sang múi giờ loc để trả về
+func (r *Reminder) In(loc *time.Location) {
+	r.ReminderTime = r.ReminderTime.In(loc)
+	r.SentAt = inLocation(r.SentAt, loc)
+	r.NextAttemptAt = inLocation(r.NextAttemptAt, loc)
+	r.SnoozedUntil = inLocation(r.SnoozedUntil, loc)
+	r.AcknowledgedAt = inLocation(r.AcknowledgedAt, loc)
+}
+
+// inLocation trả về bản sao của t (nếu có) ở múi giờ loc; không sửa giá trị gốc
+// vì con trỏ có thể đang được dùng chung
+func inLocation(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.In(loc)
+	return &v
+}
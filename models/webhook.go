@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/events"
+)
+
+// WebhookEvents liệt kê các sự kiện có thể đăng ký nhận qua webhook: mọi loại sự kiện
+// của events.Types, nên loại sự kiện mới tự động đăng ký được
+var WebhookEvents = webhookEvents()
+
+func webhookEvents() []string {
+	names := make([]string, len(events.Types))
+	for i, typ := range events.Types {
+		names[i] = string(typ)
+	}
+	return names
+}
+
+// Webhook là một đăng ký nhận sự kiện qua HTTP POST tới URL. Events rỗng nghĩa là
+// nhận mọi sự kiện. Secret dùng để ký payload và chỉ được trả về khi tạo.
+type Webhook struct {
+	ID        int       `json:"webhook_id"`
+	UserID    int       `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Wants cho biết webhook có đăng ký sự kiện event không
+func (w Webhook) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateWebhookEvents kiểm tra danh sách sự kiện, trả về lỗi với sự kiện không hợp lệ đầu tiên
+func ValidateWebhookEvents(names []string) error {
+	for _, event := range names {
+		if !events.Type(event).Valid() {
+			return fmt.Errorf("sự kiện không hợp lệ: %q (chấp nhận: %s)", event, strings.Join(WebhookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// FormatWebhookEvents nối danh sách sự kiện thành chuỗi "task.created,task.deleted" để lưu vào database
+func FormatWebhookEvents(names []string) string {
+	return strings.Join(names, ",")
+}
+
+// ParseWebhookEvents tách chuỗi đã lưu thành danh sách sự kiện
+func ParseWebhookEvents(s string) []string {
+	names := []string{}
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			names = append(names, part)
+		}
+	}
+	return names
+}
+
+// WebhookPayload là JSON được POST tới webhook của người dùng cho mỗi sự kiện
+type WebhookPayload struct {
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	UserID    int       `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookDeliveryStatus là trạng thái gửi của một sự kiện tới webhook
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery là một sự kiện cần gửi tới webhook cùng kết quả lần thử gần nhất.
+// Payload là đúng nội dung được ký và gửi đi; gửi lại (replay) tạo delivery mới với
+// cùng payload và ReplayOf trỏ về delivery gốc.
+type WebhookDelivery struct {
+	ID        int                   `json:"delivery_id"`
+	WebhookID int                   `json:"webhook_id"`
+	UserID    int                   `json:"user_id"`
+	EventID   string                `json:"event_id"`
+	Event     string                `json:"event"`
+	Payload   string                `json:"payload,omitempty"`
+	Status    WebhookDeliveryStatus `json:"status"`
+	Attempts  int                   `json:"attempts"`
+	// ResponseStatus là mã HTTP của lần thử gần nhất, 0 nếu không kết nối được
+	ResponseStatus int        `json:"response_status"`
+	Error          string     `json:"error"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	ReplayOf       *int       `json:"replay_of"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// In đổi các mốc thời gian của webhook sang múi giờ loc để trả về
+func (w *Webhook) In(loc *time.Location) {
+	w.CreatedAt = w.CreatedAt.In(loc)
+	w.UpdatedAt = w.UpdatedAt.In(loc)
+}
+
+// In đổi các mốc thời gian của delivery sang múi giờ loc để trả về
+func (d *WebhookDelivery) In(loc *time.Location) {
+	d.NextAttemptAt = inLocation(d.NextAttemptAt, loc)
+	d.DeliveredAt = inLocation(d.DeliveredAt, loc)
+	d.CreatedAt = d.CreatedAt.In(loc)
+	d.UpdatedAt = d.UpdatedAt.In(loc)
+}
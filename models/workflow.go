@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TaskStatus là trạng thái của công việc
+type TaskStatus string
+
+const (
+	StatusPending    TaskStatus = "Pending"
+	StatusInProgress TaskStatus = "In Progress"
+	StatusCompleted  TaskStatus = "Completed"
+)
+
+// TaskStatuses liệt kê các trạng thái hợp lệ
+var TaskStatuses = []TaskStatus{StatusPending, StatusInProgress, StatusCompleted}
+
+func (s TaskStatus) Valid() bool {
+	for _, status := range TaskStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskPriority là độ ưu tiên của công việc
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "Low"
+	PriorityMedium TaskPriority = "Medium"
+	PriorityHigh   TaskPriority = "High"
+)
+
+// TaskPriorities liệt kê các độ ưu tiên hợp lệ, từ thấp đến cao
+var TaskPriorities = []TaskPriority{PriorityLow, PriorityMedium, PriorityHigh}
+
+func (p TaskPriority) Valid() bool {
+	return p.Rank() > 0
+}
+
+// Rank trả về thứ hạng của độ ưu tiên để sắp xếp (giá trị lạ xếp cuối)
+func (p TaskPriority) Rank() int {
+	for i, priority := range TaskPriorities {
+		if p == priority {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Workflow là đồ thị chuyển trạng thái: trạng thái hiện tại -> các trạng thái được phép chuyển tới
+type Workflow map[TaskStatus][]TaskStatus
+
+// DefaultWorkflow: Pending -> In Progress -> Completed, cho phép tạm dừng
+// (In Progress -> Pending) và mở lại công việc đã hoàn thành (Completed -> Pending)
+var DefaultWorkflow = Workflow{
+	StatusPending:    {StatusInProgress},
+	StatusInProgress: {StatusCompleted, StatusPending},
+	StatusCompleted:  {StatusPending},
+}
+
+// CanTransition cho biết có được chuyển từ from sang to hay không.
+// Giữ nguyên trạng thái luôn hợp lệ.
+func (wf Workflow) CanTransition(from, to TaskStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, next := range wf[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaches cho biết có đi được từ from tới to qua một hay nhiều bước chuyển hay không
+func (wf Workflow) Reaches(from, to TaskStatus) bool {
+	seen := map[TaskStatus]bool{from: true}
+	queue := []TaskStatus{from}
+	for len(queue) > 0 {
+		status := queue[0]
+		queue = queue[1:]
+		if status == to {
+			return true
+		}
+		for _, next := range wf[status] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+// ParseWorkflow đọc đồ thị chuyển trạng thái từ chuỗi cấu hình dạng
+// "Pending>In Progress,In Progress>Completed,Completed>Pending"
+func ParseWorkflow(spec string) (Workflow, error) {
+	wf := Workflow{}
+	for _, edge := range strings.Split(spec, ",") {
+		if strings.TrimSpace(edge) == "" {
+			continue
+		}
+		fromStr, toStr, ok := strings.Cut(edge, ">")
+		from, to := TaskStatus(strings.TrimSpace(fromStr)), TaskStatus(strings.TrimSpace(toStr))
+		if !ok || !from.Valid() || !to.Valid() {
+			return nil, fmt.Errorf("cấu hình chuyển trạng thái không hợp lệ: %q", edge)
+		}
+		wf[from] = append(wf[from], to)
+	}
+	return wf, nil
+}
@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff tính thời gian chờ trước lần gửi lại: Base * 2^(attempt-1), tối đa Max,
+// cộng jitter ngẫu nhiên để các instance không dồn lần thử vào cùng một thời điểm
+type Backoff struct {
+	Base        time.Duration
+	Max         time.Duration
+	MaxAttempts int // Sau số lần thử này nhắc nhở chuyển sang failed
+}
+
+// DefaultBackoff: 30s, 1m, 2m, 4m rồi failed ở lần thử thứ 5
+var DefaultBackoff = Backoff{Base: 30 * time.Second, Max: time.Hour, MaxAttempts: 5}
+
+// Delay trả về thời gian chờ sau lần thử thứ attempt (bắt đầu từ 1).
+// Dùng "equal jitter": một nửa cố định, một nửa ngẫu nhiên.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Base
+	for i := 1; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
@@ -0,0 +1,26 @@
+package notify_test
+
+import (
+	"testing"
+	"time"
+
+	"backend/notify"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := notify.Backoff{Base: 30 * time.Second, Max: 4 * time.Minute, MaxAttempts: 5}
+	// Thời gian chờ gấp đôi sau mỗi lần thử, tối đa Max; jitter nằm trong nửa sau của khoảng
+	for attempt, full := range map[int]time.Duration{
+		1: 30 * time.Second,
+		2: time.Minute,
+		3: 2 * time.Minute,
+		4: 4 * time.Minute,
+		9: 4 * time.Minute,
+	} {
+		for range 100 {
+			if d := b.Delay(attempt); d < full/2 || d > full {
+				t.Fatalf("lần thử %d: chờ %s, muốn trong [%s, %s]", attempt, d, full/2, full)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"backend/models"
+)
+
+// RouterFromEnv tạo Router với các kênh được cấu hình qua biến môi trường:
+//
+//	SMTP_HOST, SMTP_PORT (mặc định 25), SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM -> kênh email
+//	NOTIFY_DEFAULT_CHANNELS (mặc định "log") -> kênh dùng khi người dùng chưa chọn
+//
+// Kênh log và kênh webhook luôn có sẵn; webhook gửi tới webhook_url trong hồ sơ của
+// từng người dùng. WEBHOOK_ALLOW_PRIVATE=on cho phép URL trỏ tới địa chỉ nội bộ.
+func RouterFromEnv() (*Router, error) {
+	rt := &Router{
+		Channels: map[models.NotificationChannel]Notifier{
+			models.ChannelLog:     LogNotifier{},
+			models.ChannelWebhook: NewWebhookNotifier(os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "on"),
+		},
+		Default: []models.NotificationChannel{models.ChannelLog},
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "25"
+		}
+		from := os.Getenv("SMTP_FROM")
+		if from == "" {
+			return nil, fmt.Errorf("thiếu SMTP_FROM")
+		}
+		rt.Channels[models.ChannelEmail] = &EmailNotifier{
+			Addr:     net.JoinHostPort(host, port),
+			From:     from,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+
+	if v := os.Getenv("NOTIFY_DEFAULT_CHANNELS"); v != "" {
+		channels := models.ParseChannels(v)
+		if err := models.ValidateChannels(channels); err != nil {
+			return nil, err
+		}
+		for _, channel := range channels {
+			if _, ok := rt.Channels[channel]; !ok {
+				return nil, fmt.Errorf("kênh mặc định %s chưa được cấu hình", channel)
+			}
+		}
+		rt.Default = channels
+	}
+	return rt, nil
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"text/template"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// Digest là bản tổng hợp công việc gửi cho một người dùng. Với bản hằng ngày, DueSoon là
+// công việc đến hạn trong hôm nay và Completed là công việc hoàn thành hôm qua; với bản
+// hằng tuần là 7 ngày tới và 7 ngày qua. Overdue dùng cùng định nghĩa với overdue_tasks
+// trong thống kê. Mọi thời gian đã được đổi sang múi giờ của người dùng.
+type Digest struct {
+	User      models.User
+	Frequency models.DigestFrequency
+	Date      time.Time
+	DueSoon   []models.Task
+	Overdue   []models.Task
+	Completed []models.Task
+}
+
+// DigestNotifier là kênh có thể gửi bản tổng hợp
+type DigestNotifier interface {
+	NotifyDigest(ctx context.Context, d Digest) error
+}
+
+// BuildDigest lấy danh sách công việc cho bản tổng hợp của user tại thời điểm now
+func BuildDigest(ctx context.Context, tasks store.TaskStore, user models.User, frequency models.DigestFrequency, now time.Time) (Digest, error) {
+	loc := user.Location()
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	days := 1
+	if frequency == models.DigestWeekly {
+		days = 7
+	}
+	dueTo := today.AddDate(0, 0, days).Add(-time.Nanosecond)
+	completedFrom := today.AddDate(0, 0, -days)
+	completedTo := today.Add(-time.Nanosecond)
+	overdue := true
+
+	d := Digest{User: user, Frequency: frequency, Date: today}
+	var err error
+	if d.DueSoon, err = store.ListAllTasks(ctx, tasks, user.ID, store.TaskFilter{DeadlineFrom: &now, DeadlineTo: &dueTo, Sort: store.SortDeadline}); err != nil {
+		return d, err
+	}
+	if d.Overdue, err = store.ListAllTasks(ctx, tasks, user.ID, store.TaskFilter{Overdue: &overdue, Sort: store.SortDeadline}); err != nil {
+		return d, err
+	}
+	if d.Completed, err = store.ListAllTasks(ctx, tasks, user.ID, store.TaskFilter{CompletedFrom: &completedFrom, CompletedTo: &completedTo, Sort: store.SortDeadline}); err != nil {
+		return d, err
+	}
+
+	// Công việc đã hoàn thành không còn "sắp đến hạn"
+	open := d.DueSoon[:0]
+	for _, task := range d.DueSoon {
+		if task.Status != models.StatusCompleted {
+			open = append(open, task)
+		}
+	}
+	d.DueSoon = open
+
+	for _, list := range [][]models.Task{d.DueSoon, d.Overdue, d.Completed} {
+		for i := range list {
+			list[i].In(loc)
+		}
+	}
+	return d, nil
+}
+
+// Empty cho biết bản tổng hợp không có công việc nào
+func (d Digest) Empty() bool {
+	return len(d.DueSoon) == 0 && len(d.Overdue) == 0 && len(d.Completed) == 0
+}
+
+// Subject là tiêu đề email của bản tổng hợp
+func (d Digest) Subject() string {
+	if d.Frequency == models.DigestWeekly {
+		return "Tổng hợp công việc tuần " + d.Date.Format("02/01/2006")
+	}
+	return "Tổng hợp công việc ngày " + d.Date.Format("02/01/2006")
+}
+
+// digestView là dữ liệu đưa vào template
+type digestView struct {
+	Digest
+	DueLabel       string
+	CompletedLabel string
+}
+
+func (d Digest) view() digestView {
+	if d.Frequency == models.DigestWeekly {
+		return digestView{Digest: d, DueLabel: "Đến hạn trong 7 ngày tới", CompletedLabel: "Đã hoàn thành 7 ngày qua"}
+	}
+	return digestView{Digest: d, DueLabel: "Đến hạn hôm nay", CompletedLabel: "Đã hoàn thành hôm qua"}
+}
+
+var digestFuncs = map[string]any{
+	"when": func(t time.Time) string { return t.Format("15:04 02/01/2006") },
+}
+
+var digestText = template.Must(template.New("digest").Funcs(digestFuncs).Parse(
+	`Xin chào {{.User.FullName}},
+
+{{.DueLabel}} ({{len .DueSoon}}):
+{{range .DueSoon}}  - {{.Title}} (hạn {{when .Deadline}}, {{.Priority}})
+{{else}}  (không có)
+{{end}}
+Quá hạn ({{len .Overdue}}):
+{{range .Overdue}}  - {{.Title}} (hạn {{when .Deadline}}, {{.Status}})
+{{else}}  (không có)
+{{end}}
+{{.CompletedLabel}} ({{len .Completed}}):
+{{range .Completed}}  - {{.Title}}
+{{else}}  (không có)
+{{end}}`))
+
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest").Funcs(digestFuncs).Parse(
+	`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<p>Xin chào {{.User.FullName}},</p>
+<h3>{{.DueLabel}} ({{len .DueSoon}})</h3>
+{{if .DueSoon}}<ul>{{range .DueSoon}}<li><b>{{.Title}}</b> &ndash; hạn {{when .Deadline}}, {{.Priority}}</li>{{end}}</ul>{{else}}<p>Không có</p>{{end}}
+<h3 style="color: #c0392b">Quá hạn ({{len .Overdue}})</h3>
+{{if .Overdue}}<ul>{{range .Overdue}}<li><b>{{.Title}}</b> &ndash; hạn {{when .Deadline}}, {{.Status}}</li>{{end}}</ul>{{else}}<p>Không có</p>{{end}}
+<h3 style="color: #27ae60">{{.CompletedLabel}} ({{len .Completed}})</h3>
+{{if .Completed}}<ul>{{range .Completed}}<li>{{.Title}}</li>{{end}}</ul>{{else}}<p>Không có</p>{{end}}
+</body></html>
+`))
+
+// Text trả về nội dung văn bản thuần của bản tổng hợp
+func (d Digest) Text() (string, error) {
+	var buf bytes.Buffer
+	err := digestText.Execute(&buf, d.view())
+	return buf.String(), err
+}
+
+// HTML trả về nội dung HTML của bản tổng hợp
+func (d Digest) HTML() (string, error) {
+	var buf bytes.Buffer
+	err := digestHTML.Execute(&buf, d.view())
+	return buf.String(), err
+}
+
+// SendDigest gửi bản tổng hợp qua các kênh channels (trống là email); lỗi của từng kênh được gộp lại
+func (rt *Router) SendDigest(ctx context.Context, channels []models.NotificationChannel, d Digest) error {
+	if len(channels) == 0 {
+		channels = []models.NotificationChannel{models.ChannelEmail}
+	}
+	var errs []error
+	for _, channel := range channels {
+		notifier, ok := rt.Channels[channel].(DigestNotifier)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: kênh chưa được cấu hình hoặc không hỗ trợ bản tổng hợp", channel))
+			continue
+		}
+		if err := notifier.NotifyDigest(ctx, d); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (LogNotifier) NotifyDigest(ctx context.Context, d Digest) error {
+	log.Printf("📋 %s cho %s: %d sắp đến hạn, %d quá hạn, %d đã hoàn thành",
+		d.Subject(), d.User.Username, len(d.DueSoon), len(d.Overdue), len(d.Completed))
+	return nil
+}
+
+func (en *EmailNotifier) NotifyDigest(ctx context.Context, d Digest) error {
+	if d.User.Email == "" {
+		return fmt.Errorf("người dùng %d chưa có email", d.User.ID)
+	}
+	text, err := d.Text()
+	if err != nil {
+		return err
+	}
+	html, err := d.HTML()
+	if err != nil {
+		return err
+	}
+	return en.SendAlternative(ctx, d.User.Email, d.Subject(), text, html)
+}
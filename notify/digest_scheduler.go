@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/store"
+)
+
+// DefaultDigestInterval là chu kỳ quét mặc định của DigestScheduler
+const DefaultDigestInterval = time.Minute
+
+// DigestScheduler định kỳ gửi bản tổng hợp cho những người dùng đã đến giờ nhận.
+// Mỗi lần gửi trước hết dời next_run_at sang lần kế tiếp; instance nào dời được
+// thì instance đó gửi, nên chạy nhiều instance cùng lúc không gửi trùng.
+// Bản tổng hợp gửi lỗi không được thử lại, người dùng sẽ nhận bản kế tiếp.
+type DigestScheduler struct {
+	Digests store.DigestStore
+	Tasks   store.TaskStore
+	Users   store.UserStore
+	Router  *Router
+
+	Interval  time.Duration
+	BatchSize int
+	// SkipEmpty bỏ qua bản tổng hợp không có công việc nào
+	SkipEmpty bool
+}
+
+// NewDigestScheduler tạo DigestScheduler dùng chung một store với cấu hình mặc định
+func NewDigestScheduler(s store.Store, router *Router) *DigestScheduler {
+	return &DigestScheduler{
+		Digests:   s,
+		Tasks:     s,
+		Users:     s,
+		Router:    router,
+		Interval:  DefaultDigestInterval,
+		BatchSize: DefaultBatchSize,
+		SkipEmpty: true,
+	}
+}
+
+// Run chạy vòng quét cho tới khi ctx bị hủy
+func (ds *DigestScheduler) Run(ctx context.Context) {
+	log.Printf("Bộ gửi bản tổng hợp đang chạy, quét mỗi %s", ds.Interval)
+	ticker := time.NewTicker(ds.Interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := ds.SendDue(ctx); err != nil {
+			log.Printf("Lỗi khi gửi bản tổng hợp: %v", err)
+		} else if sent > 0 {
+			log.Printf("Đã gửi %d bản tổng hợp", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Bộ gửi bản tổng hợp đã dừng")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue gửi các bản tổng hợp đã đến giờ, trả về số bản đã gửi thành công
+func (ds *DigestScheduler) SendDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := ds.Digests.ListDueDigests(ctx, now, ds.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, settings := range due {
+		user, err := ds.Users.GetUser(ctx, settings.UserID)
+		if err != nil {
+			log.Printf("Lỗi khi đọc người dùng %d cho bản tổng hợp: %v", settings.UserID, err)
+			continue
+		}
+
+		// Lần kế tiếp tính theo múi giờ hiện tại của người dùng
+		next := settings.Next(now, user.Location())
+		if err := ds.Digests.AdvanceDigest(ctx, settings.UserID, *settings.NextRunAt, next); err != nil {
+			if !errors.Is(err, store.ErrNotFound) {
+				log.Printf("Lỗi khi hẹn bản tổng hợp kế tiếp của user %d: %v", settings.UserID, err)
+			}
+			continue
+		}
+
+		digest, err := BuildDigest(ctx, ds.Tasks, user, settings.Frequency, now)
+		if err != nil {
+			log.Printf("Lỗi khi lập bản tổng hợp cho user %d: %v", settings.UserID, err)
+			continue
+		}
+		if ds.SkipEmpty && digest.Empty() {
+			continue
+		}
+		if err := ds.Router.SendDigest(ctx, settings.Channels, digest); err != nil {
+			log.Printf("Gửi bản tổng hợp cho user %d thất bại: %v", settings.UserID, err)
+			continue
+		}
+		if err := ds.Digests.MarkDigestSent(ctx, settings.UserID, time.Now()); err != nil {
+			log.Printf("Lỗi khi ghi nhận bản tổng hợp của user %d: %v", settings.UserID, err)
+		}
+		sent++
+	}
+	return sent, nil
+}
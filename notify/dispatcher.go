@@ -0,0 +1,215 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"backend/events"
+	"backend/models"
+	"backend/store"
+)
+
+// Giá trị mặc định của Dispatcher
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultBatchSize = 100
+	DefaultLease     = 2 * time.Minute
+)
+
+// Dispatcher định kỳ quét các nhắc nhở đến hạn, gửi qua từng kênh của Router và
+// ghi lại mỗi lần thử. Kênh gửi lỗi được thử lại theo Backoff (các kênh đã gửi
+// thành công không gửi lại); quá Backoff.MaxAttempts lần thì nhắc nhở chuyển sang failed.
+// Nhắc nhở rơi vào giờ yên lặng của người nhận được hoãn tới cuối khung giờ.
+type Dispatcher struct {
+	Queue     store.ReminderQueue
+	Reminders store.ReminderStore
+	Tasks     store.TaskStore
+	Users     store.UserStore
+	Router    *Router
+
+	Interval  time.Duration
+	BatchSize int
+	// Lease là thời gian giữ quyền nhận; nếu instance dừng giữa chừng, nhắc nhở
+	// được instance khác nhận lại sau Lease
+	Lease   time.Duration
+	Backoff Backoff
+	// Events (nếu có) nhận sự kiện reminder.fired khi nhắc nhở gửi xong
+	Events *events.Hub
+}
+
+// NewDispatcher tạo Dispatcher dùng chung một store với cấu hình mặc định
+func NewDispatcher(s store.Store, router *Router) *Dispatcher {
+	return &Dispatcher{
+		Queue:     s,
+		Reminders: s,
+		Tasks:     s,
+		Users:     s,
+		Router:    router,
+		Interval:  DefaultInterval,
+		BatchSize: DefaultBatchSize,
+		Lease:     DefaultLease,
+		Backoff:   DefaultBackoff,
+	}
+}
+
+// Run chạy vòng quét cho tới khi ctx bị hủy
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Printf("Bộ gửi nhắc nhở đang chạy, quét mỗi %s", d.Interval)
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := d.DispatchDue(ctx); err != nil {
+			log.Printf("Lỗi khi gửi nhắc nhở: %v", err)
+		} else if sent > 0 {
+			log.Printf("Đã gửi %d nhắc nhở", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Bộ gửi nhắc nhở đã dừng")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue gửi toàn bộ nhắc nhở đang đến hạn, trả về số nhắc nhở đã gửi thành công
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		reminders, err := d.Queue.ClaimDueReminders(ctx, time.Now(), d.BatchSize, d.Lease)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, reminder := range reminders {
+			ok, err := d.deliver(ctx, reminder)
+			if errors.Is(err, store.ErrStale) {
+				// Instance khác đã nhận lại hoặc người dùng đã sửa nhắc nhở trong lúc gửi
+				log.Printf("Nhắc nhở #%d đã mất quyền nhận, bỏ qua kết quả gửi", reminder.ID)
+				continue
+			}
+			if err != nil {
+				log.Printf("Lỗi khi xử lý nhắc nhở #%d, sẽ thử lại sau %s: %v", reminder.ID, d.Lease, err)
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+
+		if len(reminders) < d.BatchSize {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// deliver gửi một nhắc nhở qua các kênh chưa gửi thành công và ghi kết quả.
+// ok cho biết nhắc nhở đã gửi xong; err chỉ dành cho lỗi không ghi nhận được
+// (đọc dữ liệu, database) - khi đó nhắc nhở được thử lại sau Lease - hoặc
+// store.ErrStale khi đã mất quyền nhận và kết quả gửi không được ghi.
+func (d *Dispatcher) deliver(ctx context.Context, reminder models.Reminder) (ok bool, err error) {
+	n := Notification{Reminder: reminder}
+	if n.Task, err = d.Tasks.GetTask(ctx, reminder.UserID, reminder.TaskID); err != nil {
+		return false, err
+	}
+	if n.User, err = d.Users.GetUser(ctx, reminder.UserID); err != nil {
+		return false, err
+	}
+
+	// Trong giờ yên lặng thì hoãn tới cuối khung giờ, không tính là một lần thử
+	loc := n.User.Location()
+	if quiet := n.User.QuietHours(); quiet != nil {
+		if end, ok := quiet.Until(time.Now(), loc); ok {
+			log.Printf("Nhắc nhở #%d rơi vào giờ yên lặng của %s, hoãn tới %s", reminder.ID, n.User.Username, end.Format(time.RFC3339))
+			return false, d.Queue.ScheduleRetry(ctx, reminder, reminder.Attempts, end)
+		}
+	}
+	// Nội dung nhắc nhở hiển thị theo múi giờ của người nhận
+	n.Task.In(loc)
+	n.Reminder.In(loc)
+
+	delivered, err := d.deliveredChannels(ctx, reminder)
+	if err != nil {
+		return false, err
+	}
+
+	attempt := reminder.Attempts + 1
+	now := time.Now()
+	var nextRetryAt *time.Time
+	exhausted := attempt >= d.Backoff.MaxAttempts
+	if !exhausted {
+		next := now.Add(d.Backoff.Delay(attempt))
+		nextRetryAt = &next
+	}
+
+	failed := 0
+	for _, channel := range d.Router.ChannelsFor(n) {
+		if delivered[channel] {
+			continue
+		}
+		delivery := models.ReminderDelivery{ReminderID: reminder.ID, Attempt: attempt, Channel: channel, CreatedAt: now}
+		if err := d.Router.NotifyChannel(ctx, channel, n); err != nil {
+			failed++
+			delivery.Error = err.Error()
+			delivery.NextRetryAt = nextRetryAt
+			log.Printf("Gửi nhắc nhở #%d qua %s thất bại (lần %d): %v", reminder.ID, channel, attempt, err)
+		}
+		if err := d.Queue.RecordDelivery(ctx, &delivery); err != nil {
+			return false, err
+		}
+	}
+
+	switch {
+	case failed == 0:
+		sentAt := time.Now()
+		n.Reminder.IsSent = true
+		n.Reminder.Status = models.ReminderSent
+		n.Reminder.SentAt = &sentAt
+		n.Reminder.SnoozedUntil = nil
+		fired := map[string]any{"reminder": n.Reminder, "task": n.Task}
+		// Sự kiện webhook được ghi cùng trạng thái đã gửi; mất quyền nhận thì không ghi gì
+		event := store.Event{Type: string(events.ReminderFired), UserID: reminder.UserID, Data: fired}
+		if err := d.Queue.MarkReminderSent(store.WithEvents(ctx, event), reminder, sentAt); err != nil {
+			return false, err
+		}
+		d.Events.Publish(reminder.UserID, events.ReminderFired, fired)
+		return true, nil
+	case exhausted:
+		log.Printf("Nhắc nhở #%d chuyển sang failed sau %d lần thử", reminder.ID, attempt)
+		return false, d.Queue.MarkReminderFailed(ctx, reminder, attempt)
+	default:
+		return false, d.Queue.ScheduleRetry(ctx, reminder, attempt, *nextRetryAt)
+	}
+}
+
+// deliveredChannels trả về các kênh đã gửi thành công trong lượt gửi hiện tại.
+// Một lượt bắt đầu từ lần thử 1 gần nhất; nhắc nhở được hoãn hoặc hẹn lại
+// (attempts = 0) là lượt mới nên gửi lại qua mọi kênh.
+func (d *Dispatcher) deliveredChannels(ctx context.Context, reminder models.Reminder) (map[models.NotificationChannel]bool, error) {
+	delivered := map[models.NotificationChannel]bool{}
+	if reminder.Attempts == 0 {
+		return delivered, nil
+	}
+	history, err := d.Reminders.ListReminderDeliveries(ctx, reminder.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var roundStart time.Time
+	for _, delivery := range history {
+		if delivery.Attempt == 1 && delivery.CreatedAt.After(roundStart) {
+			roundStart = delivery.CreatedAt
+		}
+	}
+	for _, delivery := range history {
+		if delivery.Succeeded() && !delivery.CreatedAt.Before(roundStart) {
+			delivered[delivery.Channel] = true
+		}
+	}
+	return delivered, nil
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+)
+
+// EmailNotifier gửi nhắc nhở qua SMTP tới email trong hồ sơ người dùng
+type EmailNotifier struct {
+	Addr     string // host:port của máy chủ SMTP
+	From     string
+	Username string // để trống nếu máy chủ không yêu cầu đăng nhập
+	Password string
+}
+
+func (en *EmailNotifier) Notify(ctx context.Context, n Notification) error {
+	if n.User.Email == "" {
+		return fmt.Errorf("người dùng %d chưa có email", n.User.ID)
+	}
+	subject := "Nhắc nhở: " + n.Task.Title
+	body := fmt.Sprintf("Xin chào %s,\r\n\r\nCông việc %q sắp đến hạn vào %s.\r\n\r\n%s\r\n",
+		n.User.FullName, n.Task.Title, n.Task.Deadline.Format("15:04 02/01/2006 (MST)"), n.Task.Description)
+	return en.Send(ctx, n.User.Email, subject, body)
+}
+
+// Send gửi một email văn bản thuần tới to
+func (en *EmailNotifier) Send(ctx context.Context, to, subject, body string) error {
+	var msg bytes.Buffer
+	en.writeHeaders(&msg, to, subject)
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	qp.Write([]byte(body))
+	qp.Close()
+
+	return en.sendMail(ctx, to, msg.Bytes())
+}
+
+// SendAlternative gửi email có cả bản văn bản thuần và bản HTML (multipart/alternative)
+func (en *EmailNotifier) SendAlternative(ctx context.Context, to, subject, text, html string) error {
+	var msg bytes.Buffer
+	en.writeHeaders(&msg, to, subject)
+	mw := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(part.body))
+		qp.Close()
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	return en.sendMail(ctx, to, msg.Bytes())
+}
+
+func (en *EmailNotifier) writeHeaders(msg *bytes.Buffer, to, subject string) {
+	fmt.Fprintf(msg, "From: %s\r\n", en.From)
+	fmt.Fprintf(msg, "To: %s\r\n", to)
+	fmt.Fprintf(msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+}
+
+func (en *EmailNotifier) sendMail(ctx context.Context, to string, msg []byte) error {
+	var auth smtp.Auth
+	if en.Username != "" {
+		host, _, err := net.SplitHostPort(en.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", en.Username, en.Password, host)
+	}
+
+	// smtp.SendMail không nhận context nên chạy trong goroutine để có thể hủy
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(en.Addr, auth, en.From, []string{to}, msg)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,178 @@
+package notify_test
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/notify"
+)
+
+// smtpMessage là một email mà smtpStandIn nhận được
+type smtpMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// smtpStandIn là máy chủ SMTP tối giản chạy trong tiến trình test: nhận mọi thư và
+// chuyển vào Messages
+type smtpStandIn struct {
+	Addr     string
+	Messages chan smtpMessage
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("mở cổng SMTP: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &smtpStandIn{Addr: ln.Addr().String(), Messages: make(chan smtpMessage, 10)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 localhost SMTP test")
+	var msg smtpMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			reply("250 localhost")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg = smtpMessage{From: strings.Trim(line[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			msg.To = append(msg.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case verb == "DATA":
+			reply("354 Kết thúc bằng <CRLF>.<CRLF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			msg.Data = data.String()
+			s.Messages <- msg
+			reply("250 OK")
+		case verb == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpStandIn) receive(t *testing.T) (smtpMessage, *mail.Message) {
+	t.Helper()
+	select {
+	case msg := <-s.Messages:
+		parsed, err := mail.ReadMessage(strings.NewReader(msg.Data))
+		if err != nil {
+			t.Fatalf("đọc email: %v\n%s", err, msg.Data)
+		}
+		return msg, parsed
+	case <-time.After(5 * time.Second):
+		t.Fatal("máy chủ SMTP không nhận được email")
+		return smtpMessage{}, nil
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	smtp := newSMTPStandIn(t)
+	en := &notify.EmailNotifier{Addr: smtp.Addr, From: "nhac-nho@example.com"}
+	user := models.User{ID: 1, Username: "alice", Email: "alice@example.com", FullName: "Alice Nguyễn"}
+	task := models.Task{ID: 7, Title: "Viết báo cáo", Description: "Báo cáo quý",
+		Deadline: time.Date(2030, 1, 2, 9, 30, 0, 0, time.UTC)}
+
+	if err := en.Notify(t.Context(), notify.Notification{Reminder: models.Reminder{ID: 3}, Task: task, User: user}); err != nil {
+		t.Fatalf("gửi nhắc nhở: %v", err)
+	}
+	envelope, msg := smtp.receive(t)
+	if envelope.From != "nhac-nho@example.com" || len(envelope.To) != 1 || envelope.To[0] != "alice@example.com" {
+		t.Errorf("phong bì SMTP: %+v", envelope)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Nhắc nhở: Viết báo cáo" {
+		t.Errorf("Subject: %q, %v", subject, err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatalf("giải mã nội dung: %v", err)
+	}
+	for _, want := range []string{"Xin chào Alice Nguyễn", `"Viết báo cáo"`, "09:30 02/01/2030", "Báo cáo quý"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("nội dung thiếu %q:\n%s", want, body)
+		}
+	}
+
+	// Bản tổng hợp gửi cả văn bản thuần và HTML
+	digest := notify.Digest{User: user, Frequency: models.DigestDaily, Date: task.Deadline, DueSoon: []models.Task{task}}
+	if err := en.NotifyDigest(t.Context(), digest); err != nil {
+		t.Fatalf("gửi bản tổng hợp: %v", err)
+	}
+	_, msg = smtp.receive(t)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type của bản tổng hợp: %q", msg.Header.Get("Content-Type"))
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var types []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("đọc phần của email: %v", err)
+		}
+		content, _ := io.ReadAll(part)
+		if !strings.Contains(string(content), "Viết báo cáo") {
+			t.Errorf("phần %s thiếu công việc:\n%s", part.Header.Get("Content-Type"), content)
+		}
+		types = append(types, part.Header.Get("Content-Type"))
+	}
+	if len(types) != 2 || !strings.HasPrefix(types[0], "text/plain") || !strings.HasPrefix(types[1], "text/html") {
+		t.Errorf("các phần của bản tổng hợp: %v", types)
+	}
+
+	if err := en.Notify(t.Context(), notify.Notification{Task: task, User: models.User{ID: 2}}); err == nil {
+		t.Error("gửi cho người dùng không có email không báo lỗi")
+	}
+}
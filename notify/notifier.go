@@ -0,0 +1,33 @@
+// Package notify gửi nhắc nhở đến hạn tới người dùng. Bộ gửi chạy nền
+// (Dispatcher) nhận nhắc nhở từ database và chuyển cho một Notifier.
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"backend/models"
+)
+
+// Notification là nội dung một lần nhắc: nhắc nhở kèm công việc và người nhận
+type Notification struct {
+	Reminder models.Reminder
+	Task     models.Task
+	User     models.User
+}
+
+// Notifier là một kênh gửi nhắc nhở. Trả về lỗi nếu chưa gửi được;
+// nhắc nhở sẽ được thử lại ở lần quét sau.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// LogNotifier chỉ ghi nhắc nhở ra log, dùng khi chưa cấu hình kênh gửi nào khác
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Printf("🔔 Nhắc nhở #%d cho %s: %q (hạn %s)",
+		n.Reminder.ID, n.User.Username, n.Task.Title, n.Task.Deadline.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,47 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend/models"
+)
+
+// Router chọn kênh gửi cho từng nhắc nhở: kênh riêng của nhắc nhở, nếu trống thì
+// kênh mặc định trong hồ sơ người dùng, nếu vẫn trống thì Default của server
+type Router struct {
+	Channels map[models.NotificationChannel]Notifier
+	Default  []models.NotificationChannel
+}
+
+// ChannelsFor trả về danh sách kênh sẽ dùng để gửi n
+func (rt *Router) ChannelsFor(n Notification) []models.NotificationChannel {
+	if len(n.Reminder.Channels) > 0 {
+		return n.Reminder.Channels
+	}
+	if len(n.User.NotificationChannels) > 0 {
+		return n.User.NotificationChannels
+	}
+	return rt.Default
+}
+
+// Notify gửi qua tất cả các kênh đã chọn; lỗi của từng kênh được gộp lại
+func (rt *Router) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, channel := range rt.ChannelsFor(n) {
+		if err := rt.NotifyChannel(ctx, channel, n); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NotifyChannel gửi n qua đúng một kênh
+func (rt *Router) NotifyChannel(ctx context.Context, channel models.NotificationChannel, n Notification) error {
+	notifier, ok := rt.Channels[channel]
+	if !ok {
+		return fmt.Errorf("kênh %s chưa được cấu hình trên server", channel)
+	}
+	return notifier.Notify(ctx, n)
+}
@@ -0,0 +1,169 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"backend/models"
+)
+
+// Header chứa chữ ký của webhook. Chữ ký là HMAC-SHA256 (hex) của "<timestamp>.<body>"
+// với secret đã cấu hình; bên nhận nên từ chối timestamp quá cũ để chống gửi lại.
+const (
+	SignatureHeader = "X-Todo-Signature"
+	TimestampHeader = "X-Todo-Timestamp"
+)
+
+// WebhookPayload là JSON được POST tới webhook khi nhắc nhở đến hạn
+type WebhookPayload struct {
+	Event        string    `json:"event"`
+	ReminderID   int       `json:"reminder_id"`
+	ReminderTime time.Time `json:"reminder_time"`
+	TaskID       int       `json:"task_id"`
+	Title        string    `json:"title"`
+	Deadline     time.Time `json:"deadline"`
+	UserID       int       `json:"user_id"`
+}
+
+// WebhookNotifier gửi nhắc nhở tới webhook trong hồ sơ của người nhận (webhook_url),
+// ký bằng HMAC-SHA256 với webhook_secret của người đó
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+// NewWebhookNotifier tạo WebhookNotifier với client của NewWebhookClient: URL do người
+// dùng nhập nên địa chỉ nội bộ bị chặn trừ khi allowPrivate
+func NewWebhookNotifier(allowPrivate bool) *WebhookNotifier {
+	return &WebhookNotifier{Client: NewWebhookClient(allowPrivate)}
+}
+
+// endpoint trả về URL và secret webhook của user; lỗi nếu người dùng chưa cấu hình
+func (wn *WebhookNotifier) endpoint(user models.User) (string, string, error) {
+	if user.WebhookURL == "" || user.WebhookSecret == "" {
+		return "", "", fmt.Errorf("người dùng %d chưa cấu hình webhook_url", user.ID)
+	}
+	return user.WebhookURL, user.WebhookSecret, nil
+}
+
+func (wn *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	url, secret, err := wn.endpoint(n.User)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(WebhookPayload{
+		Event:        "reminder.fired",
+		ReminderID:   n.Reminder.ID,
+		ReminderTime: n.Reminder.ReminderTime,
+		TaskID:       n.Task.ID,
+		Title:        n.Task.Title,
+		Deadline:     n.Task.Deadline,
+		UserID:       n.User.ID,
+	})
+	if err != nil {
+		return err
+	}
+	return PostSigned(ctx, wn.Client, url, secret, body)
+}
+
+// DigestWebhookPayload là JSON được POST tới webhook khi gửi bản tổng hợp
+type DigestWebhookPayload struct {
+	Event     string                 `json:"event"`
+	UserID    int                    `json:"user_id"`
+	Frequency models.DigestFrequency `json:"frequency"`
+	Date      time.Time              `json:"date"`
+	DueSoon   []models.Task          `json:"due_soon"`
+	Overdue   []models.Task          `json:"overdue"`
+	Completed []models.Task          `json:"completed"`
+}
+
+func (wn *WebhookNotifier) NotifyDigest(ctx context.Context, d Digest) error {
+	url, secret, err := wn.endpoint(d.User)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(DigestWebhookPayload{
+		Event:     "digest",
+		UserID:    d.User.ID,
+		Frequency: d.Frequency,
+		Date:      d.Date,
+		DueSoon:   d.DueSoon,
+		Overdue:   d.Overdue,
+		Completed: d.Completed,
+	})
+	if err != nil {
+		return err
+	}
+	return PostSigned(ctx, wn.Client, url, secret, body)
+}
+
+// PostSigned POST body tới url kèm timestamp và chữ ký HMAC; lỗi nếu bên nhận không trả 2xx
+func PostSigned(ctx context.Context, client *http.Client, url, secret string, body []byte) error {
+	_, err := SendSigned(ctx, client, url, secret, body, nil)
+	return err
+}
+
+// SendSigned giống PostSigned, gửi thêm các header trong header và trả về mã HTTP của
+// bên nhận (0 nếu không nhận được response)
+func SendSigned(ctx context.Context, client *http.Client, url, secret string, body []byte, header http.Header) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook trả về %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign tính chữ ký HMAC-SHA256 (hex) của "<timestamp>.<body>"
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateWebhookURL kiểm tra URL webhook: bắt buộc HTTPS, trừ localhost khi phát triển
+func ValidateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("URL webhook không hợp lệ: %w", err)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL webhook không hợp lệ: %s", rawURL)
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && isLocalHost(u.Hostname())) {
+		return fmt.Errorf("URL webhook phải dùng https: %s", rawURL)
+	}
+	return nil
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
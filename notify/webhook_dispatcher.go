@@ -0,0 +1,191 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// Header gửi kèm mỗi sự kiện webhook, ngoài chữ ký và timestamp
+const (
+	EventHeader    = "X-Todo-Event"
+	DeliveryHeader = "X-Todo-Delivery"
+)
+
+// DefaultWebhookInterval ngắn hơn DefaultInterval vì bộ gửi chỉ biết có sự kiện mới khi quét hàng đợi
+const DefaultWebhookInterval = 5 * time.Second
+
+// DefaultWebhookBackoff: 30s, 1m, 2m... tối đa 1 giờ, failed sau 8 lần thử (khoảng 1 giờ)
+var DefaultWebhookBackoff = Backoff{Base: 30 * time.Second, Max: time.Hour, MaxAttempts: 8}
+
+// maxWebhookError giới hạn độ dài thông báo lỗi lưu trong nhật ký gửi
+const maxWebhookError = 1000
+
+// WebhookDispatcher gửi các delivery trong hàng đợi webhook_deliveries tới webhook mà
+// người dùng đã đăng ký. Store ghi delivery trong cùng transaction với thay đổi sinh ra sự
+// kiện (outbox, xem store.WithEvents), nên bộ gửi chỉ quét bảng này và webhook chậm không
+// làm chậm request. Lần gửi lỗi (không kết nối được hoặc không trả 2xx) được thử lại theo
+// Backoff; quá Backoff.MaxAttempts lần thì delivery chuyển sang failed và người dùng có
+// thể gửi lại (replay) qua API. Delivery được gửi bởi bất kỳ instance nào đang chạy bộ gửi.
+type WebhookDispatcher struct {
+	Webhooks store.WebhookStore
+	Queue    store.WebhookQueue
+	Client   *http.Client
+
+	Interval  time.Duration
+	BatchSize int
+	Lease     time.Duration
+	Backoff   Backoff
+}
+
+// NewWebhookDispatcher tạo WebhookDispatcher dùng chung một store với cấu hình mặc định.
+// allowPrivate cho phép gửi tới địa chỉ nội bộ (loopback, mạng riêng), chỉ nên bật khi phát triển.
+func NewWebhookDispatcher(s store.Store, allowPrivate bool) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Webhooks:  s,
+		Queue:     s,
+		Client:    NewWebhookClient(allowPrivate),
+		Interval:  DefaultWebhookInterval,
+		BatchSize: DefaultBatchSize,
+		Lease:     DefaultLease,
+		Backoff:   DefaultWebhookBackoff,
+	}
+}
+
+// NewWebhookClient tạo HTTP client gửi webhook. URL webhook do người dùng nhập nên nếu
+// allowPrivate = false, client từ chối kết nối tới địa chỉ loopback, mạng riêng,
+// link-local... (kiểm tra khi kết nối nên không bị vượt qua bằng DNS trỏ về mạng nội bộ)
+// và không đi theo redirect.
+func NewWebhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+				return fmt.Errorf("không được gửi webhook tới địa chỉ nội bộ %s", host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   15 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Run quét hàng đợi và gửi webhook cho tới khi ctx bị hủy
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	log.Printf("Bộ gửi webhook đang chạy, quét mỗi %s", d.Interval)
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		if sent, err := d.DispatchDue(ctx); err != nil {
+			log.Printf("Lỗi khi gửi webhook: %v", err)
+		} else if sent > 0 {
+			log.Printf("Đã gửi %d webhook", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Bộ gửi webhook đã dừng")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue gửi toàn bộ delivery đã đến lượt, trả về số delivery gửi thành công
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	sent := 0
+	for ctx.Err() == nil {
+		deliveries, err := d.Queue.ClaimDueWebhookDeliveries(ctx, time.Now(), d.BatchSize, d.Lease)
+		if err != nil {
+			return sent, err
+		}
+
+		for _, delivery := range deliveries {
+			ok, err := d.deliver(ctx, delivery)
+			if err != nil {
+				log.Printf("Lỗi khi xử lý webhook delivery #%d, sẽ thử lại sau %s: %v", delivery.ID, d.Lease, err)
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+
+		if len(deliveries) < d.BatchSize {
+			break
+		}
+	}
+	return sent, nil
+}
+
+// deliver gửi một delivery và ghi kết quả. err chỉ dành cho lỗi không ghi nhận được
+// (đọc dữ liệu, database); khi đó delivery được thử lại sau Lease.
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery models.WebhookDelivery) (ok bool, err error) {
+	webhook, err := d.Webhooks.GetWebhook(ctx, delivery.UserID, delivery.WebhookID)
+	if errors.Is(err, store.ErrNotFound) {
+		// Webhook vừa bị xóa; delivery bị xóa theo
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	header := http.Header{}
+	header.Set(EventHeader, delivery.Event)
+	header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	status, sendErr := SendSigned(ctx, d.Client, webhook.URL, webhook.Secret, []byte(delivery.Payload), header)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.Error = ""
+	delivery.NextAttemptAt = nil
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookSucceeded
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.Backoff.MaxAttempts:
+		delivery.Status = models.WebhookFailed
+		delivery.Error = truncateError(sendErr)
+		log.Printf("Webhook delivery #%d chuyển sang failed sau %d lần thử: %v", delivery.ID, delivery.Attempts, sendErr)
+	default:
+		next := now.Add(d.Backoff.Delay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+		delivery.Error = truncateError(sendErr)
+		log.Printf("Gửi webhook delivery #%d thất bại (lần %d): %v", delivery.ID, delivery.Attempts, sendErr)
+	}
+	if err := d.Queue.RecordWebhookAttempt(ctx, &delivery); err != nil {
+		return false, err
+	}
+	return sendErr == nil, nil
+}
+
+func truncateError(err error) string {
+	msg := []rune(err.Error())
+	if len(msg) > maxWebhookError {
+		msg = msg[:maxWebhookError]
+	}
+	return string(msg)
+}
@@ -0,0 +1,97 @@
+package notify_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/notify"
+)
+
+// webhookRequest là một request mà webhook giả nhận được
+type webhookRequest struct {
+	Header http.Header
+	Body   []byte
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan webhookRequest, 10)
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		code := status
+		received <- webhookRequest{Header: r.Header, Body: body}
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	const secret = "bi-mat-du-dai-16-ky-tu"
+	user := models.User{ID: 1, Username: "alice", WebhookURL: server.URL + "/hook", WebhookSecret: secret}
+	n := notify.Notification{
+		Reminder: models.Reminder{ID: 3, ReminderTime: time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)},
+		Task:     models.Task{ID: 7, Title: "Viết báo cáo", Deadline: time.Date(2030, 1, 2, 9, 30, 0, 0, time.UTC)},
+		User:     user,
+	}
+	// httptest chạy trên loopback nên cần cho phép địa chỉ nội bộ
+	wn := notify.NewWebhookNotifier(true)
+	if err := wn.Notify(t.Context(), n); err != nil {
+		t.Fatalf("gửi webhook: %v", err)
+	}
+
+	req := <-received
+	timestamp := req.Header.Get(notify.TimestampHeader)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(req.Body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); timestamp == "" || req.Header.Get(notify.SignatureHeader) != want {
+		t.Errorf("chữ ký: %q (timestamp %q), muốn %q", req.Header.Get(notify.SignatureHeader), timestamp, want)
+	}
+	var payload notify.WebhookPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		t.Fatalf("giải mã payload %s: %v", req.Body, err)
+	}
+	if payload.Event != "reminder.fired" || payload.ReminderID != 3 || payload.TaskID != 7 ||
+		payload.Title != "Viết báo cáo" || payload.UserID != 1 || !payload.Deadline.Equal(n.Task.Deadline) {
+		t.Errorf("payload: %+v", payload)
+	}
+
+	// Mỗi người dùng được gửi tới webhook và ký bằng secret của chính họ
+	other := n
+	other.User = models.User{ID: 2, WebhookURL: server.URL + "/other", WebhookSecret: "secret-cua-nguoi-khac"}
+	if err := wn.Notify(t.Context(), other); err != nil {
+		t.Fatalf("gửi webhook của người dùng khác: %v", err)
+	}
+	req = <-received
+	if req.Header.Get(notify.SignatureHeader) != "sha256="+notify.Sign("secret-cua-nguoi-khac", req.Header.Get(notify.TimestampHeader), req.Body) {
+		t.Error("webhook của người dùng khác không được ký bằng secret của họ")
+	}
+
+	status = http.StatusInternalServerError
+	if err := wn.Notify(t.Context(), n); err == nil {
+		t.Error("webhook trả về 500 nhưng không báo lỗi")
+	}
+	<-received
+
+	n.User.WebhookURL = ""
+	if err := wn.Notify(t.Context(), n); err == nil {
+		t.Error("người dùng chưa cấu hình webhook_url nhưng không báo lỗi")
+	}
+
+	// Mặc định không gửi tới địa chỉ nội bộ
+	n.User.WebhookURL = server.URL + "/hook"
+	if err := notify.NewWebhookNotifier(false).Notify(t.Context(), n); err == nil {
+		t.Error("gửi được webhook tới địa chỉ loopback khi không cho phép địa chỉ nội bộ")
+	}
+	select {
+	case req := <-received:
+		t.Errorf("webhook nội bộ nhận được request: %s", req.Body)
+	default:
+	}
+}
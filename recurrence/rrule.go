@@ -0,0 +1,385 @@
+// Package recurrence đọc và tính các lần lặp của quy tắc RRULE (RFC 5545).
+// Hỗ trợ FREQ (DAILY, WEEKLY, MONTHLY, YEARLY), INTERVAL, BYDAY, BYMONTHDAY, COUNT và UNTIL.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// maxPeriods giới hạn số chu kỳ được duyệt để tránh lặp vô hạn
+const maxPeriods = 100000
+
+// ErrNoMoreOccurrences được trả về khi chuỗi lặp đã kết thúc (COUNT/UNTIL)
+var ErrNoMoreOccurrences = errors.New("chuỗi lặp không còn lần tiếp theo")
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// WeekdayNum là một phần tử của BYDAY: thứ Day, kèm số thứ tự N trong tháng nếu có
+// (ví dụ 2MO là thứ Hai thứ hai, -1FR là thứ Sáu cuối cùng; N = 0 là mọi thứ Day)
+type WeekdayNum struct {
+	Day time.Weekday
+	N   int
+}
+
+// Rule là một quy tắc lặp đã được kiểm tra
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// Parse đọc chuỗi RRULE, ví dụ "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10".
+// Tiền tố "RRULE:" được chấp nhận.
+func Parse(s string) (Rule, error) {
+	rule := Rule{Interval: 1}
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return rule, errors.New("RRULE rỗng")
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || value == "" {
+			return rule, fmt.Errorf("thành phần RRULE không hợp lệ: %q", part)
+		}
+		if seen[key] {
+			return rule, fmt.Errorf("%s bị lặp lại", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "FREQ":
+			switch f := Frequency(value); f {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = f
+			default:
+				return rule, fmt.Errorf("FREQ không được hỗ trợ: %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("INTERVAL phải là số nguyên dương")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return rule, fmt.Errorf("COUNT phải là số nguyên dương")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return rule, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, err := parseWeekdayNum(code)
+				if err != nil {
+					return rule, err
+				}
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, code := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(code)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return rule, fmt.Errorf("BYMONTHDAY không hợp lệ: %s", code)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "WKST":
+			if value != "MO" {
+				return rule, fmt.Errorf("chỉ hỗ trợ WKST=MO")
+			}
+		default:
+			return rule, fmt.Errorf("thành phần RRULE không được hỗ trợ: %s", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, errors.New("RRULE thiếu FREQ")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return rule, errors.New("COUNT và UNTIL không được dùng cùng nhau")
+	}
+	if rule.Freq == Yearly && len(rule.ByDay) > 0 {
+		return rule, errors.New("BYDAY không được hỗ trợ với FREQ=YEARLY")
+	}
+	if rule.Freq != Monthly {
+		// Theo RFC 5545, BYDAY có số thứ tự chỉ có nghĩa với FREQ=MONTHLY/YEARLY
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return rule, fmt.Errorf("BYDAY có số thứ tự chỉ được hỗ trợ với FREQ=MONTHLY")
+			}
+		}
+		if len(rule.ByMonthDay) > 0 {
+			return rule, errors.New("BYMONTHDAY chỉ được hỗ trợ với FREQ=MONTHLY")
+		}
+	}
+	sort.Slice(rule.ByDay, func(i, j int) bool {
+		a, b := rule.ByDay[i], rule.ByDay[j]
+		if a.Day != b.Day {
+			return weekdayIndex(a.Day) < weekdayIndex(b.Day)
+		}
+		return a.N < b.N
+	})
+	sort.Ints(rule.ByMonthDay)
+	return rule, nil
+}
+
+// parseWeekdayNum đọc một phần tử BYDAY, ví dụ "MO", "2TU" hoặc "-1FR"
+func parseWeekdayNum(code string) (WeekdayNum, error) {
+	if len(code) < 2 {
+		return WeekdayNum{}, fmt.Errorf("BYDAY không được hỗ trợ: %s", code)
+	}
+	day, ok := weekdayCodes[code[len(code)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("BYDAY không được hỗ trợ: %s", code)
+	}
+	wd := WeekdayNum{Day: day}
+	if prefix := code[:len(code)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return WeekdayNum{}, fmt.Errorf("số thứ tự trong BYDAY không hợp lệ: %s", code)
+		}
+		wd.N = n
+	}
+	return wd, nil
+}
+
+// String trả về phần tử BYDAY ở dạng chuẩn, ví dụ "MO" hoặc "-1FR"
+func (d WeekdayNum) String() string {
+	code := strings.ToUpper(d.Day.String()[:2])
+	if d.N != 0 {
+		return strconv.Itoa(d.N) + code
+	}
+	return code
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// UNTIL dạng ngày bao gồm cả ngày đó
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL không hợp lệ: %s", value)
+}
+
+// String trả về RRULE ở dạng chuẩn (không có tiền tố "RRULE:")
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			codes[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		codes := make([]string, len(r.ByMonthDay))
+		for i, n := range r.ByMonthDay {
+			codes[i] = strconv.Itoa(n)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(codes, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next trả về lần lặp đầu tiên sau thời điểm after của chuỗi bắt đầu tại start.
+// start luôn được tính là lần lặp thứ nhất (như DTSTART trong RFC 5545).
+func (r Rule) Next(start, after time.Time) (time.Time, error) {
+	var next time.Time
+	found := false
+	r.each(start, func(_ int, t time.Time) bool {
+		if t.After(after) {
+			next, found = t, true
+			return false
+		}
+		return true
+	})
+	if !found {
+		return time.Time{}, ErrNoMoreOccurrences
+	}
+	return next, nil
+}
+
+// Index trả về số lần lặp của chuỗi xảy ra trước thời điểm at
+func (r Rule) Index(start, at time.Time) int {
+	index := 0
+	r.each(start, func(i int, t time.Time) bool {
+		if !t.Before(at) {
+			return false
+		}
+		index = i + 1
+		return true
+	})
+	return index
+}
+
+// each duyệt các lần lặp theo thứ tự thời gian cho tới khi fn trả về false
+// hoặc chuỗi kết thúc theo COUNT/UNTIL
+func (r Rule) each(start time.Time, fn func(i int, t time.Time) bool) {
+	n := 0
+	emit := func(t time.Time) bool {
+		if r.Count > 0 && n >= r.Count {
+			return false
+		}
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		ok := fn(n, t)
+		n++
+		return ok
+	}
+
+	if !emit(start) {
+		return
+	}
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.candidates(start, period) {
+			if !t.After(start) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+	}
+}
+
+// candidates trả về các thời điểm thuộc chu kỳ thứ period, theo thứ tự tăng dần
+func (r Rule) candidates(start time.Time, period int) []time.Time {
+	step := period * r.Interval
+	switch r.Freq {
+	case Daily:
+		t := start.AddDate(0, 0, step)
+		if len(r.ByDay) > 0 && !r.hasDay(t.Weekday()) {
+			return nil
+		}
+		return []time.Time{t}
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, 7*step)}
+		}
+		monday := start.AddDate(0, 0, 7*step-weekdayIndex(start.Weekday()))
+		days := make([]time.Time, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, monday.AddDate(0, 0, weekdayIndex(day.Day)))
+		}
+		return days
+	case Monthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(step), 1,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			// Bỏ qua các tháng không có ngày tương ứng (ví dụ ngày 31)
+			t := first.AddDate(0, 0, start.Day()-1)
+			if t.Month() != first.Month() {
+				return nil
+			}
+			return []time.Time{t}
+		}
+		// BYMONTHDAY và BYDAY cùng có mặt thì ngày phải thỏa cả hai; ngày không tồn tại
+		// trong tháng (BYMONTHDAY=31 vào tháng 4) bị bỏ qua
+		length := first.AddDate(0, 1, -1).Day()
+		var days []time.Time
+		for t := first; t.Month() == first.Month(); t = t.AddDate(0, 0, 1) {
+			if r.hasMonthDay(t.Day(), length) && r.hasWeekdayNum(t, length) {
+				days = append(days, t)
+			}
+		}
+		return days
+	case Yearly:
+		t := start.AddDate(step, 0, 0)
+		if t.Day() != start.Day() {
+			// 29/02 chỉ lặp vào năm nhuận
+			return nil
+		}
+		return []time.Time{t}
+	}
+	return nil
+}
+
+func (r Rule) hasDay(day time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+// hasMonthDay cho biết ngày day của tháng dài length ngày có thuộc BYMONTHDAY không
+func (r Rule) hasMonthDay(day, length int) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	for _, n := range r.ByMonthDay {
+		if n == day || n == day-length-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWeekdayNum cho biết ngày t của tháng dài length ngày có thuộc BYDAY không, tính cả
+// số thứ tự của thứ đó trong tháng (đếm từ đầu và từ cuối tháng)
+func (r Rule) hasWeekdayNum(t time.Time, length int) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	nth, fromEnd := (t.Day()-1)/7+1, -((length-t.Day())/7 + 1)
+	for _, d := range r.ByDay {
+		if d.Day == t.Weekday() && (d.N == 0 || d.N == nth || d.N == fromEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdayIndex đánh số thứ trong tuần bắt đầu từ thứ Hai (WKST=MO)
+func weekdayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
@@ -0,0 +1,217 @@
+package recurrence_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend/recurrence"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string // Rỗng nếu RRULE không hợp lệ
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:freq=weekly;interval=2;byday=we,mo", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{"FREQ=MONTHLY;BYDAY=-1FR,+2MO", "FREQ=MONTHLY;BYDAY=2MO,-1FR"},
+		{"FREQ=MONTHLY;BYMONTHDAY=31,-1,1", "FREQ=MONTHLY;BYMONTHDAY=-1,1,31"},
+		{"FREQ=YEARLY;COUNT=3", "FREQ=YEARLY;COUNT=3"},
+		{"FREQ=DAILY;UNTIL=20300102T090000Z", "FREQ=DAILY;UNTIL=20300102T090000Z"},
+		{"FREQ=WEEKLY;WKST=MO", "FREQ=WEEKLY"},
+		{"", ""},
+		{"INTERVAL=2", ""},
+		{"FREQ=HOURLY", ""},
+		{"FREQ=DAILY;INTERVAL=0", ""},
+		{"FREQ=DAILY;COUNT=2;UNTIL=20300101", ""},
+		{"FREQ=DAILY;FREQ=WEEKLY", ""},
+		{"FREQ=WEEKLY;BYDAY=XX", ""},
+		{"FREQ=WEEKLY;BYDAY=1MO", ""},
+		{"FREQ=MONTHLY;BYDAY=6MO", ""},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", ""},
+		{"FREQ=MONTHLY;BYMONTHDAY=0", ""},
+		{"FREQ=WEEKLY;BYMONTHDAY=1", ""},
+		{"FREQ=YEARLY;BYDAY=MO", ""},
+		{"FREQ=WEEKLY;WKST=SU", ""},
+		{"FREQ=DAILY;BYHOUR=9", ""},
+	} {
+		rule, err := recurrence.Parse(tc.in)
+		switch {
+		case tc.want == "" && err == nil:
+			t.Errorf("Parse(%q) = %s, muốn lỗi", tc.in, rule)
+		case tc.want != "" && err != nil:
+			t.Errorf("Parse(%q): %v", tc.in, err)
+		case tc.want != "" && rule.String() != tc.want:
+			t.Errorf("Parse(%q) = %s, muốn %s", tc.in, rule, tc.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("tải múi giờ: %v", err)
+	}
+	date := func(loc *time.Location, year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []string // Mọi lần lặp (kể cả start) tới khi chuỗi kết thúc hoặc đủ số lần cần kiểm tra
+		ended bool     // Chuỗi kết thúc ngay sau want (ErrNoMoreOccurrences)
+	}{
+		{
+			name:  "daily",
+			rule:  "FREQ=DAILY;INTERVAL=2",
+			start: date(time.UTC, 2030, 1, 30, 9),
+			want:  []string{"2030-01-30 09:00 +0000", "2030-02-01 09:00 +0000", "2030-02-03 09:00 +0000"},
+		},
+		{
+			name:  "daily theo thứ",
+			rule:  "FREQ=DAILY;BYDAY=MO,FR",
+			start: date(time.UTC, 2030, 1, 7, 9),
+			want:  []string{"2030-01-07 09:00 +0000", "2030-01-11 09:00 +0000", "2030-01-14 09:00 +0000"},
+		},
+		{
+			name:  "weekly nhiều thứ",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE",
+			start: date(time.UTC, 2030, 1, 7, 9),
+			want: []string{"2030-01-07 09:00 +0000", "2030-01-09 09:00 +0000", "2030-01-14 09:00 +0000",
+				"2030-01-16 09:00 +0000"},
+		},
+		{
+			name:  "weekly cách tuần",
+			rule:  "FREQ=WEEKLY;INTERVAL=2",
+			start: date(time.UTC, 2030, 1, 7, 9),
+			want:  []string{"2030-01-07 09:00 +0000", "2030-01-21 09:00 +0000", "2030-02-04 09:00 +0000"},
+		},
+		{
+			name:  "monthly ngày 31 bỏ qua tháng ngắn",
+			rule:  "FREQ=MONTHLY",
+			start: date(time.UTC, 2030, 1, 31, 9),
+			want:  []string{"2030-01-31 09:00 +0000", "2030-03-31 09:00 +0000", "2030-05-31 09:00 +0000"},
+		},
+		{
+			name:  "BYMONTHDAY=31",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=31",
+			start: date(time.UTC, 2030, 1, 31, 9),
+			want: []string{"2030-01-31 09:00 +0000", "2030-03-31 09:00 +0000", "2030-05-31 09:00 +0000",
+				"2030-07-31 09:00 +0000", "2030-08-31 09:00 +0000"},
+		},
+		{
+			name:  "BYMONTHDAY=-1 là ngày cuối tháng",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: date(time.UTC, 2030, 1, 31, 9),
+			want: []string{"2030-01-31 09:00 +0000", "2030-02-28 09:00 +0000", "2030-03-31 09:00 +0000",
+				"2030-04-30 09:00 +0000"},
+		},
+		{
+			name:  "BYDAY=-1FR là thứ Sáu cuối tháng",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: date(time.UTC, 2030, 1, 25, 9),
+			want: []string{"2030-01-25 09:00 +0000", "2030-02-22 09:00 +0000", "2030-03-29 09:00 +0000",
+				"2030-04-26 09:00 +0000"},
+		},
+		{
+			name:  "BYDAY=2MO là thứ Hai thứ hai",
+			rule:  "FREQ=MONTHLY;BYDAY=2MO",
+			start: date(time.UTC, 2030, 1, 14, 9),
+			want:  []string{"2030-01-14 09:00 +0000", "2030-02-11 09:00 +0000", "2030-03-11 09:00 +0000"},
+		},
+		{
+			name:  "thứ Sáu ngày 13",
+			rule:  "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			start: date(time.UTC, 2030, 9, 13, 9),
+			want:  []string{"2030-09-13 09:00 +0000", "2030-12-13 09:00 +0000", "2031-06-13 09:00 +0000"},
+		},
+		{
+			name:  "yearly",
+			rule:  "FREQ=YEARLY",
+			start: date(time.UTC, 2030, 3, 15, 9),
+			want:  []string{"2030-03-15 09:00 +0000", "2031-03-15 09:00 +0000", "2032-03-15 09:00 +0000"},
+		},
+		{
+			name:  "29/02 chỉ lặp vào năm nhuận",
+			rule:  "FREQ=YEARLY",
+			start: date(time.UTC, 2024, 2, 29, 9),
+			want:  []string{"2024-02-29 09:00 +0000", "2028-02-29 09:00 +0000", "2032-02-29 09:00 +0000"},
+		},
+		{
+			name:  "COUNT",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: date(time.UTC, 2030, 1, 1, 9),
+			want:  []string{"2030-01-01 09:00 +0000", "2030-01-02 09:00 +0000", "2030-01-03 09:00 +0000"},
+			ended: true,
+		},
+		{
+			name:  "UNTIL dạng ngày bao gồm cả ngày đó",
+			rule:  "FREQ=WEEKLY;UNTIL=20300115",
+			start: date(time.UTC, 2030, 1, 1, 9),
+			want:  []string{"2030-01-01 09:00 +0000", "2030-01-08 09:00 +0000", "2030-01-15 09:00 +0000"},
+			ended: true,
+		},
+		{
+			name:  "UNTIL dạng thời điểm",
+			rule:  "FREQ=DAILY;UNTIL=20300102T090000Z",
+			start: date(time.UTC, 2030, 1, 1, 9),
+			want:  []string{"2030-01-01 09:00 +0000", "2030-01-02 09:00 +0000"},
+			ended: true,
+		},
+		{
+			name:  "COUNT với BYDAY có số thứ tự",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR;COUNT=2",
+			start: date(time.UTC, 2030, 1, 25, 9),
+			want:  []string{"2030-01-25 09:00 +0000", "2030-02-22 09:00 +0000"},
+			ended: true,
+		},
+		{
+			// Giờ mùa hè ở New York bắt đầu 10/03/2030: giờ địa phương giữ nguyên 09:00
+			name:  "đổi giờ mùa hè",
+			rule:  "FREQ=DAILY",
+			start: date(newYork, 2030, 3, 9, 9),
+			want:  []string{"2030-03-09 09:00 -0500", "2030-03-10 09:00 -0400", "2030-03-11 09:00 -0400"},
+		},
+		{
+			// Kết thúc giờ mùa hè 03/11/2030
+			name:  "hết giờ mùa hè",
+			rule:  "FREQ=WEEKLY;BYDAY=SA,SU",
+			start: date(newYork, 2030, 11, 2, 9),
+			want:  []string{"2030-11-02 09:00 -0400", "2030-11-03 09:00 -0500", "2030-11-09 09:00 -0500"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := recurrence.Parse(tc.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tc.rule, err)
+			}
+			var got []string
+			at := tc.start
+			for i := 0; ; i++ {
+				got = append(got, at.Format("2006-01-02 15:04 -0700"))
+				if i+1 == len(tc.want) {
+					break
+				}
+				if at, err = rule.Next(tc.start, at); err != nil {
+					t.Fatalf("lần lặp thứ %d: %v (đã có %v)", i+2, err, got)
+				}
+			}
+			for i := range tc.want {
+				if got[i] != tc.want[i] {
+					t.Fatalf("các lần lặp: %v, muốn %v", got, tc.want)
+				}
+			}
+
+			_, err = rule.Next(tc.start, at)
+			if ended := errors.Is(err, recurrence.ErrNoMoreOccurrences); ended != tc.ended {
+				t.Errorf("lần lặp sau %s: %v, muốn kết thúc = %v", got[len(got)-1], err, tc.ended)
+			}
+			if got := rule.Index(tc.start, at); got != len(tc.want)-1 {
+				t.Errorf("Index(%s) = %d, muốn %d", at, got, len(tc.want)-1)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"backend/models"
+)
+
+// Các trường có thể dùng để sắp xếp danh sách công việc
+const (
+	SortDeadline  = "deadline"
+	SortPriority  = "priority"
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+)
+
+const (
+	DefaultTaskLimit = 50
+	MaxTaskLimit     = 200
+)
+
+// ErrInvalidCursor được trả về khi cursor không giải mã được hoặc không khớp cách sắp xếp
+var ErrInvalidCursor = errors.New("cursor không hợp lệ")
+
+// TaskFilter mô tả bộ lọc, cách sắp xếp và phân trang khi liệt kê công việc
+type TaskFilter struct {
+	Status        models.TaskStatus
+	Priority      models.TaskPriority
+	CategoryID    *int
+	DeadlineFrom  *time.Time
+	DeadlineTo    *time.Time
+	Overdue       *bool
+	WithReminders bool
+	// CompletedFrom/CompletedTo lọc theo completed_at
+	CompletedFrom *time.Time
+	CompletedTo   *time.Time
+
+	Sort  string
+	Desc  bool
+	Limit int
+
+	// After là vị trí trang trước đã giải mã từ next_cursor
+	After *TaskCursor
+}
+
+// TaskPage là một trang kết quả; NextCursor rỗng khi đã hết dữ liệu
+type TaskPage struct {
+	Items      []models.Task `json:"items"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+// TaskCursor ghi lại giá trị sắp xếp và ID của phần tử cuối trang trước
+type TaskCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// IsOverdue dùng cùng định nghĩa với overdue_tasks trong thống kê
+func IsOverdue(task models.Task, now time.Time) bool {
+	return task.Deadline.Before(now) && task.Status != models.StatusCompleted
+}
+
+// SortValue trả về giá trị sắp xếp của công việc theo trường sort dưới dạng chuỗi
+func SortValue(task models.Task, sort string) string {
+	switch sort {
+	case SortDeadline:
+		return task.Deadline.UTC().Format(time.RFC3339Nano)
+	case SortPriority:
+		return strconv.Itoa(task.Priority.Rank())
+	case SortUpdatedAt:
+		return task.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return task.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// EncodeTaskCursor tạo next_cursor từ phần tử cuối của trang hiện tại
+func EncodeTaskCursor(filter TaskFilter, last models.Task) string {
+	cursor := TaskCursor{Sort: filter.Sort, Desc: filter.Desc, Value: SortValue(last, filter.Sort), ID: last.ID}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeTaskCursor giải mã cursor và kiểm tra nó được tạo với cùng cách sắp xếp
+func DecodeTaskCursor(value string, sort string, desc bool) (*TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor TaskCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sort || cursor.Desc != desc {
+		return nil, ErrInvalidCursor
+	}
+	if sort == SortPriority {
+		if _, err := strconv.Atoi(cursor.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	} else if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// CursorTime trả về giá trị thời gian trong cursor (với các trường sắp xếp kiểu thời gian)
+func (c *TaskCursor) CursorTime() time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, c.Value)
+	return t
+}
+
+// CursorRank trả về thứ hạng độ ưu tiên trong cursor (khi sắp xếp theo priority)
+func (c *TaskCursor) CursorRank() int {
+	rank, _ := strconv.Atoi(c.Value)
+	return rank
+}
+
+// ListAllTasks đọc mọi trang của bộ lọc
+func ListAllTasks(ctx context.Context, tasks TaskStore, userID int, filter TaskFilter) ([]models.Task, error) {
+	all := []models.Task{}
+	err := EachTask(ctx, tasks, userID, filter, func(task models.Task) error {
+		all = append(all, task)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// EachTask gọi fn với từng công việc của bộ lọc, đọc từng trang để không giữ toàn bộ
+// danh sách trong bộ nhớ. Dừng và trả về lỗi đầu tiên của fn.
+func EachTask(ctx context.Context, tasks TaskStore, userID int, filter TaskFilter, fn func(models.Task) error) error {
+	filter.Limit = MaxTaskLimit
+	for {
+		page, err := tasks.ListTasks(ctx, userID, filter)
+		if err != nil {
+			return err
+		}
+		for _, task := range page.Items {
+			if err := fn(task); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		if filter.After, err = DecodeTaskCursor(page.NextCursor, filter.Sort, filter.Desc); err != nil {
+			return err
+		}
+	}
+}
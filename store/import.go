@@ -0,0 +1,17 @@
+package store
+
+import "backend/models"
+
+// ImportBatch là dữ liệu nhập từ công cụ khác: các danh mục mới và các công việc.
+// Sau khi nhập, ID của danh mục và công việc được điền vào batch.
+type ImportBatch struct {
+	Categories []models.Category
+	Tasks      []ImportedTask
+}
+
+// ImportedTask là một công việc của batch. Category là chỉ số của danh mục mới trong
+// ImportBatch.Categories, hoặc -1 nếu Task.CategoryID đã trỏ tới danh mục có sẵn.
+type ImportedTask struct {
+	Task     models.Task
+	Category int
+}
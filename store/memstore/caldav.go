@@ -0,0 +1,34 @@
+package memstore
+
+import (
+	"context"
+
+	"backend/models"
+)
+
+func (s *Store) ListCalDAVObjects(ctx context.Context, userID int) ([]models.CalDAVObject, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	objects := []models.CalDAVObject{}
+	for _, object := range s.caldavObjects {
+		if object.UserID == userID {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (s *Store) SaveCalDAVObject(ctx context.Context, object models.CalDAVObject) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.caldavObjects[:0]
+	for _, existing := range s.caldavObjects {
+		if existing.UserID != object.UserID || (existing.Name != object.Name && existing.TaskID != object.TaskID) {
+			kept = append(kept, existing)
+		}
+	}
+	s.caldavObjects = append(kept, object)
+	return nil
+}
@@ -0,0 +1,37 @@
+package memstore
+
+import (
+	"context"
+
+	"backend/store"
+)
+
+func (s *Store) GetCalendarToken(ctx context.Context, userID int) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokenHash, ok := s.calendarTokens[userID]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+	return tokenHash, nil
+}
+
+func (s *Store) SaveCalendarToken(ctx context.Context, userID int, tokenHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calendarTokens[userID] = tokenHash
+	return nil
+}
+
+func (s *Store) DeleteCalendarToken(ctx context.Context, userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.calendarTokens[userID]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.calendarTokens, userID)
+	return nil
+}
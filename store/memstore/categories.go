@@ -0,0 +1,81 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) CreateCategory(ctx context.Context, category *models.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.categories {
+		if c.UserID == category.UserID && c.CategoryName == category.CategoryName {
+			return store.ErrConflict
+		}
+	}
+	category.ID = s.newID("categories")
+	s.categories[category.ID] = *category
+	s.recordChange(category.UserID, models.SyncCategory, category.ID, false)
+	return s.writeOutbox(ctx)
+}
+
+func (s *Store) GetCategory(ctx context.Context, userID, id int) (models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	category, ok := s.categories[id]
+	if !ok || category.UserID != userID {
+		return models.Category{}, store.ErrNotFound
+	}
+	return category, nil
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, category *models.Category) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.categories[category.ID]
+	if !ok || existing.UserID != category.UserID {
+		return store.ErrNotFound
+	}
+	if err := s.checkPrecondition(ctx, models.SyncCategory, category.ID); err != nil {
+		return err
+	}
+	s.categories[category.ID] = *category
+	s.recordChange(category.UserID, models.SyncCategory, category.ID, false)
+	return s.writeOutbox(ctx)
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, userID, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	category, ok := s.categories[id]
+	if !ok || category.UserID != userID {
+		return store.ErrNotFound
+	}
+	if err := s.checkPrecondition(ctx, models.SyncCategory, id); err != nil {
+		return err
+	}
+	delete(s.categories, id)
+	s.recordChange(userID, models.SyncCategory, id, true)
+	return s.writeOutbox(ctx)
+}
+
+func (s *Store) ListCategories(ctx context.Context, userID int) ([]models.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := []models.Category{}
+	for _, category := range s.categories {
+		if category.UserID == userID {
+			categories = append(categories, category)
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
@@ -0,0 +1,74 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) GetDigestSettings(ctx context.Context, userID int) (models.DigestSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	settings, ok := s.digests[userID]
+	if !ok {
+		return models.DigestSettings{}, store.ErrNotFound
+	}
+	return settings, nil
+}
+
+func (s *Store) SaveDigestSettings(ctx context.Context, settings *models.DigestSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.digests[settings.UserID]
+	settings.LastSentAt = existing.LastSentAt
+	s.digests[settings.UserID] = *settings
+	return nil
+}
+
+func (s *Store) ListDueDigests(ctx context.Context, now time.Time, limit int) ([]models.DigestSettings, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	due := []models.DigestSettings{}
+	for _, settings := range s.digests {
+		if settings.Frequency != models.DigestOff && settings.NextRunAt != nil && !settings.NextRunAt.After(now) {
+			due = append(due, settings)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextRunAt.Before(*due[j].NextRunAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *Store) AdvanceDigest(ctx context.Context, userID int, from time.Time, to *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.digests[userID]
+	if !ok || settings.NextRunAt == nil || !settings.NextRunAt.Equal(from) {
+		return store.ErrNotFound
+	}
+	settings.NextRunAt = to
+	s.digests[userID] = settings
+	return nil
+}
+
+func (s *Store) MarkDigestSent(ctx context.Context, userID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings, ok := s.digests[userID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	settings.LastSentAt = &at
+	s.digests[userID] = settings
+	return nil
+}
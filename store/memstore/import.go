@@ -0,0 +1,50 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) ImportTasks(ctx context.Context, userID int, batch *store.ImportBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Kiểm tra trước khi ghi để lỗi không để lại dữ liệu dở dang
+	names := map[string]bool{}
+	for _, category := range s.categories {
+		if category.UserID == userID {
+			names[category.CategoryName] = true
+		}
+	}
+	for _, category := range batch.Categories {
+		if names[category.CategoryName] {
+			return store.ErrConflict
+		}
+		names[category.CategoryName] = true
+	}
+
+	for i := range batch.Categories {
+		category := &batch.Categories[i]
+		category.UserID = userID
+		category.ID = s.newID("categories")
+		s.categories[category.ID] = *category
+		s.recordChange(userID, models.SyncCategory, category.ID, false)
+	}
+	now := time.Now()
+	for i := range batch.Tasks {
+		task := &batch.Tasks[i].Task
+		task.UserID = userID
+		if batch.Tasks[i].Category >= 0 {
+			task.CategoryID = batch.Categories[batch.Tasks[i].Category].ID
+		}
+		task.ID = s.newID("tasks")
+		task.CreatedAt = now
+		task.UpdatedAt = now
+		s.tasks[task.ID] = *task
+		s.recordChange(userID, models.SyncTask, task.ID, false)
+	}
+	return s.writeOutbox(ctx)
+}
@@ -0,0 +1,70 @@
+// Package memstore cài đặt store.Store hoàn toàn trong bộ nhớ, dùng cho test
+// handler và chạy thử mà không cần MySQL.
+package memstore
+
+import (
+	"sync"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+type Store struct {
+	mu sync.RWMutex
+
+	users      map[int]models.User
+	sessions   map[int]store.Session
+	tasks      map[int]models.Task
+	categories map[int]models.Category
+	reminders  map[int]models.Reminder
+	// claims lưu quyền nhận của nhắc nhở đang được bộ gửi nền xử lý
+	claims     map[int]reminderClaim
+	deliveries map[int]models.ReminderDelivery
+	snoozes    map[int]models.ReminderSnooze
+	digests    map[int]models.DigestSettings // theo user_id
+	// calendarTokens lưu giá trị băm của token feed lịch theo user_id
+	calendarTokens map[int]string
+	caldavObjects  []models.CalDAVObject
+	webhooks       map[int]models.Webhook
+	// webhookClaims lưu hạn nhận của delivery đang được bộ gửi webhook xử lý
+	webhookClaims     map[int]time.Time
+	webhookDeliveries map[int]models.WebhookDelivery
+	// changes là nhật ký đồng bộ, sắp theo thứ tự ghi (seq tăng dần trong mỗi người dùng)
+	changes []models.Change
+	// syncSeq là seq cuối đã cấp trong nhật ký đồng bộ của từng người dùng (theo user_id)
+	syncSeq map[int]int64
+	// prunedSeq là seq lớn nhất của tombstone đã bị PruneTombstones xóa (theo user_id)
+	prunedSeq map[int]int64
+
+	nextID map[string]int
+}
+
+var _ store.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{
+		users:             map[int]models.User{},
+		sessions:          map[int]store.Session{},
+		tasks:             map[int]models.Task{},
+		categories:        map[int]models.Category{},
+		reminders:         map[int]models.Reminder{},
+		claims:            map[int]reminderClaim{},
+		deliveries:        map[int]models.ReminderDelivery{},
+		snoozes:           map[int]models.ReminderSnooze{},
+		digests:           map[int]models.DigestSettings{},
+		calendarTokens:    map[int]string{},
+		webhooks:          map[int]models.Webhook{},
+		webhookClaims:     map[int]time.Time{},
+		webhookDeliveries: map[int]models.WebhookDelivery{},
+		syncSeq:           map[int]int64{},
+		prunedSeq:         map[int]int64{},
+		nextID:            map[string]int{},
+	}
+}
+
+// newID cấp ID tự tăng cho từng bảng, giống AUTO_INCREMENT. Gọi khi đang giữ khóa ghi.
+func (s *Store) newID(table string) int {
+	s.nextID[table]++
+	return s.nextID[table]
+}
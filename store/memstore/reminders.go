@@ -0,0 +1,396 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) CreateReminder(ctx context.Context, reminder *models.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminder.ID = s.newID("reminders")
+	if reminder.Status == "" {
+		reminder.Status = models.ReminderScheduled
+	}
+	s.reminders[reminder.ID] = *reminder
+	s.recordChange(reminder.UserID, models.SyncReminder, reminder.ID, false)
+	return nil
+}
+
+func (s *Store) HasReminderBetween(ctx context.Context, userID, taskID int, from, to time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, reminder := range s.reminders {
+		if reminder.UserID == userID && reminder.TaskID == taskID &&
+			!reminder.ReminderTime.Before(from) && !reminder.ReminderTime.After(to) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Store) GetReminder(ctx context.Context, userID, id int) (models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reminder, ok := s.reminders[id]
+	if !ok || reminder.UserID != userID {
+		return models.Reminder{}, store.ErrNotFound
+	}
+	return reminder, nil
+}
+
+func (s *Store) ListTaskReminders(ctx context.Context, userID, taskID int) ([]models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reminders := []models.Reminder{}
+	for _, reminder := range s.reminders {
+		if reminder.UserID == userID && reminder.TaskID == taskID {
+			reminders = append(reminders, reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].ID < reminders[j].ID })
+	return reminders, nil
+}
+
+func (s *Store) ListReminders(ctx context.Context, userID int) ([]models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reminders := []models.Reminder{}
+	for _, reminder := range s.reminders {
+		if reminder.UserID == userID {
+			reminders = append(reminders, reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].ReminderTime.Equal(reminders[j].ReminderTime) {
+			return reminders[i].ReminderTime.Before(reminders[j].ReminderTime)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+	return reminders, nil
+}
+
+func (s *Store) ListRemindersAfter(ctx context.Context, userID, afterID, limit int) ([]models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reminders := []models.Reminder{}
+	for _, reminder := range s.reminders {
+		if reminder.UserID == userID && reminder.ID > afterID {
+			reminders = append(reminders, reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].ID < reminders[j].ID })
+	if len(reminders) > limit {
+		reminders = reminders[:limit]
+	}
+	return reminders, nil
+}
+
+func (s *Store) UpdateReminder(ctx context.Context, reminder *models.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.reminders[reminder.ID]
+	if !ok || existing.UserID != reminder.UserID {
+		return store.ErrNotFound
+	}
+	existing.ReminderTime = reminder.ReminderTime
+	existing.OffsetMinutes = reminder.OffsetMinutes
+	existing.IsSent = reminder.IsSent
+	existing.Channels = reminder.Channels
+	existing.Status = reminder.Status
+	existing.Attempts = reminder.Attempts
+	existing.NextAttemptAt = reminder.NextAttemptAt
+	existing.SnoozedUntil = reminder.SnoozedUntil
+	existing.AcknowledgedAt = reminder.AcknowledgedAt
+	s.reminders[reminder.ID] = existing
+	delete(s.claims, reminder.ID)
+	s.recordChange(existing.UserID, models.SyncReminder, reminder.ID, false)
+	return nil
+}
+
+// updateTaskReminders cho change sửa từng nhắc nhở của công việc taskID và ghi lại những
+// nhắc nhở change trả về true; quyền nhận của bộ gửi nền bị xóa như UpdateReminder.
+// Người gọi phải giữ s.mu.
+func (s *Store) updateTaskReminders(userID, taskID int, change func(*models.Reminder) bool) {
+	var ids []int
+	for id, reminder := range s.reminders {
+		if reminder.TaskID == taskID && reminder.UserID == userID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		reminder := s.reminders[id]
+		if !change(&reminder) {
+			continue
+		}
+		s.reminders[id] = reminder
+		delete(s.claims, id)
+		s.recordChange(userID, models.SyncReminder, id, false)
+	}
+}
+
+func (s *Store) DeleteReminder(ctx context.Context, userID, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminder, ok := s.reminders[id]
+	if !ok || reminder.UserID != userID {
+		return store.ErrNotFound
+	}
+	delete(s.reminders, id)
+	delete(s.claims, id)
+	for did, delivery := range s.deliveries {
+		if delivery.ReminderID == id {
+			delete(s.deliveries, did)
+		}
+	}
+	for sid, snooze := range s.snoozes {
+		if snooze.ReminderID == id {
+			delete(s.snoozes, sid)
+		}
+	}
+	s.recordChange(userID, models.SyncReminder, id, true)
+	return nil
+}
+
+// reminderClaim tương ứng cột claim_token/claimed_until trong sqlstore
+type reminderClaim struct {
+	token string
+	until time.Time
+}
+
+func (s *Store) ClaimDueReminders(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []models.Reminder{}
+	for _, reminder := range s.reminders {
+		claim, claimed := s.claims[reminder.ID]
+		if reminder.IsSent || !reminderDue(reminder, now) ||
+			(reminder.NextAttemptAt != nil && reminder.NextAttemptAt.After(now)) ||
+			(claimed && !claim.until.Before(now)) {
+			continue
+		}
+		due = append(due, reminder)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ReminderTime.Before(due[j].ReminderTime) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	token := fmt.Sprintf("claim-%d", s.newID("claims"))
+	for i := range due {
+		due[i].ClaimToken = token
+		s.claims[due[i].ID] = reminderClaim{token: token, until: now.Add(lease)}
+	}
+	return due, nil
+}
+
+// claimedReminder trả về nhắc nhở nếu nó vẫn đang được giữ bằng ClaimToken của claimed
+func (s *Store) claimedReminder(claimed models.Reminder) (models.Reminder, error) {
+	reminder, ok := s.reminders[claimed.ID]
+	if !ok || s.claims[claimed.ID].token != claimed.ClaimToken {
+		return models.Reminder{}, store.ErrStale
+	}
+	return reminder, nil
+}
+
+func (s *Store) MarkReminderSent(ctx context.Context, claimed models.Reminder, sentAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := claimed.ID
+	reminder, err := s.claimedReminder(claimed)
+	if err != nil {
+		return err
+	}
+	reminder.IsSent = true
+	reminder.Status = models.ReminderSent
+	reminder.SentAt = &sentAt
+	reminder.NextAttemptAt = nil
+	reminder.SnoozedUntil = nil
+	s.reminders[id] = reminder
+	delete(s.claims, id)
+	s.recordChange(reminder.UserID, models.SyncReminder, id, false)
+	return s.writeOutbox(ctx)
+}
+
+// reminderDue giống điều kiện thời gian của dueReminderCond trong sqlstore
+func reminderDue(reminder models.Reminder, now time.Time) bool {
+	switch reminder.Status {
+	case models.ReminderScheduled:
+		return !reminder.ReminderTime.After(now)
+	case models.ReminderSnoozed:
+		return reminder.SnoozedUntil != nil && !reminder.SnoozedUntil.After(now)
+	}
+	return false
+}
+
+func (s *Store) ScheduleRetry(ctx context.Context, claimed models.Reminder, attempts int, nextAttemptAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := claimed.ID
+	reminder, err := s.claimedReminder(claimed)
+	if err != nil {
+		return err
+	}
+	reminder.Attempts = attempts
+	reminder.NextAttemptAt = &nextAttemptAt
+	s.reminders[id] = reminder
+	delete(s.claims, id)
+	return nil
+}
+
+func (s *Store) MarkReminderFailed(ctx context.Context, claimed models.Reminder, attempts int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := claimed.ID
+	reminder, err := s.claimedReminder(claimed)
+	if err != nil {
+		return err
+	}
+	reminder.Status = models.ReminderFailed
+	reminder.Attempts = attempts
+	reminder.NextAttemptAt = nil
+	s.reminders[id] = reminder
+	delete(s.claims, id)
+	s.recordChange(reminder.UserID, models.SyncReminder, id, false)
+	return nil
+}
+
+func (s *Store) ListFailedReminders(ctx context.Context, userID int) ([]models.Reminder, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reminders := []models.Reminder{}
+	for _, reminder := range s.reminders {
+		if reminder.UserID == userID && reminder.Status == models.ReminderFailed {
+			reminders = append(reminders, reminder)
+		}
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i].ReminderTime.Before(reminders[j].ReminderTime) })
+	return reminders, nil
+}
+
+func (s *Store) RedriveReminder(ctx context.Context, userID, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminder, ok := s.reminders[id]
+	if !ok || reminder.UserID != userID || reminder.Status != models.ReminderFailed {
+		return store.ErrNotFound
+	}
+	reminder.Status = models.ReminderScheduled
+	reminder.Attempts = 0
+	reminder.NextAttemptAt = nil
+	s.reminders[id] = reminder
+	s.recordChange(userID, models.SyncReminder, id, false)
+	return nil
+}
+
+func (s *Store) RecordDelivery(ctx context.Context, delivery *models.ReminderDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+	delivery.ID = s.newID("reminder_deliveries")
+	s.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+func (s *Store) ListReminderDeliveries(ctx context.Context, reminderID int) ([]models.ReminderDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := []models.ReminderDelivery{}
+	for _, delivery := range s.deliveries {
+		if delivery.ReminderID == reminderID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+func (s *Store) SnoozeReminder(ctx context.Context, userID, id int, until, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminder, ok := s.reminders[id]
+	if !ok || reminder.UserID != userID ||
+		(reminder.Status != models.ReminderSent && reminder.Status != models.ReminderSnoozed) {
+		return store.ErrNotFound
+	}
+	reminder.Status = models.ReminderSnoozed
+	reminder.IsSent = false
+	reminder.SnoozedUntil = &until
+	reminder.SnoozeCount++
+	reminder.Attempts = 0
+	reminder.NextAttemptAt = nil
+	s.reminders[id] = reminder
+	delete(s.claims, id)
+
+	snoozeID := s.newID("reminder_snoozes")
+	s.snoozes[snoozeID] = models.ReminderSnooze{
+		ID:           snoozeID,
+		ReminderID:   id,
+		UserID:       userID,
+		SnoozedAt:    at,
+		SnoozedUntil: until,
+	}
+	s.recordChange(userID, models.SyncReminder, id, false)
+	return nil
+}
+
+func (s *Store) AcknowledgeReminder(ctx context.Context, userID, id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reminder, ok := s.reminders[id]
+	if !ok || reminder.UserID != userID {
+		return store.ErrNotFound
+	}
+	switch reminder.Status {
+	case models.ReminderSent, models.ReminderSnoozed, models.ReminderFailed:
+	default:
+		return store.ErrNotFound
+	}
+	reminder.Status = models.ReminderAcknowledged
+	reminder.SnoozedUntil = nil
+	reminder.NextAttemptAt = nil
+	reminder.AcknowledgedAt = &at
+	s.reminders[id] = reminder
+	delete(s.claims, id)
+	s.recordChange(userID, models.SyncReminder, id, false)
+	return nil
+}
+
+func (s *Store) ListReminderSnoozes(ctx context.Context, userID, reminderID int) ([]models.ReminderSnooze, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snoozes := []models.ReminderSnooze{}
+	for _, snooze := range s.snoozes {
+		if snooze.UserID == userID && snooze.ReminderID == reminderID {
+			snoozes = append(snoozes, snooze)
+		}
+	}
+	sort.Slice(snoozes, func(i, j int) bool { return snoozes[i].ID < snoozes[j].ID })
+	return snoozes, nil
+}
@@ -0,0 +1,68 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"backend/store"
+)
+
+func (s *Store) CreateSession(ctx context.Context, session *store.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	session.ID = s.newID("sessions")
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *Store) GetSessionByAccessToken(ctx context.Context, accessTokenHash string, now time.Time) (store.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, session := range s.sessions {
+		if session.AccessTokenHash == accessTokenHash && session.AccessExpiresAt.After(now) {
+			return session, nil
+		}
+	}
+	return store.Session{}, store.ErrNotFound
+}
+
+func (s *Store) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string, now time.Time) (store.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, session := range s.sessions {
+		if session.RefreshTokenHash == refreshTokenHash && session.RefreshExpiresAt.After(now) {
+			return session, nil
+		}
+	}
+	return store.Session{}, store.ErrNotFound
+}
+
+func (s *Store) RotateSession(ctx context.Context, oldRefreshTokenHash string, session *store.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.sessions[session.ID]
+	if !ok || existing.RefreshTokenHash != oldRefreshTokenHash {
+		return store.ErrNotFound
+	}
+	existing.AccessTokenHash = session.AccessTokenHash
+	existing.AccessExpiresAt = session.AccessExpiresAt
+	existing.RefreshTokenHash = session.RefreshTokenHash
+	existing.RefreshExpiresAt = session.RefreshExpiresAt
+	s.sessions[session.ID] = existing
+	return nil
+}
+
+func (s *Store) DeleteSession(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
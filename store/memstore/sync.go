@@ -0,0 +1,97 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// recordChange ghi thay đổi của một bản ghi và bỏ thay đổi cũ hơn của nó.
+// Gọi khi đang giữ khóa ghi.
+func (s *Store) recordChange(userID int, entity models.SyncEntity, id int, deleted bool) {
+	kept := s.changes[:0]
+	for _, change := range s.changes {
+		if change.Entity != entity || change.EntityID != id {
+			kept = append(kept, change)
+		}
+	}
+	s.syncSeq[userID]++
+	s.changes = append(kept, models.Change{
+		Seq:       s.syncSeq[userID],
+		UserID:    userID,
+		Entity:    entity,
+		EntityID:  id,
+		Deleted:   deleted,
+		ChangedAt: time.Now(),
+	})
+}
+
+// checkPrecondition so điều kiện phiên bản trong ctx (nếu có) với thay đổi gần nhất của
+// bản ghi. Gọi khi đang giữ khóa ghi, trước khi sửa bản ghi.
+func (s *Store) checkPrecondition(ctx context.Context, entity models.SyncEntity, id int) error {
+	cond, ok := store.PreconditionFor(ctx, entity, id)
+	if !ok {
+		return nil
+	}
+	for _, change := range s.changes {
+		if change.Entity == entity && change.EntityID == id {
+			if cond.Conflicts(change) {
+				return store.ErrPrecondition
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListChanges(ctx context.Context, userID int, since int64, limit int) ([]models.Change, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Thay đổi của mỗi người dùng trong s.changes luôn được sắp theo seq tăng dần
+	changes := []models.Change{}
+	for _, change := range s.changes {
+		if change.UserID == userID && change.Seq > since && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (s *Store) PrunedSeq(ctx context.Context, userID int) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.prunedSeq[userID], nil
+}
+
+func (s *Store) PruneTombstones(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pruned int64
+	kept := s.changes[:0]
+	for _, change := range s.changes {
+		if change.Deleted && change.ChangedAt.Before(before) {
+			s.prunedSeq[change.UserID] = max(s.prunedSeq[change.UserID], change.Seq)
+			pruned++
+			continue
+		}
+		kept = append(kept, change)
+	}
+	s.changes = kept
+	return pruned, nil
+}
+
+func (s *Store) LatestChange(ctx context.Context, userID int, entity models.SyncEntity, id int) (models.Change, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, change := range s.changes {
+		if change.UserID == userID && change.Entity == entity && change.EntityID == id {
+			return change, nil
+		}
+	}
+	return models.Change{}, store.ErrNotFound
+}
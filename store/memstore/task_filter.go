@@ -0,0 +1,129 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// matchTask kiểm tra công việc có thỏa bộ lọc hay không (không xét cursor)
+func (s *Store) matchTask(task models.Task, userID int, filter store.TaskFilter, now time.Time) bool {
+	if task.UserID != userID {
+		return false
+	}
+	if filter.Status != "" && task.Status != filter.Status {
+		return false
+	}
+	if filter.Priority != "" && task.Priority != filter.Priority {
+		return false
+	}
+	if filter.CategoryID != nil && task.CategoryID != *filter.CategoryID {
+		return false
+	}
+	if filter.DeadlineFrom != nil && task.Deadline.Before(*filter.DeadlineFrom) {
+		return false
+	}
+	if filter.DeadlineTo != nil && task.Deadline.After(*filter.DeadlineTo) {
+		return false
+	}
+	if filter.Overdue != nil && store.IsOverdue(task, now) != *filter.Overdue {
+		return false
+	}
+	if filter.CompletedFrom != nil && (task.CompletedAt == nil || task.CompletedAt.Before(*filter.CompletedFrom)) {
+		return false
+	}
+	if filter.CompletedTo != nil && (task.CompletedAt == nil || task.CompletedAt.After(*filter.CompletedTo)) {
+		return false
+	}
+	if filter.WithReminders {
+		for _, reminder := range s.reminders {
+			if reminder.TaskID == task.ID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// compareTasks so sánh hai công việc theo trường sắp xếp rồi theo ID (tăng dần)
+func compareTasks(a, b models.Task, field string) int {
+	var diff int
+	switch field {
+	case store.SortPriority:
+		diff = a.Priority.Rank() - b.Priority.Rank()
+	case store.SortDeadline:
+		diff = a.Deadline.Compare(b.Deadline)
+	case store.SortUpdatedAt:
+		diff = a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		diff = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if diff == 0 {
+		diff = a.ID - b.ID
+	}
+	return diff
+}
+
+// afterCursor cho biết công việc có nằm sau vị trí cursor theo thứ tự sắp xếp không
+func afterCursor(task models.Task, filter store.TaskFilter) bool {
+	cursorTask := models.Task{ID: filter.After.ID}
+	switch filter.Sort {
+	case store.SortPriority:
+		// So sánh trực tiếp theo thứ hạng trong cursor
+		diff := task.Priority.Rank() - filter.After.CursorRank()
+		if diff == 0 {
+			diff = task.ID - filter.After.ID
+		}
+		if filter.Desc {
+			return diff < 0
+		}
+		return diff > 0
+	case store.SortDeadline:
+		cursorTask.Deadline = filter.After.CursorTime()
+	case store.SortUpdatedAt:
+		cursorTask.UpdatedAt = filter.After.CursorTime()
+	default:
+		cursorTask.CreatedAt = filter.After.CursorTime()
+	}
+	diff := compareTasks(task, cursorTask, filter.Sort)
+	if filter.Desc {
+		return diff < 0
+	}
+	return diff > 0
+}
+
+func (s *Store) ListTasks(ctx context.Context, userID int, filter store.TaskFilter) (store.TaskPage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	tasks := []models.Task{}
+	for _, task := range s.tasks {
+		if !s.matchTask(task, userID, filter, now) {
+			continue
+		}
+		if filter.After != nil && !afterCursor(task, filter) {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		diff := compareTasks(tasks[i], tasks[j], filter.Sort)
+		if filter.Desc {
+			return diff > 0
+		}
+		return diff < 0
+	})
+
+	page := store.TaskPage{Items: tasks}
+	if len(tasks) > filter.Limit {
+		page.Items = tasks[:filter.Limit]
+		page.NextCursor = store.EncodeTaskCursor(filter, page.Items[len(page.Items)-1])
+	}
+	return page, nil
+}
@@ -0,0 +1,197 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) CreateTask(ctx context.Context, task *models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	task.ID = s.newID("tasks")
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	if task.Recurrence != "" && task.SeriesID == nil {
+		id := task.ID
+		task.SeriesID = &id
+	}
+	s.tasks[task.ID] = *task
+	s.recordChange(task.UserID, models.SyncTask, task.ID, false)
+	return s.writeOutbox(ctx)
+}
+
+func (s *Store) GetTask(ctx context.Context, userID, id int) (models.Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, ok := s.tasks[id]
+	if !ok || task.UserID != userID {
+		return models.Task{}, store.ErrNotFound
+	}
+	return task, nil
+}
+
+func (s *Store) UpdateTask(ctx context.Context, task *models.Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.tasks[task.ID]
+	if !ok || existing.UserID != task.UserID {
+		return store.ErrNotFound
+	}
+	if err := s.checkPrecondition(ctx, models.SyncTask, task.ID); err != nil {
+		return err
+	}
+	task.CreatedAt = existing.CreatedAt
+	task.UpdatedAt = time.Now()
+	s.tasks[task.ID] = *task
+	s.recordChange(task.UserID, models.SyncTask, task.ID, false)
+	s.syncRelativeReminders(task)
+	return s.writeOutbox(ctx)
+}
+
+// syncRelativeReminders tính lại nhắc nhở tương đối của task theo deadline vừa lưu.
+// Người gọi phải giữ s.mu.
+func (s *Store) syncRelativeReminders(task *models.Task) {
+	now := time.Now()
+	s.updateTaskReminders(task.UserID, task.ID, func(reminder *models.Reminder) bool {
+		return reminder.FollowDeadline(task.Deadline, now)
+	})
+}
+
+func (s *Store) CompleteOccurrence(ctx context.Context, task, next *models.Task, reminders []models.Reminder) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.tasks[task.ID]
+	if !ok || existing.UserID != task.UserID {
+		return store.ErrNotFound
+	}
+	if err := s.checkPrecondition(ctx, models.SyncTask, task.ID); err != nil {
+		return err
+	}
+	if existing.Status == models.StatusCompleted || existing.Recurrence == "" {
+		return store.ErrStale
+	}
+	now := time.Now()
+	task.CreatedAt = existing.CreatedAt
+	task.UpdatedAt = now
+	s.tasks[task.ID] = *task
+	s.recordChange(task.UserID, models.SyncTask, task.ID, false)
+	s.syncRelativeReminders(task)
+
+	next.ID = s.newID("tasks")
+	next.CreatedAt = now
+	next.UpdatedAt = now
+	if next.SeriesID == nil {
+		id := next.ID
+		next.SeriesID = &id
+	}
+	s.tasks[next.ID] = *next
+	s.recordChange(next.UserID, models.SyncTask, next.ID, false)
+	for i := range reminders {
+		reminder := &reminders[i]
+		reminder.ID = s.newID("reminders")
+		reminder.TaskID = next.ID
+		if reminder.Status == "" {
+			reminder.Status = models.ReminderScheduled
+		}
+		s.reminders[reminder.ID] = *reminder
+		s.recordChange(reminder.UserID, models.SyncReminder, reminder.ID, false)
+	}
+	return s.writeOutbox(ctx)
+}
+
+func (s *Store) SkipOccurrence(ctx context.Context, task *models.Task, from time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.tasks[task.ID]
+	if !ok || existing.UserID != task.UserID {
+		return store.ErrNotFound
+	}
+	if existing.Recurrence == "" || !existing.Deadline.Equal(from) {
+		return store.ErrStale
+	}
+	if err := s.checkPrecondition(ctx, models.SyncTask, task.ID); err != nil {
+		return err
+	}
+	task.CreatedAt = existing.CreatedAt
+	task.UpdatedAt = time.Now()
+	s.tasks[task.ID] = *task
+	s.recordChange(task.UserID, models.SyncTask, task.ID, false)
+
+	shift := task.Deadline.Sub(from)
+	s.updateTaskReminders(task.UserID, task.ID, func(reminder *models.Reminder) bool {
+		reminder.ReminderTime = reminder.ReminderTime.Add(shift)
+		reminder.IsSent = false
+		reminder.ResetDelivery()
+		return true
+	})
+	return s.writeOutbox(ctx)
+}
+
+func (s *Store) DeleteTask(ctx context.Context, userID, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, ok := s.tasks[id]
+	if !ok || task.UserID != userID {
+		return store.ErrNotFound
+	}
+	if err := s.checkPrecondition(ctx, models.SyncTask, id); err != nil {
+		return err
+	}
+	delete(s.tasks, id)
+	for rid, reminder := range s.reminders {
+		if reminder.TaskID == id {
+			delete(s.reminders, rid)
+			s.recordChange(userID, models.SyncReminder, rid, true)
+		}
+	}
+	s.recordChange(userID, models.SyncTask, id, true)
+	return s.writeOutbox(ctx)
+}
+
+func (s *Store) GetTaskStatistics(ctx context.Context, userID int) (models.UserTaskStatistics, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := models.UserTaskStatistics{
+		UserID:           userID,
+		TasksByMonth:     make(map[string]int),
+		CompletedByMonth: make(map[string]int),
+	}
+	now := time.Now()
+	for _, task := range s.tasks {
+		if task.UserID != userID {
+			continue
+		}
+		month := task.CreatedAt.Format("Jan")
+		stats.TotalTasks++
+		stats.TasksByMonth[month]++
+		switch task.Status {
+		case models.StatusCompleted:
+			stats.CompletedTasks++
+			stats.CompletedByMonth[month]++
+		case models.StatusInProgress:
+			stats.InProgressTasks++
+		case models.StatusPending:
+			stats.PendingTasks++
+		}
+		if store.IsOverdue(task, now) {
+			stats.OverdueTasks++
+		}
+	}
+	for _, snooze := range s.snoozes {
+		if snooze.UserID == userID {
+			stats.TotalSnoozes++
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,149 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) CreateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Username == user.Username || u.Email == user.Email {
+			return store.ErrConflict
+		}
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	user.ID = s.newID("users")
+	s.users[user.ID] = *user
+	return nil
+}
+
+func (s *Store) GetUser(ctx context.Context, id int) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, store.ErrNotFound
+	}
+	user.Password = ""
+	return user, nil
+}
+
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return models.User{}, store.ErrNotFound
+}
+
+func (s *Store) UpdateUser(ctx context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.users[user.ID]
+	if !ok {
+		return nil
+	}
+	for _, u := range s.users {
+		if u.ID != user.ID && (u.Username == user.Username || u.Email == user.Email) {
+			return store.ErrConflict
+		}
+	}
+	existing.Username = user.Username
+	existing.Email = user.Email
+	existing.FullName = user.FullName
+	existing.NotificationChannels = user.NotificationChannels
+	existing.Timezone = user.Timezone
+	existing.QuietHoursStart = user.QuietHoursStart
+	existing.QuietHoursEnd = user.QuietHoursEnd
+	existing.WebhookURL = user.WebhookURL
+	existing.WebhookSecret = user.WebhookSecret
+	s.users[user.ID] = existing
+	return nil
+}
+
+func (s *Store) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.Password = passwordHash
+		s.users[id] = user
+	}
+	return nil
+}
+
+func (s *Store) UpdateLastLogin(ctx context.Context, id int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[id]; ok {
+		user.LastLogin = &at
+		s.users[id] = user
+	}
+	return nil
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Xóa dây chuyền như ON DELETE CASCADE
+	delete(s.users, id)
+	for sid, session := range s.sessions {
+		if session.UserID == id {
+			delete(s.sessions, sid)
+		}
+	}
+	for tid, task := range s.tasks {
+		if task.UserID == id {
+			delete(s.tasks, tid)
+		}
+	}
+	for cid, category := range s.categories {
+		if category.UserID == id {
+			delete(s.categories, cid)
+		}
+	}
+	for rid, reminder := range s.reminders {
+		if reminder.UserID == id {
+			delete(s.reminders, rid)
+		}
+	}
+	delete(s.calendarTokens, id)
+	for wid, webhook := range s.webhooks {
+		if webhook.UserID == id {
+			s.deleteWebhook(wid)
+		}
+	}
+	caldavObjects := s.caldavObjects[:0]
+	for _, object := range s.caldavObjects {
+		if object.UserID != id {
+			caldavObjects = append(caldavObjects, object)
+		}
+	}
+	s.caldavObjects = caldavObjects
+	changes := s.changes[:0]
+	for _, change := range s.changes {
+		if change.UserID != id {
+			changes = append(changes, change)
+		}
+	}
+	s.changes = changes
+	delete(s.syncSeq, id)
+	delete(s.prunedSeq, id)
+	return nil
+}
@@ -0,0 +1,214 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	webhook.ID = s.newID("webhooks")
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	s.webhooks[webhook.ID] = copyWebhook(*webhook)
+	return nil
+}
+
+func (s *Store) GetWebhook(ctx context.Context, userID, id int) (models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return models.Webhook{}, store.ErrNotFound
+	}
+	return copyWebhook(webhook), nil
+}
+
+func (s *Store) ListWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := []models.Webhook{}
+	for _, webhook := range s.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, copyWebhook(webhook))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
+
+func (s *Store) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.webhooks[webhook.ID]
+	if !ok || existing.UserID != webhook.UserID {
+		return store.ErrNotFound
+	}
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = time.Now()
+	s.webhooks[webhook.ID] = copyWebhook(*webhook)
+	return nil
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, userID, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	webhook, ok := s.webhooks[id]
+	if !ok || webhook.UserID != userID {
+		return store.ErrNotFound
+	}
+	s.deleteWebhook(id)
+	return nil
+}
+
+// deleteWebhook xóa webhook cùng các delivery của nó như ON DELETE CASCADE. Gọi khi đang giữ khóa ghi.
+func (s *Store) deleteWebhook(id int) {
+	delete(s.webhooks, id)
+	for did, delivery := range s.webhookDeliveries {
+		if delivery.WebhookID == id {
+			delete(s.webhookDeliveries, did)
+			delete(s.webhookClaims, did)
+		}
+	}
+}
+
+// copyWebhook sao chép danh sách sự kiện để bản lưu không bị sửa qua slice của người gọi
+func copyWebhook(webhook models.Webhook) models.Webhook {
+	webhook.Events = append([]string{}, webhook.Events...)
+	return webhook
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[delivery.WebhookID]; !ok {
+		return store.ErrNotFound
+	}
+	s.insertWebhookDelivery(delivery)
+	return nil
+}
+
+// insertWebhookDelivery lưu delivery mới. Gọi khi đang giữ khóa ghi.
+func (s *Store) insertWebhookDelivery(delivery *models.WebhookDelivery) {
+	now := time.Now()
+	if delivery.Status == "" {
+		delivery.Status = models.WebhookPending
+	}
+	delivery.ID = s.newID("webhook_deliveries")
+	delivery.Attempts = 0
+	delivery.ResponseStatus = 0
+	delivery.Error = ""
+	delivery.DeliveredAt = nil
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	s.webhookDeliveries[delivery.ID] = *delivery
+}
+
+// writeOutbox ghi delivery của các sự kiện gắn vào ctx (store.WithEvents), giống outbox
+// trong transaction của sqlstore. Gọi ở cuối thao tác ghi, khi đang giữ khóa ghi.
+func (s *Store) writeOutbox(ctx context.Context) error {
+	deliveries, err := store.OutboxDeliveries(ctx, func(userID int) ([]models.Webhook, error) {
+		var webhooks []models.Webhook
+		for _, webhook := range s.webhooks {
+			if webhook.UserID == userID && webhook.Active {
+				webhooks = append(webhooks, copyWebhook(webhook))
+			}
+		}
+		sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+		return webhooks, nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		s.insertWebhookDelivery(&deliveries[i])
+	}
+	store.EventsWritten(ctx)
+	return nil
+}
+
+func (s *Store) GetWebhookDelivery(ctx context.Context, userID, id int) (models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	delivery, ok := s.webhookDeliveries[id]
+	if !ok || delivery.UserID != userID {
+		return models.WebhookDelivery{}, store.ErrNotFound
+	}
+	return delivery, nil
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, userID, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deliveries := []models.WebhookDelivery{}
+	for _, delivery := range s.webhookDeliveries {
+		if delivery.WebhookID == webhookID && delivery.UserID == userID {
+			delivery.Payload = ""
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID > deliveries[j].ID })
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []models.WebhookDelivery{}
+	for _, delivery := range s.webhookDeliveries {
+		until, claimed := s.webhookClaims[delivery.ID]
+		if delivery.Status != models.WebhookPending || !s.webhooks[delivery.WebhookID].Active ||
+			(delivery.NextAttemptAt != nil && delivery.NextAttemptAt.After(now)) ||
+			(claimed && !until.Before(now)) {
+			continue
+		}
+		due = append(due, delivery)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	for _, delivery := range due {
+		s.webhookClaims[delivery.ID] = now.Add(lease)
+	}
+	return due, nil
+}
+
+func (s *Store) RecordWebhookAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.webhookDeliveries[delivery.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	delivery.UpdatedAt = time.Now()
+	existing.Status = delivery.Status
+	existing.Attempts = delivery.Attempts
+	existing.ResponseStatus = delivery.ResponseStatus
+	existing.Error = delivery.Error
+	existing.NextAttemptAt = delivery.NextAttemptAt
+	existing.DeliveredAt = delivery.DeliveredAt
+	existing.UpdatedAt = delivery.UpdatedAt
+	s.webhookDeliveries[delivery.ID] = existing
+	delete(s.webhookClaims, delivery.ID)
+	return nil
+}
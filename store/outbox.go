@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"backend/models"
+)
+
+// Sự kiện webhook đi qua outbox: handler gắn sự kiện vào ctx của đúng thao tác ghi sinh ra
+// nó (WithEvents) và store ghi delivery vào webhook_deliveries trong cùng transaction với
+// thay đổi, một lần duy nhất cho mỗi sự kiện. Sự kiện vì vậy không bị mất khi server dừng
+// hay khi có nhiều thay đổi cùng lúc (nhập dữ liệu); bộ gửi webhook chỉ đọc bảng này.
+
+// Event là một sự kiện cần gửi tới webhook của người dùng UserID. Data được mã hóa JSON
+// lúc ghi outbox, nên có thể là con trỏ tới bản ghi đang được ghi để payload chứa ID và
+// thời gian do store đặt.
+type Event struct {
+	Type   string
+	UserID int
+	Data   any
+}
+
+type eventsKey struct{}
+
+// pendingEvents là các sự kiện của một lần gọi WithEvents, nối với sự kiện đã gắn vào
+// ctx cha. written được đặt khi transaction ghi chúng vào outbox đã commit.
+type pendingEvents struct {
+	parent  *pendingEvents
+	events  []Event
+	written atomic.Bool
+}
+
+// WithEvents thêm sự kiện vào ctx của một thao tác ghi. Sự kiện được ghi vào outbox bởi
+// transaction đầu tiên nhận ctx và commit thành công; các thao tác ghi sau dùng lại ctx
+// (hoặc ctx con của nó) không ghi lại chúng. Transaction bị hủy không đánh dấu gì nên
+// sự kiện vẫn chờ thao tác ghi kế tiếp.
+func WithEvents(ctx context.Context, events ...Event) context.Context {
+	parent, _ := ctx.Value(eventsKey{}).(*pendingEvents)
+	return context.WithValue(ctx, eventsKey{}, &pendingEvents{parent: parent, events: events})
+}
+
+// EventsFrom trả về các sự kiện đã gắn vào ctx và chưa được ghi vào outbox, theo thứ tự gắn
+func EventsFrom(ctx context.Context) []Event {
+	var batches []*pendingEvents
+	for p, _ := ctx.Value(eventsKey{}).(*pendingEvents); p != nil; p = p.parent {
+		if !p.written.Load() {
+			batches = append(batches, p)
+		}
+	}
+	var events []Event
+	for i := len(batches) - 1; i >= 0; i-- {
+		events = append(events, batches[i].events...)
+	}
+	return events
+}
+
+// EventsWritten đánh dấu các sự kiện trong ctx là đã ghi vào outbox. Store gọi sau khi
+// transaction ghi chúng đã commit.
+func EventsWritten(ctx context.Context) {
+	for p, _ := ctx.Value(eventsKey{}).(*pendingEvents); p != nil; p = p.parent {
+		p.written.Store(true)
+	}
+}
+
+// OutboxDeliveries dựng (chưa lưu) delivery của các sự kiện trong ctx: mỗi sự kiện một
+// payload, gửi tới mọi webhook đang bật đã đăng ký nó. webhooks trả về webhook của một
+// người dùng, đọc trong transaction của thao tác ghi.
+func OutboxDeliveries(ctx context.Context, webhooks func(userID int) ([]models.Webhook, error)) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	byUser := map[int][]models.Webhook{}
+	for _, event := range EventsFrom(ctx) {
+		list, ok := byUser[event.UserID]
+		if !ok {
+			var err error
+			if list, err = webhooks(event.UserID); err != nil {
+				return nil, err
+			}
+			byUser[event.UserID] = list
+		}
+
+		var payload models.WebhookPayload
+		var data []byte
+		for _, webhook := range list {
+			if !webhook.Active || !webhook.Wants(event.Type) {
+				continue
+			}
+			if data == nil {
+				id, err := newEventID()
+				if err != nil {
+					return nil, err
+				}
+				payload = models.WebhookPayload{ID: id, Event: event.Type, UserID: event.UserID, CreatedAt: time.Now(), Data: event.Data}
+				if data, err = json.Marshal(payload); err != nil {
+					return nil, err
+				}
+			}
+			deliveries = append(deliveries, models.WebhookDelivery{
+				WebhookID: webhook.ID,
+				UserID:    event.UserID,
+				EventID:   payload.ID,
+				Event:     event.Type,
+				Payload:   string(data),
+				Status:    models.WebhookPending,
+			})
+		}
+	}
+	return deliveries, nil
+}
+
+// newEventID sinh ID ngẫu nhiên của sự kiện, dùng chung cho mọi delivery của nó để
+// người nhận bỏ qua sự kiện trùng
+func newEventID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
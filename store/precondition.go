@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/models"
+)
+
+// ErrPrecondition được trả về khi bản ghi đã thay đổi so với phiên bản mà thao tác ghi dựa vào
+var ErrPrecondition = errors.New("bản ghi đã thay đổi so với phiên bản yêu cầu")
+
+// Precondition là điều kiện phiên bản của một thao tác ghi từ đồng bộ offline. Store kiểm tra
+// điều kiện trong cùng transaction với câu lệnh ghi bản ghi Entity/ID (UpdateTask,
+// CompleteOccurrence, DeleteTask, UpdateCategory, DeleteCategory) nên hai thiết bị gửi thay
+// đổi cùng lúc không thể cùng vượt qua kiểm tra; nếu không thỏa trả về ErrPrecondition.
+type Precondition struct {
+	Entity models.SyncEntity
+	ID     int
+	// Version là seq của thay đổi gần nhất mà client đã đọc
+	Version *int64
+	// UpdatedAt là thời điểm client sửa bản ghi khi không có Version (last-write-wins)
+	UpdatedAt *time.Time
+}
+
+// Conflicts cho biết latest (thay đổi gần nhất của bản ghi trên server) không thỏa điều kiện
+func (p Precondition) Conflicts(latest models.Change) bool {
+	switch {
+	case p.Version != nil:
+		return latest.Seq != *p.Version
+	case p.UpdatedAt != nil:
+		return latest.ChangedAt.After(*p.UpdatedAt)
+	}
+	// Không có phiên bản hay thời điểm: ghi đè theo thứ tự tới server
+	return false
+}
+
+type preconditionKey struct{}
+
+// WithPrecondition gắn điều kiện phiên bản vào context của thao tác ghi
+func WithPrecondition(ctx context.Context, p Precondition) context.Context {
+	return context.WithValue(ctx, preconditionKey{}, p)
+}
+
+// PreconditionFor trả về điều kiện phiên bản trong ctx nếu nó áp dụng cho bản ghi entity/id
+func PreconditionFor(ctx context.Context, entity models.SyncEntity, id int) (Precondition, bool) {
+	p, ok := ctx.Value(preconditionKey{}).(Precondition)
+	if !ok || p.Entity != entity || p.ID != id {
+		return Precondition{}, false
+	}
+	return p, true
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultTombstoneRetention là thời gian mặc định giữ tombstone trong nhật ký đồng bộ
+	DefaultTombstoneRetention = 30 * 24 * time.Hour
+	// DefaultPruneInterval là chu kỳ xóa tombstone quá hạn mặc định
+	DefaultPruneInterval = time.Hour
+)
+
+// TombstonePruner định kỳ xóa tombstone cũ hơn Retention khỏi nhật ký đồng bộ để nhật ký
+// không lớn mãi. Client có cursor cũ hơn tombstone đã xóa (PrunedSeq) phải đồng bộ lại
+// từ đầu, nên Retention cần dài hơn thời gian một thiết bị thường offline.
+type TombstonePruner struct {
+	Sync      SyncStore
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// NewTombstonePruner tạo TombstonePruner với cấu hình mặc định
+func NewTombstonePruner(s SyncStore) *TombstonePruner {
+	return &TombstonePruner{Sync: s, Retention: DefaultTombstoneRetention, Interval: DefaultPruneInterval}
+}
+
+// Run xóa tombstone quá hạn sau mỗi Interval cho tới khi ctx bị hủy
+func (p *TombstonePruner) Run(ctx context.Context) {
+	log.Printf("Bộ dọn nhật ký đồng bộ đang chạy, giữ tombstone trong %s", p.Retention)
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		if pruned, err := p.Sync.PruneTombstones(ctx, time.Now().Add(-p.Retention)); err != nil {
+			log.Printf("Lỗi khi xóa tombstone quá hạn: %v", err)
+		} else if pruned > 0 {
+			log.Printf("Đã xóa %d tombstone quá hạn", pruned)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Bộ dọn nhật ký đồng bộ đã dừng")
+			return
+		case <-ticker.C:
+		}
+	}
+}
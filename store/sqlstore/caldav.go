@@ -0,0 +1,45 @@
+package sqlstore
+
+import (
+	"context"
+
+	"backend/models"
+)
+
+func (s *Store) ListCalDAVObjects(ctx context.Context, userID int) ([]models.CalDAVObject, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT user_id, name, uid, task_id FROM caldav_objects WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	objects := []models.CalDAVObject{}
+	for rows.Next() {
+		var object models.CalDAVObject
+		if err := rows.Scan(&object.UserID, &object.Name, &object.UID, &object.TaskID); err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, rows.Err()
+}
+
+func (s *Store) SaveCalDAVObject(ctx context.Context, object models.CalDAVObject) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM caldav_objects WHERE user_id = ? AND (name = ? OR task_id = ?)",
+		object.UserID, object.Name, object.TaskID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO caldav_objects (user_id, name, uid, task_id) VALUES (?, ?, ?, ?)",
+		object.UserID, object.Name, object.UID, object.TaskID)
+	if err != nil {
+		return err
+	}
+	return s.commit(ctx, tx)
+}
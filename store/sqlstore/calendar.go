@@ -0,0 +1,33 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+)
+
+func (s *Store) GetCalendarToken(ctx context.Context, userID int) (string, error) {
+	var tokenHash string
+	err := s.db.QueryRowContext(ctx, "SELECT token_hash FROM calendar_tokens WHERE user_id = ?", userID).Scan(&tokenHash)
+	return tokenHash, notFound(err)
+}
+
+func (s *Store) SaveCalendarToken(ctx context.Context, userID int, tokenHash string) error {
+	now := time.Now()
+	insert := "INSERT INTO calendar_tokens (user_id, token_hash, created_at) VALUES (?, ?, ?)"
+	_, err := s.db.ExecContext(ctx, insert, userID, tokenHash, now)
+	if !isDuplicate(err) {
+		return err
+	}
+
+	update := "UPDATE calendar_tokens SET token_hash = ?, created_at = ? WHERE user_id = ?"
+	_, err = s.db.ExecContext(ctx, update, tokenHash, now, userID)
+	return err
+}
+
+func (s *Store) DeleteCalendarToken(ctx context.Context, userID int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM calendar_tokens WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
@@ -0,0 +1,106 @@
+package sqlstore
+
+import (
+	"context"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) CreateCategory(ctx context.Context, category *models.Category) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		return s.insertCategory(ctx, tx, category)
+	})
+}
+
+// insertCategory thêm danh mục trong transaction tx
+func (s *Store) insertCategory(ctx context.Context, tx utcTx, category *models.Category) error {
+	// Kiểm tra trùng lặp danh mục
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM categories WHERE category_name = ? AND user_id = ?)", category.CategoryName, category.UserID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return store.ErrConflict
+	}
+
+	query := "INSERT INTO categories (category_name, color, user_id, description) VALUES (?, ?, ?, ?)"
+	result, err := tx.ExecContext(ctx, query, category.CategoryName, category.Color, category.UserID, category.Description)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	category.ID = int(id)
+	return s.recordChange(ctx, tx, category.UserID, models.SyncCategory, category.ID, false)
+}
+
+func (s *Store) GetCategory(ctx context.Context, userID, id int) (models.Category, error) {
+	var category models.Category
+	query := "SELECT category_id, category_name, color, user_id, description FROM categories WHERE category_id = ? AND user_id = ?"
+	err := s.db.QueryRowContext(ctx, query, id, userID).Scan(&category.ID, &category.CategoryName, &category.Color, &category.UserID, &category.Description)
+	return category, notFound(err)
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, category *models.Category) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		if err := s.checkPrecondition(ctx, tx, models.SyncCategory, category.ID); err != nil {
+			return err
+		}
+		query := "UPDATE categories SET category_name = ?, color = ?, description = ? WHERE category_id = ? AND user_id = ?"
+		result, err := tx.ExecContext(ctx, query, category.CategoryName, category.Color, category.Description, category.ID, category.UserID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(result); err == store.ErrNotFound {
+			// MySQL báo 0 dòng khi giá trị mới trùng giá trị cũ, nên kiểm tra lại sự tồn tại
+			var exists int
+			err := tx.QueryRowContext(ctx, "SELECT 1 FROM categories WHERE category_id = ? AND user_id = ?", category.ID, category.UserID).Scan(&exists)
+			if err != nil {
+				return notFound(err)
+			}
+		} else if err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, category.UserID, models.SyncCategory, category.ID, false)
+	})
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, userID, id int) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		if err := s.checkPrecondition(ctx, tx, models.SyncCategory, id); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE category_id = ? AND user_id = ?", id, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(result); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, userID, models.SyncCategory, id, true)
+	})
+}
+
+func (s *Store) ListCategories(ctx context.Context, userID int) ([]models.Category, error) {
+	query := "SELECT category_id, category_name, color, user_id, description FROM categories WHERE user_id = ?"
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(&category.ID, &category.CategoryName, &category.Color, &category.UserID, &category.Description); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
@@ -0,0 +1,81 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/models"
+)
+
+const digestColumns = "user_id, frequency, send_time, weekday, channels, last_sent_at, next_run_at"
+
+func scanDigestSettings(row scanner) (models.DigestSettings, error) {
+	var settings models.DigestSettings
+	var lastSentAt, nextRunAt sql.NullTime
+	var channels string
+	err := row.Scan(&settings.UserID, &settings.Frequency, &settings.Time, &settings.Weekday, &channels,
+		&lastSentAt, &nextRunAt)
+	settings.Channels = models.ParseChannels(channels)
+	settings.LastSentAt = nullTime(lastSentAt)
+	settings.NextRunAt = nullTime(nextRunAt)
+	return settings, err
+}
+
+func (s *Store) GetDigestSettings(ctx context.Context, userID int) (models.DigestSettings, error) {
+	query := "SELECT " + digestColumns + " FROM digest_settings WHERE user_id = ?"
+	settings, err := scanDigestSettings(s.db.QueryRowContext(ctx, query, userID))
+	return settings, notFound(err)
+}
+
+func (s *Store) SaveDigestSettings(ctx context.Context, settings *models.DigestSettings) error {
+	channels := models.FormatChannels(settings.Channels)
+	insert := `INSERT INTO digest_settings (user_id, frequency, send_time, weekday, channels, next_run_at)
+	           VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := s.db.ExecContext(ctx, insert, settings.UserID, settings.Frequency, settings.Time, settings.Weekday,
+		channels, settings.NextRunAt)
+	if !isDuplicate(err) {
+		return err
+	}
+
+	update := `UPDATE digest_settings
+	           SET frequency = ?, send_time = ?, weekday = ?, channels = ?, next_run_at = ?
+	           WHERE user_id = ?`
+	_, err = s.db.ExecContext(ctx, update, settings.Frequency, settings.Time, settings.Weekday, channels,
+		settings.NextRunAt, settings.UserID)
+	return err
+}
+
+func (s *Store) ListDueDigests(ctx context.Context, now time.Time, limit int) ([]models.DigestSettings, error) {
+	query := "SELECT " + digestColumns + ` FROM digest_settings
+	          WHERE frequency != 'off' AND next_run_at <= ? ORDER BY next_run_at LIMIT ?`
+	rows, err := s.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	due := []models.DigestSettings{}
+	for rows.Next() {
+		settings, err := scanDigestSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		due = append(due, settings)
+	}
+	return due, rows.Err()
+}
+
+func (s *Store) AdvanceDigest(ctx context.Context, userID int, from time.Time, to *time.Time) error {
+	query := "UPDATE digest_settings SET next_run_at = ? WHERE user_id = ? AND next_run_at = ?"
+	result, err := s.db.ExecContext(ctx, query, to, userID, from)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (s *Store) MarkDigestSent(ctx context.Context, userID int, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE digest_settings SET last_sent_at = ? WHERE user_id = ?", at, userID)
+	return err
+}
@@ -0,0 +1,33 @@
+package sqlstore
+
+import (
+	"context"
+
+	"backend/store"
+)
+
+func (s *Store) ImportTasks(ctx context.Context, userID int, batch *store.ImportBatch) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range batch.Categories {
+		batch.Categories[i].UserID = userID
+		if err := s.insertCategory(ctx, tx, &batch.Categories[i]); err != nil {
+			return err
+		}
+	}
+	for i := range batch.Tasks {
+		item := &batch.Tasks[i]
+		item.Task.UserID = userID
+		if item.Category >= 0 {
+			item.Task.CategoryID = batch.Categories[item.Category].ID
+		}
+		if err := s.insertTask(ctx, tx, &item.Task); err != nil {
+			return err
+		}
+	}
+	return s.commit(ctx, tx)
+}
@@ -0,0 +1,473 @@
+package sqlstore
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/store"
+)
+
+const reminderColumns = `reminder_id, task_id, user_id, reminder_time, is_sent, sent_at, channels,
+	status, attempts, next_attempt_at, offset_minutes, snoozed_until, snooze_count, acknowledged_at`
+
+func scanReminder(row scanner) (models.Reminder, error) {
+	var reminder models.Reminder
+	var sentAt, nextAttemptAt, snoozedUntil, acknowledgedAt sql.NullTime
+	var offset sql.NullInt64
+	var channels string
+	err := row.Scan(
+		&reminder.ID,
+		&reminder.TaskID,
+		&reminder.UserID,
+		&reminder.ReminderTime,
+		&reminder.IsSent,
+		&sentAt,
+		&channels,
+		&reminder.Status,
+		&reminder.Attempts,
+		&nextAttemptAt,
+		&offset,
+		&snoozedUntil,
+		&reminder.SnoozeCount,
+		&acknowledgedAt,
+	)
+	if offset.Valid {
+		minutes := int(offset.Int64)
+		reminder.OffsetMinutes = &minutes
+	}
+	reminder.SentAt = nullTime(sentAt)
+	reminder.NextAttemptAt = nullTime(nextAttemptAt)
+	reminder.SnoozedUntil = nullTime(snoozedUntil)
+	reminder.AcknowledgedAt = nullTime(acknowledgedAt)
+	reminder.Channels = models.ParseChannels(channels)
+	return reminder, err
+}
+
+func (s *Store) queryReminders(ctx context.Context, query string, args ...any) ([]models.Reminder, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []models.Reminder{}
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func (s *Store) CreateReminder(ctx context.Context, reminder *models.Reminder) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		return s.insertReminder(ctx, tx, reminder)
+	})
+}
+
+// insertReminder thêm nhắc nhở trong transaction tx
+func (s *Store) insertReminder(ctx context.Context, tx utcTx, reminder *models.Reminder) error {
+	now := time.Now()
+	if reminder.Status == "" {
+		reminder.Status = models.ReminderScheduled
+	}
+	query := `INSERT INTO reminders (task_id, user_id, reminder_time, offset_minutes, is_sent, channels, status, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, reminder.TaskID, reminder.UserID, reminder.ReminderTime, reminder.OffsetMinutes,
+		reminder.IsSent, models.FormatChannels(reminder.Channels), reminder.Status, now, now)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	reminder.ID = int(id)
+	return s.recordChange(ctx, tx, reminder.UserID, models.SyncReminder, reminder.ID, false)
+}
+
+func (s *Store) HasReminderBetween(ctx context.Context, userID, taskID int, from, to time.Time) (bool, error) {
+	var count int
+	query := `
+		SELECT COUNT(*) 
+		FROM reminders 
+		WHERE task_id = ? 
+		AND user_id = ? 
+		AND reminder_time BETWEEN ? AND ?`
+	err := s.db.QueryRowContext(ctx, query, taskID, userID, from, to).Scan(&count)
+	return count > 0, err
+}
+
+func (s *Store) GetReminder(ctx context.Context, userID, id int) (models.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE reminder_id = ? AND user_id = ?"
+	reminder, err := scanReminder(s.db.QueryRowContext(ctx, query, id, userID))
+	return reminder, notFound(err)
+}
+
+func (s *Store) ListTaskReminders(ctx context.Context, userID, taskID int) ([]models.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE task_id = ? AND user_id = ?"
+	return s.queryReminders(ctx, query, taskID, userID)
+}
+
+func (s *Store) ListReminders(ctx context.Context, userID int) ([]models.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE user_id = ? ORDER BY reminder_time, reminder_id"
+	return s.queryReminders(ctx, query, userID)
+}
+
+func (s *Store) ListRemindersAfter(ctx context.Context, userID, afterID, limit int) ([]models.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE user_id = ? AND reminder_id > ? ORDER BY reminder_id LIMIT ?"
+	return s.queryReminders(ctx, query, userID, afterID, limit)
+}
+
+// UpdateReminder (cũng như SnoozeReminder, AcknowledgeReminder) xóa quyền nhận của bộ gửi
+// nền để lượt gửi đang chạy không ghi đè thay đổi của người dùng
+func (s *Store) UpdateReminder(ctx context.Context, reminder *models.Reminder) error {
+	query := `UPDATE reminders
+	          SET reminder_time = ?, offset_minutes = ?, is_sent = ?, channels = ?, status = ?, attempts = ?,
+	              next_attempt_at = ?, snoozed_until = ?, acknowledged_at = ?, updated_at = ?,
+	              claim_token = NULL, claimed_until = NULL
+	          WHERE reminder_id = ? AND user_id = ?`
+	return s.inTx(ctx, func(tx utcTx) error {
+		result, err := tx.ExecContext(ctx, query, reminder.ReminderTime, reminder.OffsetMinutes, reminder.IsSent,
+			models.FormatChannels(reminder.Channels), reminder.Status, reminder.Attempts, reminder.NextAttemptAt,
+			reminder.SnoozedUntil, reminder.AcknowledgedAt, time.Now(), reminder.ID, reminder.UserID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(result); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, reminder.UserID, models.SyncReminder, reminder.ID, false)
+	})
+}
+
+// updateTaskReminders cho change sửa từng nhắc nhở của công việc taskID trong tx và ghi
+// lại những nhắc nhở change trả về true; quyền nhận của bộ gửi nền bị xóa như UpdateReminder
+func (s *Store) updateTaskReminders(ctx context.Context, tx utcTx, userID, taskID int, change func(*models.Reminder) bool) error {
+	rows, err := tx.QueryContext(ctx, "SELECT "+reminderColumns+" FROM reminders WHERE task_id = ? AND user_id = ?", taskID, userID)
+	if err != nil {
+		return err
+	}
+	var changed []models.Reminder
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if change(&reminder) {
+			changed = append(changed, reminder)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query := `UPDATE reminders
+	          SET reminder_time = ?, is_sent = ?, status = ?, attempts = ?, next_attempt_at = ?,
+	              snoozed_until = ?, acknowledged_at = ?, updated_at = ?, claim_token = NULL, claimed_until = NULL
+	          WHERE reminder_id = ?`
+	for _, reminder := range changed {
+		_, err := tx.ExecContext(ctx, query, reminder.ReminderTime, reminder.IsSent, reminder.Status, reminder.Attempts,
+			reminder.NextAttemptAt, reminder.SnoozedUntil, reminder.AcknowledgedAt, time.Now(), reminder.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordChange(ctx, tx, userID, models.SyncReminder, reminder.ID, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) DeleteReminder(ctx context.Context, userID, id int) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM reminders WHERE reminder_id = ? AND user_id = ?", id, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(result); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, userID, models.SyncReminder, id, true)
+	})
+}
+
+// dueReminderCond chọn nhắc nhở đến hạn (hoặc hết thời gian hoãn, hoặc đến lượt gửi lại)
+// chưa gửi và chưa bị instance khác nhận (hoặc đã hết lease). Cần 4 tham số now.
+const dueReminderCond = `is_sent = FALSE
+	AND ((status = 'scheduled' AND reminder_time <= ?) OR (status = 'snoozed' AND snoozed_until <= ?))
+	AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+	AND (claimed_until IS NULL OR claimed_until < ?)`
+
+func (s *Store) ClaimDueReminders(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Reminder, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, err
+	}
+	until := now.Add(lease)
+
+	if s.dialect == database.SQLite {
+		// SQLite chỉ có một writer tại một thời điểm nên UPDATE ... IN (SELECT ...) là nguyên tử
+		query := `UPDATE reminders SET claim_token = ?, claimed_until = ?
+		          WHERE reminder_id IN (
+		              SELECT reminder_id FROM reminders WHERE ` + dueReminderCond + `
+		              ORDER BY reminder_time LIMIT ?)`
+		if _, err := s.db.ExecContext(ctx, query, token, until, now, now, now, now, limit); err != nil {
+			return nil, err
+		}
+	} else if err := s.claimSkipLocked(ctx, token, now, until, limit); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE claim_token = ? ORDER BY reminder_time"
+	reminders, err := s.queryReminders(ctx, query, token)
+	for i := range reminders {
+		reminders[i].ClaimToken = token
+	}
+	return reminders, err
+}
+
+// claimSkipLocked khóa các dòng đến hạn bằng FOR UPDATE SKIP LOCKED (MySQL 8+) để
+// các instance chạy song song không nhận trùng nhắc nhở
+func (s *Store) claimSkipLocked(ctx context.Context, token string, now, until time.Time, limit int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "SELECT reminder_id FROM reminders WHERE " + dueReminderCond +
+		" ORDER BY reminder_time LIMIT ? FOR UPDATE SKIP LOCKED"
+	rows, err := tx.QueryContext(ctx, query, now, now, now, now, limit)
+	if err != nil {
+		return err
+	}
+	args := []any{token, until}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		args = append(args, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		return tx.Commit()
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)-2), ", ")
+	update := "UPDATE reminders SET claim_token = ?, claimed_until = ? WHERE reminder_id IN (" + placeholders + ")"
+	if _, err := tx.ExecContext(ctx, update, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) MarkReminderSent(ctx context.Context, reminder models.Reminder, sentAt time.Time) error {
+	query := `UPDATE reminders
+	          SET is_sent = TRUE, status = 'sent', sent_at = ?, next_attempt_at = NULL, snoozed_until = NULL,
+	              claim_token = NULL, claimed_until = NULL, updated_at = ?
+	          WHERE reminder_id = ? AND claim_token = ?`
+	return s.inTx(ctx, func(tx utcTx) error {
+		result, err := tx.ExecContext(ctx, query, sentAt, sentAt, reminder.ID, reminder.ClaimToken)
+		if err != nil {
+			return err
+		}
+		if err := checkClaim(result); err != nil {
+			return err
+		}
+		return s.recordReminderChange(ctx, tx, reminder.ID)
+	})
+}
+
+// checkClaim trả về store.ErrStale nếu câu lệnh có điều kiện claim_token không khớp dòng nào
+func checkClaim(result sql.Result) error {
+	if err := checkAffected(result); errors.Is(err, store.ErrNotFound) {
+		return store.ErrStale
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+func newClaimToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *Store) ScheduleRetry(ctx context.Context, reminder models.Reminder, attempts int, nextAttemptAt time.Time) error {
+	query := `UPDATE reminders
+	          SET attempts = ?, next_attempt_at = ?, claim_token = NULL, claimed_until = NULL, updated_at = ?
+	          WHERE reminder_id = ? AND claim_token = ?`
+	result, err := s.db.ExecContext(ctx, query, attempts, nextAttemptAt, time.Now(), reminder.ID, reminder.ClaimToken)
+	if err != nil {
+		return err
+	}
+	return checkClaim(result)
+}
+
+func (s *Store) MarkReminderFailed(ctx context.Context, reminder models.Reminder, attempts int) error {
+	query := `UPDATE reminders
+	          SET status = 'failed', attempts = ?, next_attempt_at = NULL, claim_token = NULL, claimed_until = NULL, updated_at = ?
+	          WHERE reminder_id = ? AND claim_token = ?`
+	return s.inTx(ctx, func(tx utcTx) error {
+		result, err := tx.ExecContext(ctx, query, attempts, time.Now(), reminder.ID, reminder.ClaimToken)
+		if err != nil {
+			return err
+		}
+		if err := checkClaim(result); err != nil {
+			return err
+		}
+		return s.recordReminderChange(ctx, tx, reminder.ID)
+	})
+}
+
+func (s *Store) ListFailedReminders(ctx context.Context, userID int) ([]models.Reminder, error) {
+	query := "SELECT " + reminderColumns + " FROM reminders WHERE user_id = ? AND status = 'failed' ORDER BY reminder_time"
+	return s.queryReminders(ctx, query, userID)
+}
+
+func (s *Store) RedriveReminder(ctx context.Context, userID, id int) error {
+	query := `UPDATE reminders
+	          SET status = 'scheduled', attempts = 0, next_attempt_at = NULL, updated_at = ?
+	          WHERE reminder_id = ? AND user_id = ? AND status = 'failed'`
+	return s.inTx(ctx, func(tx utcTx) error {
+		result, err := tx.ExecContext(ctx, query, time.Now(), id, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(result); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, userID, models.SyncReminder, id, false)
+	})
+}
+
+func (s *Store) RecordDelivery(ctx context.Context, delivery *models.ReminderDelivery) error {
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+	query := `INSERT INTO reminder_deliveries (reminder_id, attempt, channel, error, next_retry_at, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := s.db.ExecContext(ctx, query, delivery.ReminderID, delivery.Attempt, delivery.Channel,
+		delivery.Error, delivery.NextRetryAt, delivery.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	delivery.ID = int(id)
+	return nil
+}
+
+func (s *Store) ListReminderDeliveries(ctx context.Context, reminderID int) ([]models.ReminderDelivery, error) {
+	query := `SELECT delivery_id, reminder_id, attempt, channel, error, next_retry_at, created_at
+	          FROM reminder_deliveries WHERE reminder_id = ? ORDER BY delivery_id`
+	rows, err := s.db.QueryContext(ctx, query, reminderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.ReminderDelivery{}
+	for rows.Next() {
+		var delivery models.ReminderDelivery
+		var nextRetryAt sql.NullTime
+		err := rows.Scan(&delivery.ID, &delivery.ReminderID, &delivery.Attempt, &delivery.Channel,
+			&delivery.Error, &nextRetryAt, &delivery.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		delivery.NextRetryAt = nullTime(nextRetryAt)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *Store) SnoozeReminder(ctx context.Context, userID, id int, until, at time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE reminders
+	          SET status = 'snoozed', is_sent = FALSE, snoozed_until = ?, snooze_count = snooze_count + 1,
+	              attempts = 0, next_attempt_at = NULL, claim_token = NULL, claimed_until = NULL, updated_at = ?
+	          WHERE reminder_id = ? AND user_id = ? AND status IN ('sent', 'snoozed')`
+	result, err := tx.ExecContext(ctx, query, until, at, id, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+
+	insert := `INSERT INTO reminder_snoozes (reminder_id, user_id, snoozed_at, snoozed_until) VALUES (?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, insert, id, userID, at, until); err != nil {
+		return err
+	}
+	if err := s.recordChange(ctx, tx, userID, models.SyncReminder, id, false); err != nil {
+		return err
+	}
+	return s.commit(ctx, tx)
+}
+
+func (s *Store) AcknowledgeReminder(ctx context.Context, userID, id int, at time.Time) error {
+	query := `UPDATE reminders
+	          SET status = 'acknowledged', snoozed_until = NULL, next_attempt_at = NULL, acknowledged_at = ?,
+	              claim_token = NULL, claimed_until = NULL, updated_at = ?
+	          WHERE reminder_id = ? AND user_id = ? AND status IN ('sent', 'snoozed', 'failed')`
+	return s.inTx(ctx, func(tx utcTx) error {
+		result, err := tx.ExecContext(ctx, query, at, at, id, userID)
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(result); err != nil {
+			return err
+		}
+		return s.recordChange(ctx, tx, userID, models.SyncReminder, id, false)
+	})
+}
+
+func (s *Store) ListReminderSnoozes(ctx context.Context, userID, reminderID int) ([]models.ReminderSnooze, error) {
+	query := `SELECT snooze_id, reminder_id, user_id, snoozed_at, snoozed_until
+	          FROM reminder_snoozes WHERE reminder_id = ? AND user_id = ? ORDER BY snooze_id`
+	rows, err := s.db.QueryContext(ctx, query, reminderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snoozes := []models.ReminderSnooze{}
+	for rows.Next() {
+		var snooze models.ReminderSnooze
+		if err := rows.Scan(&snooze.ID, &snooze.ReminderID, &snooze.UserID, &snooze.SnoozedAt, &snooze.SnoozedUntil); err != nil {
+			return nil, err
+		}
+		snoozes = append(snoozes, snooze)
+	}
+	return snoozes, rows.Err()
+}
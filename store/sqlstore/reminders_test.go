@@ -0,0 +1,103 @@
+package sqlstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// claimIDs nhận nhắc nhở đến hạn tại now và trả về ID của chúng
+func claimIDs(t *testing.T, s *Store, now time.Time, limit int) ([]int, []models.Reminder) {
+	t.Helper()
+	claimed, err := s.ClaimDueReminders(t.Context(), now, limit, time.Minute)
+	if err != nil {
+		t.Fatalf("nhận nhắc nhở: %v", err)
+	}
+	ids := make([]int, len(claimed))
+	for i, reminder := range claimed {
+		ids[i] = reminder.ID
+		if reminder.ClaimToken == "" {
+			t.Errorf("nhắc nhở #%d được nhận nhưng không có claim token", reminder.ID)
+		}
+	}
+	return ids, claimed
+}
+
+func TestClaimDueReminders(t *testing.T) {
+	s := newTestStore(t)
+	ctx := t.Context()
+	due := time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+	user, task := seedTask(t, s, "alice", due.Add(time.Hour))
+
+	reminders := map[string]*models.Reminder{
+		"due":    {ReminderTime: due},
+		"future": {ReminderTime: due.Add(30 * time.Minute)},
+		"sent":   {ReminderTime: due.Add(-time.Hour), IsSent: true, Status: models.ReminderSent},
+	}
+	for name, reminder := range reminders {
+		reminder.TaskID, reminder.UserID = task.ID, user.ID
+		if err := s.CreateReminder(ctx, reminder); err != nil {
+			t.Fatalf("tạo nhắc nhở %s: %v", name, err)
+		}
+	}
+
+	// now ở múi giờ khác UTC: 15:59 +07:00 là một phút trước khi nhắc nhở đến hạn
+	hanoi := time.FixedZone("ICT", 7*3600)
+	if ids, _ := claimIDs(t, s, due.Add(-time.Minute).In(hanoi), 10); len(ids) != 0 {
+		t.Errorf("nhận được %v trước khi đến hạn", ids)
+	}
+	now := due.Add(time.Minute).In(hanoi)
+	ids, first := claimIDs(t, s, now, 10)
+	if len(ids) != 1 || ids[0] != reminders["due"].ID {
+		t.Fatalf("nhận được %v, muốn [%d]", ids, reminders["due"].ID)
+	}
+
+	// Trong thời gian lease không instance nào nhận lại được
+	if ids, _ := claimIDs(t, s, now, 10); len(ids) != 0 {
+		t.Errorf("nhận lại %v trong thời gian lease", ids)
+	}
+
+	// Hết lease: nhắc nhở được nhận lại với token mới, token cũ không ghi được kết quả
+	ids, second := claimIDs(t, s, now.Add(2*time.Minute), 10)
+	if len(ids) != 1 || second[0].ClaimToken == first[0].ClaimToken {
+		t.Fatalf("nhận lại sau lease: %+v", second)
+	}
+	if err := s.MarkReminderSent(ctx, first[0], now); !errors.Is(err, store.ErrStale) {
+		t.Errorf("MarkReminderSent với token cũ: %v, muốn ErrStale", err)
+	}
+	if err := s.MarkReminderSent(ctx, second[0], now); err != nil {
+		t.Fatalf("MarkReminderSent: %v", err)
+	}
+	if ids, _ := claimIDs(t, s, now.Add(time.Hour), 10); len(ids) != 1 || ids[0] != reminders["future"].ID {
+		t.Errorf("sau khi gửi nhận được %v, muốn chỉ [%d]", ids, reminders["future"].ID)
+	}
+
+	// Gửi lại theo lịch: chưa tới next_attempt_at thì không nhận
+	retry := reminders["future"]
+	_, claimed := claimIDs(t, s, now.Add(3*time.Hour), 10)
+	if err := s.ScheduleRetry(ctx, claimed[0], 1, now.Add(4*time.Hour)); err != nil {
+		t.Fatalf("ScheduleRetry: %v", err)
+	}
+	if ids, _ := claimIDs(t, s, now.Add(4*time.Hour-time.Minute), 10); len(ids) != 0 {
+		t.Errorf("nhận %v trước next_attempt_at", ids)
+	}
+	if ids, _ := claimIDs(t, s, now.Add(4*time.Hour), 10); len(ids) != 1 || ids[0] != retry.ID {
+		t.Errorf("nhận %v tại next_attempt_at, muốn [%d]", ids, retry.ID)
+	}
+
+	// limit giới hạn số nhắc nhở mỗi lượt, nhắc nhở sớm nhất được nhận trước
+	var batch []int
+	for i := range 3 {
+		reminder := models.Reminder{TaskID: task.ID, UserID: user.ID, ReminderTime: due.Add(time.Duration(3-i) * time.Second)}
+		if err := s.CreateReminder(ctx, &reminder); err != nil {
+			t.Fatal(err)
+		}
+		batch = append(batch, reminder.ID)
+	}
+	if ids, _ := claimIDs(t, s, now.Add(10*time.Hour), 2); len(ids) != 2 || ids[0] != batch[2] || ids[1] != batch[1] {
+		t.Errorf("nhận với limit 2: %v, muốn [%d %d]", ids, batch[2], batch[1])
+	}
+}
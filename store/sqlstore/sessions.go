@@ -0,0 +1,87 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"backend/store"
+)
+
+func (s *Store) CreateSession(ctx context.Context, session *store.Session) error {
+	if session.CreatedAt.IsZero() {
+		session.CreatedAt = time.Now()
+	}
+	query := `INSERT INTO sessions
+	          (user_id, access_token_hash, access_expires_at, refresh_token_hash, refresh_expires_at, created_at)
+	          VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := s.db.ExecContext(
+		ctx,
+		query,
+		session.UserID,
+		session.AccessTokenHash,
+		session.AccessExpiresAt,
+		session.RefreshTokenHash,
+		session.RefreshExpiresAt,
+		session.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	session.ID = int(id)
+	return nil
+}
+
+func (s *Store) getSession(ctx context.Context, where string, args ...any) (store.Session, error) {
+	var session store.Session
+	query := `SELECT session_id, user_id, access_token_hash, access_expires_at,
+	          refresh_token_hash, refresh_expires_at, created_at
+	          FROM sessions WHERE ` + where
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.AccessTokenHash,
+		&session.AccessExpiresAt,
+		&session.RefreshTokenHash,
+		&session.RefreshExpiresAt,
+		&session.CreatedAt,
+	)
+	return session, notFound(err)
+}
+
+func (s *Store) GetSessionByAccessToken(ctx context.Context, accessTokenHash string, now time.Time) (store.Session, error) {
+	return s.getSession(ctx, "access_token_hash = ? AND access_expires_at > ?", accessTokenHash, now)
+}
+
+func (s *Store) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string, now time.Time) (store.Session, error) {
+	return s.getSession(ctx, "refresh_token_hash = ? AND refresh_expires_at > ?", refreshTokenHash, now)
+}
+
+func (s *Store) RotateSession(ctx context.Context, oldRefreshTokenHash string, session *store.Session) error {
+	query := `UPDATE sessions
+	          SET access_token_hash = ?, access_expires_at = ?, refresh_token_hash = ?, refresh_expires_at = ?
+	          WHERE session_id = ? AND refresh_token_hash = ?`
+	result, err := s.db.ExecContext(
+		ctx,
+		query,
+		session.AccessTokenHash,
+		session.AccessExpiresAt,
+		session.RefreshTokenHash,
+		session.RefreshExpiresAt,
+		session.ID,
+		oldRefreshTokenHash,
+	)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (s *Store) DeleteSession(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE session_id = ?", id)
+	return err
+}
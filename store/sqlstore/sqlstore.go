@@ -0,0 +1,120 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/database"
+	"backend/store"
+
+	"github.com/go-sql-driver/mysql"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Store cài đặt store.Store trên MySQL hoặc SQLite. Các câu SQL viết theo cú
+// pháp chung của hai database; phần khác biệt đi qua các hàm theo dialect bên dưới.
+type Store struct {
+	db      utcDB
+	dialect database.Dialect
+}
+
+var _ store.Store = (*Store)(nil)
+
+func New(db *database.DB) *Store {
+	return &Store{db: utcDB{db.DB}, dialect: db.Dialect}
+}
+
+// monthExpr trả về biểu thức SQL lấy số tháng (1-12) của một cột thời gian
+func (s *Store) monthExpr(column string) string {
+	if s.dialect == database.SQLite {
+		return fmt.Sprintf("CAST(strftime('%%m', %s) AS INTEGER)", column)
+	}
+	return fmt.Sprintf("MONTH(%s)", column)
+}
+
+// forUpdate trả về hậu tố khóa dòng cho câu SELECT trong transaction. SQLite không có
+// FOR UPDATE nhưng transaction đã giữ khóa ghi cả database từ đầu (_txlock=immediate).
+func (s *Store) forUpdate() string {
+	if s.dialect == database.SQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// inTx chạy fn trong một transaction và commit nếu fn không trả về lỗi
+func (s *Store) inTx(ctx context.Context, fn func(tx utcTx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return s.commit(ctx, tx)
+}
+
+// commit ghi sự kiện webhook gắn vào ctx (store.WithEvents) vào outbox rồi commit tx;
+// sự kiện chỉ được đánh dấu đã ghi khi commit thành công
+func (s *Store) commit(ctx context.Context, tx utcTx) error {
+	if err := s.writeOutbox(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	store.EventsWritten(ctx)
+	return nil
+}
+
+// scanner là điểm chung của *sql.Row và *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// nullTime chuyển giá trị thời gian có thể NULL sang con trỏ
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// notFound chuyển sql.ErrNoRows thành store.ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// isDuplicate cho biết lỗi có phải do vi phạm khóa duy nhất hay không
+func isDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// checkAffected trả về store.ErrNotFound khi câu lệnh không tác động dòng nào
+func checkAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
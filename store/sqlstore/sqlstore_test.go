@@ -0,0 +1,137 @@
+package sqlstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/store"
+)
+
+// newTestDB tạo database SQLite tạm với lược đồ từ các migration thật
+func newTestDB(t *testing.T) *database.DB {
+	t.Helper()
+	db, err := database.OpenSQLite(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("mở database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("chạy migration: %v", err)
+	}
+	return db
+}
+
+// newTestStore tạo store trên database tạm của newTestDB
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	return New(newTestDB(t))
+}
+
+// seedTask tạo người dùng username kèm một công việc của họ với deadline cho trước
+func seedTask(t *testing.T, s *Store, username string, deadline time.Time) (models.User, models.Task) {
+	t.Helper()
+	user := models.User{Username: username, Email: username + "@example.com", Password: "x", FullName: username}
+	if err := s.CreateUser(t.Context(), &user); err != nil {
+		t.Fatalf("tạo người dùng: %v", err)
+	}
+	task := models.Task{Title: "Viết báo cáo", Description: "Báo cáo quý", Deadline: deadline,
+		Priority: models.PriorityMedium, Status: models.StatusPending, UserID: user.ID}
+	if err := s.CreateTask(t.Context(), &task); err != nil {
+		t.Fatalf("tạo công việc: %v", err)
+	}
+	return user, task
+}
+
+// rawColumn đọc nguyên văn giá trị cột (dạng chuỗi SQLite lưu) của công việc id
+func rawColumn(t *testing.T, s *Store, column string, id int) string {
+	t.Helper()
+	var raw string
+	query := "SELECT CAST(" + column + " AS TEXT) FROM tasks WHERE task_id = ?"
+	if err := s.db.QueryRowContext(t.Context(), query, id).Scan(&raw); err != nil {
+		t.Fatalf("đọc cột %s: %v", column, err)
+	}
+	return raw
+}
+
+func TestDialect(t *testing.T) {
+	for _, tc := range []struct {
+		dialect   database.Dialect
+		month     string
+		forUpdate string
+	}{
+		{database.SQLite, "CAST(strftime('%m', created_at) AS INTEGER)", ""},
+		{database.MySQL, "MONTH(created_at)", " FOR UPDATE"},
+	} {
+		s := &Store{dialect: tc.dialect}
+		if got := s.monthExpr("created_at"); got != tc.month {
+			t.Errorf("%s: monthExpr = %q, muốn %q", tc.dialect, got, tc.month)
+		}
+		if got := s.forUpdate(); got != tc.forUpdate {
+			t.Errorf("%s: forUpdate = %q, muốn %q", tc.dialect, got, tc.forUpdate)
+		}
+	}
+
+	// Câu SQL theo dialect chạy được trên SQLite thật
+	s := newTestStore(t)
+	user, _ := seedTask(t, s, "alice", time.Now().Add(time.Hour))
+	stats, err := s.GetTaskStatistics(t.Context(), user.ID)
+	if err != nil {
+		t.Fatalf("thống kê: %v", err)
+	}
+	if month := time.Now().UTC().Month().String()[:3]; stats.TotalTasks != 1 || stats.TasksByMonth[month] != 1 {
+		t.Errorf("thống kê: %+v, muốn 1 công việc trong tháng %s", stats, month)
+	}
+}
+
+func TestUTCTimes(t *testing.T) {
+	s := newTestStore(t)
+	ctx := t.Context()
+	hanoi := time.FixedZone("ICT", 7*3600)
+
+	// Ghi qua utcDB/utcTx: thời gian ở múi giờ khác được lưu ở UTC
+	_, task := seedTask(t, s, "alice", time.Date(2030, 1, 2, 9, 0, 0, 0, hanoi))
+	if raw := rawColumn(t, s, "deadline", task.ID); raw != "2030-01-02 02:00:00+00:00" {
+		t.Errorf("deadline lưu dạng %q, muốn UTC", raw)
+	}
+	completedAt := time.Date(2030, 1, 2, 8, 30, 0, 0, hanoi)
+	task.Status = models.StatusCompleted
+	task.CompletedAt = &completedAt
+	if err := s.UpdateTask(ctx, &task); err != nil {
+		t.Fatalf("cập nhật công việc: %v", err)
+	}
+	if raw := rawColumn(t, s, "completed_at", task.ID); raw != "2030-01-02 01:30:00+00:00" {
+		t.Errorf("completed_at lưu dạng %q, muốn UTC", raw)
+	}
+	if !completedAt.Equal(*task.CompletedAt) {
+		t.Errorf("UpdateTask đổi completed_at của người gọi: %v", task.CompletedAt)
+	}
+
+	// Tham số so sánh ở múi giờ khác cũng được đổi sang UTC: nếu không, chuỗi
+	// "2030-01-02 08:59:00+07:00" sẽ lớn hơn "2030-01-02 02:00:00+00:00"
+	before := time.Date(2030, 1, 2, 8, 59, 0, 0, hanoi)
+	from := before.Add(2 * time.Minute)
+	page, err := s.ListTasks(ctx, task.UserID, store.TaskFilter{DeadlineFrom: &before, Limit: store.DefaultTaskLimit})
+	if err != nil {
+		t.Fatalf("lọc công việc: %v", err)
+	}
+	if len(page.Items) != 1 {
+		t.Errorf("lọc deadline từ %v: %d công việc, muốn 1", before, len(page.Items))
+	}
+	if page, err = s.ListTasks(ctx, task.UserID, store.TaskFilter{DeadlineFrom: &from, Limit: store.DefaultTaskLimit}); err != nil {
+		t.Fatalf("lọc công việc: %v", err)
+	}
+	if len(page.Items) != 0 {
+		t.Errorf("lọc deadline từ %v: %d công việc, muốn 0", from, len(page.Items))
+	}
+
+	got, err := s.GetTask(ctx, task.UserID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, offset := got.Deadline.Zone(); !got.Deadline.Equal(task.Deadline) || offset != 0 {
+		t.Errorf("deadline đọc lại: %v, muốn %v ở UTC", got.Deadline, task.Deadline.UTC())
+	}
+}
@@ -0,0 +1,180 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// recordChange ghi thay đổi của một bản ghi vào nhật ký đồng bộ rồi bỏ các dòng cũ hơn
+// của cùng bản ghi. Phải chạy trong transaction của chính thao tác ghi để bản ghi và
+// nhật ký được commit (hoặc hủy) cùng nhau.
+//
+// seq được cấp từ bộ đếm của người dùng trong sync_state thay vì AUTO_INCREMENT (cấp lúc
+// INSERT, nên một transaction commit muộn có thể mang seq nhỏ hơn cursor client đã đọc).
+// Khóa dòng bộ đếm của userID được giữ tới khi commit: các transaction ghi của cùng một
+// người dùng nối tiếp nhau, của người dùng khác thì không phải chờ. Hãy gọi recordChange
+// sau các câu lệnh ghi khác của transaction.
+func (s *Store) recordChange(ctx context.Context, tx utcTx, userID int, entity models.SyncEntity, id int, deleted bool) error {
+	result, err := tx.ExecContext(ctx, "UPDATE sync_state SET last_seq = last_seq + 1 WHERE user_id = ?", userID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+	var seq int64
+	if err := tx.QueryRowContext(ctx, "SELECT last_seq FROM sync_state WHERE user_id = ?", userID).Scan(&seq); err != nil {
+		return err
+	}
+
+	insert := "INSERT INTO sync_changes (seq, user_id, entity, entity_id, deleted, changed_at) VALUES (?, ?, ?, ?, ?, ?)"
+	if _, err := tx.ExecContext(ctx, insert, seq, userID, entity, id, deleted, time.Now()); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM sync_changes WHERE entity = ? AND entity_id = ? AND seq < ?", entity, id, seq)
+	return err
+}
+
+// syncTables là bảng và cột khóa chính của từng loại bản ghi trong nhật ký đồng bộ
+var syncTables = map[models.SyncEntity][2]string{
+	models.SyncTask:     {"tasks", "task_id"},
+	models.SyncCategory: {"categories", "category_id"},
+	models.SyncReminder: {"reminders", "reminder_id"},
+}
+
+// checkPrecondition so điều kiện phiên bản trong ctx (nếu có) với thay đổi gần nhất của
+// bản ghi entity/id. Phải là câu lệnh đầu tiên của transaction: nó khóa chính dòng của
+// bản ghi (FOR UPDATE trên khóa chính, không khóa khoảng trống trong sync_changes) nên
+// thao tác ghi song song phải chờ tới khi transaction này kết thúc. Lần đọc nhật ký sau
+// đó là lần đọc thường đầu tiên nên snapshot của nó (REPEATABLE READ) được lấy sau khi
+// đã có khóa và thấy thay đổi mới nhất đã commit.
+func (s *Store) checkPrecondition(ctx context.Context, tx utcTx, entity models.SyncEntity, id int) error {
+	cond, ok := store.PreconditionFor(ctx, entity, id)
+	if !ok {
+		return nil
+	}
+	table := syncTables[entity]
+	var exists int
+	lock := "SELECT 1 FROM " + table[0] + " WHERE " + table[1] + " = ?" + s.forUpdate()
+	if err := tx.QueryRowContext(ctx, lock, id).Scan(&exists); errors.Is(err, sql.ErrNoRows) {
+		// Câu lệnh ghi phía sau tự báo bản ghi không tồn tại
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	query := "SELECT " + changeColumns + " FROM sync_changes WHERE entity = ? AND entity_id = ? ORDER BY seq DESC LIMIT 1"
+	latest, err := scanChange(tx.QueryRowContext(ctx, query, entity, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if cond.Conflicts(latest) {
+		return store.ErrPrecondition
+	}
+	return nil
+}
+
+// recordReminderChange ghi thay đổi của nhắc nhở id khi không biết trước người dùng
+// (các thao tác của bộ gửi nền)
+func (s *Store) recordReminderChange(ctx context.Context, tx utcTx, id int) error {
+	var userID int
+	err := tx.QueryRowContext(ctx, "SELECT user_id FROM reminders WHERE reminder_id = ?", id).Scan(&userID)
+	if err != nil {
+		return notFound(err)
+	}
+	return s.recordChange(ctx, tx, userID, models.SyncReminder, id, false)
+}
+
+// taskReminderIDs liệt kê nhắc nhở của công việc taskID, dùng để ghi tombstone cho các
+// nhắc nhở bị xóa theo công việc (ON DELETE CASCADE)
+func taskReminderIDs(ctx context.Context, tx utcTx, userID, taskID int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT reminder_id FROM reminders WHERE task_id = ? AND user_id = ?", taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+const changeColumns = "seq, user_id, entity, entity_id, deleted, changed_at"
+
+func scanChange(row scanner) (models.Change, error) {
+	var change models.Change
+	err := row.Scan(&change.Seq, &change.UserID, &change.Entity, &change.EntityID, &change.Deleted, &change.ChangedAt)
+	return change, err
+}
+
+func (s *Store) ListChanges(ctx context.Context, userID int, since int64, limit int) ([]models.Change, error) {
+	query := "SELECT " + changeColumns + " FROM sync_changes WHERE user_id = ? AND seq > ? ORDER BY seq LIMIT ?"
+	rows, err := s.db.QueryContext(ctx, query, userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.Change{}
+	for rows.Next() {
+		change, err := scanChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, rows.Err()
+}
+
+func (s *Store) PrunedSeq(ctx context.Context, userID int) (int64, error) {
+	var pruned int64
+	err := s.db.QueryRowContext(ctx, "SELECT pruned_seq FROM sync_state WHERE user_id = ?", userID).Scan(&pruned)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return pruned, err
+}
+
+// PruneTombstones nâng pruned_seq của mỗi người dùng lên seq lớn nhất trong các tombstone
+// quá hạn rồi chỉ xóa tombstone có seq không vượt quá pruned_seq: tombstone được commit
+// giữa hai câu lệnh chưa được pruned_seq tính tới nên được giữ lại tới lần xóa sau.
+func (s *Store) PruneTombstones(ctx context.Context, before time.Time) (int64, error) {
+	var pruned int64
+	err := s.inTx(ctx, func(tx utcTx) error {
+		expired := "SELECT MAX(seq) FROM sync_changes c WHERE c.user_id = sync_state.user_id AND c.deleted = TRUE AND c.changed_at < ?"
+		update := "UPDATE sync_state SET pruned_seq = (" + expired + ") WHERE pruned_seq < (" + expired + ")"
+		if _, err := tx.ExecContext(ctx, update, before, before); err != nil {
+			return err
+		}
+		remove := `DELETE FROM sync_changes WHERE deleted = TRUE AND changed_at < ?
+		           AND seq <= (SELECT pruned_seq FROM sync_state WHERE sync_state.user_id = sync_changes.user_id)`
+		result, err := tx.ExecContext(ctx, remove, before)
+		if err != nil {
+			return err
+		}
+		pruned, err = result.RowsAffected()
+		return err
+	})
+	return pruned, err
+}
+
+func (s *Store) LatestChange(ctx context.Context, userID int, entity models.SyncEntity, id int) (models.Change, error) {
+	query := "SELECT " + changeColumns + ` FROM sync_changes
+	          WHERE user_id = ? AND entity = ? AND entity_id = ? ORDER BY seq DESC LIMIT 1`
+	change, err := scanChange(s.db.QueryRowContext(ctx, query, userID, entity, id))
+	return change, notFound(err)
+}
@@ -0,0 +1,281 @@
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/store"
+)
+
+// changesOf trả về nhật ký đồng bộ của người dùng, theo entity/id
+func changesOf(t *testing.T, s *Store, userID int) map[models.SyncEntity]map[int]models.Change {
+	t.Helper()
+	changes, err := s.ListChanges(t.Context(), userID, 0, 1000)
+	if err != nil {
+		t.Fatalf("đọc nhật ký đồng bộ: %v", err)
+	}
+	byEntity := map[models.SyncEntity]map[int]models.Change{}
+	var last int64
+	for _, change := range changes {
+		if change.Seq <= last {
+			t.Errorf("seq không tăng dần: %d sau %d", change.Seq, last)
+		}
+		last = change.Seq
+		if byEntity[change.Entity] == nil {
+			byEntity[change.Entity] = map[int]models.Change{}
+		}
+		if _, dup := byEntity[change.Entity][change.EntityID]; dup {
+			t.Errorf("%s #%d có nhiều dòng trong nhật ký", change.Entity, change.EntityID)
+		}
+		byEntity[change.Entity][change.EntityID] = change
+	}
+	return byEntity
+}
+
+func TestRecordChange(t *testing.T) {
+	s := newTestStore(t)
+	ctx := t.Context()
+	user, task := seedTask(t, s, "alice", time.Now().Add(time.Hour))
+	other, _ := seedTask(t, s, "bob", time.Now().Add(time.Hour))
+	reminder := models.Reminder{TaskID: task.ID, UserID: user.ID, ReminderTime: task.Deadline.Add(-time.Minute)}
+	if err := s.CreateReminder(ctx, &reminder); err != nil {
+		t.Fatal(err)
+	}
+
+	created := changesOf(t, s, user.ID)[models.SyncTask][task.ID]
+	if err := s.UpdateTask(ctx, &task); err != nil {
+		t.Fatal(err)
+	}
+	changes := changesOf(t, s, user.ID)
+	updated := changes[models.SyncTask][task.ID]
+	if updated.Seq <= created.Seq || updated.Deleted {
+		t.Errorf("thay đổi sau cập nhật: %+v, trước đó %+v", updated, created)
+	}
+	if _, ok := changes[models.SyncReminder][reminder.ID]; !ok {
+		t.Errorf("nhật ký thiếu nhắc nhở #%d", reminder.ID)
+	}
+	if len(changesOf(t, s, other.ID)[models.SyncTask]) != 1 {
+		t.Error("nhật ký của người dùng khác bị lẫn")
+	}
+
+	// Transaction bị hủy thì thay đổi (và seq) cũng bị hủy
+	fail := errors.New("hủy")
+	err := s.inTx(ctx, func(tx utcTx) error {
+		if err := s.recordChange(ctx, tx, user.ID, models.SyncTask, task.ID, true); err != nil {
+			return err
+		}
+		return fail
+	})
+	if !errors.Is(err, fail) {
+		t.Fatalf("inTx: %v", err)
+	}
+	if got := changesOf(t, s, user.ID)[models.SyncTask][task.ID]; got != updated {
+		t.Errorf("thay đổi của transaction bị hủy vẫn còn: %+v", got)
+	}
+
+	// Xóa công việc ghi tombstone cho công việc và nhắc nhở bị xóa theo
+	if err := s.DeleteTask(ctx, user.ID, task.ID); err != nil {
+		t.Fatal(err)
+	}
+	changes = changesOf(t, s, user.ID)
+	if got := changes[models.SyncTask][task.ID]; !got.Deleted || got.Seq <= updated.Seq {
+		t.Errorf("tombstone của công việc: %+v", got)
+	}
+	if got := changes[models.SyncReminder][reminder.ID]; !got.Deleted {
+		t.Errorf("tombstone của nhắc nhở: %+v", got)
+	}
+}
+
+func TestCheckPrecondition(t *testing.T) {
+	s := newTestStore(t)
+	ctx := t.Context()
+	user, task := seedTask(t, s, "alice", time.Now().Add(time.Hour))
+	latest, err := s.LatestChange(ctx, user.ID, models.SyncTask, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := func(title string, p store.Precondition) error {
+		changed := task
+		changed.Title = title
+		return s.UpdateTask(store.WithPrecondition(ctx, p), &changed)
+	}
+	version := func(seq int64) store.Precondition {
+		return store.Precondition{Entity: models.SyncTask, ID: task.ID, Version: &seq}
+	}
+
+	if err := update("Bản 1", version(latest.Seq)); err != nil {
+		t.Fatalf("cập nhật đúng phiên bản: %v", err)
+	}
+	// Phiên bản cũ: bị từ chối và không ghi gì
+	if err := update("Bản cũ", version(latest.Seq)); !errors.Is(err, store.ErrPrecondition) {
+		t.Fatalf("cập nhật phiên bản cũ: %v, muốn ErrPrecondition", err)
+	}
+	got, err := s.GetTask(ctx, user.ID, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := s.LatestChange(ctx, user.ID, models.SyncTask, task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "Bản 1" || current.Seq == latest.Seq {
+		t.Errorf("sau khi bị từ chối: tiêu đề %q, seq %d", got.Title, current.Seq)
+	}
+
+	// Không có phiên bản: so theo thời điểm client sửa (last-write-wins)
+	earlier := current.ChangedAt.Add(-time.Minute)
+	later := current.ChangedAt.Add(time.Minute)
+	if err := update("Sửa offline cũ", store.Precondition{Entity: models.SyncTask, ID: task.ID, UpdatedAt: &earlier}); !errors.Is(err, store.ErrPrecondition) {
+		t.Errorf("sửa offline cũ hơn server: %v, muốn ErrPrecondition", err)
+	}
+	if err := update("Sửa offline mới", store.Precondition{Entity: models.SyncTask, ID: task.ID, UpdatedAt: &later}); err != nil {
+		t.Errorf("sửa offline mới hơn server: %v", err)
+	}
+
+	// Điều kiện của bản ghi khác không áp dụng
+	if err := update("Bản 3", store.Precondition{Entity: models.SyncTask, ID: task.ID + 1, Version: &latest.Seq}); err != nil {
+		t.Errorf("điều kiện của công việc khác: %v", err)
+	}
+	if err := s.DeleteTask(store.WithPrecondition(ctx, version(latest.Seq)), user.ID, task.ID); !errors.Is(err, store.ErrPrecondition) {
+		t.Errorf("xóa với phiên bản cũ: %v, muốn ErrPrecondition", err)
+	}
+	if _, err := s.GetTask(ctx, user.ID, task.ID); err != nil {
+		t.Errorf("công việc bị xóa dù điều kiện không thỏa: %v", err)
+	}
+}
+
+func TestPerUserSequence(t *testing.T) {
+	db := newTestDB(t)
+	s := New(db)
+	ctx := t.Context()
+
+	// Nhật ký được ghi trước khi có bộ đếm theo người dùng (0019): seq chung cho mọi người
+	alice, _ := seedTask(t, s, "alice", time.Now())
+	bob, _ := seedTask(t, s, "bob", time.Now())
+	statuses, err := database.GetMigrationStatus(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := 0
+	for _, status := range statuses {
+		if status.Version >= 19 {
+			steps++
+		}
+	}
+	if _, err := database.MigrateDown(db, steps); err != nil {
+		t.Fatalf("hoàn tác migration: %v", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM sync_changes"); err != nil {
+		t.Fatal(err)
+	}
+	for i, userID := range []int{alice.ID, bob.ID, alice.ID, bob.ID} {
+		_, err := s.db.ExecContext(ctx, `INSERT INTO sync_changes (seq, user_id, entity, entity_id, deleted, changed_at)
+		                                 VALUES (?, ?, 'category', ?, FALSE, ?)`, i+1, userID, 100+i, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, "UPDATE sync_sequence SET last_seq = 4"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("chạy lại migration: %v", err)
+	}
+
+	seqs := func(userID int, since int64) []int64 {
+		t.Helper()
+		changes, err := s.ListChanges(ctx, userID, since, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var seqs []int64
+		for _, change := range changes {
+			seqs = append(seqs, change.Seq)
+		}
+		return seqs
+	}
+	// Cursor client đang giữ vẫn đúng sau migration
+	if got := seqs(alice.ID, 1); len(got) != 1 || got[0] != 3 {
+		t.Errorf("thay đổi của alice sau seq 1: %v, muốn [3]", got)
+	}
+
+	// Mỗi người dùng có bộ đếm riêng, tiếp tục từ seq lớn nhất của họ
+	for i, userID := range []int{bob.ID, alice.ID, bob.ID} {
+		category := models.Category{CategoryName: fmt.Sprintf("Danh mục %d", i), UserID: userID}
+		if err := s.CreateCategory(ctx, &category); err != nil {
+			t.Fatalf("tạo danh mục: %v", err)
+		}
+	}
+	if got := seqs(alice.ID, 3); len(got) != 1 || got[0] != 4 {
+		t.Errorf("thay đổi mới của alice: %v, muốn [4]", got)
+	}
+	if got := seqs(bob.ID, 4); len(got) != 2 || got[0] != 5 || got[1] != 6 {
+		t.Errorf("thay đổi mới của bob: %v, muốn [5 6]", got)
+	}
+
+	// Người dùng mới bắt đầu từ seq 1
+	carol, _ := seedTask(t, s, "carol", time.Now())
+	if got := seqs(carol.ID, 0); len(got) != 1 || got[0] != 1 {
+		t.Errorf("nhật ký của người dùng mới: %v, muốn [1]", got)
+	}
+}
+
+func TestPruneTombstones(t *testing.T) {
+	s := newTestStore(t)
+	ctx := t.Context()
+	user, old := seedTask(t, s, "alice", time.Now().Add(time.Hour))
+	other, kept := seedTask(t, s, "bob", time.Now().Add(time.Hour))
+	if err := s.DeleteTask(ctx, user.ID, old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteTask(ctx, other.ID, kept.ID); err != nil {
+		t.Fatal(err)
+	}
+	tombstone := changesOf(t, s, user.ID)[models.SyncTask][old.ID]
+	if _, err := s.db.ExecContext(ctx, "UPDATE sync_changes SET changed_at = ? WHERE user_id = ?", time.Now().Add(-48*time.Hour), user.ID); err != nil {
+		t.Fatal(err)
+	}
+	live := models.Task{Title: "Còn lại", Description: "Báo cáo", Deadline: time.Now().Add(time.Hour),
+		Priority: models.PriorityMedium, Status: models.StatusPending, UserID: user.ID}
+	if err := s.CreateTask(ctx, &live); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chỉ tombstone cũ hơn mốc bị xóa; pruned_seq của người sở hữu nâng lên seq của nó
+	pruned, err := s.PruneTombstones(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("xóa tombstone: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("xóa %d tombstone, muốn 1", pruned)
+	}
+	changes := changesOf(t, s, user.ID)
+	if _, ok := changes[models.SyncTask][old.ID]; ok {
+		t.Error("tombstone quá hạn vẫn còn")
+	}
+	if _, ok := changes[models.SyncTask][live.ID]; !ok {
+		t.Error("thay đổi chưa bị xóa mất")
+	}
+	if seq, err := s.PrunedSeq(ctx, user.ID); err != nil || seq != tombstone.Seq {
+		t.Errorf("pruned_seq: %d, %v, muốn %d", seq, err, tombstone.Seq)
+	}
+	if got := changesOf(t, s, other.ID)[models.SyncTask][kept.ID]; !got.Deleted {
+		t.Errorf("tombstone còn hạn bị xóa: %+v", got)
+	}
+	if seq, err := s.PrunedSeq(ctx, other.ID); err != nil || seq != 0 {
+		t.Errorf("pruned_seq của người dùng khác: %d, %v", seq, err)
+	}
+
+	// Không còn tombstone quá hạn: pruned_seq giữ nguyên
+	if pruned, err := s.PruneTombstones(ctx, time.Now().Add(-24*time.Hour)); err != nil || pruned != 0 {
+		t.Errorf("xóa lại: %d, %v", pruned, err)
+	}
+	if seq, _ := s.PrunedSeq(ctx, user.ID); seq != tombstone.Seq {
+		t.Errorf("pruned_seq sau lần xóa thứ hai: %d, muốn %d", seq, tombstone.Seq)
+	}
+}
@@ -0,0 +1,121 @@
+package sqlstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+// priorityRankExpr ánh xạ priority sang thứ hạng giống TaskPriority.Rank
+var priorityRankExpr = func() string {
+	expr := "(CASE priority"
+	for _, p := range models.TaskPriorities {
+		expr += fmt.Sprintf(" WHEN '%s' THEN %d", p, p.Rank())
+	}
+	return expr + " ELSE 0 END)"
+}()
+
+// sortColumn trả về biểu thức SQL tương ứng với trường sắp xếp
+func sortColumn(sort string) string {
+	switch sort {
+	case store.SortDeadline:
+		return "deadline"
+	case store.SortPriority:
+		return priorityRankExpr
+	case store.SortUpdatedAt:
+		return "updated_at"
+	default:
+		return "created_at"
+	}
+}
+
+// buildTaskQuery dựng câu truy vấn danh sách công việc từ bộ lọc. Phân trang
+// theo keyset (giá trị sắp xếp, task_id) nên không bị lệch khi dữ liệu thay đổi.
+func buildTaskQuery(userID int, filter store.TaskFilter, now time.Time) (string, []any) {
+	where := []string{"user_id = ?"}
+	args := []any{userID}
+
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Priority != "" {
+		where = append(where, "priority = ?")
+		args = append(args, filter.Priority)
+	}
+	if filter.CategoryID != nil {
+		where = append(where, "category_id = ?")
+		args = append(args, *filter.CategoryID)
+	}
+	if filter.DeadlineFrom != nil {
+		where = append(where, "deadline >= ?")
+		args = append(args, *filter.DeadlineFrom)
+	}
+	if filter.DeadlineTo != nil {
+		where = append(where, "deadline <= ?")
+		args = append(args, *filter.DeadlineTo)
+	}
+	if filter.Overdue != nil {
+		if *filter.Overdue {
+			where = append(where, "(deadline < ? AND status != ?)")
+		} else {
+			where = append(where, "NOT (deadline < ? AND status != ?)")
+		}
+		args = append(args, now, models.StatusCompleted)
+	}
+	if filter.CompletedFrom != nil {
+		where = append(where, "completed_at >= ?")
+		args = append(args, *filter.CompletedFrom)
+	}
+	if filter.CompletedTo != nil {
+		where = append(where, "completed_at <= ?")
+		args = append(args, *filter.CompletedTo)
+	}
+	if filter.WithReminders {
+		where = append(where, "EXISTS (SELECT 1 FROM reminders r WHERE r.task_id = tasks.task_id)")
+	}
+
+	column := sortColumn(filter.Sort)
+	op, direction := ">", "ASC"
+	if filter.Desc {
+		op, direction = "<", "DESC"
+	}
+
+	if filter.After != nil {
+		var value any
+		if filter.Sort == store.SortPriority {
+			value = filter.After.CursorRank()
+		} else {
+			// utcDB đổi sang UTC như giá trị đã ghi để phép so sánh trên SQLite (dạng chuỗi) đúng thứ tự
+			value = filter.After.CursorTime()
+		}
+		where = append(where, "("+column+" "+op+" ? OR ("+column+" = ? AND task_id "+op+" ?))")
+		args = append(args, value, value, filter.After.ID)
+	}
+
+	query := "SELECT " + taskColumns + " FROM tasks WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY " + column + " " + direction + ", task_id " + direction +
+		" LIMIT ?"
+	// Lấy thêm một dòng để biết còn trang sau hay không
+	args = append(args, filter.Limit+1)
+	return query, args
+}
+
+func (s *Store) ListTasks(ctx context.Context, userID int, filter store.TaskFilter) (store.TaskPage, error) {
+	query, args := buildTaskQuery(userID, filter, time.Now())
+	tasks, err := s.queryTasks(ctx, query, args...)
+	if err != nil {
+		return store.TaskPage{}, err
+	}
+
+	page := store.TaskPage{Items: tasks}
+	if len(tasks) > filter.Limit {
+		page.Items = tasks[:filter.Limit]
+		page.NextCursor = store.EncodeTaskCursor(filter, page.Items[len(page.Items)-1])
+	}
+	return page, nil
+}
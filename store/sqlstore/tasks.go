@@ -0,0 +1,374 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+const taskColumns = `task_id, title, description, deadline, priority, status, 
+              category_id, user_id, created_at, updated_at, completed_at,
+              recurrence, series_id, recurrence_start, occurrence_at`
+
+func scanTask(row scanner) (models.Task, error) {
+	var task models.Task
+	var completedAt, recurrenceStart, occurrenceAt sql.NullTime
+	var seriesID sql.NullInt64
+	err := row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.Deadline,
+		&task.Priority,
+		&task.Status,
+		&task.CategoryID,
+		&task.UserID,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&completedAt,
+		&task.Recurrence,
+		&seriesID,
+		&recurrenceStart,
+		&occurrenceAt,
+	)
+	task.CompletedAt = nullTime(completedAt)
+	task.RecurrenceStart = nullTime(recurrenceStart)
+	task.OccurrenceAt = nullTime(occurrenceAt)
+	if seriesID.Valid {
+		id := int(seriesID.Int64)
+		task.SeriesID = &id
+	}
+	return task, err
+}
+
+func (s *Store) queryTasks(ctx context.Context, query string, args ...any) ([]models.Task, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (s *Store) CreateTask(ctx context.Context, task *models.Task) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		return s.insertTask(ctx, tx, task)
+	})
+}
+
+// insertTask thêm công việc trong transaction tx
+func (s *Store) insertTask(ctx context.Context, tx utcTx, task *models.Task) error {
+	now := time.Now()
+	query := `INSERT INTO tasks 
+	          (title, description, deadline, priority, status, category_id, user_id, created_at, updated_at, completed_at,
+	           recurrence, series_id, recurrence_start, occurrence_at) 
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		task.Title,
+		task.Description,
+		task.Deadline,
+		task.Priority,
+		task.Status,
+		task.CategoryID,
+		task.UserID,
+		now,
+		now,
+		task.CompletedAt,
+		task.Recurrence,
+		task.SeriesID,
+		task.RecurrenceStart,
+		task.OccurrenceAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	task.ID = int(id)
+
+	// Công việc lặp đầu tiên là gốc của chuỗi
+	if task.Recurrence != "" && task.SeriesID == nil {
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET series_id = task_id WHERE task_id = ?", task.ID); err != nil {
+			return err
+		}
+		task.SeriesID = &task.ID
+	}
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	return s.recordChange(ctx, tx, task.UserID, models.SyncTask, task.ID, false)
+}
+
+func (s *Store) GetTask(ctx context.Context, userID, id int) (models.Task, error) {
+	query := "SELECT " + taskColumns + " FROM tasks WHERE task_id = ? AND user_id = ?"
+	task, err := scanTask(s.db.QueryRowContext(ctx, query, id, userID))
+	return task, notFound(err)
+}
+
+func (s *Store) UpdateTask(ctx context.Context, task *models.Task) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		if err := s.checkPrecondition(ctx, tx, models.SyncTask, task.ID); err != nil {
+			return err
+		}
+		result, err := s.updateTask(ctx, tx, task, "")
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(result); err != nil {
+			return err
+		}
+		if err := s.recordChange(ctx, tx, task.UserID, models.SyncTask, task.ID, false); err != nil {
+			return err
+		}
+		return s.syncRelativeReminders(ctx, tx, task)
+	})
+}
+
+// syncRelativeReminders tính lại nhắc nhở tương đối của task theo deadline đã lưu trong
+// cùng transaction tx, để deadline và nhắc nhở không bao giờ lệch nhau
+func (s *Store) syncRelativeReminders(ctx context.Context, tx utcTx, task *models.Task) error {
+	now := time.Now()
+	return s.updateTaskReminders(ctx, tx, task.UserID, task.ID, func(reminder *models.Reminder) bool {
+		return reminder.FollowDeadline(task.Deadline, now)
+	})
+}
+
+// updateTask ghi lại mọi cột có thể sửa của task trong transaction tx; guard là điều kiện
+// bổ sung cho mệnh đề WHERE (bắt đầu bằng " AND ...") kèm tham số của nó
+func (s *Store) updateTask(ctx context.Context, tx utcTx, task *models.Task, guard string, guardArgs ...any) (sql.Result, error) {
+	now := time.Now()
+	query := `UPDATE tasks 
+              SET title = ?, description = ?, deadline = ?, priority = ?, 
+              status = ?, category_id = ?, updated_at = ?, completed_at = ?, 
+              recurrence = ?, series_id = ?, recurrence_start = ?, occurrence_at = ? 
+              WHERE task_id = ? AND user_id = ?` + guard
+	args := []any{
+		task.Title,
+		task.Description,
+		task.Deadline,
+		task.Priority,
+		task.Status,
+		task.CategoryID,
+		now,
+		task.CompletedAt,
+		task.Recurrence,
+		task.SeriesID,
+		task.RecurrenceStart,
+		task.OccurrenceAt,
+		task.ID,
+		task.UserID,
+	}
+	result, err := tx.ExecContext(ctx, query, append(args, guardArgs...)...)
+	if err == nil {
+		task.UpdatedAt = now
+	}
+	return result, err
+}
+
+func (s *Store) CompleteOccurrence(ctx context.Context, task, next *models.Task, reminders []models.Reminder) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.checkPrecondition(ctx, tx, models.SyncTask, task.ID); err != nil {
+		return err
+	}
+
+	// Hai request cùng hoàn thành một lần lặp: request đến sau không còn khớp dòng nào
+	// (status đã là Completed, recurrence đã bị xóa) nên không sinh trùng lần kế tiếp
+	result, err := s.updateTask(ctx, tx, task, " AND status <> ? AND recurrence <> ''", models.StatusCompleted)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		var exists int
+		if err := tx.QueryRowContext(ctx, "SELECT 1 FROM tasks WHERE task_id = ? AND user_id = ?", task.ID, task.UserID).Scan(&exists); err != nil {
+			return notFound(err)
+		}
+		return store.ErrStale
+	}
+	if err := s.recordChange(ctx, tx, task.UserID, models.SyncTask, task.ID, false); err != nil {
+		return err
+	}
+	if err := s.syncRelativeReminders(ctx, tx, task); err != nil {
+		return err
+	}
+
+	if err := s.insertTask(ctx, tx, next); err != nil {
+		return err
+	}
+	for i := range reminders {
+		reminders[i].TaskID = next.ID
+		if err := s.insertReminder(ctx, tx, &reminders[i]); err != nil {
+			return err
+		}
+	}
+	return s.commit(ctx, tx)
+}
+
+func (s *Store) SkipOccurrence(ctx context.Context, task *models.Task, from time.Time) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.checkPrecondition(ctx, tx, models.SyncTask, task.ID); err != nil {
+		return err
+	}
+
+	// Khóa dòng công việc trước khi so deadline: hai request cùng bỏ qua một lần lặp thì
+	// request đến sau thấy deadline đã đổi và không dời thêm lần nữa
+	var deadline time.Time
+	var rrule string
+	query := "SELECT deadline, recurrence FROM tasks WHERE task_id = ? AND user_id = ?" + s.forUpdate()
+	if err := tx.QueryRowContext(ctx, query, task.ID, task.UserID).Scan(&deadline, &rrule); err != nil {
+		return notFound(err)
+	}
+	if rrule == "" || !deadline.Equal(from) {
+		return store.ErrStale
+	}
+	if _, err := s.updateTask(ctx, tx, task, ""); err != nil {
+		return err
+	}
+	if err := s.recordChange(ctx, tx, task.UserID, models.SyncTask, task.ID, false); err != nil {
+		return err
+	}
+
+	shift := task.Deadline.Sub(from)
+	err = s.updateTaskReminders(ctx, tx, task.UserID, task.ID, func(reminder *models.Reminder) bool {
+		reminder.ReminderTime = reminder.ReminderTime.Add(shift)
+		reminder.IsSent = false
+		reminder.ResetDelivery()
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return s.commit(ctx, tx)
+}
+
+func (s *Store) DeleteTask(ctx context.Context, userID, id int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.checkPrecondition(ctx, tx, models.SyncTask, id); err != nil {
+		return err
+	}
+
+	// Nhắc nhở bị xóa theo công việc cũng cần tombstone
+	reminderIDs, err := taskReminderIDs(ctx, tx, userID, id)
+	if err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE task_id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(result); err != nil {
+		return err
+	}
+	for _, reminderID := range reminderIDs {
+		if err := s.recordChange(ctx, tx, userID, models.SyncReminder, reminderID, true); err != nil {
+			return err
+		}
+	}
+	if err := s.recordChange(ctx, tx, userID, models.SyncTask, id, true); err != nil {
+		return err
+	}
+	return s.commit(ctx, tx)
+}
+
+func (s *Store) GetTaskStatistics(ctx context.Context, userID int) (models.UserTaskStatistics, error) {
+	stats := models.UserTaskStatistics{
+		UserID:           userID,
+		TasksByMonth:     make(map[string]int),
+		CompletedByMonth: make(map[string]int),
+	}
+
+	// Truy vấn tổng quan thống kê
+	queryOverview := `
+        SELECT 
+            COUNT(*) as total_tasks,
+            COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) as completed_tasks,
+            COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) as in_progress_tasks,
+            COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) as pending_tasks,
+            COALESCE(SUM(CASE WHEN deadline < ? AND status != ? THEN 1 ELSE 0 END), 0) as overdue_tasks
+        FROM tasks
+        WHERE user_id = ?`
+	err := s.db.QueryRowContext(
+		ctx,
+		queryOverview,
+		models.StatusCompleted,
+		models.StatusInProgress,
+		models.StatusPending,
+		time.Now(),
+		models.StatusCompleted,
+		userID,
+	).Scan(
+		&stats.TotalTasks,
+		&stats.CompletedTasks,
+		&stats.InProgressTasks,
+		&stats.PendingTasks,
+		&stats.OverdueTasks,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return stats, err
+	}
+
+	// Truy vấn thống kê theo tháng
+	monthExpr := s.monthExpr("created_at")
+	queryByMonth := `
+        SELECT 
+            ` + monthExpr + ` as month,
+            COUNT(*) as total_tasks,
+            SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) as completed_tasks
+        FROM tasks
+        WHERE user_id = ?
+        GROUP BY ` + monthExpr
+	rows, err := s.db.QueryContext(ctx, queryByMonth, models.StatusCompleted, userID)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var month, totalTasks, completedTasks int
+		if err := rows.Scan(&month, &totalTasks, &completedTasks); err != nil {
+			return stats, err
+		}
+		// Khóa là tên tháng viết tắt tiếng Anh ("Jan", "Feb"...) như DATE_FORMAT(..., '%b') trước đây
+		name := time.Month(month).String()[:3]
+		stats.TasksByMonth[name] += totalTasks
+		stats.CompletedByMonth[name] += completedTasks
+	}
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
+
+	// Số lần hoãn nhắc nhở
+	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM reminder_snoozes WHERE user_id = ?", userID).
+		Scan(&stats.TotalSnoozes)
+	return stats, err
+}
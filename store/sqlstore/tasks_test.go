@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func TestOccurrenceGuards(t *testing.T) {
+	s := newTestStore(t)
+	ctx := t.Context()
+	deadline := time.Date(2030, 1, 2, 9, 0, 0, 0, time.UTC)
+	user, task := seedTask(t, s, "alice", deadline)
+	task.Recurrence = "FREQ=WEEKLY"
+	if err := s.UpdateTask(ctx, &task); err != nil {
+		t.Fatal(err)
+	}
+	offset := 30
+	reminder := models.Reminder{TaskID: task.ID, UserID: user.ID, ReminderTime: deadline.Add(-30 * time.Minute), OffsetMinutes: &offset}
+	if err := s.CreateReminder(ctx, &reminder); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bỏ qua lần lặp: công việc và nhắc nhở cùng được dời
+	week := 7 * 24 * time.Hour
+	skipped := task
+	skipped.Deadline = deadline.Add(week)
+	if err := s.SkipOccurrence(ctx, &skipped, deadline); err != nil {
+		t.Fatalf("bỏ qua lần lặp: %v", err)
+	}
+	got, err := s.GetReminder(ctx, user.ID, reminder.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.ReminderTime.Equal(reminder.ReminderTime.Add(week)) {
+		t.Errorf("nhắc nhở sau khi bỏ qua: %v, muốn %v", got.ReminderTime, reminder.ReminderTime.Add(week))
+	}
+	// Yêu cầu thứ hai đọc deadline cũ không dời thêm lần nữa
+	again := skipped
+	again.Deadline = skipped.Deadline.Add(week)
+	if err := s.SkipOccurrence(ctx, &again, deadline); !errors.Is(err, store.ErrStale) {
+		t.Errorf("bỏ qua lần lặp cũ: %v, muốn ErrStale", err)
+	}
+	missing := again
+	missing.ID = task.ID + 100
+	if err := s.SkipOccurrence(ctx, &missing, skipped.Deadline); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("bỏ qua công việc không tồn tại: %v, muốn ErrNotFound", err)
+	}
+
+	// Đổi deadline qua UpdateTask tính lại nhắc nhở tương đối trong cùng transaction
+	skipped.Deadline = skipped.Deadline.Add(2 * time.Hour)
+	if err := s.UpdateTask(ctx, &skipped); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = s.GetReminder(ctx, user.ID, reminder.ID); err != nil {
+		t.Fatal(err)
+	}
+	if want := skipped.Deadline.Add(-30 * time.Minute); !got.ReminderTime.Equal(want) {
+		t.Errorf("nhắc nhở tương đối sau khi đổi deadline: %v, muốn %v", got.ReminderTime, want)
+	}
+
+	// Hoàn thành lần lặp chỉ sinh lần kế tiếp một lần
+	completed := skipped
+	completed.Status = models.StatusCompleted
+	completed.Recurrence = ""
+	next := models.Task{Title: task.Title, Description: task.Description, Deadline: skipped.Deadline.Add(week),
+		Priority: task.Priority, Status: models.StatusPending, UserID: user.ID, Recurrence: "FREQ=WEEKLY", SeriesID: task.SeriesID}
+	carried := []models.Reminder{{UserID: user.ID, ReminderTime: next.Deadline.Add(-30 * time.Minute), OffsetMinutes: &offset}}
+	if err := s.CompleteOccurrence(ctx, &completed, &next, carried); err != nil {
+		t.Fatalf("hoàn thành lần lặp: %v", err)
+	}
+	if carried[0].TaskID != next.ID || next.ID == 0 {
+		t.Errorf("nhắc nhở của lần kế tiếp gắn với công việc #%d, muốn #%d", carried[0].TaskID, next.ID)
+	}
+	duplicate := next
+	duplicate.ID = 0
+	if err := s.CompleteOccurrence(ctx, &completed, &duplicate, nil); !errors.Is(err, store.ErrStale) {
+		t.Errorf("hoàn thành lại lần lặp: %v, muốn ErrStale", err)
+	}
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func (s *Store) CreateUser(ctx context.Context, user *models.User) error {
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	query := `INSERT INTO users (username, email, password, full_name, created_at, notification_channels,
+                  timezone, quiet_hours_start, quiet_hours_end, webhook_url, webhook_secret) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	return s.inTx(ctx, func(tx utcTx) error {
+		result, err := tx.ExecContext(ctx, query, user.Username, user.Email, user.Password, user.FullName, user.CreatedAt,
+			models.FormatChannels(user.NotificationChannels), user.Timezone, user.QuietHoursStart, user.QuietHoursEnd,
+			user.WebhookURL, user.WebhookSecret)
+		if err != nil {
+			if isDuplicate(err) {
+				return store.ErrConflict
+			}
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		user.ID = int(id)
+		// Bộ đếm seq của nhật ký đồng bộ (xem recordChange)
+		_, err = tx.ExecContext(ctx, "INSERT INTO sync_state (user_id, last_seq) VALUES (?, 0)", user.ID)
+		return err
+	})
+}
+
+const userColumns = `user_id, username, email, full_name, created_at, last_login, notification_channels,
+	timezone, quiet_hours_start, quiet_hours_end, webhook_url, webhook_secret`
+
+func (s *Store) scanUser(row scanner, withPassword bool) (models.User, error) {
+	var user models.User
+	var lastLogin sql.NullTime
+	var channels string
+	dest := []any{&user.ID, &user.Username, &user.Email, &user.FullName, &user.CreatedAt, &lastLogin, &channels,
+		&user.Timezone, &user.QuietHoursStart, &user.QuietHoursEnd, &user.WebhookURL, &user.WebhookSecret}
+	if withPassword {
+		dest = append(dest, &user.Password)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return models.User{}, notFound(err)
+	}
+	if lastLogin.Valid {
+		user.LastLogin = &lastLogin.Time
+	}
+	user.NotificationChannels = models.ParseChannels(channels)
+	return user, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, id int) (models.User, error) {
+	query := "SELECT " + userColumns + " FROM users WHERE user_id = ?"
+	return s.scanUser(s.db.QueryRowContext(ctx, query, id), false)
+}
+
+func (s *Store) GetUserByUsername(ctx context.Context, username string) (models.User, error) {
+	query := "SELECT " + userColumns + ", password FROM users WHERE username = ?"
+	return s.scanUser(s.db.QueryRowContext(ctx, query, username), true)
+}
+
+func (s *Store) UpdateUser(ctx context.Context, user *models.User) error {
+	query := `UPDATE users
+	          SET username = ?, email = ?, full_name = ?, notification_channels = ?,
+	              timezone = ?, quiet_hours_start = ?, quiet_hours_end = ?, webhook_url = ?, webhook_secret = ?
+	          WHERE user_id = ?`
+	_, err := s.db.ExecContext(ctx, query, user.Username, user.Email, user.FullName,
+		models.FormatChannels(user.NotificationChannels), user.Timezone, user.QuietHoursStart, user.QuietHoursEnd,
+		user.WebhookURL, user.WebhookSecret, user.ID)
+	if isDuplicate(err) {
+		return store.ErrConflict
+	}
+	return err
+}
+
+func (s *Store) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET password = ? WHERE user_id = ?", passwordHash, id)
+	return err
+}
+
+func (s *Store) UpdateLastLogin(ctx context.Context, id int, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE users SET last_login = ? WHERE user_id = ?", at, id)
+	return err
+}
+
+func (s *Store) DeleteUser(ctx context.Context, id int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE user_id = ?", id)
+	return err
+}
@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// utcDB đổi mọi tham số thời gian sang UTC trước khi gửi xuống database. SQLite lưu
+// thời gian dạng chuỗi kèm độ lệch múi giờ nên các giá trị phải cùng múi giờ thì
+// so sánh chuỗi mới đúng; MySQL cũng được cấu hình loc=UTC (xem database.InitDB).
+type utcDB struct {
+	*sql.DB
+}
+
+func (db utcDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (db utcDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return db.DB.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (db utcDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return db.DB.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func (db utcDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (utcTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	return utcTx{tx}, err
+}
+
+// utcTx là transaction tương ứng của utcDB
+type utcTx struct {
+	*sql.Tx
+}
+
+func (tx utcTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return tx.Tx.ExecContext(ctx, query, utcArgs(args)...)
+}
+
+func (tx utcTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(ctx, query, utcArgs(args)...)
+}
+
+func (tx utcTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return tx.Tx.QueryRowContext(ctx, query, utcArgs(args)...)
+}
+
+func utcArgs(args []any) []any {
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			args[i] = v.UTC()
+		case *time.Time:
+			if v != nil {
+				args[i] = v.UTC()
+			}
+		}
+	}
+	return args
+}
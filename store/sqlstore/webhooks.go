@@ -0,0 +1,290 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"backend/database"
+	"backend/models"
+	"backend/store"
+)
+
+const webhookColumns = "webhook_id, user_id, url, secret, events, active, created_at, updated_at"
+
+func scanWebhook(row scanner) (models.Webhook, error) {
+	var webhook models.Webhook
+	var events string
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &events, &webhook.Active,
+		&webhook.CreatedAt, &webhook.UpdatedAt)
+	webhook.Events = models.ParseWebhookEvents(events)
+	return webhook, err
+}
+
+func (s *Store) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	now := time.Now()
+	query := `INSERT INTO webhooks (user_id, url, secret, events, active, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := s.db.ExecContext(ctx, query, webhook.UserID, webhook.URL, webhook.Secret,
+		models.FormatWebhookEvents(webhook.Events), webhook.Active, now, now)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	webhook.ID = int(id)
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	return nil
+}
+
+func (s *Store) GetWebhook(ctx context.Context, userID, id int) (models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE webhook_id = ? AND user_id = ?"
+	webhook, err := scanWebhook(s.db.QueryRowContext(ctx, query, id, userID))
+	return webhook, notFound(err)
+}
+
+func (s *Store) ListWebhooks(ctx context.Context, userID int) ([]models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE user_id = ? ORDER BY webhook_id"
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (s *Store) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	webhook.UpdatedAt = time.Now()
+	query := `UPDATE webhooks SET url = ?, secret = ?, events = ?, active = ?, updated_at = ?
+	          WHERE webhook_id = ? AND user_id = ?`
+	result, err := s.db.ExecContext(ctx, query, webhook.URL, webhook.Secret, models.FormatWebhookEvents(webhook.Events),
+		webhook.Active, webhook.UpdatedAt, webhook.ID, webhook.UserID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, userID, id int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM webhooks WHERE webhook_id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
+
+const webhookDeliveryColumns = `delivery_id, webhook_id, user_id, event_id, event, payload, status, attempts,
+	response_status, error, next_attempt_at, delivered_at, replay_of, created_at, updated_at`
+
+func scanWebhookDelivery(row scanner) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var nextAttemptAt, deliveredAt sql.NullTime
+	var replayOf sql.NullInt64
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.UserID, &delivery.EventID, &delivery.Event,
+		&delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.ResponseStatus, &delivery.Error,
+		&nextAttemptAt, &deliveredAt, &replayOf, &delivery.CreatedAt, &delivery.UpdatedAt)
+	delivery.NextAttemptAt = nullTime(nextAttemptAt)
+	delivery.DeliveredAt = nullTime(deliveredAt)
+	if replayOf.Valid {
+		id := int(replayOf.Int64)
+		delivery.ReplayOf = &id
+	}
+	return delivery, err
+}
+
+func (s *Store) queryWebhookDeliveries(ctx context.Context, query string, args ...any) ([]models.WebhookDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *Store) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return s.inTx(ctx, func(tx utcTx) error {
+		return insertWebhookDelivery(ctx, tx, delivery)
+	})
+}
+
+func insertWebhookDelivery(ctx context.Context, tx utcTx, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	if delivery.Status == "" {
+		delivery.Status = models.WebhookPending
+	}
+	query := `INSERT INTO webhook_deliveries (webhook_id, user_id, event_id, event, payload, status, attempts,
+	              response_status, error, next_attempt_at, replay_of, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, 0, 0, '', ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, delivery.WebhookID, delivery.UserID, delivery.EventID, delivery.Event,
+		delivery.Payload, delivery.Status, delivery.NextAttemptAt, delivery.ReplayOf, now, now)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	delivery.ID = int(id)
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	return nil
+}
+
+// writeOutbox ghi delivery của các sự kiện gắn vào ctx trong transaction tx của thao tác ghi
+func (s *Store) writeOutbox(ctx context.Context, tx utcTx) error {
+	if len(store.EventsFrom(ctx)) == 0 {
+		return nil
+	}
+	deliveries, err := store.OutboxDeliveries(ctx, func(userID int) ([]models.Webhook, error) {
+		return activeWebhooks(ctx, tx, userID)
+	})
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		if err := insertWebhookDelivery(ctx, tx, &deliveries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeWebhooks liệt kê webhook đang bật của người dùng trong transaction tx
+func activeWebhooks(ctx context.Context, tx utcTx, userID int) ([]models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE user_id = ? AND active = TRUE ORDER BY webhook_id"
+	rows, err := tx.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (s *Store) GetWebhookDelivery(ctx context.Context, userID, id int) (models.WebhookDelivery, error) {
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE delivery_id = ? AND user_id = ?"
+	delivery, err := scanWebhookDelivery(s.db.QueryRowContext(ctx, query, id, userID))
+	return delivery, notFound(err)
+}
+
+func (s *Store) ListWebhookDeliveries(ctx context.Context, userID, webhookID, limit int) ([]models.WebhookDelivery, error) {
+	query := "SELECT " + webhookDeliveryColumns + ` FROM webhook_deliveries
+	          WHERE webhook_id = ? AND user_id = ? ORDER BY delivery_id DESC LIMIT ?`
+	deliveries, err := s.queryWebhookDeliveries(ctx, query, webhookID, userID, limit)
+	for i := range deliveries {
+		deliveries[i].Payload = ""
+	}
+	return deliveries, err
+}
+
+// dueWebhookCond chọn delivery đang chờ gửi của webhook đang bật (d: webhook_deliveries, w: webhooks)
+const dueWebhookCond = `d.status = 'pending' AND w.active = TRUE
+	AND (d.next_attempt_at IS NULL OR d.next_attempt_at <= ?)
+	AND (d.claimed_until IS NULL OR d.claimed_until < ?)`
+
+func (s *Store) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, err
+	}
+	until := now.Add(lease)
+
+	if s.dialect == database.SQLite {
+		query := `UPDATE webhook_deliveries SET claim_token = ?, claimed_until = ?
+		          WHERE delivery_id IN (
+		              SELECT d.delivery_id FROM webhook_deliveries d JOIN webhooks w ON w.webhook_id = d.webhook_id
+		              WHERE ` + dueWebhookCond + ` ORDER BY d.delivery_id LIMIT ?)`
+		if _, err := s.db.ExecContext(ctx, query, token, until, now, now, limit); err != nil {
+			return nil, err
+		}
+	} else if err := s.claimWebhookSkipLocked(ctx, token, now, until, limit); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE claim_token = ? ORDER BY delivery_id"
+	return s.queryWebhookDeliveries(ctx, query, token)
+}
+
+// claimWebhookSkipLocked giống claimSkipLocked của nhắc nhở: khóa các delivery đến lượt
+// bằng FOR UPDATE SKIP LOCKED để các instance không gửi trùng
+func (s *Store) claimWebhookSkipLocked(ctx context.Context, token string, now, until time.Time, limit int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT d.delivery_id FROM webhook_deliveries d JOIN webhooks w ON w.webhook_id = d.webhook_id
+	          WHERE ` + dueWebhookCond + ` ORDER BY d.delivery_id LIMIT ? FOR UPDATE OF d SKIP LOCKED`
+	rows, err := tx.QueryContext(ctx, query, now, now, limit)
+	if err != nil {
+		return err
+	}
+	args := []any{token, until}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		args = append(args, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		return tx.Commit()
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(args)-2), ", ")
+	update := "UPDATE webhook_deliveries SET claim_token = ?, claimed_until = ? WHERE delivery_id IN (" + placeholders + ")"
+	if _, err := tx.ExecContext(ctx, update, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) RecordWebhookAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.UpdatedAt = time.Now()
+	query := `UPDATE webhook_deliveries
+	          SET status = ?, attempts = ?, response_status = ?, error = ?, next_attempt_at = ?, delivered_at = ?,
+	              claim_token = NULL, claimed_until = NULL, updated_at = ?
+	          WHERE delivery_id = ?`
+	result, err := s.db.ExecContext(ctx, query, delivery.Status, delivery.Attempts, delivery.ResponseStatus,
+		delivery.Error, delivery.NextAttemptAt, delivery.DeliveredAt, delivery.UpdatedAt, delivery.ID)
+	if err != nil {
+		return err
+	}
+	return checkAffected(result)
+}
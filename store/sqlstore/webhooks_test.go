@@ -0,0 +1,111 @@
+package sqlstore
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/store"
+)
+
+func TestOutbox(t *testing.T) {
+	s := newTestStore(t)
+	ctx := t.Context()
+	user, task := seedTask(t, s, "alice", time.Now().Add(time.Hour))
+
+	webhooks := map[string]*models.Webhook{
+		"created":  {URL: "https://example.com/created", Events: []string{"task.created"}, Active: true},
+		"all":      {URL: "https://example.com/all", Events: []string{"task.created", "task.updated"}, Active: true},
+		"inactive": {URL: "https://example.com/off", Events: []string{"task.created"}, Active: false},
+	}
+	for _, webhook := range webhooks {
+		webhook.UserID, webhook.Secret = user.ID, "bi-mat-du-dai-16-ky-tu"
+		if err := s.CreateWebhook(ctx, webhook); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deliveries := func(name string) []models.WebhookDelivery {
+		t.Helper()
+		list, err := s.ListWebhookDeliveries(ctx, user.ID, webhooks[name].ID, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return list
+	}
+
+	// Sự kiện gắn vào ctx được ghi vào outbox khi transaction commit; Data là con trỏ
+	// nên payload mang ID do store cấp
+	next := models.Task{Title: "Lần sau", Description: "Báo cáo", Deadline: task.Deadline, Priority: models.PriorityMedium,
+		Status: models.StatusPending, UserID: user.ID}
+	created := store.WithEvents(ctx, store.Event{Type: "task.created", UserID: user.ID, Data: &next})
+	if err := s.CreateTask(created, &next); err != nil {
+		t.Fatal(err)
+	}
+	if got := deliveries("created"); len(got) != 1 || got[0].Event != "task.created" || got[0].Status != models.WebhookPending {
+		t.Fatalf("delivery của webhook task.created: %+v", got)
+	}
+	if got := deliveries("inactive"); len(got) != 0 {
+		t.Errorf("webhook đang tắt nhận delivery: %+v", got)
+	}
+	all := deliveries("all")
+	if len(all) != 1 {
+		t.Fatalf("webhook đăng ký mọi sự kiện có %d delivery, muốn 1", len(all))
+	}
+	delivery, err := s.GetWebhookDelivery(ctx, user.ID, all[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		ID    string      `json:"id"`
+		Event string      `json:"event"`
+		Data  models.Task `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(delivery.Payload), &payload); err != nil {
+		t.Fatalf("payload %q: %v", delivery.Payload, err)
+	}
+	if payload.ID != delivery.EventID || payload.Event != "task.created" || payload.Data.ID != next.ID {
+		t.Errorf("payload: %+v, muốn công việc #%d", payload, next.ID)
+	}
+
+	// Dùng lại ctx cho thao tác ghi khác không ghi lại sự kiện đã ghi
+	if err := s.UpdateTask(created, &next); err != nil {
+		t.Fatal(err)
+	}
+	if got := deliveries("created"); len(got) != 1 {
+		t.Errorf("dùng lại ctx ghi trùng sự kiện: %+v", got)
+	}
+
+	// Transaction bị hủy (điều kiện phiên bản không thỏa) thì không có delivery nào, sự kiện
+	// vẫn chờ thao tác ghi kế tiếp và chỉ được ghi một lần
+	stale := int64(0)
+	updated := store.WithEvents(ctx, store.Event{Type: "task.updated", UserID: user.ID, Data: &task})
+	conditional := store.WithPrecondition(updated, store.Precondition{Entity: models.SyncTask, ID: task.ID, Version: &stale})
+	if err := s.UpdateTask(conditional, &task); !errors.Is(err, store.ErrPrecondition) {
+		t.Fatalf("cập nhật với phiên bản cũ: %v", err)
+	}
+	if got := deliveries("all"); len(got) != 1 {
+		t.Errorf("transaction bị hủy vẫn ghi delivery: %+v", got)
+	}
+	for range 2 {
+		if err := s.UpdateTask(updated, &task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := deliveries("all"); len(got) != 2 || got[0].Event != "task.updated" && got[1].Event != "task.updated" {
+		t.Errorf("delivery sau khi cập nhật lại: %+v", got)
+	}
+
+	// Bộ gửi nền chỉ nhận delivery của webhook đang bật
+	claimed, err := s.ClaimDueWebhookDeliveries(ctx, time.Now(), 10, time.Minute)
+	if err != nil {
+		t.Fatalf("nhận delivery: %v", err)
+	}
+	if len(claimed) != 3 {
+		t.Errorf("nhận %d delivery, muốn 3", len(claimed))
+	}
+	if again, err := s.ClaimDueWebhookDeliveries(ctx, time.Now(), 10, time.Minute); err != nil || len(again) != 0 {
+		t.Errorf("nhận lại trong thời gian lease: %+v, %v", again, err)
+	}
+}
@@ -0,0 +1,217 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/models"
+)
+
+var (
+	// ErrNotFound được trả về khi bản ghi không tồn tại hoặc không thuộc về người dùng
+	ErrNotFound = errors.New("không tìm thấy bản ghi")
+	// ErrConflict được trả về khi dữ liệu vi phạm ràng buộc duy nhất
+	ErrConflict = errors.New("dữ liệu đã tồn tại")
+	// ErrStale được trả về khi bản ghi đã bị một yêu cầu khác thay đổi nên điều kiện ghi không còn đúng
+	ErrStale = errors.New("dữ liệu đã bị thay đổi bởi yêu cầu khác")
+)
+
+// Session là một phiên đăng nhập; chỉ lưu giá trị băm của token
+type Session struct {
+	ID               int
+	UserID           int
+	AccessTokenHash  string
+	AccessExpiresAt  time.Time
+	RefreshTokenHash string
+	RefreshExpiresAt time.Time
+	CreatedAt        time.Time
+}
+
+type UserStore interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetUser(ctx context.Context, id int) (models.User, error)
+	// GetUserByUsername trả về cả mật khẩu đã băm để kiểm tra đăng nhập
+	GetUserByUsername(ctx context.Context, username string) (models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	UpdateLastLogin(ctx context.Context, id int, at time.Time) error
+	DeleteUser(ctx context.Context, id int) error
+}
+
+type SessionStore interface {
+	CreateSession(ctx context.Context, session *Session) error
+	GetSessionByAccessToken(ctx context.Context, accessTokenHash string, now time.Time) (Session, error)
+	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string, now time.Time) (Session, error)
+	// RotateSession thay cặp token của phiên; trả về ErrNotFound nếu refresh token cũ đã được dùng
+	RotateSession(ctx context.Context, oldRefreshTokenHash string, session *Session) error
+	DeleteSession(ctx context.Context, id int) error
+}
+
+type TaskStore interface {
+	CreateTask(ctx context.Context, task *models.Task) error
+	GetTask(ctx context.Context, userID, id int) (models.Task, error)
+	// UpdateTask lưu task và tính lại reminder_time của các nhắc nhở tương đối
+	// (offset_minutes) theo deadline mới trong cùng transaction
+	UpdateTask(ctx context.Context, task *models.Task) error
+	// CompleteOccurrence lưu lần lặp task vừa hoàn thành, đồng thời tạo lần lặp kế tiếp next
+	// cùng các nhắc nhở của nó (TaskID được gán theo next) trong một transaction. Chỉ ghi
+	// nếu lần lặp chưa Completed và còn mang RRULE; nếu không trả về ErrStale.
+	CompleteOccurrence(ctx context.Context, task, next *models.Task, reminders []models.Reminder) error
+	// SkipOccurrence dời lần lặp đang mở task (đã mang deadline/occurrence_at của lần kế
+	// tiếp) từ deadline from tới task.Deadline, đồng thời dời mọi nhắc nhở của nó cùng
+	// khoảng đó trong một transaction. Chỉ ghi nếu công việc còn mang RRULE và deadline vẫn
+	// là from; nếu không trả về ErrStale.
+	SkipOccurrence(ctx context.Context, task *models.Task, from time.Time) error
+	DeleteTask(ctx context.Context, userID, id int) error
+	// ListTasks liệt kê công việc theo bộ lọc, sắp xếp và phân trang bằng cursor
+	ListTasks(ctx context.Context, userID int, filter TaskFilter) (TaskPage, error)
+	GetTaskStatistics(ctx context.Context, userID int) (models.UserTaskStatistics, error)
+}
+
+type CategoryStore interface {
+	CreateCategory(ctx context.Context, category *models.Category) error
+	GetCategory(ctx context.Context, userID, id int) (models.Category, error)
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, userID, id int) error
+	ListCategories(ctx context.Context, userID int) ([]models.Category, error)
+}
+
+type ReminderStore interface {
+	CreateReminder(ctx context.Context, reminder *models.Reminder) error
+	GetReminder(ctx context.Context, userID, id int) (models.Reminder, error)
+	// HasReminderBetween kiểm tra công việc đã có nhắc nhở trong khoảng [from, to] chưa
+	HasReminderBetween(ctx context.Context, userID, taskID int, from, to time.Time) (bool, error)
+	ListTaskReminders(ctx context.Context, userID, taskID int) ([]models.Reminder, error)
+	// ListReminders liệt kê mọi nhắc nhở của người dùng theo reminder_time
+	ListReminders(ctx context.Context, userID int) ([]models.Reminder, error)
+	// ListRemindersAfter liệt kê tối đa limit nhắc nhở có ID lớn hơn afterID theo thứ tự ID, dùng để đọc từng phần
+	ListRemindersAfter(ctx context.Context, userID, afterID, limit int) ([]models.Reminder, error)
+	UpdateReminder(ctx context.Context, reminder *models.Reminder) error
+	DeleteReminder(ctx context.Context, userID, id int) error
+	// ListFailedReminders liệt kê nhắc nhở đã gửi thất bại quá số lần cho phép
+	ListFailedReminders(ctx context.Context, userID int) ([]models.Reminder, error)
+	// RedriveReminder đưa nhắc nhở failed về hàng đợi gửi; ErrNotFound nếu không có nhắc nhở failed tương ứng
+	RedriveReminder(ctx context.Context, userID, id int) error
+	ListReminderDeliveries(ctx context.Context, reminderID int) ([]models.ReminderDelivery, error)
+	// SnoozeReminder hoãn nhắc nhở đã gửi (hoặc đang hoãn) tới until và ghi lịch sử hoãn;
+	// ErrNotFound nếu nhắc nhở không ở trạng thái sent/snoozed
+	SnoozeReminder(ctx context.Context, userID, id int, until, at time.Time) error
+	// AcknowledgeReminder đánh dấu người dùng đã xác nhận nhắc nhở, bộ gửi sẽ bỏ qua nó
+	AcknowledgeReminder(ctx context.Context, userID, id int, at time.Time) error
+	ListReminderSnoozes(ctx context.Context, userID, reminderID int) ([]models.ReminderSnooze, error)
+}
+
+// ReminderQueue được bộ gửi nhắc nhở chạy nền dùng để nhận nhắc nhở đến hạn.
+// Mỗi nhắc nhở chỉ được một tiến trình nhận trong thời hạn lease, kể cả khi
+// nhiều instance của server cùng chạy.
+type ReminderQueue interface {
+	// ClaimDueReminders nhận tối đa limit nhắc nhở chưa gửi có reminder_time <= now
+	// (hoặc đang hoãn có snoozed_until <= now); mỗi nhắc nhở mang ClaimToken của lượt nhận.
+	// Ba thao tác dưới đây chỉ ghi khi nhắc nhở vẫn giữ đúng ClaimToken đó và trả về
+	// ErrStale nếu đã mất quyền nhận (instance khác nhận lại sau Lease, hoặc người dùng
+	// đã sửa/xác nhận nhắc nhở).
+	ClaimDueReminders(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Reminder, error)
+	// MarkReminderSent đánh dấu nhắc nhở đã gửi lúc sentAt và bỏ quyền nhận
+	MarkReminderSent(ctx context.Context, reminder models.Reminder, sentAt time.Time) error
+	// ScheduleRetry ghi số lần đã thử và hẹn lần gửi lại tại nextAttemptAt
+	ScheduleRetry(ctx context.Context, reminder models.Reminder, attempts int, nextAttemptAt time.Time) error
+	// MarkReminderFailed chuyển nhắc nhở sang trạng thái failed sau attempts lần thử
+	MarkReminderFailed(ctx context.Context, reminder models.Reminder, attempts int) error
+	RecordDelivery(ctx context.Context, delivery *models.ReminderDelivery) error
+}
+
+// DigestStore lưu lịch gửi bản tổng hợp công việc của từng người dùng
+type DigestStore interface {
+	// GetDigestSettings trả về ErrNotFound nếu người dùng chưa cấu hình
+	GetDigestSettings(ctx context.Context, userID int) (models.DigestSettings, error)
+	SaveDigestSettings(ctx context.Context, settings *models.DigestSettings) error
+	// ListDueDigests liệt kê tối đa limit cấu hình có next_run_at <= now
+	ListDueDigests(ctx context.Context, now time.Time, limit int) ([]models.DigestSettings, error)
+	// AdvanceDigest dời next_run_at từ from sang to; ErrNotFound nếu instance khác đã dời trước
+	// (dùng như một lần nhận việc để mỗi bản tổng hợp chỉ được gửi một lần)
+	AdvanceDigest(ctx context.Context, userID int, from time.Time, to *time.Time) error
+	MarkDigestSent(ctx context.Context, userID int, at time.Time) error
+}
+
+// CalendarStore lưu token bí mật của feed lịch (.ics) của từng người dùng
+type CalendarStore interface {
+	// GetCalendarToken trả về giá trị băm của token; ErrNotFound nếu feed chưa được bật
+	GetCalendarToken(ctx context.Context, userID int) (string, error)
+	// SaveCalendarToken bật feed hoặc thay token cũ
+	SaveCalendarToken(ctx context.Context, userID int, tokenHash string) error
+	DeleteCalendarToken(ctx context.Context, userID int) error
+}
+
+// ImportStore ghi dữ liệu nhập trong một transaction: lỗi ở bất kỳ bản ghi nào
+// (kể cả danh mục trùng tên, ErrConflict) hủy toàn bộ batch
+type ImportStore interface {
+	ImportTasks(ctx context.Context, userID int, batch *ImportBatch) error
+}
+
+// CalDAVStore lưu tên tài nguyên và UID của công việc được tạo qua CalDAV
+type CalDAVStore interface {
+	ListCalDAVObjects(ctx context.Context, userID int) ([]models.CalDAVObject, error)
+	// SaveCalDAVObject thay bản ghi cũ có cùng tên hoặc cùng công việc
+	SaveCalDAVObject(ctx context.Context, object models.CalDAVObject) error
+}
+
+// WebhookStore lưu đăng ký webhook và nhật ký gửi sự kiện tới chúng
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	// GetWebhook trả về cả secret để ký payload
+	GetWebhook(ctx context.Context, userID, id int) (models.Webhook, error)
+	ListWebhooks(ctx context.Context, userID int) ([]models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, userID, id int) error
+	// CreateWebhookDelivery đưa một delivery vào hàng đợi gửi (gửi lại). Delivery của sự
+	// kiện mới được ghi qua outbox cùng thay đổi sinh ra nó (xem WithEvents).
+	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, userID, id int) (models.WebhookDelivery, error)
+	// ListWebhookDeliveries liệt kê tối đa limit delivery mới nhất của webhook, không kèm payload
+	ListWebhookDeliveries(ctx context.Context, userID, webhookID, limit int) ([]models.WebhookDelivery, error)
+}
+
+// WebhookQueue là hàng đợi gửi webhook dùng cho tác vụ chạy nền
+type WebhookQueue interface {
+	// ClaimDueWebhookDeliveries nhận tối đa limit delivery pending đã đến lượt gửi của
+	// các webhook đang bật và giữ quyền nhận trong lease
+	ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// RecordWebhookAttempt ghi kết quả lần thử (status, attempts, response_status, error,
+	// next_attempt_at, delivered_at) và bỏ quyền nhận
+	RecordWebhookAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+// SyncStore đọc nhật ký thay đổi phục vụ đồng bộ offline. Các store ghi một thay đổi
+// mỗi khi công việc, danh mục hoặc nhắc nhở được tạo, sửa, xóa hay đổi trạng thái gửi;
+// mỗi bản ghi chỉ giữ thay đổi mới nhất. Tombstone chỉ được giữ tới khi PruneTombstones
+// xóa chúng.
+type SyncStore interface {
+	// ListChanges trả về tối đa limit thay đổi của userID có seq > since, theo seq tăng dần
+	ListChanges(ctx context.Context, userID int, since int64, limit int) ([]models.Change, error)
+	// LatestChange trả về thay đổi gần nhất của một bản ghi; ErrNotFound nếu chưa có
+	LatestChange(ctx context.Context, userID int, entity models.SyncEntity, id int) (models.Change, error)
+	// PrunedSeq trả về seq lớn nhất trong các tombstone của userID đã bị xóa. Client đã đồng
+	// bộ tới cursor nhỏ hơn giá trị này có thể đã bỏ lỡ một lần xóa nên phải đồng bộ lại
+	// từ đầu; đọc sau ListChanges vì giá trị chỉ tăng cùng lúc tombstone bị xóa.
+	PrunedSeq(ctx context.Context, userID int) (int64, error)
+	// PruneTombstones xóa tombstone ghi trước before và trả về số tombstone đã xóa
+	PruneTombstones(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Store gom tất cả các kho dữ liệu mà handler cần
+type Store interface {
+	UserStore
+	SessionStore
+	TaskStore
+	CategoryStore
+	ReminderStore
+	ReminderQueue
+	DigestStore
+	SyncStore
+	CalendarStore
+	CalDAVStore
+	ImportStore
+	WebhookStore
+	WebhookQueue
+}